@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+type ApiTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"`
+}
+
+type ApiTokenResponse struct {
+	models.ApiToken
+	Token string `json:"token"`
+}
+
+func GetApiTokens(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		apiTokens, err := models.GetApiTokens(c.Request.Context(), db, c.MustGet("uid").(int))
+		if err != nil {
+			err = fmt.Errorf("%w: when getting api tokens", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get tokens.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, apiTokens)
+	}
+}
+
+// PostApiToken mints a personal access token, the response is the only time
+// the token itself is shown
+func PostApiToken(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var req ApiTokenRequest
+		if err = c.ShouldBindJSON(&req); err != nil {
+			err = fmt.Errorf("%w: when parsing request body", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse request body.")
+			return
+		}
+		if req.ExpiresInDays < 0 {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid expiration.")
+			return
+		}
+
+		apiToken, token, err := models.CreateApiToken(
+			c.Request.Context(),
+			db,
+			c.MustGet("uid").(int),
+			req.Name,
+			req.Scopes,
+			time.Duration(req.ExpiresInDays)*24*time.Hour,
+		)
+		switch {
+		case errors.Is(err, models.ErrApiTokenNameEmpty):
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, "The token needs a name.")
+			return
+		case errors.Is(err, models.ErrNoScopes), errors.Is(err, models.ErrUnknownScope):
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, "Choose at least one of the scopes: "+fmt.Sprint(models.API_TOKEN_SCOPES)+".")
+			return
+		case errors.Is(err, models.ErrTooManyApiTokens):
+			err = nil
+			c.IndentedJSON(http.StatusConflict, "Too many tokens, revoke some of them first.")
+			return
+		case err != nil:
+			err = fmt.Errorf("%w: when creating api token", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to create token.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusCreated, ApiTokenResponse{ApiToken: apiToken, Token: token})
+	}
+}
+
+func DeleteApiToken(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid token ID provided.")
+			return
+		}
+
+		err = models.RevokeApiToken(c.Request.Context(), db, id, c.MustGet("uid").(int))
+		if errors.Is(err, models.ErrApiTokenNotFound) {
+			err = nil
+			c.IndentedJSON(http.StatusNotFound, "Token not found.")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when revoking api token", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to revoke token.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, "Token revoked.")
+	}
+}
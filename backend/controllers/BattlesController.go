@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+type BattleChallenge struct {
+	Opponent     string `json:"opponent"`
+	EventId      int    `json:"eventId"`
+	NoOfAttempts int    `json:"noOfAttempts"`
+}
+
+type BattleSolve struct {
+	Solve string `json:"solve"`
+}
+
+// battleStatus maps the errors of battle actions to the response status
+func battleStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, models.ErrBattleNotFound):
+		return http.StatusNotFound, "Battle not found."
+	case errors.Is(err, models.ErrNotInBattle):
+		return http.StatusForbidden, "You are not in this battle."
+	case errors.Is(err, models.ErrBattleNotPending):
+		return http.StatusConflict, "The battle was already answered."
+	case errors.Is(err, models.ErrBattleNotActive):
+		return http.StatusConflict, "The battle is not running."
+	case errors.Is(err, models.ErrAttemptAlreadySubmitted):
+		return http.StatusConflict, "You already submitted this attempt, wait for your opponent."
+	case errors.Is(err, models.ErrInvalidBattle):
+		return http.StatusBadRequest, "Invalid battle."
+	}
+
+	return http.StatusInternalServerError, "Failed to process the battle."
+}
+
+func PostBattle(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var challenge BattleChallenge
+		if err = c.BindJSON(&challenge); err != nil {
+			err = fmt.Errorf("%w: when parsing battle challenge", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse battle challenge.")
+			return
+		}
+
+		opponentId, err := getUserIdByProfileId(db, challenge.Opponent)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting opponent=%s", err, challenge.Opponent)
+			c.IndentedJSON(http.StatusInternalServerError, "Finding opponent in database failed.")
+			return
+		}
+		if opponentId == 0 {
+			c.IndentedJSON(http.StatusNotFound, "Opponent not found.")
+			return
+		}
+
+		ctx := c.Request.Context()
+		battle := models.Battle{
+			ChallengerId: c.MustGet("uid").(int),
+			OpponentId:   opponentId,
+			NoOfAttempts: challenge.NoOfAttempts,
+		}
+		if err = battle.Validate(); err != nil {
+			c.IndentedJSON(battleStatus(err))
+			return
+		}
+
+		events, err := models.GetAvailableEvents(db)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting available events", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get events.")
+			return
+		}
+		for _, event := range events {
+			if event.Id == challenge.EventId {
+				battle.Event = event
+			}
+		}
+		if battle.Event.Id == 0 {
+			c.IndentedJSON(http.StatusNotFound, "Event not found.")
+			return
+		}
+
+		if err = battle.Insert(ctx, db); err != nil {
+			err = fmt.Errorf("%w: when inserting battle", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to create battle.")
+			return
+		}
+
+		if err = battle.Get(ctx, db, battle.Id); err != nil {
+			err = fmt.Errorf("%w: when getting created battle", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get battle.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusCreated, battle)
+	}
+}
+
+// getBattle loads the battle from the :id path param, the error is already
+// answered if it is returned
+func getBattle(c *gin.Context, db *pgxpool.Pool) (models.Battle, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, "Failed to parse battle id.")
+		return models.Battle{}, fmt.Errorf("%w: when parsing battle id", err)
+	}
+
+	var battle models.Battle
+	if err = battle.Get(c.Request.Context(), db, id); err != nil {
+		c.IndentedJSON(battleStatus(err))
+		return models.Battle{}, err
+	}
+
+	return battle, nil
+}
+
+func GetBattle(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		battle, err := getBattle(c, db)
+		if err != nil {
+			return
+		}
+
+		battle.HideFor(c.GetInt("uid"))
+		c.IndentedJSON(http.StatusOK, battle)
+	}
+}
+
+func PostBattleAccept(
+	db *pgxpool.Pool,
+	s scrambler.Scrambler,
+	envMap map[string]string,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		battle, err := getBattle(c, db)
+		if err != nil {
+			return
+		}
+
+		if c.MustGet("uid").(int) != battle.OpponentId {
+			c.IndentedJSON(http.StatusForbidden, "Only the challenged user can accept the battle.")
+			return
+		}
+
+		ismbld := battle.Event.Iconcode == "333mbf"
+		scrambles, err := models.GenerateScramblesForEvent(s, battle.Event.Scramblingcode, battle.NoOfAttempts, ismbld)
+		if err != nil {
+			err = fmt.Errorf("%w: when generating battle scrambles", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to generate scrambles.")
+			return
+		}
+
+		images, err := models.GenerateImagesForScrambles(scrambles, battle.Event.Scramblingcode, ismbld, envMap)
+		if err != nil {
+			err = fmt.Errorf("%w: when generating battle scramble images", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to generate scramble images.")
+			return
+		}
+
+		ctx := c.Request.Context()
+		if err = battle.Accept(ctx, db, scrambles, images); err != nil {
+			c.IndentedJSON(battleStatus(err))
+			return
+		}
+
+		battle.HideFor(battle.OpponentId)
+		c.IndentedJSON(http.StatusOK, battle)
+	}
+}
+
+func PostBattleDecline(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		battle, err := getBattle(c, db)
+		if err != nil {
+			return
+		}
+
+		// the challenger can take the challenge back
+		if !battle.IsParticipant(c.MustGet("uid").(int)) {
+			c.IndentedJSON(battleStatus(models.ErrNotInBattle))
+			return
+		}
+
+		if err = battle.Decline(c.Request.Context(), db); err != nil {
+			c.IndentedJSON(battleStatus(err))
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, battle)
+	}
+}
+
+func PostBattleSolve(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var solve BattleSolve
+		if err = c.BindJSON(&solve); err != nil {
+			err = fmt.Errorf("%w: when parsing battle solve", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse solve.")
+			return
+		}
+
+		battle, err := getBattle(c, db)
+		if err != nil {
+			return
+		}
+
+		uid := c.MustGet("uid").(int)
+		if err = battle.Submit(c.Request.Context(), db, uid, solve.Solve); err != nil {
+			c.IndentedJSON(battleStatus(err))
+			return
+		}
+
+		battle.HideFor(uid)
+		c.IndentedJSON(http.StatusCreated, battle)
+	}
+}
+
+func GetMyBattles(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		battles, err := models.GetUserBattles(c.Request.Context(), db, c.MustGet("uid").(int), false)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting battles of the user", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get battles.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, battles)
+	}
+}
+
+// getUserIdByProfileId finds the user the same way as the profile does, by
+// wca id first and by name second, 0 if there is no such user
+func getUserIdByProfileId(db *pgxpool.Pool, id string) (int, error) {
+	uid, err := models.GetUserByWCAID(db, id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when getting user by wca id=%s", err, id)
+	}
+	if uid != 0 {
+		return uid, nil
+	}
+
+	uid, err = models.GetUserByName(db, id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when getting user by name=%s", err, id)
+	}
+
+	return uid, nil
+}
+
+// GetBattleHistory returns the finished battles of the user from the profile
+// with the :id (wca id or name)
+func GetBattleHistory(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		uid, err := getUserIdByProfileId(db, c.Param("id"))
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, "Finding user in database failed.")
+			return
+		}
+
+		battles, err := models.GetUserBattles(c.Request.Context(), db, uid, true)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting battle history of user with id=%d", err, uid)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get battle history.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, battles)
+	}
+}
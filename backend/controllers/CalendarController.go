@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/ical"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+func writeCalendar(c *gin.Context, name string, events []ical.Event) {
+	calendar := ical.Calendar{ProdId: models.CALENDAR_PRODID, Name: name, Events: events}
+	c.Header("Content-Disposition", `inline; filename="calendar.ics"`)
+	c.Data(http.StatusOK, ical.CONTENT_TYPE, []byte(calendar.String(time.Now())))
+}
+
+// GetPublicCalendar serves the weekly competitions and, with the region query
+// (the country name, optionally followed by ", " and the state), the WCA
+// competitions there
+func GetPublicCalendar(db interfaces.DB, envMap map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		ctx := c.Request.Context()
+
+		competitions, err := models.GetCalendarCompetitions(ctx, db, time.Now().Add(-models.CALENDAR_HISTORY))
+		if err != nil {
+			err = fmt.Errorf("%w: when getting calendar competitions", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to load competitions.")
+			return
+		}
+
+		name := "Speedcubing Slovakia"
+		wcaCompetitions := []models.UpcomingWCACompetition{}
+		if region := c.Query("region"); region != "" {
+			countryName, state, _ := strings.Cut(region, ", ")
+
+			var country models.Country
+			err = country.Get(ctx, db, countryName)
+			if errors.Is(err, models.ErrCountryNotFound) {
+				err = nil
+				c.IndentedJSON(http.StatusNotFound, "Unknown region.")
+				return
+			}
+			if err != nil {
+				err = fmt.Errorf("%w: when getting country %s", err, countryName)
+				c.IndentedJSON(http.StatusInternalServerError, "Failed to get country information from name.")
+				return
+			}
+
+			wcaCompetitions, err = models.GetRegionWCACompetitions(ctx, db, country.Id, state)
+			if err != nil {
+				err = fmt.Errorf("%w: when getting wca competitions in %s", err, region)
+				c.IndentedJSON(http.StatusInternalServerError, "Failed to load competitions.")
+				return
+			}
+			name += " - " + region
+		}
+
+		writeCalendar(c, name, models.CalendarEvents(envMap, competitions, wcaCompetitions))
+	}
+}
+
+// GetMyCalendar serves the weekly competitions and the WCA competitions the
+// user follows. Calendar apps cannot send the Authorization header, so the
+// personal access token with the calendar scope comes in the token query.
+func GetMyCalendar(db interfaces.DB, envMap map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		ctx := c.Request.Context()
+
+		token := c.Query("token")
+		if !models.IsApiToken(token) {
+			c.IndentedJSON(http.StatusUnauthorized, "Missing token.")
+			return
+		}
+
+		apiToken, err := models.UseApiToken(ctx, db, token)
+		if errors.Is(err, models.ErrApiTokenNotFound) {
+			err = nil
+			c.IndentedJSON(http.StatusUnauthorized, "Invalid token.")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when using api token", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to verify token.")
+			return
+		}
+		if !apiToken.HasScope(models.SCOPE_CALENDAR_READ) {
+			c.IndentedJSON(http.StatusForbidden, "Token does not allow reading the calendar.")
+			return
+		}
+
+		competitions, err := models.GetCalendarCompetitions(ctx, db, time.Now().Add(-models.CALENDAR_HISTORY))
+		if err != nil {
+			err = fmt.Errorf("%w: when getting calendar competitions", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to load competitions.")
+			return
+		}
+
+		wcaCompetitions, err := models.GetFollowedWCACompetitions(ctx, db, apiToken.UserId)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting wca competitions followed by user with id=%d", err, apiToken.UserId)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to load competitions.")
+			return
+		}
+
+		writeCalendar(c, "Speedcubing Slovakia", models.CalendarEvents(envMap, competitions, wcaCompetitions))
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,6 +15,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
 )
 
@@ -127,10 +130,11 @@ func GetCompetitionById(db *pgxpool.Pool) gin.HandlerFunc {
 func CreateCompetition(
 	db *pgxpool.Pool,
 	competition models.CompetitionData,
+	s scrambler.Scrambler,
 	envMap map[string]string,
 ) (string, string) {
 	competition.RecomputeCompetitionId()
-	err := competition.GenerateScrambles(envMap)
+	err := competition.GenerateScrambles(s, envMap)
 	if err != nil {
 		return "ERR GenerateScrambles in PostCompetition: " + err.Error(), "Failed to generate scrambles."
 	}
@@ -157,32 +161,32 @@ func CreateCompetition(
 	for _, event := range competition.Events {
 		_, err = tx.Exec(
 			context.Background(),
-			`INSERT INTO competition_events (competition_id, event_id, format) VALUES ($1,$2,$3);`,
+			`INSERT INTO competition_events (competition_id, event_id, format, cutoff_attempts, cutoff_time, time_limit, time_limit_cumulative) VALUES ($1,$2,$3,$4,$5,$6,$7);`,
 			competition.Id,
 			event.Id,
 			event.Format,
+			event.Cutoff.Attempts,
+			event.Cutoff.Time,
+			event.TimeLimit.Time,
+			event.TimeLimit.Cumulative,
 		)
 		if err != nil {
 			tx.Rollback(context.Background())
 			return "ERR tx.Exec INSERT INTO competition_events in PostCompetition: " + err.Error(), "Failed to insert competition events connections into database."
 		}
+
+		err = event.SaveRounds(tx, competition.Id)
+		if err != nil {
+			tx.Rollback(context.Background())
+			return "ERR event.SaveRounds in PostCompetition: " + err.Error(), "Failed to insert competition rounds into database."
+		}
 	}
 
 	for _, scrambleSet := range competition.Scrambles {
-		for scrambleIdx, scramble := range scrambleSet.Scrambles {
-			_, err := tx.Exec(
-				context.Background(),
-				`INSERT INTO scrambles (scramble, event_id, competition_id, "order", img) VALUES ($1,$2,$3,$4,$5);`,
-				scramble.Scramble,
-				scrambleSet.Event.Id,
-				competition.Id,
-				scrambleIdx+1,
-				scramble.Img,
-			)
-			if err != nil {
-				tx.Rollback(context.Background())
-				return "ERR tx.Exec INSERT INTO scrambles in PostCompetition: " + err.Error(), "Failed to insert scrambles into database."
-			}
+		err = scrambleSet.Insert(tx, competition.Id)
+		if err != nil {
+			tx.Rollback(context.Background())
+			return "ERR scrambleSet.Insert in PostCompetition: " + err.Error(), "Failed to insert scrambles into database."
 		}
 	}
 
@@ -194,7 +198,7 @@ func CreateCompetition(
 	return "", ""
 }
 
-func PostCompetition(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
+func PostCompetition(db *pgxpool.Pool, s scrambler.Scrambler, envMap map[string]string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var competition models.CompetitionData
 
@@ -204,7 +208,23 @@ func PostCompetition(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc
 			return
 		}
 
-		errLog, errOut := CreateCompetition(db, competition, envMap)
+		for _, event := range competition.Events {
+			if event.Id < 0 {
+				continue
+			}
+			if err := event.ValidateRoundRules(); err != nil {
+				log.Println("ERR event.ValidateRoundRules in PostCompetition: " + err.Error())
+				c.IndentedJSON(http.StatusBadRequest, "Invalid cutoff or time limit for "+event.Displayname+".")
+				return
+			}
+			if err := event.ValidateRounds(competition.Startdate, competition.Enddate); err != nil {
+				log.Println("ERR event.ValidateRounds in PostCompetition: " + err.Error())
+				c.IndentedJSON(http.StatusBadRequest, "Invalid rounds for "+event.Displayname+".")
+				return
+			}
+		}
+
+		errLog, errOut := CreateCompetition(db, competition, s, envMap)
 		if errLog != "" && errOut != "" {
 			log.Println(errLog)
 			c.IndentedJSON(http.StatusInternalServerError, errOut)
@@ -215,7 +235,7 @@ func PostCompetition(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc
 	}
 }
 
-func PutCompetition(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
+func PutCompetition(db *pgxpool.Pool, s scrambler.Scrambler, envMap map[string]string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var competition models.CompetitionData
 
@@ -225,6 +245,22 @@ func PutCompetition(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc
 			return
 		}
 
+		for _, event := range competition.Events {
+			if event.Id < 0 {
+				continue
+			}
+			if err := event.ValidateRoundRules(); err != nil {
+				log.Println("ERR event.ValidateRoundRules in PutCompetition: " + err.Error())
+				c.IndentedJSON(http.StatusBadRequest, "Invalid cutoff or time limit for "+event.Displayname+".")
+				return
+			}
+			if err := event.ValidateRounds(competition.Startdate, competition.Enddate); err != nil {
+				log.Println("ERR event.ValidateRounds in PutCompetition: " + err.Error())
+				c.IndentedJSON(http.StatusBadRequest, "Invalid rounds for "+event.Displayname+".")
+				return
+			}
+		}
+
 		tx, err := db.Begin(context.Background())
 		if err != nil {
 			log.Println("ERR db.begin in PutCompetition: " + err.Error())
@@ -251,7 +287,7 @@ func PutCompetition(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc
 			return
 		}
 
-		err = models.UpdateCompetitionEvents(&competition, db, tx, envMap)
+		err = models.UpdateCompetitionEvents(&competition, db, tx, s, envMap)
 		if err != nil {
 			log.Println("ERR UpdateCompetitionEvents in PutCompetition: " + err.Error())
 			c.IndentedJSON(
@@ -283,7 +319,14 @@ func GetResultsFromCompetition(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		competitionResults, err := models.GetResultsFromCompetitionByEventName(db, cid, eid)
+		round, err := strconv.Atoi(c.DefaultQuery("round", "1"))
+		if err != nil {
+			log.Println("ERR strconv(round) in GetResultsFromCompetition: " + err.Error())
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse round.")
+			return
+		}
+
+		competitionResults, err := models.GetResultsFromCompetitionByEventName(db, cid, eid, round)
 		if err != nil {
 			log.Println(
 				"ERR GetResultsFromCompetitionByEventName in GetResultsFromCompetition: " + err.Error(),
@@ -345,7 +388,7 @@ func GetNewWeeklyCompetitionInfo(db *pgxpool.Pool) (models.CompetitionData, erro
 	return competition, nil
 }
 
-func AddNewWeeklyCompetition(db *pgxpool.Pool, envMap map[string]string) {
+func AddNewWeeklyCompetition(db *pgxpool.Pool, s scrambler.Scrambler, envMap map[string]string) {
 	competition, err := GetNewWeeklyCompetitionInfo(db)
 	if err != nil {
 		log.Println(
@@ -354,9 +397,10 @@ func AddNewWeeklyCompetition(db *pgxpool.Pool, envMap map[string]string) {
 		return
 	}
 
+	competition.RecomputeCompetitionId()
 	log.Printf("competition: %+v\n", competition)
 
-	errLog, errOut := CreateCompetition(db, competition, envMap)
+	errLog, errOut := CreateCompetition(db, competition, s, envMap)
 	if errLog != "" && errOut != "" {
 		log.Println(errLog)
 		log.Println("ERR_OUT: " + errOut)
@@ -365,4 +409,212 @@ func AddNewWeeklyCompetition(db *pgxpool.Pool, envMap map[string]string) {
 
 	log.Println("Competition successfully created !!!")
 	log.Printf("competition: %+v\n", competition)
+
+	if err = SendWeeklyCompetitionNotifications(db, competition, envMap); err != nil {
+		log.Println("ERR SendWeeklyCompetitionNotifications in AddNewWeeklyCompetition: " + err.Error())
+	}
+}
+
+// SendWeeklyCompetitionNotifications emails the users who opted in that the
+// new weekly competition is ready
+func SendWeeklyCompetitionNotifications(db *pgxpool.Pool, competition models.CompetitionData, envMap map[string]string) error {
+	ctx := context.Background()
+
+	uids, err := models.GetUsersWithNotificationEnabled(ctx, db, models.NOTIFICATION_WEEKLY_COMPETITION)
+	if err != nil {
+		log.Println("ERR models.GetUsersWithNotificationEnabled in SendWeeklyCompetitionNotifications: " + err.Error())
+		return err
+	}
+
+	// a failed user does not stop the others, the errors are returned together
+	var errs []error
+	queued := 0
+	for _, uid := range uids {
+		user, err := models.GetUserById(db, uid)
+		if err != nil {
+			log.Println("ERR models.GetUserById in SendWeeklyCompetitionNotifications: " + err.Error())
+			errs = append(errs, err)
+			continue
+		}
+
+		data := templates.WeeklyCompetitionData{
+			Username:        user.Name,
+			CompetitionName: competition.Name,
+			CompetitionUrl:  envMap["WEBSITE_HOME"] + "/competition/" + competition.Id,
+			Startdate:       competition.Startdate.UTC().Format("02 Jan 2006 15:04 MST"),
+			Enddate:         competition.Enddate.UTC().Format("02 Jan 2006 15:04 MST"),
+			UnsubscribeUrl:  models.UnsubscribeUrl(envMap, uid, models.NOTIFICATION_WEEKLY_COMPETITION),
+		}
+
+		sent, err := queueNotification(ctx, db, envMap, user, models.NOTIFICATION_WEEKLY_COMPETITION, templates.EMAIL_WEEKLY_COMPETITION, data)
+		if err != nil {
+			log.Println("ERR queueNotification in SendWeeklyCompetitionNotifications: " + err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		if sent {
+			queued++
+		}
+	}
+
+	log.Printf("Queued %d notifications of %s.\n", queued, competition.Name)
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+func overallScore(scores map[int]string, uid int) string {
+	score, ok := scores[uid]
+	if !ok {
+		return "0.00"
+	}
+
+	return score
+}
+
+// SendWeeklyDigests emails the participants who opted in their results and
+// their new records from the weekly competitions which ended since the last
+// run
+func SendWeeklyDigests(db *pgxpool.Pool, envMap map[string]string) error {
+	ctx := context.Background()
+
+	competitions, err := models.GetCompetitionsDueForDigest(ctx, db)
+	if err != nil {
+		log.Println("ERR models.GetCompetitionsDueForDigest in SendWeeklyDigests: " + err.Error())
+		return err
+	}
+
+	// a failed user does not stop the others, the errors are returned together
+	var errs []error
+	for _, competition := range competitions {
+		log.Println("Queueing digests of " + competition.Name + "...")
+
+		participants, err := models.GetCompetitionParticipants(ctx, db, competition.Id)
+		if err != nil {
+			log.Println("ERR models.GetCompetitionParticipants in SendWeeklyDigests: " + err.Error())
+			return err
+		}
+
+		scoresBefore, err := models.GetOverallScoresUntil(db, competition.Enddate, competition.Id)
+		if err != nil {
+			log.Println("ERR models.GetOverallScoresUntil(before) in SendWeeklyDigests: " + err.Error())
+			return err
+		}
+		scoresAfter, err := models.GetOverallScoresUntil(db, competition.Enddate, "")
+		if err != nil {
+			log.Println("ERR models.GetOverallScoresUntil(after) in SendWeeklyDigests: " + err.Error())
+			return err
+		}
+
+		nextCompetition, hasNext, err := models.GetNextWeeklyCompetition(ctx, db, competition.Enddate)
+		if err != nil {
+			log.Println("ERR models.GetNextWeeklyCompetition in SendWeeklyDigests: " + err.Error())
+			return err
+		}
+
+		queued := 0
+		for _, uid := range participants {
+			// skip loading the profile of users who would not get any email
+			digestEnabled, err := models.IsNotificationEnabled(ctx, db, uid, models.NOTIFICATION_WEEKLY_DIGEST)
+			if err != nil {
+				log.Println("ERR models.IsNotificationEnabled(digest) in SendWeeklyDigests: " + err.Error())
+				errs = append(errs, err)
+				continue
+			}
+			recordsEnabled, err := models.IsNotificationEnabled(ctx, db, uid, models.NOTIFICATION_PERSONAL_RECORD)
+			if err != nil {
+				log.Println("ERR models.IsNotificationEnabled(records) in SendWeeklyDigests: " + err.Error())
+				errs = append(errs, err)
+				continue
+			}
+			if !digestEnabled && !recordsEnabled {
+				continue
+			}
+
+			user, err := models.GetUserById(db, uid)
+			if err != nil {
+				log.Println("ERR models.GetUserById in SendWeeklyDigests: " + err.Error())
+				errs = append(errs, err)
+				continue
+			}
+
+			var profile models.ProfileType
+			if err = profile.Load(db, uid); err != nil {
+				log.Println("ERR profile.Load in SendWeeklyDigests: " + err.Error())
+				errs = append(errs, err)
+				continue
+			}
+
+			events := profile.DigestEvents(competition.Id)
+			if len(events) == 0 {
+				continue
+			}
+
+			if recordsEnabled {
+				records := models.RecordDigestEvents(events)
+				if len(records) > 0 {
+					data := templates.PersonalRecordData{
+						Username:        user.Name,
+						CompetitionName: competition.Name,
+						CompetitionUrl:  envMap["WEBSITE_HOME"] + "/competition/" + competition.Id,
+						Events:          records,
+						UnsubscribeUrl:  models.UnsubscribeUrl(envMap, uid, models.NOTIFICATION_PERSONAL_RECORD),
+					}
+
+					_, err = queueNotification(ctx, db, envMap, user, models.NOTIFICATION_PERSONAL_RECORD, templates.EMAIL_PERSONAL_RECORD, data)
+					if err != nil {
+						log.Println("ERR queueNotification(records) in SendWeeklyDigests: " + err.Error())
+						errs = append(errs, err)
+					}
+				}
+			}
+
+			if !digestEnabled {
+				continue
+			}
+
+			data := templates.WeeklyDigestData{
+				Username:        user.Name,
+				CompetitionName: competition.Name,
+				CompetitionUrl:  envMap["WEBSITE_HOME"] + "/competition/" + competition.Id,
+				Events:          events,
+				KinchBefore:     overallScore(scoresBefore, uid),
+				KinchAfter:      overallScore(scoresAfter, uid),
+				UnsubscribeUrl:  models.UnsubscribeUrl(envMap, uid, models.NOTIFICATION_WEEKLY_DIGEST),
+			}
+			data.KinchChange = models.KinchChange(data.KinchBefore, data.KinchAfter)
+			if hasNext {
+				data.NextCompetitionName = nextCompetition.Name
+				data.NextCompetitionUrl = envMap["WEBSITE_HOME"] + "/competition/" + nextCompetition.Id
+			}
+
+			sent, err := queueNotification(ctx, db, envMap, user, models.NOTIFICATION_WEEKLY_DIGEST, templates.EMAIL_WEEKLY_DIGEST, data)
+			if err != nil {
+				log.Println("ERR queueNotification(digest) in SendWeeklyDigests: " + err.Error())
+				errs = append(errs, err)
+				continue
+			}
+			if sent {
+				queued++
+			}
+		}
+
+		// marked even after failed users, running again would send the digest
+		// twice to the others
+		if err = models.MarkDigestSent(ctx, db, competition.Id); err != nil {
+			log.Println("ERR models.MarkDigestSent in SendWeeklyDigests: " + err.Error())
+			return err
+		}
+
+		log.Printf("Queued %d digests of %s.\n", queued, competition.Name)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
 }
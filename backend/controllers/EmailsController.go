@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+const DEFAULT_OUTBOX_EMAILS_LENGTH = 100
+
+// GetOutboxEmails returns the delivery status of the latest emails, filtered
+// by the status query parameter
+func GetOutboxEmails(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		status := c.Query("status")
+		if status != "" && !slices.Contains([]string{models.EMAIL_STATUS_PENDING, models.EMAIL_STATUS_SENT, models.EMAIL_STATUS_FAILED}, status) {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid status.")
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DEFAULT_OUTBOX_EMAILS_LENGTH)))
+		if err != nil || limit <= 0 {
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, "Invalid limit.")
+			return
+		}
+
+		emails, err := models.GetOutboxEmails(c.Request.Context(), db, status, limit)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting outbox emails", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get emails.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, emails)
+	}
+}
+
+func PostRetryOutboxEmail(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid email ID provided.")
+			return
+		}
+
+		err = models.RetryOutboxEmail(c.Request.Context(), db, id)
+		if errors.Is(err, models.ErrOutboxEmailNotFound) {
+			err = nil
+			c.IndentedJSON(http.StatusNotFound, "Failed email not found.")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when retrying email", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to retry email.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, "Email queued again.")
+	}
+}
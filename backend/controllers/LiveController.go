@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/live"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+const LIVE_RESULTS_EVENT = "results"
+
+type CompetitionResultsGetter func(db *pgxpool.Pool, cid string, eid int, round int) (models.CompetitionResultStruct, error)
+
+func liveResultsMessage(db *pgxpool.Pool, getResults CompetitionResultsGetter, topic live.Topic) (live.Message, error) {
+	results, err := getResults(db, topic.CompetitionId, topic.EventId, topic.Round)
+	if err != nil {
+		return live.Message{}, fmt.Errorf("%w: when getting results of topic=%+v", err, topic)
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return live.Message{}, fmt.Errorf("%w: when marshalling results of topic=%+v", err, topic)
+	}
+
+	return live.Message{Event: LIVE_RESULTS_EVENT, Data: data}, nil
+}
+
+func resultsTopic(resultEntry models.ResultEntry) live.Topic {
+	return live.Topic{
+		CompetitionId: resultEntry.Competitionid,
+		EventId:       resultEntry.Eventid,
+		Round:         resultEntry.RoundNumber(),
+	}
+}
+
+// PublishResults pushes the current results of the round to its live
+// subscribers, nothing is queried if there are none
+func PublishResults(db *pgxpool.Pool, hub *live.Hub, getResults CompetitionResultsGetter, topic live.Topic) {
+	if !hub.HasSubscribers(topic) {
+		return
+	}
+
+	msg, err := liveResultsMessage(db, getResults, topic)
+	if err != nil {
+		slog.Error("failed to publish live results", "error", err)
+		return
+	}
+
+	hub.Publish(topic, msg)
+}
+
+// GetLiveResults streams the results of the round of the event in the
+// competition as server-sent events, first the current ones and then every
+// change
+func GetLiveResults(db *pgxpool.Pool, hub *live.Hub, getResults CompetitionResultsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		query := r.URL.Query()
+		topic := live.Topic{CompetitionId: query.Get("competitionId"), Round: 1}
+
+		topic.EventId, err = strconv.Atoi(query.Get("eventId"))
+		if err != nil {
+			err = fmt.Errorf("%w: when parsing eventId", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Failed to parse eventId."))
+			return
+		}
+
+		if round := query.Get("round"); round != "" {
+			topic.Round, err = strconv.Atoi(round)
+			if err != nil {
+				err = fmt.Errorf("%w: when parsing round", err)
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Failed to parse round."))
+				return
+			}
+		}
+
+		initial, err := liveResultsMessage(db, getResults, topic)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Failed to get competition results."))
+			return
+		}
+
+		err = hub.ServeSSE(w, r, topic, initial, live.HEARTBEAT_INTERVAL)
+		if errors.Is(err, live.ErrStreamingUnsupported) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Streaming is not supported."))
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when streaming live results of topic=%+v", err, topic)
+		}
+	}
+}
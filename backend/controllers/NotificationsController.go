@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+// queueNotification renders the email in the language of the user and queues
+// it if they have the category on, returns whether the email was queued
+func queueNotification(
+	ctx context.Context,
+	db interfaces.DB,
+	envMap map[string]string,
+	user models.User,
+	category string,
+	name string,
+	data any,
+) (bool, error) {
+	subject, content, err := templates.RenderEmail(name, user.Language, data)
+	if err != nil {
+		return false, err
+	}
+	if os.Getenv("SPEEDCUBINGSLOVAKIA_BACKEND_ENV") == "development" {
+		subject = "DEVELOPMENT: " + subject
+	}
+
+	return models.QueueNotificationEmail(ctx, db, envMap, user.Id, category, email.Message{
+		From:    envMap["MAIL_USERNAME"],
+		To:      user.Email,
+		Subject: subject,
+		Body:    content,
+	})
+}
+
+func GetMyNotificationPreferences(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		preferences, err := models.GetNotificationPreferences(c.Request.Context(), db, c.MustGet("uid").(int))
+		if err != nil {
+			err = fmt.Errorf("%w: when getting notification preferences", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get notification preferences.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, preferences)
+	}
+}
+
+func PostMyNotificationPreference(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var req models.NotificationPreference
+		if err = c.ShouldBindJSON(&req); err != nil {
+			err = fmt.Errorf("%w: when parsing request body", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse request body.")
+			return
+		}
+
+		err = models.SetNotificationPreference(c.Request.Context(), db, c.MustGet("uid").(int), req.Category, req.Enabled)
+		if errors.Is(err, models.ErrUnknownNotificationCategory) {
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, "Unknown category, choose one of: "+strings.Join(models.NOTIFICATION_CATEGORIES, ", ")+".")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when setting notification preference", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to set notification preference.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, req)
+	}
+}
+
+// PostUnsubscribe turns off the category of the signed token, it is public so
+// the link works from the email without logging in and for the one-click
+// unsubscribe of the mail clients
+func PostUnsubscribe(db interfaces.DB, envMap map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		uid, category, err := models.ParseUnsubscribeToken(c.Query("token"), envMap["JWT_SECRET_KEY"])
+		if err != nil {
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, "Invalid unsubscribe link.")
+			return
+		}
+
+		err = models.SetNotificationPreference(c.Request.Context(), db, uid, category, false)
+		if err != nil {
+			err = fmt.Errorf("%w: when unsubscribing user with id=%d", err, uid)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to unsubscribe.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, models.NotificationPreference{Category: category, Enabled: false})
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+func respondPolygonSubscriptions(c *gin.Context, db interfaces.DB, uid int) error {
+	subscriptions, err := models.GetWCACompAnnouncementsPolygonSubscriptions(c.Request.Context(), db, uid)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, "Failed to query area subscriptions from db.")
+		return fmt.Errorf("%w: when getting polygon subscriptions", err)
+	}
+
+	collection, err := models.PolygonSubscriptionsFeatureCollection(subscriptions)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, "Failed to query area subscriptions from db.")
+		return err
+	}
+
+	c.IndentedJSON(http.StatusOK, collection)
+	return nil
+}
+
+// GetWCACompAnnouncementsPolygonSubscriptions returns the areas the user
+// subscribed to as a GeoJSON feature collection
+func GetWCACompAnnouncementsPolygonSubscriptions(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		err = respondPolygonSubscriptions(c, db, c.MustGet("uid").(int))
+	}
+}
+
+// PutWCACompAnnouncementsPolygonSubscriptions replaces the areas of the user
+// with the ones in the GeoJSON feature collection edited on the map
+func PutWCACompAnnouncementsPolygonSubscriptions(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		uid := c.MustGet("uid").(int)
+
+		var collection models.PolygonSubscriptionFeatureCollection
+		if err = c.ShouldBindJSON(&collection); err != nil {
+			err = fmt.Errorf("%w: when parsing request body", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse request body.")
+			return
+		}
+
+		subscriptions, err := models.ParsePolygonSubscriptions(uid, collection)
+		switch {
+		case errors.Is(err, models.ErrTooManyPolygonSubscriptions):
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, fmt.Sprintf("You can subscribe to at most %d areas.", models.MAX_POLYGON_SUBSCRIPTIONS_PER_USER))
+			return
+		case errors.Is(err, models.ErrInvalidGeoJSON):
+			message := "Invalid areas: " + err.Error() + "."
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, message)
+			return
+		case err != nil:
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to parse areas.")
+			return
+		}
+
+		err = models.ReplaceWCACompAnnouncementsPolygonSubscriptions(c.Request.Context(), db, uid, subscriptions)
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to save area subscriptions.")
+			return
+		}
+
+		err = respondPolygonSubscriptions(c, db, uid)
+	}
+}
@@ -2,20 +2,24 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
-	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/cube"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/live"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/middlewares"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
 )
 
@@ -164,7 +168,9 @@ type ValidateResultsBody struct {
 	Verdict  bool `json:"verdict"`
 }
 
-func ValidateResults(db *pgxpool.Pool, body ValidateResultsBody, isadmin bool) (string, string) {
+// ValidateResults approves or denies the result, the routes calling it check
+// the permission so the result is updated with the admin privileges
+func ValidateResults(db *pgxpool.Pool, hub *live.Hub, envMap map[string]string, body ValidateResultsBody) (string, string) {
 	resultEntry, err := models.GetResultEntryById(db, body.ResultId)
 	if err != nil {
 		return "ERR GetResultEntryById in PostResultsValidation: " + err.Error(), "Failed getting result entry from database."
@@ -174,21 +180,53 @@ func ValidateResults(db *pgxpool.Pool, body ValidateResultsBody, isadmin bool) (
 	if !body.Verdict {
 		statusId = 2
 	}
+	// the links in the email can be clicked more times
+	newlyApproved := body.Verdict && resultEntry.Status.Id != statusId
 	resultStatus, err := models.GetResultsStatus(db, statusId)
 	if err != nil {
 		return "ERR GetResultsStatus in PostResultsValidation: " + err.Error(), "Failed getting result status in database."
 	}
 
 	resultEntry.Status = resultStatus
-	err = resultEntry.Update(db, isadmin, resultEntry.IsFMC(), true)
+	err = resultEntry.Update(db, true, resultEntry.IsFMC(), true)
 	if err != nil {
 		return "ERR resultEntry.Update in PostResultsValidation: " + err.Error(), "Failed updating result entry in database."
 	}
 
+	go PublishResults(db, hub, models.GetResultsFromCompetitionByEventName, resultsTopic(resultEntry))
+
+	if newlyApproved {
+		go SendResultsApprovedNotification(db, resultEntry, envMap)
+	}
+
 	return "", ""
 }
 
-func GetResultsValidation(db *pgxpool.Pool) gin.HandlerFunc {
+// SendResultsApprovedNotification lets the user know their result passed the
+// check of the admins
+func SendResultsApprovedNotification(db *pgxpool.Pool, resultEntry models.ResultEntry, envMap map[string]string) {
+	user, err := models.GetUserById(db, resultEntry.Userid)
+	if err != nil {
+		log.Println("ERR models.GetUserById in SendResultsApprovedNotification: " + err.Error())
+		return
+	}
+
+	data := templates.ResultsApprovedData{
+		Username:        user.Name,
+		CompetitionName: resultEntry.Competitionname,
+		CompetitionUrl:  envMap["WEBSITE_HOME"] + "/competition/" + resultEntry.Competitionid,
+		Event:           resultEntry.Eventname,
+		Round:           resultEntry.RoundNumber(),
+		UnsubscribeUrl:  models.UnsubscribeUrl(envMap, user.Id, models.NOTIFICATION_RESULTS_APPROVED),
+	}
+
+	_, err = queueNotification(context.Background(), db, envMap, user, models.NOTIFICATION_RESULTS_APPROVED, templates.EMAIL_RESULTS_APPROVED, data)
+	if err != nil {
+		log.Println("ERR queueNotification in SendResultsApprovedNotification: " + err.Error())
+	}
+}
+
+func GetResultsValidation(db *pgxpool.Pool, hub *live.Hub, envMap map[string]string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		resultId, err := strconv.Atoi(c.DefaultQuery("resultId", "0"))
 		if err != nil {
@@ -206,8 +244,7 @@ func GetResultsValidation(db *pgxpool.Pool) gin.HandlerFunc {
 
 		body := ValidateResultsBody{ResultId: resultId, Verdict: verdict}
 
-		isadmin := c.MustGet("isadmin").(bool)
-		logMsg, retMsg := ValidateResults(db, body, isadmin)
+		logMsg, retMsg := ValidateResults(db, hub, envMap, body)
 		if logMsg != "" || retMsg != "" {
 			log.Println(logMsg)
 			c.IndentedJSON(http.StatusInternalServerError, retMsg)
@@ -223,7 +260,7 @@ func GetResultsValidation(db *pgxpool.Pool) gin.HandlerFunc {
 	}
 }
 
-func PostResultsValidation(db *pgxpool.Pool) gin.HandlerFunc {
+func PostResultsValidation(db *pgxpool.Pool, hub *live.Hub, envMap map[string]string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var body ValidateResultsBody
 
@@ -233,8 +270,7 @@ func PostResultsValidation(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		isadmin := c.MustGet("isadmin").(bool)
-		logMsg, retMsg := ValidateResults(db, body, isadmin)
+		logMsg, retMsg := ValidateResults(db, hub, envMap, body)
 		if logMsg != "" || retMsg != "" {
 			log.Println(logMsg)
 			c.IndentedJSON(http.StatusInternalServerError, retMsg)
@@ -267,9 +303,20 @@ func GetResultsByIdAndEvent(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		event, err := models.GetCompetitionEventById(db, competitionId, eventId)
+		round, err := strconv.Atoi(c.DefaultQuery("round", "1"))
 		if err != nil {
-			log.Println("ERR GetEventById in GetResultsByIdAndEvent: " + err.Error())
+			log.Println("ERR strconv(round) in GetResultsByIdAndEvent: " + err.Error())
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse round.")
+			return
+		}
+
+		event, err := models.GetCompetitionEventInRound(db, competitionId, eventId, round)
+		if err != nil {
+			log.Println("ERR GetCompetitionEventInRound in GetResultsByIdAndEvent: " + err.Error())
+			if errors.Is(err, models.ErrRoundNotFound) {
+				c.IndentedJSON(http.StatusNotFound, "Round not found.")
+				return
+			}
 			c.IndentedJSON(
 				http.StatusInternalServerError,
 				"Failed getting event information from database.",
@@ -287,7 +334,7 @@ func GetResultsByIdAndEvent(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		resultEntry, err := models.GetResultEntry(db, userId, competitionId, eventId)
+		resultEntry, err := models.GetResultEntry(db, userId, competitionId, eventId, round)
 
 		if err != nil {
 			if err.Error() != "not found" {
@@ -298,6 +345,17 @@ func GetResultsByIdAndEvent(db *pgxpool.Pool) gin.HandlerFunc {
 				)
 				return
 			} else {
+				advanced, err := models.HasAdvanced(db, userId, competitionId, eventId, round)
+				if err != nil {
+					log.Println("ERR HasAdvanced in GetResultsByIdAndEvent: " + err.Error())
+					c.IndentedJSON(http.StatusInternalServerError, "Failed checking advancement to the round.")
+					return
+				}
+				if !advanced {
+					c.IndentedJSON(http.StatusForbidden, "You did not advance to this round.")
+					return
+				}
+
 				approvedResultsStatus, err := models.GetResultsStatus(db, 3)
 				if err != nil {
 					log.Println("ERR GetResultsStatus.approved in GetResultsByIdAndEvent: " + err.Error())
@@ -305,6 +363,18 @@ func GetResultsByIdAndEvent(db *pgxpool.Pool) gin.HandlerFunc {
 					return
 				}
 
+				format, err := formats.Get(event.Format)
+				if err != nil {
+					log.Println("ERR formats.Get in GetResultsByIdAndEvent: " + err.Error())
+					c.IndentedJSON(http.StatusInternalServerError, "Unknown event format.")
+					return
+				}
+
+				solves := make([]string, format.Attempts)
+				for idx := range solves {
+					solves[idx] = "DNS"
+				}
+
 				resultEntry = models.ResultEntry{
 					Userid:          userId,
 					Username:        user.Name,
@@ -315,11 +385,8 @@ func GetResultsByIdAndEvent(db *pgxpool.Pool) gin.HandlerFunc {
 					Eventname:       event.Displayname,
 					Iconcode:        event.Iconcode,
 					Format:          event.Format,
-					Solve1:          "DNS",
-					Solve2:          "DNS",
-					Solve3:          "DNS",
-					Solve4:          "DNS",
-					Solve5:          "DNS",
+					Round:           round,
+					Solves:          solves,
 					Comment:         "",
 					Status:          approvedResultsStatus,
 				}
@@ -348,7 +415,8 @@ func GetResultsByIdAndEvent(db *pgxpool.Pool) gin.HandlerFunc {
 			resultEntry.Format = event.Format
 
 			err = resultEntry.Update(db, false, resultEntry.IsFMC())
-			if err != nil {
+			// the cutoff, advancement or format could have changed after the results were saved, keep them as they are
+			if err != nil && !errors.Is(err, formats.ErrCutoffNotMet) && !errors.Is(err, models.ErrNotAdvanced) && !errors.Is(err, models.ErrTooManyAttempts) {
 				log.Println("ERR resultEntry.Update in GetResultsByIdAndEvent: " + err.Error())
 				c.IndentedJSON(http.StatusInternalServerError, "Failed updating results in database.")
 				return
@@ -359,7 +427,7 @@ func GetResultsByIdAndEvent(db *pgxpool.Pool) gin.HandlerFunc {
 	}
 }
 
-func PostResults(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
+func PostResults(db *pgxpool.Pool, envMap map[string]string, hub *live.Hub) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var resultEntry models.ResultEntry
 		var err error
@@ -370,7 +438,7 @@ func PostResults(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
 			return
 		}
 
-		isadmin := c.MustGet("isadmin").(bool)
+		isadmin := middlewares.HasPermission(c, models.PERMISSION_RESULTS_EDIT)
 		uid := c.MustGet("uid").(int)
 		if !isadmin && uid != resultEntry.Userid {
 			c.IndentedJSON(http.StatusCreated, "Nope")
@@ -397,6 +465,21 @@ func PostResults(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
 		}
 
 		err = resultEntry.Update(db, isadmin, resultEntry.IsFMC())
+		if errors.Is(err, formats.ErrCutoffNotMet) {
+			c.IndentedJSON(
+				http.StatusBadRequest,
+				"You did not make the cutoff, no more attempts are allowed.",
+			)
+			return
+		}
+		if errors.Is(err, models.ErrNotAdvanced) {
+			c.IndentedJSON(http.StatusForbidden, "You did not advance to this round.")
+			return
+		}
+		if errors.Is(err, models.ErrTooManyAttempts) {
+			c.IndentedJSON(http.StatusBadRequest, "Too many attempts for the format of the event.")
+			return
+		}
 		if err != nil {
 			log.Println("ERR resultEntry.Update in PostResults: " + err.Error())
 			c.IndentedJSON(http.StatusInternalServerError, "Failed updating results in database.")
@@ -404,6 +487,7 @@ func PostResults(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
 		}
 
 		go resultEntry.SendSuspicousMailAsync(context.TODO(), db, envMap, previousTimes)
+		go PublishResults(db, hub, models.GetResultsFromCompetitionByEventName, resultsTopic(resultEntry))
 
 		c.IndentedJSON(http.StatusCreated, resultEntry)
 	}
@@ -547,33 +631,6 @@ func AddPlacementToRankings(rankings []RankingsEntry) {
 	}
 }
 
-func MergeNonUniqueRankings(rankings []RankingsEntry, isfmc bool) []RankingsEntry {
-	result := make([]RankingsEntry, 0)
-	best := make(map[string]RankingsEntry)
-
-	for _, rankingsEntry := range rankings {
-		entry, ok := best[rankingsEntry.Username]
-		if !ok ||
-			utils.ParseSolveToMilliseconds(
-				entry.Result,
-				false,
-				"",
-			) > utils.ParseSolveToMilliseconds(
-				rankingsEntry.Result,
-				false,
-				"",
-			) {
-			best[rankingsEntry.Username] = rankingsEntry
-		}
-	}
-
-	for _, v := range best {
-		result = append(result, v)
-	}
-
-	return result
-}
-
 func GetRankings(db *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		eid, err := strconv.Atoi(c.Query("eid"))
@@ -655,125 +712,31 @@ func GetRankings(db *pgxpool.Pool) gin.HandlerFunc {
 				rankings = append(rankings, rankingsEntry)
 			}
 		} else {
-			isfmc := false
-
-			var rows pgx.Rows
-
-			if regionType == "World" {
-				rows, err = db.Query(context.Background(), `SELECT u.name, u.wcaid, c.iso2, c.name, r.competition_id, comp.name, r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, ce.format, e.iconcode, r.event_id, rs.visible FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN competitions comp ON comp.competition_id = r.competition_id JOIN events e ON e.event_id = r.event_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id WHERE r.event_id = $1 AND rs.visible IS TRUE;`, eid)
-				if err != nil {
-					log.Println("ERR db.Query (World) in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error())
-					c.IndentedJSON(http.StatusInternalServerError, "Failed to query rankings entries from database.")
-					return
-				}
-			} else {
-				regionTypeColumn := "cont.name"
-				if regionType == "Country" {
-					regionTypeColumn = "c.name"
-				}
-				rows, err = db.Query(context.Background(), `SELECT u.name, u.wcaid, c.iso2, c.name, r.competition_id, comp.name, r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, ce.format, e.iconcode, r.event_id, rs.visible FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN competitions comp ON comp.competition_id = r.competition_id JOIN continents cont ON cont.continent_id = c.continent_id JOIN events e ON r.event_id = e.event_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id WHERE r.event_id = $1 AND `+regionTypeColumn+` = $2 AND rs.visible IS TRUE;`, eid, regionPrecise)
-				if err != nil {
-					log.Println("ERR db.Query (" + regionType + ") in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error())
-					c.IndentedJSON(http.StatusInternalServerError, "Failed to query rankings entries from database.")
-					return
-				}
+			rankingType := models.RANKING_AVERAGE
+			if single {
+				rankingType = models.RANKING_SINGLE
 			}
-			defer rows.Close()
 
-			rankingsEntries := make([]RankingsEntry, 0)
-			resultsEntries := make([]models.ResultEntry, 0)
-
-			for rows.Next() {
-				var rankingsEntry RankingsEntry
-				var resultsEntry models.ResultEntry
-				err := rows.Scan(&rankingsEntry.Username, &rankingsEntry.WcaId, &rankingsEntry.CountryISO2, &rankingsEntry.CountryName, &rankingsEntry.CompetitionId, &rankingsEntry.CompetitionName, &resultsEntry.Solve1, &resultsEntry.Solve2, &resultsEntry.Solve3, &resultsEntry.Solve4, &resultsEntry.Solve5, &resultsEntry.Format, &resultsEntry.Iconcode, &resultsEntry.Eventid, &resultsEntry.Status.Visible)
-				if err != nil {
-					log.Println("ERR scanning rows in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error())
-					c.IndentedJSON(http.StatusInternalServerError, "Failed to query rows from database.")
-					return
-				}
-				rankingsEntries = append(rankingsEntries, rankingsEntry)
-				resultsEntries = append(resultsEntries, resultsEntry)
-			}
-
-			for idx := range len(rankingsEntries) {
-				rankingsEntry := rankingsEntries[idx]
-				resultsEntry := resultsEntries[idx]
-
-				if rankingsEntry.WcaId == "" {
-					rankingsEntry.WcaId = rankingsEntry.Username
-				}
-				isfmc = utils.IsFMC(resultsEntry.Iconcode)
-				scrambles := make([]string, 5)
-				if isfmc {
-					scrambles, err = utils.GetScramblesByResultEntryId(db, resultsEntry.Eventid, rankingsEntry.CompetitionId)
-					if err != nil {
-						log.Println("ERR GetScramblesByResultEntryId in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error())
-						c.IndentedJSON(http.StatusInternalServerError, "Failed to load scrambles.")
-						return
-					}
-				}
-
-				ismbld := resultsEntry.Iconcode == "333mbf"
-
-				if single {
-					rankingsEntry.Times = []string{}
-
-					if persons {
-						rankingsEntry.Result = resultsEntry.SingleFormatted(isfmc, scrambles)
-						if utils.ParseSolveToMilliseconds(rankingsEntry.Result, false, "") >= constants.VERY_SLOW {
-							continue
-						}
-
-						rankings = append(rankings, rankingsEntry)
-					} else {
-						noOfSolves, _ := utils.GetNoOfSolves(resultsEntry.Format)
-
-						for idx, solve := range []string{resultsEntry.Solve1, resultsEntry.Solve2, resultsEntry.Solve3, resultsEntry.Solve4, resultsEntry.Solve5} {
-							if idx >= noOfSolves {
-								break
-							}
-
-							result := utils.ParseSolveToMilliseconds(solve, isfmc, scrambles[idx])
-							if ismbld {
-								rankingsEntry.Result = solve
-							} else {
-								rankingsEntry.Result = utils.FormatTime(result, isfmc)
-							}
-							if utils.ParseSolveToMilliseconds(rankingsEntry.Result, false, "") < constants.VERY_SLOW {
-								rankings = append(rankings, rankingsEntry)
-							}
-						}
-					}
-				} else if !ismbld && resultsEntry.Format != "bo1" {
-					resultFormatted, err := resultsEntry.AverageFormatted(isfmc, scrambles)
-					if err != nil {
-						log.Println("ERR AverageFormatted in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error())
-						c.IndentedJSON(http.StatusInternalServerError, "Failed to calculate average in rankings entry.")
-						return
-					}
-					rankingsEntry.Result = resultFormatted
-					if utils.ParseSolveToMilliseconds(rankingsEntry.Result, false, "") >= constants.VERY_SLOW {
-						continue
-					}
-					rankingsEntry.Times, _ = resultsEntry.GetFormattedTimes(isfmc, scrambles)
-
-					rankings = append(rankings, rankingsEntry)
-				}
+			entries, err := models.GetRankings(context.Background(), db, eid, rankingType, persons, regionType, regionPrecise, numOfEntries)
+			if err != nil {
+				log.Println("ERR models.GetRankings in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error())
+				c.IndentedJSON(http.StatusInternalServerError, "Failed to query rankings entries from database.")
+				return
 			}
 
-			if persons {
-				rankings = MergeNonUniqueRankings(rankings, isfmc)
+			for _, entry := range entries {
+				rankings = append(rankings, RankingsEntry{
+					Username:        entry.Username,
+					WcaId:           entry.WcaId,
+					CountryISO2:     entry.CountryISO2,
+					CountryName:     entry.CountryName,
+					Result:          entry.Result,
+					CompetitionId:   entry.CompetitionId,
+					CompetitionName: entry.CompetitionName,
+					Times:           entry.Times,
+				})
 			}
 
-			sort.Slice(rankings, func(i int, j int) bool {
-				val1, val2 := utils.ParseSolveToMilliseconds(rankings[i].Result, false, ""), utils.ParseSolveToMilliseconds(rankings[j].Result, false, "")
-				if val1 == val2 {
-					return rankings[i].Username < rankings[j].Username
-				}
-				return val1 < val2
-			})
-
 			AddPlacementToRankings(rankings)
 		}
 
@@ -801,259 +764,38 @@ func GetRecords(db *pgxpool.Pool) gin.HandlerFunc {
 		regionType := c.Query("regionGroup")
 		regionPrecise := c.Query("region")
 
-		recordItems := make([]RecordsItem, 0)
-
-		isfmc := false
-
-		var rows pgx.Rows
-
-		if regionType == "World" {
-			queryString := `SELECT u.name, u.wcaid, c.iso2, c.name, r.competition_id, comp.name, r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, ce.format, e.iconcode, r.event_id, rs.visible, comp.enddate, e.fulldisplayname FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN competitions comp ON comp.competition_id = r.competition_id JOIN events e ON e.event_id = r.event_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id WHERE rs.visible IS TRUE`
-			eidQueryPart := ` AND r.event_id = $1;`
-			if eid != ALL_EVENT {
-				rows, err = db.Query(context.Background(), queryString+eidQueryPart, eid)
-			} else {
-				rows, err = db.Query(context.Background(), queryString+`;`)
-			}
-			if err != nil {
-				log.Println(
-					"ERR db.Query (World) in GetRecords (" + regionType + "+" + regionPrecise + "): " + err.Error(),
-				)
-				c.IndentedJSON(
-					http.StatusInternalServerError,
-					"Failed to query records entries from database.",
-				)
-				return
-			}
-		} else {
-			regionTypeColumn := "cont.name"
-			if regionType == "Country" {
-				regionTypeColumn = "c.name"
-			}
-
-			queryString := `SELECT u.name, u.wcaid, c.iso2, c.name, r.competition_id, comp.name, r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, ce.format, e.iconcode, r.event_id, rs.visible, comp.enddate, e.fulldisplayname FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN competitions comp ON comp.competition_id = r.competition_id JOIN continents cont ON cont.continent_id = c.continent_id JOIN events e ON r.event_id = e.event_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id WHERE ` + regionTypeColumn + ` = $1 AND rs.visible IS TRUE `
-			eidQueryPart := ` AND r.event_id = $2;`
-			if eid != ALL_EVENT {
-				rows, err = db.Query(context.Background(), queryString+eidQueryPart, regionPrecise, eid)
-			} else {
-				rows, err = db.Query(context.Background(), queryString+`;`, regionPrecise)
-			}
-			if err != nil {
-				log.Println("ERR db.Query (" + regionType + ") in GetRecords (" + regionType + "+" + regionPrecise + "): " + err.Error())
-				c.IndentedJSON(http.StatusInternalServerError, "Failed to query records entries from database.")
-				return
-			}
-		}
-		defer rows.Close()
-
-		singleEntries := make(map[int][]RecordsItemEntry)
-		averageEntries := make(map[int][]RecordsItemEntry)
-
-		for rows.Next() {
-			var rankingsEntry RankingsEntry
-			var resultsEntry models.ResultEntry
-			var competitionEndDate time.Time
-			err := rows.Scan(
-				&rankingsEntry.Username,
-				&rankingsEntry.WcaId,
-				&rankingsEntry.CountryISO2,
-				&rankingsEntry.CountryName,
-				&rankingsEntry.CompetitionId,
-				&rankingsEntry.CompetitionName,
-				&resultsEntry.Solve1,
-				&resultsEntry.Solve2,
-				&resultsEntry.Solve3,
-				&resultsEntry.Solve4,
-				&resultsEntry.Solve5,
-				&resultsEntry.Format,
-				&resultsEntry.Iconcode,
-				&resultsEntry.Eventid,
-				&resultsEntry.Status.Visible,
-				&competitionEndDate,
-				&resultsEntry.Eventname,
-			)
-			if err != nil {
-				log.Println(
-					"ERR scanning rows in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error(),
-				)
-				c.IndentedJSON(
-					http.StatusInternalServerError,
-					"Failed to query rows from database.",
-				)
-				return
-			}
-
-			if rankingsEntry.WcaId == "" {
-				rankingsEntry.WcaId = rankingsEntry.Username
-			}
-			isfmc = utils.IsFMC(resultsEntry.Iconcode)
-			scrambles := make([]string, 5)
-			if isfmc {
-				scrambles, err = utils.GetScramblesByResultEntryId(
-					db,
-					resultsEntry.Eventid,
-					rankingsEntry.CompetitionId,
-				)
-				if err != nil {
-					log.Println(
-						"ERR GetScramblesByResultEntryId in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error(),
-					)
-					c.IndentedJSON(http.StatusInternalServerError, "Failed to load scrambles.")
-					return
-				}
-			}
-
-			recordsItemEntrySingle := RecordsItemEntry{
-				Type:               "Single",
-				Username:           rankingsEntry.Username,
-				WcaId:              rankingsEntry.WcaId,
-				CountryIso2:        rankingsEntry.CountryISO2,
-				CountryName:        rankingsEntry.CountryName,
-				CompetitionName:    rankingsEntry.CompetitionName,
-				CompetitionId:      rankingsEntry.CompetitionId,
-				Solves:             []string{},
-				CompetitionEndDate: competitionEndDate,
-				EventName:          resultsEntry.Eventname,
-				IconCode:           resultsEntry.Iconcode,
-			}
-
-			recordsItemEntryAverage := recordsItemEntrySingle
-			recordsItemEntryAverage.Type = "Average"
-			if val, err := utils.GetNoOfSolves(resultsEntry.Format); err == nil && val < 5 {
-				recordsItemEntryAverage.Type = "Mean"
-			}
-
-			recordsItemEntrySingle.Result = resultsEntry.SingleFormatted(isfmc, scrambles)
-			if utils.ParseSolveToMilliseconds(
-				recordsItemEntrySingle.Result,
-				false,
-				"",
-			) >= constants.VERY_SLOW {
-				continue
-			}
-			singleEntry, ok := singleEntries[resultsEntry.Eventid]
-			if !ok ||
-				utils.ParseSolveToMilliseconds(
-					singleEntry[0].Result,
-					false,
-					"",
-				) >= utils.ParseSolveToMilliseconds(
-					recordsItemEntrySingle.Result,
-					false,
-					"",
-				) {
-				if !ok ||
-					utils.ParseSolveToMilliseconds(
-						singleEntry[0].Result,
-						false,
-						"",
-					) > utils.ParseSolveToMilliseconds(
-						recordsItemEntrySingle.Result,
-						false,
-						"",
-					) {
-					singleEntries[resultsEntry.Eventid] = []RecordsItemEntry{}
-				}
-				singleEntries[resultsEntry.Eventid] = append(
-					singleEntries[resultsEntry.Eventid],
-					recordsItemEntrySingle,
-				)
-			}
-
-			recordsItemEntryAverage.Result = "DNS"
-
-			if resultsEntry.Iconcode != "333mbf" && resultsEntry.Format != "bo1" {
-				resultFormatted, err := resultsEntry.AverageFormatted(isfmc, scrambles)
-				if err != nil {
-					log.Println(
-						"ERR AverageFormatted in GetRankings (" + regionType + "+" + regionPrecise + "): " + err.Error(),
-					)
-					c.IndentedJSON(
-						http.StatusInternalServerError,
-						"Failed to calculate average in rankings entry.",
-					)
-					return
-				}
-				recordsItemEntryAverage.Result = resultFormatted
-				if utils.ParseSolveToMilliseconds(
-					recordsItemEntryAverage.Result,
-					false,
-					"",
-				) >= constants.VERY_SLOW {
-					continue
-				}
-				recordsItemEntryAverage.Solves, _ = resultsEntry.GetFormattedTimes(isfmc, scrambles)
-
-				averageEntry, ok := averageEntries[resultsEntry.Eventid]
-				if !ok ||
-					utils.ParseSolveToMilliseconds(
-						averageEntry[0].Result,
-						false,
-						"",
-					) >= utils.ParseSolveToMilliseconds(
-						recordsItemEntryAverage.Result,
-						false,
-						"",
-					) {
-					if !ok ||
-						utils.ParseSolveToMilliseconds(
-							averageEntry[0].Result,
-							false,
-							"",
-						) > utils.ParseSolveToMilliseconds(
-							recordsItemEntryAverage.Result,
-							false,
-							"",
-						) {
-						averageEntries[resultsEntry.Eventid] = []RecordsItemEntry{}
-					}
-					averageEntries[resultsEntry.Eventid] = append(
-						averageEntries[resultsEntry.Eventid],
-						recordsItemEntryAverage,
-					)
-				}
-			}
-		}
-
-		eventIDs := make([]int, len(singleEntries))
-		idx := 0
-		for key := range singleEntries {
-			eventIDs[idx] = key
-			idx++
+		records, err := models.GetRecords(context.Background(), db, eid, regionType, regionPrecise)
+		if err != nil {
+			log.Println("ERR models.GetRecords in GetRecords (" + regionType + "+" + regionPrecise + "): " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to query records entries from database.")
+			return
 		}
-		sort.Slice(eventIDs, func(i int, j int) bool { return eventIDs[i] < eventIDs[j] })
 
-		for _, eventID := range eventIDs {
-			if len(singleEntries[eventID]) == 0 {
-				continue
-			}
-
-			recordItem := RecordsItem{
-				EventName: singleEntries[eventID][0].EventName,
-				Iconcode:  singleEntries[eventID][0].IconCode,
-				Entries:   []RecordsItemEntry{},
+		recordItems := make([]RecordsItem, 0)
+		for _, record := range records {
+			if len(recordItems) == 0 || recordItems[len(recordItems)-1].Iconcode != record.Iconcode {
+				recordItems = append(recordItems, RecordsItem{
+					EventName: record.EventName,
+					Iconcode:  record.Iconcode,
+					Entries:   []RecordsItemEntry{},
+				})
 			}
 
-			sort.Slice(singleEntries[eventID], func(i int, j int) bool {
-				item1 := singleEntries[eventID][i]
-				item2 := singleEntries[eventID][j]
-				if item1.CompetitionEndDate.Equal(item1.CompetitionEndDate) {
-					return item1.Username < item2.Username
-				}
-				return item1.CompetitionEndDate.Before(item2.CompetitionEndDate)
-			})
-			recordItem.Entries = append(recordItem.Entries, singleEntries[eventID]...)
-			sort.Slice(averageEntries[eventID], func(i int, j int) bool {
-				item1 := averageEntries[eventID][i]
-				item2 := averageEntries[eventID][j]
-				if item1.CompetitionEndDate.Equal(item1.CompetitionEndDate) {
-					return item1.Username < item2.Username
-				}
-				return item1.CompetitionEndDate.Before(item2.CompetitionEndDate)
+			item := &recordItems[len(recordItems)-1]
+			item.Entries = append(item.Entries, RecordsItemEntry{
+				Type:               record.Type,
+				Username:           record.Username,
+				WcaId:              record.WcaId,
+				Result:             record.Result,
+				CountryIso2:        record.CountryISO2,
+				CountryName:        record.CountryName,
+				CompetitionName:    record.CompetitionName,
+				CompetitionId:      record.CompetitionId,
+				Solves:             record.Times,
+				CompetitionEndDate: record.CompetitionEndDate,
+				EventName:          record.EventName,
+				IconCode:           record.Iconcode,
 			})
-			recordItem.Entries = append(recordItem.Entries, averageEntries[eventID]...)
-
-			recordItems = append(recordItems, recordItem)
 		}
 
 		c.IndentedJSON(http.StatusOK, recordItems)
@@ -1068,6 +810,7 @@ type AverageInfo struct {
 	Wpa                 string   `json:"wpa"`
 	ShowPossibleAverage bool     `json:"showPossibleAverage"`
 	FinishedCompeting   bool     `json:"finishedCompeting"`
+	CutoffFailed        bool     `json:"cutoffFailed"`
 	Place               string   `json:"place"`
 	SingleRecord        string   `json:"singleRecord"`
 	SingleRecordColor   string   `json:"singleRecordColor"`
@@ -1092,6 +835,7 @@ func GetAverageInfo(db *pgxpool.Pool) gin.HandlerFunc {
 			db,
 			resultEntry.Eventid,
 			resultEntry.Competitionid,
+			resultEntry.RoundNumber(),
 		)
 		if err != nil {
 			log.Println("ERR utils.GetScramblesByResultEntryId in GetAverageInfo: " + err.Error())
@@ -1101,6 +845,27 @@ func GetAverageInfo(db *pgxpool.Pool) gin.HandlerFunc {
 			)
 			return
 		}
+
+		event, err := models.GetCompetitionEventInRound(db, resultEntry.Competitionid, resultEntry.Eventid, resultEntry.RoundNumber())
+		if err != nil {
+			log.Println("ERR models.GetCompetitionEventInRound in GetAverageInfo: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed getting event information from database.")
+			return
+		}
+
+		// show the attempts the way they are going to be saved
+		err = resultEntry.ApplyRoundRules(event, resultEntry.IsFMC(), resultEntry.Scrambles)
+		if err != nil && !errors.Is(err, formats.ErrCutoffNotMet) {
+			log.Println("ERR resultEntry.ApplyRoundRules in GetAverageInfo: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to apply cutoff and time limit.")
+			return
+		}
+		averageInfo.CutoffFailed = resultEntry.CutoffFailed(
+			event,
+			resultEntry.IsFMC(),
+			resultEntry.Scrambles,
+		)
+
 		averageInfo.Single = resultEntry.SingleFormatted(resultEntry.IsFMC(), resultEntry.Scrambles)
 
 		avg, err := resultEntry.AverageFormatted(resultEntry.IsFMC(), resultEntry.Scrambles)
@@ -1113,6 +878,9 @@ func GetAverageInfo(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 		averageInfo.Average = avg
+		if averageInfo.CutoffFailed {
+			averageInfo.Average = ""
+		}
 
 		formattedTimes, err := resultEntry.GetFormattedTimes(
 			resultEntry.IsFMC(),
@@ -1135,7 +903,7 @@ func GetAverageInfo(db *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		if ok {
+		if ok && !averageInfo.CutoffFailed {
 			averageInfo.ShowPossibleAverage = true
 
 			averageInfo.Bpa, err = resultEntry.GetBPA()
@@ -1159,9 +927,9 @@ func GetAverageInfo(db *pgxpool.Pool) gin.HandlerFunc {
 			}
 		}
 
-		averageInfo.FinishedCompeting, err = resultEntry.FinishedCompeting()
+		averageInfo.FinishedCompeting, err = resultEntry.FinishedCompetingInEvent(event)
 		if err != nil {
-			log.Println("ERR resultEntry.FinishedCompeting in GetAverageInfo: " + err.Error())
+			log.Println("ERR resultEntry.FinishedCompetingInEvent in GetAverageInfo: " + err.Error())
 			c.IndentedJSON(
 				http.StatusInternalServerError,
 				"Failed to check if you finished competing.",
@@ -1201,12 +969,31 @@ func GetAverageInfoRecords(db *pgxpool.Pool) gin.HandlerFunc {
 		resultEntry := body.ResultEntry
 		averageInfo := body.AverageInfo
 
-		var err error
+		event, err := models.GetCompetitionEventInRound(db, resultEntry.Competitionid, resultEntry.Eventid, resultEntry.RoundNumber())
+		if err != nil {
+			log.Println("ERR models.GetCompetitionEventInRound in GetAverageInfoRecords: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed getting event information from database.")
+			return
+		}
 
-		averageInfo.FinishedCompeting, err = resultEntry.FinishedCompeting()
+		if resultEntry.IsFMC() {
+			resultEntry.Scrambles, err = utils.GetScramblesByResultEntryId(
+				db,
+				resultEntry.Eventid,
+				resultEntry.Competitionid,
+				resultEntry.RoundNumber(),
+			)
+			if err != nil {
+				log.Println("ERR utils.GetScramblesByResultEntryId in GetAverageInfoRecords: " + err.Error())
+				c.IndentedJSON(http.StatusInternalServerError, "Failed to get scrambles for result entry.")
+				return
+			}
+		}
+
+		averageInfo.FinishedCompeting, err = resultEntry.FinishedCompetingInEvent(event)
 		if err != nil {
 			log.Println(
-				"ERR resultEntry.FinishedCompeting in GetAverageInfoRecords: " + err.Error(),
+				"ERR resultEntry.FinishedCompetingInEvent in GetAverageInfoRecords: " + err.Error(),
 			)
 			c.IndentedJSON(
 				http.StatusInternalServerError,
@@ -1282,3 +1069,89 @@ func GetAverageInfoRecords(db *pgxpool.Pool) gin.HandlerFunc {
 		c.IndentedJSON(http.StatusOK, averageInfo)
 	}
 }
+
+type FMCSolveVerification struct {
+	Solve    int             `json:"solve"`
+	Scramble string          `json:"scramble"`
+	Solution string          `json:"solution"`
+	Report   *cube.FMCReport `json:"report"`
+}
+
+// GetFMCVerification explains the saved fewest moves solutions of the user,
+// solves without a solution (DNF, DNS) have no report.
+func GetFMCVerification(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		eventId, err := strconv.Atoi(c.Param("eid"))
+		if err != nil {
+			log.Println("ERR strconv.eid in GetFMCVerification: " + err.Error())
+			c.IndentedJSON(http.StatusBadRequest, "Failed parsing eventId.")
+			return
+		}
+
+		competitionId := c.Param("cid")
+		uid := c.MustGet("uid").(int)
+
+		round, err := strconv.Atoi(c.DefaultQuery("round", "1"))
+		if err != nil {
+			log.Println("ERR strconv(round) in GetFMCVerification: " + err.Error())
+			c.IndentedJSON(http.StatusBadRequest, "Failed parsing round.")
+			return
+		}
+
+		event, err := models.GetCompetitionEventById(db, competitionId, eventId)
+		if err != nil {
+			log.Println("ERR GetCompetitionEventById in GetFMCVerification: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed getting event information from database.")
+			return
+		}
+
+		if event.Iconcode != "333fm" {
+			c.IndentedJSON(http.StatusBadRequest, "Event is not fewest moves.")
+			return
+		}
+
+		resultEntry, err := models.GetResultEntry(db, uid, competitionId, eventId, round)
+		if err != nil {
+			if err.Error() == "not found" {
+				c.IndentedJSON(http.StatusNotFound, "No results saved for this event.")
+				return
+			}
+			log.Println("ERR GetResultEntry in GetFMCVerification: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed getting result entry from database.")
+			return
+		}
+
+		scrambles, err := utils.GetScramblesByResultEntryId(db, eventId, competitionId, round)
+		if err != nil {
+			log.Println("ERR GetScramblesByResultEntryId in GetFMCVerification: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed getting scrambles from database.")
+			return
+		}
+
+		format, err := formats.Get(event.Format)
+		if err != nil {
+			log.Println("ERR formats.Get in GetFMCVerification: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Unknown event format.")
+			return
+		}
+		noOfSolves := format.Attempts
+
+		verifications := make([]FMCSolveVerification, 0, noOfSolves)
+		for idx := range min(noOfSolves, len(scrambles)) {
+			verification := FMCSolveVerification{
+				Solve:    idx + 1,
+				Scramble: scrambles[idx],
+				Solution: resultEntry.GetNthSolve(idx + 1),
+			}
+
+			if verification.Solution != "DNF" && verification.Solution != "DNS" {
+				report := cube.VerifyFMCSolution(verification.Scramble, verification.Solution)
+				verification.Report = &report
+			}
+
+			verifications = append(verifications, verification)
+		}
+
+		c.IndentedJSON(http.StatusOK, verifications)
+	}
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func setSessionTokens(authInfo *models.AuthorizationInfo, session models.Session, refreshToken string, envMap map[string]string) error {
+	accessToken, err := utils.CreateToken(session.UserId, session.Id, envMap["JWT_SECRET_KEY"], models.ACCESS_TOKEN_TTL)
+	if err != nil {
+		return err
+	}
+
+	authInfo.AccessToken = accessToken
+	authInfo.ExpiresIn = int(models.ACCESS_TOKEN_TTL.Seconds())
+	authInfo.RefreshToken = refreshToken
+	authInfo.RefreshExpiresIn = int(models.REFRESH_TOKEN_TTL.Seconds())
+
+	return nil
+}
+
+// PostRefreshToken exchanges the refresh token for a new access token and a
+// new refresh token, the old refresh token can not be used again
+func PostRefreshToken(db interfaces.DB, envMap map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var req RefreshTokenRequest
+		if err = c.ShouldBindJSON(&req); err != nil {
+			err = fmt.Errorf("%w: when parsing request body", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse request body.")
+			return
+		}
+
+		session, refreshToken, err := models.RotateRefreshToken(c.Request.Context(), db, req.RefreshToken)
+		switch {
+		case errors.Is(err, models.ErrRefreshTokenReused):
+			c.IndentedJSON(http.StatusUnauthorized, "Refresh token was already used, the session was revoked.")
+			return
+		case errors.Is(err, models.ErrSessionNotFound), errors.Is(err, models.ErrSessionRevoked):
+			err = nil
+			c.IndentedJSON(http.StatusUnauthorized, "Session expired or revoked.")
+			return
+		case err != nil:
+			err = fmt.Errorf("%w: when rotating refresh token", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to refresh session.")
+			return
+		}
+
+		var authInfo models.AuthorizationInfo
+		if err = setSessionTokens(&authInfo, session, refreshToken, envMap); err != nil {
+			err = fmt.Errorf("%w: when creating token", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed creating token.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, gin.H{
+			"access_token":       authInfo.AccessToken,
+			"expires_in":         authInfo.ExpiresIn,
+			"refresh_token":      authInfo.RefreshToken,
+			"refresh_expires_in": authInfo.RefreshExpiresIn,
+		})
+	}
+}
+
+func PostLogOut(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		err = models.RevokeSession(c.Request.Context(), db, c.MustGet("sid").(int), c.MustGet("uid").(int))
+		if err != nil {
+			err = fmt.Errorf("%w: when revoking session", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to log out.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, "Logged out.")
+	}
+}
+
+func PostLogOutEverywhere(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		revoked, err := models.RevokeUserSessions(c.Request.Context(), db, c.MustGet("uid").(int))
+		if err != nil {
+			err = fmt.Errorf("%w: when revoking sessions", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to log out.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, revoked)
+	}
+}
+
+func GetMySessions(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		sessions, err := models.GetActiveSessions(c.Request.Context(), db, c.MustGet("uid").(int))
+		if err != nil {
+			err = fmt.Errorf("%w: when getting sessions", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get sessions.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, sessions)
+	}
+}
+
+func DeleteMySession(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		sid, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid session ID provided.")
+			return
+		}
+
+		err = models.RevokeSession(c.Request.Context(), db, sid, c.MustGet("uid").(int))
+		if errors.Is(err, models.ErrSessionNotFound) {
+			c.IndentedJSON(http.StatusNotFound, "Session not found.")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when revoking session", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to revoke session.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, "Session revoked.")
+	}
+}
+
+// PostRevokeUserSessions lets an admin log out the user everywhere, e.g. when
+// their account was compromised or their admin role was taken away
+func PostRevokeUserSessions(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		uid, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid user ID provided.")
+			return
+		}
+
+		revoked, err := models.RevokeUserSessions(c.Request.Context(), db, uid)
+		if err != nil {
+			err = fmt.Errorf("%w: when revoking sessions of user with id=%d", err, uid)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to revoke sessions.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, revoked)
+	}
+}
@@ -22,7 +22,7 @@ func GetAdminStats(db *pgxpool.Pool) gin.HandlerFunc {
 
 		rows, err := db.Query(
 			context.Background(),
-			`SELECT r.user_id, r.competition_id, c.enddate FROM results r JOIN competitions c ON c.competition_id = r.competition_id WHERE solve1 != 'DNS' or solve2 != 'DNS' or solve3 != 'DNS' or solve4 != 'DNS' or solve5 != 'DNS';`,
+			`SELECT r.user_id, r.competition_id, c.enddate FROM results r JOIN competitions c ON c.competition_id = r.competition_id WHERE EXISTS (SELECT 1 FROM unnest(r.solves) s WHERE s != 'DNS');`,
 		)
 		if err != nil {
 			log.Println("ERR db.Query(results) in GetAdminStats: " + err.Error())
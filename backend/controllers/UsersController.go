@@ -3,19 +3,25 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
 )
 
 func GetManageUsers(db interfaces.DB) gin.HandlerFunc {
@@ -50,7 +56,18 @@ func ManageUserRole(db interfaces.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err = manageUser.UpdateRole(ctx, db); err != nil {
+		// without this the last super admin could lock everyone out
+		if manageUser.Id == c.MustGet("uid").(int) && !slices.Contains(manageUser.Roles, models.ROLE_SUPER_ADMIN) {
+			c.IndentedJSON(http.StatusBadRequest, "You can not take away your own super admin role.")
+			return
+		}
+
+		err = manageUser.UpdateRole(ctx, db)
+		if errors.Is(err, models.ErrUnknownRole) {
+			c.IndentedJSON(http.StatusBadRequest, "Unknown role.")
+			return
+		}
+		if err != nil {
 			err = fmt.Errorf("%w: when updating user role", err)
 			c.IndentedJSON(http.StatusInternalServerError, "Failed to update user role.")
 			return
@@ -60,7 +77,39 @@ func ManageUserRole(db interfaces.DB) gin.HandlerFunc {
 	}
 }
 
-func PostLogIn(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
+func GetRoles(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		roles, err := models.GetRoles(c.Request.Context(), db)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting roles", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get roles.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, roles)
+	}
+}
+
+// GetMyPermissions returns the permissions of the user, users without any
+// have no access to the dashboard
+func GetMyPermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions := c.MustGet("permissions").([]string)
+		if len(permissions) == 0 {
+			c.IndentedJSON(http.StatusForbidden, "forbidden")
+			return
+		}
+
+		c.IndentedJSON(http.StatusAccepted, permissions)
+	}
+}
+
+// PostLogIn logs the user in through the provider, the request body holds the
+// credentials for it (the WCA authorization code, the login link credentials)
+func PostLogIn(db interfaces.DB, envMap map[string]string, provider models.LoginProvider) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.TODO()
 
@@ -71,36 +120,26 @@ func PostLogIn(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
 			return
 		}
 
-		code := string(reqBodyBytes)
-		authInfo, err := models.GetAuthInfo(code, envMap)
-		if err != nil {
-			log.Println("ERR GetAuthInfo in PostLogIn: " + err.Error())
-			c.IndentedJSON(http.StatusInternalServerError, "Failed getting auth info for WCA.")
+		user, created, err := provider.Login(ctx, db, string(reqBodyBytes))
+		if errors.Is(err, models.ErrLoginLinkInvalid) {
+			c.IndentedJSON(http.StatusBadRequest, "The login link is invalid, already used or expired.")
 			return
 		}
-
-		user, err := models.GetUserInfoFromWCA(&authInfo, envMap)
-		if err != nil {
-			log.Println("ERR GetUserInfoFromWCA in PostLogIn: " + err.Error())
-			c.IndentedJSON(http.StatusInternalServerError, "Failed getting user info from WCA.")
+		if errors.Is(err, models.ErrNameRequired) {
+			c.IndentedJSON(http.StatusUnprocessableEntity, "Fill in your name to finish the registration.")
+			return
+		}
+		if errors.Is(err, models.ErrUserNameTaken) {
+			c.IndentedJSON(http.StatusConflict, "A user with this name already exists, use a different one.")
 			return
 		}
-
-		exists, err := user.Exists(ctx, db)
 		if err != nil {
-			log.Println("ERR user.Exists in PostLogIn: " + err.Error())
-			c.IndentedJSON(
-				http.StatusInternalServerError,
-				"Failed getting user info from database.",
-			)
+			log.Println("ERR provider.Login in PostLogIn: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to log in.")
 			return
 		}
 
-		if exists {
-			err = user.Update(db)
-		} else {
-			err = user.Insert(ctx, db)
-
+		if created {
 			go func() {
 				if err := user.SendNewUserMailAsync(ctx, db, envMap); err != nil {
 					utils.PrintStack(&err)
@@ -108,36 +147,146 @@ func PostLogIn(db *pgxpool.Pool, envMap map[string]string) gin.HandlerFunc {
 			}()
 		}
 
+		authInfo, err := startSession(ctx, c, db, envMap, user)
+		if err != nil {
+			log.Println("ERR startSession in PostLogIn: " + err.Error())
+			c.IndentedJSON(http.StatusInternalServerError, "Failed creating session.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, authInfo)
+	}
+}
+
+func startSession(ctx context.Context, c *gin.Context, db interfaces.DB, envMap map[string]string, user models.User) (models.AuthorizationInfo, error) {
+	var authInfo models.AuthorizationInfo
+	authInfo.AvatarUrl = user.AvatarUrl
+	authInfo.WcaId = user.WcaId
+	if user.WcaId == "" {
+		authInfo.WcaId = user.Name
+	}
+	authInfo.IsAdmin = user.IsAdmin
+	authInfo.Username = user.Name
+
+	session, refreshToken, err := models.CreateSession(ctx, db, user.Id, c.Request.UserAgent(), models.REFRESH_TOKEN_TTL, true)
+	if err != nil {
+		return models.AuthorizationInfo{}, fmt.Errorf("%w: when creating session", err)
+	}
+
+	err = setSessionTokens(&authInfo, session, refreshToken, envMap)
+	if err != nil {
+		return models.AuthorizationInfo{}, fmt.Errorf("%w: when creating token", err)
+	}
+
+	return authInfo, nil
+}
+
+type LoginLinkRequest struct {
+	Email    string `json:"email"`
+	Language string `json:"language"`
+}
+
+// PostLoginLink emails a one-time login link, for people logging in without a
+// WCA account. The answer does not tell whether a user has the email.
+func PostLoginLink(db interfaces.DB, envMap map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var req LoginLinkRequest
+		if err = c.ShouldBindJSON(&req); err != nil {
+			err = fmt.Errorf("%w: when parsing request body", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse request body.")
+			return
+		}
+
+		token, language, err := models.CreateLoginLink(c.Request.Context(), db, req.Email, req.Language)
+		switch {
+		case errors.Is(err, models.ErrInvalidEmail):
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, "Invalid email.")
+			return
+		case errors.Is(err, models.ErrTooManyLoginLinks):
+			err = nil
+			c.IndentedJSON(http.StatusTooManyRequests, "Too many login links requested, try again later.")
+			return
+		case err != nil:
+			err = fmt.Errorf("%w: when creating login link", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to create login link.")
+			return
+		}
+
+		mailSubject, content, err := templates.RenderEmail(templates.EMAIL_LOGIN_LINK, language, templates.LoginLinkData{
+			Link:       envMap["WEBSITE_HOME"] + "/login/email?token=" + token,
+			TTLMinutes: int(models.LOGIN_LINK_TTL.Minutes()),
+		})
+		if err != nil {
+			err = fmt.Errorf("%w: when rendering login link email", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to send login link.")
+			return
+		}
+		if envMap["NODE_ENV"] == "development" {
+			mailSubject = "DEVELOPMENT: " + mailSubject
+		}
+
+		_, err = models.QueueEmail(c.Request.Context(), db, 0, email.Message{
+			From:    envMap["MAIL_USERNAME"],
+			To:      strings.TrimSpace(req.Email),
+			Subject: mailSubject,
+			Body:    content,
+		})
+		if err != nil {
+			err = fmt.Errorf("%w: when queueing login link", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to send login link.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, "Login link sent, check your email.")
+	}
+}
+
+// PostLinkWCAAccount links the WCA account (the request body is the WCA
+// authorization code) to the user registered by the email, a new session with
+// the updated user info is returned
+func PostLinkWCAAccount(db interfaces.DB, envMap map[string]string, client wca.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		ctx := c.Request.Context()
+
+		reqBodyBytes, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			err = fmt.Errorf("%w: when reading request body", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to parse incoming data.")
+			return
+		}
+
+		wcaUser, err := models.GetWCAUser(ctx, client, string(reqBodyBytes))
 		if err != nil {
-			log.Println(
-				"ERR (",
-				exists,
-				")user.Update or (",
-				!exists,
-				")user.Insert in PostLogIn: "+err.Error(),
-			)
-			c.IndentedJSON(
-				http.StatusInternalServerError,
-				"Failed updating/insert user data into database.",
-			)
-			return
-		}
-
-		authInfo.AvatarUrl = user.AvatarUrl
-		authInfo.WcaId = user.WcaId
-		if user.WcaId == "" {
-			authInfo.WcaId = user.Name
-		}
-		authInfo.AccessToken, err = utils.CreateToken(
-			user.Id,
-			envMap["JWT_SECRET_KEY"],
-			authInfo.ExpiresIn,
-		)
-		authInfo.IsAdmin = user.IsAdmin
-		authInfo.Username = user.Name
+			c.IndentedJSON(http.StatusInternalServerError, "Failed getting user info from WCA.")
+			return
+		}
+
+		user, err := models.LinkWCAAccount(ctx, db, c.MustGet("uid").(int), wcaUser)
+		switch {
+		case errors.Is(err, models.ErrWCAAccountAlreadyLinked):
+			err = nil
+			c.IndentedJSON(http.StatusConflict, "Your account already has a linked WCA account.")
+			return
+		case errors.Is(err, models.ErrWCAAccountInUse):
+			err = nil
+			c.IndentedJSON(http.StatusConflict, "The WCA account already belongs to another user, ask an admin to merge the users.")
+			return
+		case err != nil:
+			err = fmt.Errorf("%w: when linking wca account", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to link WCA account.")
+			return
+		}
+
+		authInfo, err := startSession(ctx, c, db, envMap, user)
 		if err != nil {
-			log.Println("ERR CreateToken in PostLogIn: " + err.Error())
-			c.IndentedJSON(http.StatusInternalServerError, "Failed creating token.")
+			c.IndentedJSON(http.StatusInternalServerError, "Failed creating session.")
 			return
 		}
 
@@ -232,6 +381,34 @@ func FindDuplicateUser(db interfaces.DB) gin.HandlerFunc {
 	}
 }
 
+func GetDuplicateUsers(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		minScore, err := strconv.ParseFloat(c.DefaultQuery("minScore", strconv.Itoa(models.DUPLICATE_DEFAULT_MIN_SCORE)), 64)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid minScore provided.")
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(models.DUPLICATE_DEFAULT_REPORT_LENGTH)))
+		if err != nil || limit < 1 {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid limit provided.")
+			return
+		}
+
+		report, err := models.GetDuplicateUsersReport(c.Request.Context(), db, minScore, limit)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting duplicate users report", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to find duplicate users.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, report)
+	}
+}
+
 type MergeUsersRequest struct {
 	OldUserID int `json:"old_user_id" binding:"required"`
 	NewUserID int `json:"new_user_id" binding:"required"`
@@ -250,7 +427,7 @@ func MergeUsers(db interfaces.DB) gin.HandlerFunc {
 		}
 
 		ctx := c.Request.Context()
-		if err := models.MergeUsers(ctx, db, req.OldUserID, req.NewUserID); err != nil {
+		if _, err = models.MergeUsers(ctx, db, req.OldUserID, req.NewUserID, c.MustGet("uid").(int)); err != nil {
 			err = fmt.Errorf("%w: when merging users", err)
 			c.IndentedJSON(http.StatusInternalServerError, "Failed to merge users.")
 			return
@@ -259,3 +436,125 @@ func MergeUsers(db interfaces.DB) gin.HandlerFunc {
 		c.IndentedJSON(http.StatusOK, "Users merged successfully.")
 	}
 }
+
+func GetUserMerges(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		merges, err := models.GetUserMerges(c.Request.Context(), db)
+		if err != nil {
+			err = fmt.Errorf("%w: when getting user merges", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get user merges.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, merges)
+	}
+}
+
+func GetUserMerge(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		mergeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid merge ID provided.")
+			return
+		}
+
+		var merge models.UserMerge
+		err = merge.Get(c.Request.Context(), db, mergeID)
+		if errors.Is(err, models.ErrUserMergeNotFound) {
+			c.IndentedJSON(http.StatusNotFound, "Merge not found.")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when getting user merge", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get user merge.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, merge)
+	}
+}
+
+func UnmergeUsers(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		mergeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest, "Invalid merge ID provided.")
+			return
+		}
+
+		err = models.UnmergeUsers(c.Request.Context(), db, mergeID, c.MustGet("uid").(int))
+		switch {
+		case errors.Is(err, models.ErrUserMergeNotFound):
+			c.IndentedJSON(http.StatusNotFound, "Merge not found.")
+		case errors.Is(err, models.ErrAlreadyUnmerged):
+			c.IndentedJSON(http.StatusConflict, "Merge was already undone.")
+		case errors.Is(err, models.ErrMergeNotLatest):
+			c.IndentedJSON(http.StatusConflict, "The user was merged again since, undo that merge first.")
+		case err != nil:
+			err = fmt.Errorf("%w: when unmerging users", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to unmerge users.")
+		default:
+			c.IndentedJSON(http.StatusOK, "Users unmerged successfully.")
+		}
+	}
+}
+
+// GetMe returns the profile of the authorized user, e.g. for tools using a
+// personal access token to find out whose it is
+func GetMe(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		user, err := models.GetUserById(db, c.MustGet("uid").(int))
+		if err != nil {
+			err = fmt.Errorf("%w: when getting user", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get user.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, user)
+	}
+}
+
+type LanguageRequest struct {
+	Language string `json:"language" binding:"required"`
+}
+
+// PostMyLanguage sets the language of the emails sent to the user
+func PostMyLanguage(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var req LanguageRequest
+		if err = c.ShouldBindJSON(&req); err != nil {
+			err = fmt.Errorf("%w: when parsing request body", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse request body.")
+			return
+		}
+
+		err = models.SetUserLanguage(c.Request.Context(), db, c.MustGet("uid").(int), req.Language)
+		if errors.Is(err, models.ErrUnknownLanguage) {
+			err = nil
+			c.IndentedJSON(http.StatusBadRequest, "Unknown language, choose one of: "+strings.Join(templates.LANGUAGES, ", ")+".")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when setting language", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to set language.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, req.Language)
+	}
+}
@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -14,13 +13,17 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	views "github.com/jakubdrobny/speedcubingslovakia/backend"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
 )
 
 func GetWCARegionGroups(db *pgxpool.Pool) gin.HandlerFunc {
@@ -146,19 +149,15 @@ func GetSavedUpcomingWCACompetitions(
 	countryId,
 	stateId string,
 ) ([]models.UpcomingWCACompetition, error) {
-	queryString := `SELECT upcoming_wca_competition_id as id, name, startdate, enddate, registered, competitor_limit, venue_address, url, registration_open, state, registration_close, latitude_degrees, longitude_degrees FROM upcoming_wca_competitions`
+	queryString := `SELECT upcoming_wca_competition_id as id, name, startdate, enddate, registered, competitor_limit, venue_address, url, registration_open, state, registration_close, latitude_degrees, longitude_degrees FROM upcoming_wca_competitions WHERE cancelled IS FALSE`
 	args := make([]interface{}, 0)
 	if countryId != "_" {
-		queryString += " WHERE country_id = $1"
 		args = append(args, countryId)
+		queryString += fmt.Sprintf(" AND country_id = $%d", len(args))
 	}
 	if stateId != "" {
-		if countryId != "_" {
-			queryString += " AND state = $2"
-		} else {
-			queryString += " WHERE state = $1"
-		}
 		args = append(args, stateId)
+		queryString += fmt.Sprintf(" AND state = $%d", len(args))
 	}
 	queryString += " ORDER BY enddate;"
 
@@ -218,23 +217,21 @@ func GetSavedUpcomingWCACompetitions(
 
 func constructContent(
 	notifEntry map[string]map[string]models.UpcomingWCACompetition,
-	username string,
+	user models.User,
 	events []models.CompetitionEvent,
-) string {
-	content := "<!DOCTYPE html><html lang=\"en-US\"><head><title>New WCA competitions announced</title></head><body>"
-
-	country_ids := make([]string, len(notifEntry))
+	envMap map[string]string,
+) (string, string, error) {
+	country_ids := make([]string, 0, len(notifEntry))
 	for country_id := range notifEntry {
 		country_ids = append(country_ids, country_id)
 	}
-	sort.Slice(country_ids, func(i, j int) bool {
-		return country_ids[i] < country_ids[j]
-	})
-
-	content += fmt.Sprintf("Hi %s!<br/><br/>", username)
-	content += "there have been new WCA competitions announced in regions you have subscribed to. Here :<br/><br/>"
+	sort.Strings(country_ids)
 
-	content += "<table style=\"border-collapse: collapse;\">"
+	data := templates.CompAnnouncementSubscriptionData{
+		Username:       user.Name,
+		WebsiteHome:    envMap["WEBSITE_HOME"],
+		UnsubscribeUrl: models.UnsubscribeUrl(envMap, user.Id, models.NOTIFICATION_WCA_COMPETITIONS),
+	}
 	for _, country_id := range country_ids {
 		comps := []models.UpcomingWCACompetition{}
 		for _, comp := range notifEntry[country_id] {
@@ -248,59 +245,26 @@ func constructContent(
 			continue
 		}
 
-		borderBottomStyle := " style=\"border-bottom: 1px solid black;\""
-		content += fmt.Sprintf(
-			"<tr%s><td style=\"vertical-align:middle;\"><img style=\"vertical-align: middle;\" title=\"Flag of %s\" alt=\"flag of %s\" src=\"https://flagpedia.net/data/flags/h20/%s.png\"/><h1 style=\"vertical-align: middle; display: inline-block; margin: 0; padding-left: 10px;\">%s</h1></td></tr>",
-			borderBottomStyle,
-			comps[0].CountryName,
-			comps[0].CountryName,
-			strings.ToLower(comps[0].CountryIso2),
-			country_id,
-		)
-
+		region := templates.SubscriptionRegion{
+			Name:        country_id,
+			CountryName: comps[0].CountryName,
+			CountryIso2: strings.ToLower(comps[0].CountryIso2),
+		}
 		for _, comp := range comps {
-			content += fmt.Sprintf(
-				"<tr><td style=\"padding-left: 10px\"><h2 style=\"margin: 0\">%s</h2></td></tr>",
-				comp.Name,
-			)
-
-			content += fmt.Sprintf(
-				"<tr><td style=\"padding-left: 20px\"><b>Place:</b> <span style=\"font-weight: normal;\">%s</span></td></tr>",
-				comp.VenueAddress,
-			)
-			content += fmt.Sprintf(
-				"<tr><td style=\"padding-left: 20px\"><b>Date:</b> <span style=\"font-weight: normal;\">%s</span></td></tr>",
-				comp.DateFormatted(),
-			)
-			if comp.CompetitorLimit != 0 {
-				content += fmt.Sprintf(
-					"<tr><td style=\"padding-left: 20px\"><b>Competitor limit:</b> <span style=\"font-weight: normal;\">%d</span></td></tr>",
-					comp.CompetitorLimit,
-				)
-			}
-			content += fmt.Sprintf(
-				"<tr><td style=\"padding-left: 20px\"><b>Registration opens:</b> <span style=\"font-weight: normal;\">%s</span></td></tr>",
-				comp.RegistrationOpen.UTC().Format("02 Jan 2006 15:04:05 MST"),
-			)
-			content += fmt.Sprintf(
-				"<tr><td style=\"padding-left: 20px\"><b>Events:</b> <span style=\"font-weight: normal;\">%s</span></td></tr>",
-				strings.Join(comp.GetEventNamesFromCompetitionEvents(events), ", "),
-			)
-			content += fmt.Sprintf(
-				"<tr><td style=\"font-weight: normal; padding-left: 20px\">For more info click <a href=\"%s\"><b>here</b></a>.</td></tr>",
-				comp.Url,
-			)
+			region.Competitions = append(region.Competitions, templates.Competition{
+				Name:             comp.Name,
+				Place:            comp.VenueAddress,
+				Date:             comp.DateFormatted(),
+				CompetitorLimit:  comp.CompetitorLimit,
+				RegistrationOpen: comp.RegistrationOpen.UTC().Format("02 Jan 2006 15:04:05 MST"),
+				Events:           comp.GetEventNamesFromCompetitionEvents(events),
+				Url:              comp.Url,
+			})
 		}
-		content += "<tr><td>&nbsp;</td></tr>"
+		data.Regions = append(data.Regions, region)
 	}
-	content += "</table><br/>"
 
-	content +=
-		"Thank you for subscribing to our competition announcement newsletter.<br/><br/>If you want to prepare for WCA competitions and compete with your friends, don't forget to compete in Online Weekly Competitions at our <a href=\"https://speedcubingslovakia.sk/competitions\"><b>website</b></a>.<br/><br/>"
-
-	content += "Have a great day.<br/><br/><i>Jakub</i></body></html>"
-
-	return content
+	return templates.RenderEmail(templates.EMAIL_COMP_ANNOUNCEMENT_SUBSCRIPTION, user.Language, data)
 }
 
 // notifications if user_id -> location (country_id, state_name (if present))-> comp_id -> comp
@@ -319,56 +283,155 @@ func SendCompAnnouncementSubscriptions(
 		return err
 	}
 
+	// a failed user does not stop the others, the errors are returned together
+	var errs []error
 	for userId, notifEntry := range notifications {
 		user, err := models.GetUserById(db, userId)
 		if err != nil {
 			log.Println(
 				"ERR models.GetUserById in SendCompAnnouncementSubscriptions: " + err.Error(),
 			)
-			return err
+			errs = append(errs, err)
+			continue
 		}
-		log.Println("Sending email notification to user: " + user.Name)
+		log.Println("Queueing email notification to user: " + user.Name)
 
-		from := envMap["MAIL_USERNAME"]
-		to := user.Email
-		subject := "New WCA competitions announced"
+		subject, content, err := constructContent(notifEntry, user, events, envMap)
+		if err != nil {
+			log.Println("ERR constructContent in SendCompAnnouncementSubscriptions: " + err.Error())
+			errs = append(errs, err)
+			continue
+		}
 		if os.Getenv("SPEEDCUBINGSLOVAKIA_BACKEND_ENV") == "development" {
 			subject = "DEVELOPMENT: " + subject
 		}
-		content := constructContent(notifEntry, user.Name, events)
 
-		err = email.SendMail(from, to, subject, content, envMap)
+		queued, err := models.QueueNotificationEmail(context.Background(), db, envMap, user.Id, models.NOTIFICATION_WCA_COMPETITIONS, email.Message{
+			From:    envMap["MAIL_USERNAME"],
+			To:      user.Email,
+			Subject: subject,
+			Body:    content,
+		})
 		if err != nil {
-			log.Println("ERR email.SendMail in SendCompAnnouncementSubscriptions: " + err.Error())
-			return err
+			log.Println("ERR models.QueueNotificationEmail in SendCompAnnouncementSubscriptions: " + err.Error())
+			errs = append(errs, err)
+			continue
 		}
 
-		log.Println("Email sent successfully.")
+		if queued {
+			log.Println("Email queued successfully.")
+		} else {
+			log.Println("User turned off WCA competition emails, skipping.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
-	log.Println("All emails sent successfully.")
+	log.Println("All emails queued successfully.")
 
 	return nil
 }
 
+// constructChangesContent returns the subject and the body of the email about
+// the changed competitions, the earliest first
+func constructChangesContent(
+	changed map[string]changedCompetition,
+	user models.User,
+	envMap map[string]string,
+) (string, string, error) {
+	comps := make([]changedCompetition, 0, len(changed))
+	for _, comp := range changed {
+		comps = append(comps, comp)
+	}
+	sort.Slice(comps, func(i, j int) bool {
+		return comps[i].Competition.Startdate.Before(comps[j].Competition.Startdate)
+	})
+
+	data := templates.CompChangesData{
+		Username:       user.Name,
+		WebsiteHome:    envMap["WEBSITE_HOME"],
+		UnsubscribeUrl: models.UnsubscribeUrl(envMap, user.Id, models.NOTIFICATION_WCA_COMPETITIONS),
+	}
+	for _, comp := range comps {
+		data.Competitions = append(data.Competitions, templates.ChangedCompetition{
+			Name: comp.Competition.Name,
+			Date: comp.Competition.DateFormatted(),
+			Url:  comp.Competition.Url,
+			Changes: utils.Map(comp.Changes, func(change models.UpcomingWCACompetitionChange) templates.CompetitionChange {
+				return templates.CompetitionChange{Field: change.Field, Old: change.OldValue, New: change.NewValue}
+			}),
+		})
+	}
+
+	return templates.RenderEmail(templates.EMAIL_COMP_CHANGES, user.Language, data)
+}
+
+// SendCompChangeNotifications emails the subscribers about the changes of the
+// competitions they were notified about before, notifications are user_id ->
+// comp_id -> changed comp
+func SendCompChangeNotifications(
+	db *pgxpool.Pool,
+	envMap map[string]string,
+	notifications map[int]map[string]changedCompetition,
+) error {
+	log.Println("Sending email notifications about changed WCA competitions...")
+
+	// a failed user does not stop the others, the errors are returned together
+	var errs []error
+	for userId, changed := range notifications {
+		user, err := models.GetUserById(db, userId)
+		if err != nil {
+			log.Println("ERR models.GetUserById in SendCompChangeNotifications: " + err.Error())
+			errs = append(errs, err)
+			continue
+		}
+
+		subject, content, err := constructChangesContent(changed, user, envMap)
+		if err != nil {
+			log.Println("ERR constructChangesContent in SendCompChangeNotifications: " + err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		if os.Getenv("SPEEDCUBINGSLOVAKIA_BACKEND_ENV") == "development" {
+			subject = "DEVELOPMENT: " + subject
+		}
+
+		_, err = models.QueueNotificationEmail(context.Background(), db, envMap, user.Id, models.NOTIFICATION_WCA_COMPETITIONS, email.Message{
+			From:    envMap["MAIL_USERNAME"],
+			To:      user.Email,
+			Subject: subject,
+			Body:    content,
+		})
+		if err != nil {
+			log.Println("ERR models.QueueNotificationEmail in SendCompChangeNotifications: " + err.Error())
+			errs = append(errs, err)
+			continue
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// MakeCompAnnouncementContent returns the title and the content of the
+// announcement of the competition
 func MakeCompAnnouncementContent(
 	comp models.UpcomingWCACompetition,
 	events []models.CompetitionEvent,
-) string {
+	language string,
+) (string, string, error) {
 	timeLoc, _ := time.LoadLocation("Europe/Bratislava")
-	content := fmt.Sprintf(
-		"Hello everyone,\n\nnew WCA competition in Slovakia has just been announced:\n\n**Name:** %s<br>**Place:** %s<br>**Date:** %s<br>**Events:** %s\n\n**Registration** starts on **%s**. Mark it in your calendars so you don't miss it.\n\nFor more info check out the [competition website](%s).\n\nHope to see you there.\n\nSpeedcubing Slovakia",
-		comp.Name,
-		comp.VenueAddress,
-		comp.DateFormatted(),
-		strings.Join(comp.GetEventNamesFromCompetitionEvents(events), ", "),
-		comp.RegistrationOpen.UTC().
-			In(timeLoc).
-			Format("2 Jan 2006 15:04:05"),
-		comp.Url,
-	)
 
-	return content
+	return templates.RenderAnnouncement(templates.ANNOUNCEMENT_WCA_COMPETITION, language, templates.Competition{
+		Name:             comp.Name,
+		Place:            comp.VenueAddress,
+		Date:             comp.DateFormatted(),
+		CompetitorLimit:  comp.CompetitorLimit,
+		RegistrationOpen: comp.RegistrationOpen.UTC().In(timeLoc).Format("2 Jan 2006 15:04:05"),
+		Events:           comp.GetEventNamesFromCompetitionEvents(events),
+		Url:              comp.Url,
+	})
 }
 
 // make announcements for newly announced WCA competitions in Slovakia
@@ -408,9 +471,15 @@ func MakeCompAnnouncementAnnouncements(
 	}
 
 	for _, comp := range comps {
+		title, content, err := MakeCompAnnouncementContent(comp, events, templates.DEFAULT_LANGUAGE)
+		if err != nil {
+			log.Println("ERR MakeCompAnnouncementContent in MakeCompAnnouncementAnnouncements: " + err.Error())
+			return err
+		}
+
 		announcement := models.AnnouncementState{
-			Title:    "Official WCA competition: " + comp.Name,
-			Content:  MakeCompAnnouncementContent(comp, events),
+			Title:    title,
+			Content:  content,
 			AuthorId: 1,
 			Tags:     []models.Tag{compAnnouncementTag},
 		}
@@ -430,8 +499,69 @@ func MakeCompAnnouncementAnnouncements(
 	return nil
 }
 
-func CheckUpcomingWCACompetitions(db *pgxpool.Pool, envMap map[string]string) error {
+// subscribersOf returns the users subscribed to the region, the position or an
+// area containing the competition, with the state they subscribed to ("" for
+// whole country)
+func subscribersOf(
+	tx pgx.Tx,
+	comp models.UpcomingWCACompetition,
+	positionSubscriptions []models.WCACompAnnouncementsPositionSubscription,
+	polygonSubscriptions []models.WCACompAnnouncementsPolygonSubscription,
+) (map[int]string, error) {
+	queryString := `SELECT user_id, state FROM wca_competitions_announcements_subscriptions WHERE (country_id = $1 AND state = '')`
+	args := []any{comp.CountryId}
+	if comp.State != "" {
+		queryString += " OR (country_id = $1 AND state = $2)"
+		args = append(args, comp.State)
+	}
+	queryString += ";"
+	rows, err := tx.Query(context.Background(), queryString, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: when querying subscribers of %s", err, comp.CountryId)
+	}
+	defer rows.Close()
+
+	subscribers := make(map[int]string)
+	for rows.Next() {
+		var userId int
+		var state string
+		if err = rows.Scan(&userId, &state); err != nil {
+			return nil, fmt.Errorf("%w: when scanning subscriber", err)
+		}
+		subscribers[userId] = state
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: when iterating through subscribers", err)
+	}
+
+	for _, positionSubscription := range positionSubscriptions {
+		if utils.PointInsideCircle(comp.LatitudeDegrees, comp.LongitudeDegrees, float64(positionSubscription.Radius), positionSubscription.LatitudeDegrees, positionSubscription.LongitudeDegrees) {
+			subscribers[positionSubscription.UserId] = comp.State
+		}
+	}
+
+	for _, polygonSubscription := range polygonSubscriptions {
+		if polygonSubscription.Contains(comp.LatitudeDegrees, comp.LongitudeDegrees) {
+			subscribers[polygonSubscription.UserId] = comp.State
+		}
+	}
+
+	return subscribers, nil
+}
+
+// changedCompetition is the competition with the changes its subscribers are
+// notified about
+type changedCompetition struct {
+	Competition models.UpcomingWCACompetition
+	Changes     []models.UpcomingWCACompetitionChange
+}
+
+// CheckUpcomingWCACompetitions saves the upcoming competitions from the WCA,
+// records the changes of the already saved ones and notifies the subscribers
+// of the newly announced and the changed competitions
+func CheckUpcomingWCACompetitions(db *pgxpool.Pool, client wca.Client, envMap map[string]string) error {
 	ctx := context.TODO()
+	now := time.Now().Round(0)
 
 	log.Println("Querying countries...")
 	countriesArray, err := models.GetCountries(ctx, db)
@@ -442,6 +572,14 @@ func CheckUpcomingWCACompetitions(db *pgxpool.Pool, envMap map[string]string) er
 
 	countriesMap := models.CountriesArrayToMap(countriesArray)
 
+	// the events we do not have are not saved, so they are not compared either
+	events, err := models.GetAvailableEvents(db)
+	if err != nil {
+		log.Println("ERR models.GetAvailableEvents in CheckUpcomingWCACompetitions: " + err.Error())
+		return err
+	}
+	knownIconcodes := utils.Map(events, func(e models.CompetitionEvent) string { return e.Iconcode })
+
 	log.Println("Starting db transaction...")
 	tx, err := db.Begin(context.Background())
 	if err != nil {
@@ -451,19 +589,21 @@ func CheckUpcomingWCACompetitions(db *pgxpool.Pool, envMap map[string]string) er
 	defer tx.Rollback(context.Background())
 
 	log.Println("Checking if already announced comps are loaded in db...")
-	upcomingCompsFromDB, err := GetSavedUpcomingWCACompetitions(db, "_", "")
+	known, err := models.GetKnownUpcomingWCACompetitions(ctx, tx)
 	if err != nil {
 		log.Println(
-			"ERR GetSavedUpcomingWCACompetitions in CheckUpcomingWCACompetitions: " + err.Error(),
+			"ERR models.GetKnownUpcomingWCACompetitions in CheckUpcomingWCACompetitions: " + err.Error(),
 		)
 		return err
 	}
 
-	notifySubscribers := len(upcomingCompsFromDB) > 0
+	notifySubscribers := len(known) > 0
 	notifications := make(map[int]map[string]map[string]models.UpcomingWCACompetition)
+	changeNotifications := make(map[int]map[string]changedCompetition)
 	newlyAnnouncedSlovakComps := make([]models.UpcomingWCACompetition, 0)
 
 	var positionSubscriptions []models.WCACompAnnouncementsPositionSubscription
+	var polygonSubscriptions []models.WCACompAnnouncementsPolygonSubscription
 	if notifySubscribers {
 		positionSubscriptions, err = PositionSubscriptionFromDB(tx, 0)
 		if err != nil {
@@ -472,73 +612,76 @@ func CheckUpcomingWCACompetitions(db *pgxpool.Pool, envMap map[string]string) er
 			)
 			return err
 		}
+
+		polygonSubscriptions, err = models.GetWCACompAnnouncementsPolygonSubscriptions(ctx, tx, 0)
+		if err != nil {
+			log.Println(
+				"ERR models.GetWCACompAnnouncementsPolygonSubscriptions in CheckUpcomingWCACompetitions: " + err.Error(),
+			)
+			return err
+		}
 	}
 
-	page := 0
-	can := true
-	for can {
-		page += 1
+	notifyChanges := func(comp models.UpcomingWCACompetition, changes []models.UpcomingWCACompetitionChange) error {
+		changes = slices.DeleteFunc(changes, func(change models.UpcomingWCACompetitionChange) bool { return !change.Notify() })
+		if !notifySubscribers || len(changes) == 0 {
+			return nil
+		}
 
-		var respComps []models.GetWCACompetitionsResponse
-		attempts, attempt, success := 10, 0, false
-		for ; attempt < attempts && !success; attempt++ {
-			time.Sleep(30 * time.Second)
-			url := fmt.Sprintf(
-				"https://www.worldcubeassociation.org/api/v0/competitions?page=%d&sort=-end_date",
-				page,
-			)
-			body, err := utils.GetRequest(url)
-			if err != nil {
-				log.Println(
-					"ERR utils.GetRequest(url=" + url + ") in CheckUpcomingWCACompetitions: " + err.Error(),
-				)
-				return err
-			}
+		subscribers, err := subscribersOf(tx, comp, positionSubscriptions, polygonSubscriptions)
+		if err != nil {
+			return err
+		}
 
-			err = json.Unmarshal(body, &respComps)
-			if err != nil {
-				log.Printf("ERR json.Unmarshal in CheckUpcomingWCACompetitions with url=%q: %v", url, err.Error())
-				log.Printf(
-					"==========\nFailed to unmarshal this body: %v\n==========\n",
-					string(body),
-				)
-				success = false
-				attempt += 1
-			} else {
-				success = true
+		for userId := range subscribers {
+			if _, ok := changeNotifications[userId]; !ok {
+				changeNotifications[userId] = make(map[string]changedCompetition)
 			}
+			changeNotifications[userId][comp.Id] = changedCompetition{Competition: comp, Changes: changes}
 		}
 
-		if success {
-			log.Printf("Succeeded loading page number %d in %d attempts.", page, attempts)
-		} else {
-			log.Printf("Failed to load page number %d in %d attempts. Notifying...", page, attempts)
-			from := envMap["MAIL_USERNAME"]
-			to := from
+		return nil
+	}
+
+	page := 0
+	can := true
+	for can {
+		page += 1
+
+		// the client retries the failed requests itself, failing here means
+		// the WCA is down, so the admin is notified and the next run tries again
+		respComps, err := client.GetCompetitions(ctx, page)
+		if err != nil {
+			log.Printf("Failed to load page number %d: %v. Notifying...", page, err)
 			subject := "Querying upcoming WCA competitions failed"
 			if os.Getenv("SPEEDCUBINGSLOVAKIA_BACKEND_ENV") == "development" {
 				subject = "DEVELOPMENT: " + subject
 			}
-			content := fmt.Sprintf("Failed to load page number %d in %d attempts.", page, attempts)
 
-			err = email.SendMail(from, to, subject, content, envMap)
-			if err != nil {
-				log.Println("ERR email.SendMail in CheckUpcomingWCACompetitions: " + err.Error())
-				return err
+			_, queueErr := models.QueueEmail(context.Background(), db, 0, email.Message{
+				From:    envMap["MAIL_USERNAME"],
+				To:      envMap["MAIL_USERNAME"],
+				Subject: subject,
+				Body:    fmt.Sprintf("Failed to load page number %d: %v", page, err),
+			})
+			if queueErr != nil {
+				log.Println("ERR models.QueueEmail in CheckUpcomingWCACompetitions: " + queueErr.Error())
+				return errors.Join(err, queueErr)
 			}
 
-			log.Println("Email sent successfully.")
-			continue
+			log.Println("Email queued successfully.")
+			return err
 		}
+		log.Printf("Succeeded loading page number %d.", page)
 
-		if len(respComps) < 25 {
+		if len(respComps) < wca.COMPETITIONS_PAGE_SIZE {
 			can = false
 		}
 
 		for _, respComp := range respComps {
 			const layout = "2006-01-02"
 			enddate, _ := time.Parse(layout, respComp.Enddate)
-			if enddate.Before(time.Now().Round(0)) {
+			if enddate.Before(now) {
 				can = false
 				break
 			}
@@ -554,6 +697,7 @@ func CheckUpcomingWCACompetitions(db *pgxpool.Pool, envMap map[string]string) er
 				continue
 			}
 
+			registered := -1
 			for _, country := range countries {
 				startdate, _ := time.Parse(layout, respComp.Startdate)
 				upcomingWCACompetition := models.UpcomingWCACompetition{
@@ -566,117 +710,156 @@ func CheckUpcomingWCACompetitions(db *pgxpool.Pool, envMap map[string]string) er
 					Url:             respComp.Url,
 					City:            respComp.City,
 					Events: utils.Map(
-						respComp.EventIds,
+						slices.DeleteFunc(slices.Clone(respComp.EventIds), func(iconcode string) bool { return !slices.Contains(knownIconcodes, iconcode) }),
 						func(iconcode string) models.CompetitionEvent { return models.CompetitionEvent{Iconcode: iconcode} },
 					),
 					CountryId:         country.Id,
 					CountryName:       country.Name,
 					CountryIso2:       country.Iso2,
-					RegistrationOpen:  respComp.RegistrationOpen,
-					RegistrationClose: respComp.RegistrationClose,
+					RegistrationOpen:  respComp.RegistrationOpen.UTC(),
+					RegistrationClose: respComp.RegistrationClose.UTC(),
 					LatitudeDegrees:   respComp.LatitudeDegrees,
 					LongitudeDegrees:  respComp.LongitudeDegrees,
 				}
 				upcomingWCACompetition.LoadState()
 
-				err = upcomingWCACompetition.GetRegistered(tx)
-				if err != nil {
-					log.Println(
-						"ERR upcomingWCACompetition.GetRegistered in CheckUpcomingWCACompetitions: " + err.Error(),
-					)
-					return err
-				}
+				// what is left in known after the walk was not listed anymore
+				previous, isKnown := known[upcomingWCACompetition.Key()]
+				delete(known, upcomingWCACompetition.Key())
 
-				res, err := upcomingWCACompetition.Save(tx)
-				if err != nil {
-					log.Println(
-						"ERR upcomingWCACompetition.Save in CheckUpcomingWCACompetitions: " + err.Error(),
-					)
-					return err
-				}
-
-				if res.RowsAffected() == 0 {
-					// for now don't log anything if competition exists so i can easily check which comps were not in the db after each run
-					//log.Println(
-					//"Competition " + upcomingWCACompetition.Name + " is already in the database.",
-					//)
-				} else {
-					log.Println("Competition " + upcomingWCACompetition.Name + " saved successfully.")
-
-					if !notifySubscribers {
+				if respComp.CancelledAt != nil {
+					if !isKnown || previous.Cancelled {
 						continue
 					}
 
-					log.Println("Querying subscribers...")
-					queryString := `SELECT user_id, state FROM wca_competitions_announcements_subscriptions WHERE (country_id = $1 AND state = '')`
-					args := []any{country.Id}
-					if upcomingWCACompetition.State != "" {
-						queryString += " OR (country_id = $2 AND state = $3)"
-						args = append(args, upcomingWCACompetition.CountryId, upcomingWCACompetition.State)
+					change, err := previous.Cancel(ctx, tx)
+					if err != nil {
+						log.Println("ERR previous.Cancel in CheckUpcomingWCACompetitions: " + err.Error())
+						return err
+					}
+					log.Println("Competition " + previous.Name + " was cancelled.")
+
+					if err = notifyChanges(previous, []models.UpcomingWCACompetitionChange{change}); err != nil {
+						log.Println("ERR notifyChanges in CheckUpcomingWCACompetitions: " + err.Error())
+						return err
 					}
-					queryString += ";"
-					rows, err := tx.Query(context.Background(), queryString, args...)
+					continue
+				}
+
+				// the registrations are the same for all the countries
+				if registered == -1 {
+					err = upcomingWCACompetition.GetRegistered(ctx, client)
 					if err != nil {
-						log.Println("ERR tx.Query(subscriptions) for " + country.Id + " in CheckUpcomingWCACompetitions: " + err.Error())
+						log.Println(
+							"ERR upcomingWCACompetition.GetRegistered in CheckUpcomingWCACompetitions: " + err.Error(),
+						)
 						return err
 					}
+					registered = upcomingWCACompetition.Registered
+				}
+				upcomingWCACompetition.Registered = registered
 
-					// find subscribers and add notification to them
-					for rows.Next() {
-						var currentUserId int
-						var state string
-						err = rows.Scan(&currentUserId, &state)
+				if isKnown {
+					changes := upcomingWCACompetition.Changes(previous, now)
+					if len(changes) == 0 {
+						err = upcomingWCACompetition.UpdateRegistered(ctx, tx)
 						if err != nil {
-							log.Println("ERR rows.Scan(user_id) in CheckUpcomingWCACompetitions: " + err.Error())
+							log.Println("ERR upcomingWCACompetition.UpdateRegistered in CheckUpcomingWCACompetitions: " + err.Error())
 							return err
 						}
+						continue
+					}
 
-						if _, ok := notifications[currentUserId]; !ok {
-							notifications[currentUserId] = make(map[string]map[string]models.UpcomingWCACompetition)
-						}
+					_, err = upcomingWCACompetition.Save(tx)
+					if err != nil {
+						log.Println("ERR upcomingWCACompetition.Save in CheckUpcomingWCACompetitions: " + err.Error())
+						return err
+					}
 
-						location := country.Name
-						if state != "" {
-							location += ", " + state
-						}
-						if _, ok := notifications[currentUserId][location]; !ok {
-							notifications[currentUserId][location] = make(map[string]models.UpcomingWCACompetition)
+					for idx := range changes {
+						if err = changes[idx].Insert(ctx, tx); err != nil {
+							log.Println("ERR change.Insert in CheckUpcomingWCACompetitions: " + err.Error())
+							return err
 						}
+					}
+					log.Printf("Competition %s changed (%d changes).", upcomingWCACompetition.Name, len(changes))
 
-						notifications[currentUserId][location][upcomingWCACompetition.Id] = upcomingWCACompetition
+					if err = notifyChanges(upcomingWCACompetition, changes); err != nil {
+						log.Println("ERR notifyChanges in CheckUpcomingWCACompetitions: " + err.Error())
+						return err
 					}
+					continue
+				}
 
-					for _, positionSupscription := range positionSubscriptions {
-						if utils.PointInsideCircle(upcomingWCACompetition.LatitudeDegrees, upcomingWCACompetition.LongitudeDegrees, float64(positionSupscription.Radius), positionSupscription.LatitudeDegrees, positionSupscription.LongitudeDegrees) {
-							currentUserId := positionSupscription.UserId
-							if _, ok := notifications[currentUserId]; !ok {
-								notifications[currentUserId] = make(map[string]map[string]models.UpcomingWCACompetition)
-							}
-
-							location := country.Name
-							if upcomingWCACompetition.State != "" {
-								location += ", " + upcomingWCACompetition.State
-							}
-							if _, ok := notifications[currentUserId][location]; !ok {
-								notifications[currentUserId][location] = make(map[string]models.UpcomingWCACompetition)
-							}
-
-							notifications[currentUserId][location][upcomingWCACompetition.Id] = upcomingWCACompetition
-						}
+				_, err = upcomingWCACompetition.Save(tx)
+				if err != nil {
+					log.Println(
+						"ERR upcomingWCACompetition.Save in CheckUpcomingWCACompetitions: " + err.Error(),
+					)
+					return err
+				}
+
+				log.Println("Competition " + upcomingWCACompetition.Name + " saved successfully.")
+
+				if !notifySubscribers {
+					continue
+				}
+
+				log.Println("Querying subscribers...")
+				subscribers, err := subscribersOf(tx, upcomingWCACompetition, positionSubscriptions, polygonSubscriptions)
+				if err != nil {
+					log.Println("ERR subscribersOf in CheckUpcomingWCACompetitions: " + err.Error())
+					return err
+				}
+
+				for currentUserId, state := range subscribers {
+					if _, ok := notifications[currentUserId]; !ok {
+						notifications[currentUserId] = make(map[string]map[string]models.UpcomingWCACompetition)
 					}
 
-					// if comp added is slovak, add it to the list of comps need to make an announcement for
-					if country.Iso2 == "SK" {
-						newlyAnnouncedSlovakComps = append(newlyAnnouncedSlovakComps, upcomingWCACompetition)
+					location := country.Name
+					if state != "" {
+						location += ", " + state
+					}
+					if _, ok := notifications[currentUserId][location]; !ok {
+						notifications[currentUserId][location] = make(map[string]models.UpcomingWCACompetition)
 					}
+
+					notifications[currentUserId][location][upcomingWCACompetition.Id] = upcomingWCACompetition
+				}
+
+				// if comp added is slovak, add it to the list of comps need to make an announcement for
+				if country.Iso2 == "SK" {
+					newlyAnnouncedSlovakComps = append(newlyAnnouncedSlovakComps, upcomingWCACompetition)
 				}
 			}
 		}
 	}
 
+	// the walk got through all the upcoming competitions, the saved ones it
+	// did not find were cancelled (or taken down) on the WCA
+	for _, previous := range known {
+		if previous.Cancelled || previous.Enddate.Before(now) {
+			continue
+		}
+
+		change, err := previous.Cancel(ctx, tx)
+		if err != nil {
+			log.Println("ERR previous.Cancel in CheckUpcomingWCACompetitions: " + err.Error())
+			return err
+		}
+		log.Println("Competition " + previous.Name + " is not listed anymore, marked as cancelled.")
+
+		if err = notifyChanges(previous, []models.UpcomingWCACompetitionChange{change}); err != nil {
+			log.Println("ERR notifyChanges in CheckUpcomingWCACompetitions: " + err.Error())
+			return err
+		}
+	}
+
 	defer func() {
 		if notifySubscribers {
 			SendCompAnnouncementSubscriptions(db, envMap, notifications)
+			SendCompChangeNotifications(db, envMap, changeNotifications)
 			MakeCompAnnouncementAnnouncements(db, envMap, newlyAnnouncedSlovakComps)
 		}
 	}()
@@ -707,6 +890,14 @@ func DeletePastWCACompetitions(db *pgxpool.Pool) error {
 		res.RowsAffected(),
 	)
 
+	deleted, err := models.DeleteUnusedWCACompetitionWatches(context.Background(), db)
+	if err != nil {
+		log.Println("ERR models.DeleteUnusedWCACompetitionWatches in DeletePastWCACompetitions: " + err.Error())
+		return err
+	}
+
+	fmt.Printf("Successfully deleted %d watches of the deleted competitions.\n", deleted)
+
 	return nil
 }
 
@@ -794,3 +985,60 @@ func UpdateWCAAnnouncementSubscriptions(db *pgxpool.Pool) gin.HandlerFunc {
 		c.IndentedJSON(http.StatusOK, body)
 	}
 }
+
+func GetWCACompetitionWatches(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		ids, err := models.GetWatchedWCACompetitionIds(c.Request.Context(), db, c.MustGet("uid").(int))
+		if err != nil {
+			err = fmt.Errorf("%w: when getting watched wca competitions", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to get watched competitions.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, ids)
+	}
+}
+
+type wcaCompetitionWatchRequestBody struct {
+	CompetitionId string `json:"competitionId"`
+	Watched       bool   `json:"watched"`
+}
+
+// PostWCACompetitionWatch watches or unwatches the upcoming WCA competition,
+// the watchers are reminded before its registration opens
+func PostWCACompetitionWatch(db interfaces.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		defer utils.PrintStack(&err)
+
+		var body wcaCompetitionWatchRequestBody
+		if err = c.ShouldBindJSON(&body); err != nil {
+			err = fmt.Errorf("%w: when parsing request body", err)
+			c.IndentedJSON(http.StatusBadRequest, "Failed to parse request body.")
+			return
+		}
+
+		ctx := c.Request.Context()
+		uid := c.MustGet("uid").(int)
+		if body.Watched {
+			err = models.WatchWCACompetition(ctx, db, uid, body.CompetitionId)
+		} else {
+			err = models.UnwatchWCACompetition(ctx, db, uid, body.CompetitionId)
+		}
+		if errors.Is(err, models.ErrUpcomingWCACompetitionNotFound) {
+			err = nil
+			c.IndentedJSON(http.StatusNotFound, "Competition not found.")
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: when updating watch of wca competition", err)
+			c.IndentedJSON(http.StatusInternalServerError, "Failed to update watched competition.")
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, body)
+	}
+}
@@ -0,0 +1,121 @@
+package controllers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/controllers"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/live"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+)
+
+func TestGetLiveResults(t *testing.T) {
+	results := models.CompetitionResultStruct{Results: []models.CompetitionResult{{Place: "1.", Username: "user"}}}
+	resultsJson, err := json.Marshal(results)
+	require.NoError(t, err)
+
+	callbackOk := func(db *pgxpool.Pool, cid string, eid int, round int) (models.CompetitionResultStruct, error) {
+		return results, nil
+	}
+	callbackFailed := func(db *pgxpool.Pool, cid string, eid int, round int) (models.CompetitionResultStruct, error) {
+		return models.CompetitionResultStruct{}, errors.New("error")
+	}
+
+	t.Run("bad request", func(t *testing.T) {
+		tests := []struct {
+			name                 string
+			query                string
+			callback             controllers.CompetitionResultsGetter
+			expectedResponseCode int
+			expectedMsg          string
+		}{
+			{
+				name:                 "invalid event",
+				query:                "?competitionId=c&eventId=x",
+				callback:             callbackOk,
+				expectedResponseCode: http.StatusBadRequest,
+				expectedMsg:          "Failed to parse eventId.",
+			},
+			{
+				name:                 "invalid round",
+				query:                "?competitionId=c&eventId=1&round=x",
+				callback:             callbackOk,
+				expectedResponseCode: http.StatusBadRequest,
+				expectedMsg:          "Failed to parse round.",
+			},
+			{
+				name:                 "callback failed",
+				query:                "?competitionId=c&eventId=1",
+				callback:             callbackFailed,
+				expectedResponseCode: http.StatusInternalServerError,
+				expectedMsg:          "Failed to get competition results.",
+			},
+		}
+
+		for _, testcase := range tests {
+			req := httptest.NewRequest("GET", "/live"+testcase.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler := controllers.GetLiveResults(nil, live.NewHub(live.DEFAULT_BUFFER_SIZE), testcase.callback)
+			handler(rr, req)
+
+			assert.Equal(t, testcase.expectedResponseCode, rr.Code, testcase.name)
+			assert.Contains(t, rr.Body.String(), testcase.expectedMsg, testcase.name)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		hub := live.NewHub(live.DEFAULT_BUFFER_SIZE)
+		server := httptest.NewServer(controllers.GetLiveResults(nil, hub, callbackOk))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?competitionId=c&eventId=1&round=2", nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "event: results\n", line)
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "data: "+string(resultsJson), strings.TrimSuffix(line, "\n"))
+
+		topic := live.Topic{CompetitionId: "c", EventId: 1, Round: 2}
+		require.True(t, hub.HasSubscribers(topic))
+
+		results.Results[0].Place = "2."
+		controllers.PublishResults(nil, hub, callbackOk, topic)
+		updatedJson, err := json.Marshal(results)
+		require.NoError(t, err)
+
+		_, err = reader.ReadString('\n') // empty line after the initial event
+		require.NoError(t, err)
+		_, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "data: "+string(updatedJson), strings.TrimSuffix(line, "\n"))
+
+		cancel()
+		require.Eventually(t, func() bool { return !hub.HasSubscribers(topic) }, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+)
+
+// runs nightly to repair entries a failed RefreshRankings left behind, run it
+// by hand after changing how results are parsed
+func main() {
+	envMap, err := godotenv.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load environmental variables from file: %v\n", err)
+		return
+	}
+
+	db, err := pgxpool.New(context.Background(), envMap["DB_URL"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to connect to database: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	inserted, err := models.RebuildRankings(context.Background(), db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Something went wrong during rebuilding rankings: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Rebuilt rankings with %d entries.\n", inserted)
+}
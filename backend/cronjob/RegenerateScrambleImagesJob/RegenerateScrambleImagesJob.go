@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+)
+
+// not scheduled, run by hand after changing the scramble image renderer
+func main() {
+	envMap, err := godotenv.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load environmental variables from file: %v\n", err)
+		return
+	}
+
+	db, err := pgxpool.New(context.Background(), envMap["DB_URL"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to connect to database: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	regenerated, err := models.RegenerateScrambleImages(context.Background(), db, envMap)
+	if err != nil {
+		fmt.Fprintf(
+			os.Stderr,
+			"Something went wrong during regenerating scramble images: %v\n",
+			err,
+		)
+		return
+	}
+
+	fmt.Printf("Regenerated %d scramble images.\n", regenerated)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/controllers"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
 )
 
 func main() {
@@ -25,7 +26,7 @@ func main() {
 	}
 	defer db.Close()
 
-	err = controllers.CheckUpcomingWCACompetitions(db, envMap)
+	err = controllers.CheckUpcomingWCACompetitions(db, wca.NewClient(envMap, wca.DEFAULT_REQUEST_INTERVAL), envMap)
 	if err != nil {
 		fmt.Fprintf(
 			os.Stderr,
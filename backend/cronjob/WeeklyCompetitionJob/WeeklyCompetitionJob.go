@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/controllers"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
@@ -25,5 +26,5 @@ func main() {
 	}
 	defer db.Close()
 
-	controllers.AddNewWeeklyCompetition(db, envMap)
+	controllers.AddNewWeeklyCompetition(db, scrambler.New(), envMap)
 }
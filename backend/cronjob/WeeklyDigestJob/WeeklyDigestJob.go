@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/controllers"
+)
+
+func main() {
+	envMap, err := godotenv.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load environmental variables from file: %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := pgxpool.New(context.Background(), envMap["DB_URL"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	err = controllers.SendWeeklyDigests(db, envMap)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Something went wrong during sending weekly digests: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -3,37 +3,38 @@ package cube
 import (
 	"fmt"
 	"strings"
-
-	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
 )
 
+// Cube is an NxN cube. State holds the stickers of the faces in order
+// U, L, F, R, B, D, every face is seen from the outside with U at the top
+// (B at the top for U, F at the top for D).
 type Cube struct {
-	State    [6][3][3]int
+	Size     int
+	State    [6][][]int
 	Scramble string
 	Solution string
 }
 
+// moves allowed in fewest moves solutions
 var VALID_MOVES = []string{"U", "D", "R", "L", "F", "B", "U'", "D'", "R'", "L'", "F'", "B'", "U2", "D2", "R2", "L2", "F2", "B2", "Uw", "Dw", "Rw", "Lw", "Fw", "Bw", "Uw'", "Dw'", "Rw'", "Lw'", "Fw'", "Bw'", "Uw2", "Dw2", "Rw2", "Lw2", "Fw2", "Bw2", "x", "x'", "x2", "y", "y'", "y2", "z", "z'", "z2"}
 var ROTATIONS = []string{"x", "x'", "x2", "y", "y'", "y2", "z", "z'", "z2"}
 var COLORS = []string{"W", "O", "G", "R", "B", "Y"}
 
-func InitialState() [6][3][3]int {
-	state := [6][3][3]int{}
+func New(size int) *Cube {
+	return &Cube{Size: size, State: InitialState(size)}
+}
 
-	SIDES := 6
-	for i := 0; i < SIDES; i++ {
-		side := [3][3]int{}
+func InitialState(size int) [6][][]int {
+	state := [6][][]int{}
 
-		line := [3]int{}
-		for j := 0; j < 3; j++ {
-			line[j] = i
-		}
-
-		for j := 0; j < 3; j++ {
-			side[j] = line
+	for face := range state {
+		state[face] = make([][]int, size)
+		for row := range state[face] {
+			state[face][row] = make([]int, size)
+			for col := range state[face][row] {
+				state[face][row][col] = face
+			}
 		}
-
-		state[i] = side
 	}
 
 	return state
@@ -49,9 +50,10 @@ func IndexFunc[T any](arr []T, test func(T) bool) int {
 	return -1
 }
 
+// ValidMoves reports whether the solution uses only moves allowed in fewest
+// moves solutions.
 func (c *Cube) ValidMoves() bool {
-	moves := strings.Split(c.Solution, " ")
-	for _, move := range moves {
+	for _, move := range strings.Fields(c.Solution) {
 		idx := IndexFunc(VALID_MOVES, func(m string) bool { return m == move })
 		if idx == -1 {
 			return false
@@ -61,346 +63,169 @@ func (c *Cube) ValidMoves() bool {
 	return true
 }
 
-func (c *Cube) RotateFace(face int) {
-	for i, j := 0, len(c.State[face])-1; i < j; i, j = i+1, j-1 {
-		c.State[face][i], c.State[face][j] = c.State[face][j], c.State[face][i]
-	}
-
-	for i := 0; i < len(c.State[face]); i++ {
-		for j := 0; j < i; j++ {
-			c.State[face][i][j], c.State[face][j][i] = c.State[face][j][i], c.State[face][i][j]
-		}
+// Internally every sticker is given coordinates in a cube spanning -Size to
+// Size on every axis (x to the right, y up, z to the front). Stickers lie on
+// the planes ±Size and the layer k counted from the positive end of an axis
+// has the coordinate Size+1-2k, so turning layers is just a rotation of the
+// coordinates.
+type coords [3]int
+
+func (c *Cube) coords(face, row, col int) coords {
+	n, a, b := c.Size, 2*col-(c.Size-1), 2*row-(c.Size-1)
+	switch face {
+	case 0:
+		return coords{a, n, b}
+	case 1:
+		return coords{-n, -b, a}
+	case 2:
+		return coords{a, -b, n}
+	case 3:
+		return coords{n, -b, -a}
+	case 4:
+		return coords{-a, -b, -n}
+	default:
+		return coords{a, -n, -b}
 	}
 }
 
-func (c *Cube) ApplyU() {
-	c.RotateFace(0)
-	c.State[1][0][0], c.State[1][0][1], c.State[1][0][2], c.State[2][0][0], c.State[2][0][1], c.State[2][0][2], c.State[3][0][0], c.State[3][0][1], c.State[3][0][2], c.State[4][0][0], c.State[4][0][1], c.State[4][0][2] = c.State[2][0][0], c.State[2][0][1], c.State[2][0][2], c.State[3][0][0], c.State[3][0][1], c.State[3][0][2], c.State[4][0][0], c.State[4][0][1], c.State[4][0][2], c.State[1][0][0], c.State[1][0][1], c.State[1][0][2]
-}
-
-func (c *Cube) ApplyUPrime() {
-	c.RotateFace(0)
-	c.RotateFace(0)
-	c.RotateFace(0)
-	c.State[1][0][0], c.State[1][0][1], c.State[1][0][2], c.State[2][0][0], c.State[2][0][1], c.State[2][0][2], c.State[3][0][0], c.State[3][0][1], c.State[3][0][2], c.State[4][0][0], c.State[4][0][1], c.State[4][0][2] = c.State[4][0][0], c.State[4][0][1], c.State[4][0][2], c.State[1][0][0], c.State[1][0][1], c.State[1][0][2], c.State[2][0][0], c.State[2][0][1], c.State[2][0][2], c.State[3][0][0], c.State[3][0][1], c.State[3][0][2]
-}
-
-func (c *Cube) ApplyD() {
-	c.RotateFace(5)
-	c.State[1][2][0], c.State[1][2][1], c.State[1][2][2], c.State[2][2][0], c.State[2][2][1], c.State[2][2][2], c.State[3][2][0], c.State[3][2][1], c.State[3][2][2], c.State[4][2][0], c.State[4][2][1], c.State[4][2][2] = c.State[4][2][0], c.State[4][2][1], c.State[4][2][2], c.State[1][2][0], c.State[1][2][1], c.State[1][2][2], c.State[2][2][0], c.State[2][2][1], c.State[2][2][2], c.State[3][2][0], c.State[3][2][1], c.State[3][2][2]
-}
-
-func (c *Cube) ApplyDPrime() {
-	c.RotateFace(5)
-	c.RotateFace(5)
-	c.RotateFace(5)
-	c.State[1][2][0], c.State[1][2][1], c.State[1][2][2], c.State[2][2][0], c.State[2][2][1], c.State[2][2][2], c.State[3][2][0], c.State[3][2][1], c.State[3][2][2], c.State[4][2][0], c.State[4][2][1], c.State[4][2][2] = c.State[2][2][0], c.State[2][2][1], c.State[2][2][2], c.State[3][2][0], c.State[3][2][1], c.State[3][2][2], c.State[4][2][0], c.State[4][2][1], c.State[4][2][2], c.State[1][2][0], c.State[1][2][1], c.State[1][2][2]
-}
-
-func (c *Cube) ApplyF() {
-	c.RotateFace(2)
-	c.State[0][2][0], c.State[0][2][1], c.State[0][2][2], c.State[3][0][0], c.State[3][1][0], c.State[3][2][0], c.State[5][0][2], c.State[5][0][1], c.State[5][0][0], c.State[1][2][2], c.State[1][1][2], c.State[1][0][2] = c.State[1][2][2], c.State[1][1][2], c.State[1][0][2], c.State[0][2][0], c.State[0][2][1], c.State[0][2][2], c.State[3][0][0], c.State[3][1][0], c.State[3][2][0], c.State[5][0][2], c.State[5][0][1], c.State[5][0][0]
-}
-
-func (c *Cube) ApplyFPrime() {
-	c.RotateFace(2)
-	c.RotateFace(2)
-	c.RotateFace(2)
-	c.State[0][2][0], c.State[0][2][1], c.State[0][2][2], c.State[3][0][0], c.State[3][1][0], c.State[3][2][0], c.State[5][0][2], c.State[5][0][1], c.State[5][0][0], c.State[1][2][2], c.State[1][1][2], c.State[1][0][2] = c.State[3][0][0], c.State[3][1][0], c.State[3][2][0], c.State[5][0][2], c.State[5][0][1], c.State[5][0][0], c.State[1][2][2], c.State[1][1][2], c.State[1][0][2], c.State[0][2][0], c.State[0][2][1], c.State[0][2][2]
-}
-
-func (c *Cube) ApplyB() {
-	c.RotateFace(4)
-	c.State[0][0][0], c.State[0][0][1], c.State[0][0][2], c.State[3][0][2], c.State[3][1][2], c.State[3][2][2], c.State[5][2][2], c.State[5][2][1], c.State[5][2][0], c.State[1][2][0], c.State[1][1][0], c.State[1][0][0] = c.State[3][0][2], c.State[3][1][2], c.State[3][2][2], c.State[5][2][2], c.State[5][2][1], c.State[5][2][0], c.State[1][2][0], c.State[1][1][0], c.State[1][0][0], c.State[0][0][0], c.State[0][0][1], c.State[0][0][2]
-}
-
-func (c *Cube) ApplyBPrime() {
-	c.RotateFace(4)
-	c.RotateFace(4)
-	c.RotateFace(4)
-	c.State[0][0][0], c.State[0][0][1], c.State[0][0][2], c.State[3][0][2], c.State[3][1][2], c.State[3][2][2], c.State[5][2][2], c.State[5][2][1], c.State[5][2][0], c.State[1][2][0], c.State[1][1][0], c.State[1][0][0] = c.State[1][2][0], c.State[1][1][0], c.State[1][0][0], c.State[0][0][0], c.State[0][0][1], c.State[0][0][2], c.State[3][0][2], c.State[3][1][2], c.State[3][2][2], c.State[5][2][2], c.State[5][2][1], c.State[5][2][0]
-}
-
-func (c *Cube) ApplyR() {
-	c.RotateFace(3)
-	c.State[0][0][2], c.State[0][1][2], c.State[0][2][2], c.State[2][0][2], c.State[2][1][2], c.State[2][2][2], c.State[5][0][2], c.State[5][1][2], c.State[5][2][2], c.State[4][2][0], c.State[4][1][0], c.State[4][0][0] = c.State[2][0][2], c.State[2][1][2], c.State[2][2][2], c.State[5][0][2], c.State[5][1][2], c.State[5][2][2], c.State[4][2][0], c.State[4][1][0], c.State[4][0][0], c.State[0][0][2], c.State[0][1][2], c.State[0][2][2]
-}
-
-func (c *Cube) ApplyRPrime() {
-	c.RotateFace(3)
-	c.RotateFace(3)
-	c.RotateFace(3)
-	c.State[0][0][2], c.State[0][1][2], c.State[0][2][2], c.State[2][0][2], c.State[2][1][2], c.State[2][2][2], c.State[5][0][2], c.State[5][1][2], c.State[5][2][2], c.State[4][2][0], c.State[4][1][0], c.State[4][0][0] = c.State[4][2][0], c.State[4][1][0], c.State[4][0][0], c.State[0][0][2], c.State[0][1][2], c.State[0][2][2], c.State[2][0][2], c.State[2][1][2], c.State[2][2][2], c.State[5][0][2], c.State[5][1][2], c.State[5][2][2]
-}
-
-func (c *Cube) ApplyL() {
-	c.RotateFace(1)
-	c.State[0][0][0], c.State[0][1][0], c.State[0][2][0], c.State[2][0][0], c.State[2][1][0], c.State[2][2][0], c.State[5][0][0], c.State[5][1][0], c.State[5][2][0], c.State[4][2][2], c.State[4][1][2], c.State[4][0][2] = c.State[4][2][2], c.State[4][1][2], c.State[4][0][2], c.State[0][0][0], c.State[0][1][0], c.State[0][2][0], c.State[2][0][0], c.State[2][1][0], c.State[2][2][0], c.State[5][0][0], c.State[5][1][0], c.State[5][2][0]
-}
-
-func (c *Cube) ApplyLPrime() {
-	c.RotateFace(1)
-	c.RotateFace(1)
-	c.RotateFace(1)
-	c.State[0][0][0], c.State[0][1][0], c.State[0][2][0], c.State[2][0][0], c.State[2][1][0], c.State[2][2][0], c.State[5][0][0], c.State[5][1][0], c.State[5][2][0], c.State[4][2][2], c.State[4][1][2], c.State[4][0][2] = c.State[2][0][0], c.State[2][1][0], c.State[2][2][0], c.State[5][0][0], c.State[5][1][0], c.State[5][2][0], c.State[4][2][2], c.State[4][1][2], c.State[4][0][2], c.State[0][0][0], c.State[0][1][0], c.State[0][2][0]
-}
-
-func (c *Cube) ApplyX() {
-	c.RotateFace(1)
-	c.RotateFace(1)
-	c.RotateFace(1)
-
-	c.RotateFace(3)
-
-	c.RotateFace(0)
-	c.RotateFace(0)
-	c.RotateFace(4)
-	c.RotateFace(4)
-
-	c.State[0], c.State[2], c.State[5], c.State[4] = c.State[2], c.State[5], c.State[4], c.State[0]
+func (c *Cube) sticker(p coords) (int, int, int) {
+	n, m := c.Size, c.Size-1
+	idx := func(v int) int { return (v + m) / 2 }
+	switch {
+	case p[1] == n:
+		return 0, idx(p[2]), idx(p[0])
+	case p[0] == -n:
+		return 1, idx(-p[1]), idx(p[2])
+	case p[2] == n:
+		return 2, idx(-p[1]), idx(p[0])
+	case p[0] == n:
+		return 3, idx(-p[1]), idx(-p[2])
+	case p[2] == -n:
+		return 4, idx(-p[1]), idx(-p[0])
+	default:
+		return 5, idx(-p[2]), idx(p[0])
+	}
 }
 
-func (c *Cube) ApplyXPrime() {
-	c.RotateFace(1)
-
-	c.RotateFace(3)
-	c.RotateFace(3)
-	c.RotateFace(3)
-
-	c.RotateFace(4)
-	c.RotateFace(4)
-	c.RotateFace(5)
-	c.RotateFace(5)
-
-	c.State[0], c.State[2], c.State[5], c.State[4] = c.State[4], c.State[0], c.State[2], c.State[5]
+// rotate turns the coordinates a quarter turn clockwise as seen from the
+// positive end of the axis
+func (p coords) rotate(axis int) coords {
+	switch axis {
+	case 0:
+		return coords{p[0], p[2], -p[1]}
+	case 1:
+		return coords{-p[2], p[1], p[0]}
+	default:
+		return coords{p[1], -p[0], p[2]}
+	}
 }
 
-func (c *Cube) ApplyY() {
-	c.RotateFace(0)
+// turn rotates the stickers with layer(coordinate on the axis) true by the
+// given number of clockwise quarter turns seen from the positive end of the
+// axis
+func (c *Cube) turn(axis, quarters int, layer func(int) bool) {
+	var next [6][][]int
+	for face := range c.State {
+		next[face] = make([][]int, c.Size)
+		for row := range c.State[face] {
+			next[face][row] = append([]int{}, c.State[face][row]...)
+		}
+	}
 
-	c.RotateFace(5)
-	c.RotateFace(5)
-	c.RotateFace(5)
+	for face := range c.State {
+		for row := range c.State[face] {
+			for col := range c.State[face][row] {
+				p := c.coords(face, row, col)
+				if !layer(p[axis]) {
+					continue
+				}
+				for range quarters {
+					p = p.rotate(axis)
+				}
+				f, r, cl := c.sticker(p)
+				next[f][r][cl] = c.State[face][row][col]
+			}
+		}
+	}
 
-	c.State[1], c.State[2], c.State[3], c.State[4] = c.State[2], c.State[3], c.State[4], c.State[1]
+	c.State = next
 }
 
-func (c *Cube) ApplyYPrime() {
-	c.RotateFace(0)
-	c.RotateFace(0)
-	c.RotateFace(0)
-
-	c.RotateFace(5)
-
-	c.State[1], c.State[2], c.State[3], c.State[4] = c.State[4], c.State[1], c.State[2], c.State[3]
+// axis and the sign of the direction every move turns around
+var moveAxes = map[string][2]int{
+	"R": {0, 1}, "L": {0, -1}, "U": {1, 1}, "D": {1, -1}, "F": {2, 1}, "B": {2, -1},
+	"M": {0, -1}, "E": {1, -1}, "S": {2, 1},
+	"x": {0, 1}, "y": {1, 1}, "z": {2, 1},
 }
 
-func (c *Cube) ApplyZ() {
-	c.RotateFace(2)
-
-	c.RotateFace(4)
-	c.RotateFace(4)
-	c.RotateFace(4)
+func (c *Cube) Turn(move Move) error {
+	axis, sign := moveAxes[move.Face][0], moveAxes[move.Face][1]
+	quarters := move.Turns
+	if sign < 0 {
+		quarters = (4 - quarters) % 4
+	}
 
-	for _, f := range []int{0, 1, 3, 5} {
-		c.RotateFace(f)
+	n := c.Size
+	switch {
+	case move.IsRotation():
+		c.turn(axis, quarters, func(int) bool { return true })
+	case move.IsSlice():
+		// every layer except the two outer ones, on odd cubes the middle slice
+		if n < 3 {
+			return fmt.Errorf("%w: slice move=%s on %dx%d", ErrInvalidMove, move, n, n)
+		}
+		c.turn(axis, quarters, func(v int) bool { return v >= -(n-3) && v <= n-3 })
+	default:
+		if move.Width > n {
+			return fmt.Errorf("%w: move=%s too wide for %dx%d", ErrInvalidMove, move, n, n)
+		}
+		c.turn(axis, quarters, func(v int) bool { return sign*v >= n+1-2*move.Width })
 	}
 
-	c.State[0], c.State[1], c.State[5], c.State[3] = c.State[1], c.State[5], c.State[3], c.State[0]
+	return nil
 }
 
-func (c *Cube) ApplyZPrime() {
-	c.RotateFace(2)
-	c.RotateFace(2)
-	c.RotateFace(2)
-
-	c.RotateFace(4)
-
-	for _, f := range []int{0, 1, 3, 5} {
-		for range []int{0, 1, 2} {
-			c.RotateFace(f)
-		}
+func (c *Cube) ApplyMove(move string) error {
+	m, err := ParseMove(move)
+	if err != nil {
+		return err
 	}
 
-	c.State[0], c.State[1], c.State[5], c.State[3] = c.State[3], c.State[0], c.State[1], c.State[5]
+	return c.Turn(m)
 }
 
-func (c *Cube) ApplyMove(move string) {
-	switch move {
-	case "U":
-		c.ApplyU()
-	case "U'":
-		c.ApplyUPrime()
-	case "U2":
-		c.ApplyU()
-		c.ApplyU()
-
-	case "D":
-		c.ApplyD()
-	case "D'":
-		c.ApplyDPrime()
-	case "D2":
-		c.ApplyD()
-		c.ApplyD()
-
-	case "F":
-		c.ApplyF()
-	case "F'":
-		c.ApplyFPrime()
-	case "F2":
-		c.ApplyF()
-		c.ApplyF()
-
-	case "B":
-		c.ApplyB()
-	case "B'":
-		c.ApplyBPrime()
-	case "B2":
-		c.ApplyB()
-		c.ApplyB()
-
-	case "R":
-		c.ApplyR()
-	case "R'":
-		c.ApplyRPrime()
-	case "R2":
-		c.ApplyR()
-		c.ApplyR()
-
-	case "L":
-		c.ApplyL()
-	case "L'":
-		c.ApplyLPrime()
-	case "L2":
-		c.ApplyL()
-		c.ApplyL()
-
-	case "Uw":
-		c.ApplyD()
-		c.ApplyY()
-	case "Uw'":
-		c.ApplyDPrime()
-		c.ApplyYPrime()
-	case "Uw2":
-		c.ApplyD()
-		c.ApplyY()
-		c.ApplyD()
-		c.ApplyY()
-
-	case "Dw":
-		c.ApplyU()
-		c.ApplyYPrime()
-	case "Dw'":
-		c.ApplyUPrime()
-		c.ApplyY()
-	case "Dw2":
-		c.ApplyU()
-		c.ApplyYPrime()
-		c.ApplyU()
-		c.ApplyYPrime()
-
-	case "Fw":
-		c.ApplyB()
-		c.ApplyZ()
-	case "Fw'":
-		c.ApplyBPrime()
-		c.ApplyZPrime()
-	case "Fw2":
-		c.ApplyB()
-		c.ApplyZ()
-		c.ApplyB()
-		c.ApplyZ()
-
-	case "Bw":
-		c.ApplyF()
-		c.ApplyZPrime()
-	case "Bw'":
-		c.ApplyFPrime()
-		c.ApplyZ()
-	case "Bw2":
-		c.ApplyF()
-		c.ApplyZPrime()
-		c.ApplyF()
-		c.ApplyZPrime()
-
-	case "Rw":
-		c.ApplyL()
-		c.ApplyX()
-	case "Rw'":
-		c.ApplyLPrime()
-		c.ApplyXPrime()
-	case "Rw2":
-		c.ApplyL()
-		c.ApplyX()
-		c.ApplyL()
-		c.ApplyX()
-
-	case "Lw":
-		c.ApplyR()
-		c.ApplyXPrime()
-	case "Lw'":
-		c.ApplyRPrime()
-		c.ApplyX()
-	case "Lw2":
-		c.ApplyR()
-		c.ApplyXPrime()
-		c.ApplyR()
-		c.ApplyXPrime()
-
-	case "x":
-		c.ApplyX()
-	case "x'":
-		c.ApplyXPrime()
-	case "x2":
-		c.ApplyX()
-		c.ApplyX()
-
-	case "y":
-		c.ApplyY()
-	case "y'":
-		c.ApplyYPrime()
-	case "y2":
-		c.ApplyY()
-		c.ApplyY()
-
-	case "z":
-		c.ApplyZ()
-	case "z'":
-		c.ApplyZPrime()
-	case "z2":
-		c.ApplyZ()
-		c.ApplyZ()
+func (c *Cube) ApplyAlgorithm(algorithm string) error {
+	moves, err := ParseAlgorithm(algorithm)
+	if err != nil {
+		return err
 	}
-}
 
-func (c *Cube) ApplyAlgorihtm(algorihtm string) {
-	moves := strings.Split(algorihtm, " ")
 	for _, move := range moves {
-		c.ApplyMove(move)
+		if err := c.Turn(move); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-func (c *Cube) ApplyScramble() {
-	c.ApplyAlgorihtm(c.Scramble)
+func (c *Cube) ApplyScramble() error {
+	return c.ApplyAlgorithm(c.Scramble)
 }
 
-func (c *Cube) ApplySolution() {
-	c.ApplyAlgorihtm(c.Solution)
+func (c *Cube) ApplySolution() error {
+	return c.ApplyAlgorithm(c.Solution)
 }
 
 func (c *Cube) Solved() bool {
 	for face := range c.State {
-		all := true
 		for i := range c.State[face] {
 			for j := range c.State[face][i] {
 				if c.State[face][i][j] != c.State[face][0][0] {
-					all = false
+					return false
 				}
 			}
 		}
-		if !all {
-			return false
-		}
 	}
 
 	return true
@@ -409,7 +234,7 @@ func (c *Cube) Solved() bool {
 func (c *Cube) OfficialSolutionLength() int {
 	moveCount := 0
 
-	for _, move := range strings.Split(c.Solution, " ") {
+	for _, move := range strings.Fields(c.Solution) {
 		idx := IndexFunc(ROTATIONS, func(rot string) bool { return rot == move })
 		if idx == -1 {
 			moveCount++
@@ -420,49 +245,40 @@ func (c *Cube) OfficialSolutionLength() int {
 }
 
 func (c *Cube) TotalSolutionLength() int {
-	return len(strings.Split(c.Solution, " "))
+	return len(strings.Fields(c.Solution))
 }
 
 func (c *Cube) PrintState() {
-	for i := range c.State[0] {
-		fmt.Print("   ")
-		for j := range c.State[0][i] {
-			fmt.Print(COLORS[c.State[0][i][j]])
+	pad := strings.Repeat(" ", c.Size)
+
+	for _, row := range c.State[0] {
+		fmt.Print(pad)
+		for _, color := range row {
+			fmt.Print(COLORS[color])
 		}
 		fmt.Println()
 	}
 
-	for i := range []int{0, 1, 2} {
+	for i := range c.Size {
 		for _, face := range []int{1, 2, 3, 4} {
-			for j := range []int{0, 1, 2} {
-				fmt.Print(COLORS[c.State[face][i][j]])
+			for _, color := range c.State[face][i] {
+				fmt.Print(COLORS[color])
 			}
 		}
 		fmt.Println()
 	}
 
-	for i := range c.State[5] {
-		fmt.Print("   ")
-		for j := range c.State[5][i] {
-			fmt.Print(COLORS[c.State[5][i][j]])
+	for _, row := range c.State[5] {
+		fmt.Print(pad)
+		for _, color := range row {
+			fmt.Print(COLORS[color])
 		}
 		fmt.Println()
 	}
 }
 
+// ParseFMCSolutionToMilliseconds is the result of the fewest moves solution,
+// copying the inverse scramble does not make it a DNF
 func ParseFMCSolutionToMilliseconds(scramble string, solution string) int {
-	c := Cube{InitialState(), scramble, solution}
-
-	if !c.ValidMoves() {
-		return constants.DNF
-	}
-
-	c.ApplyScramble()
-	c.ApplySolution()
-
-	if !c.Solved() || c.TotalSolutionLength() > 80 {
-		return constants.DNF
-	}
-
-	return c.OfficialSolutionLength()
+	return VerifyFMCSolution(scramble, solution).Result
 }
@@ -0,0 +1,100 @@
+package cube_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/cube"
+)
+
+func TestParseMove(t *testing.T) {
+	for notation, expected := range map[string]cube.Move{
+		"R":     {Face: "R", Width: 1, Turns: 1},
+		"U'":    {Face: "U", Width: 1, Turns: 3},
+		"F2":    {Face: "F", Width: 1, Turns: 2},
+		"Lw":    {Face: "L", Width: 2, Turns: 1},
+		"2Bw2'": {Face: "B", Width: 2, Turns: 2},
+		"3Rw'":  {Face: "R", Width: 3, Turns: 3},
+		"M2":    {Face: "M", Turns: 2},
+		"y'":    {Face: "y", Turns: 3},
+	} {
+		move, err := cube.ParseMove(notation)
+		require.NoError(t, err, notation)
+		assert.Equal(t, expected, move, notation)
+	}
+
+	for _, notation := range []string{"", "Q", "3R", "r", "R3", "Rw''", "0Rw", "M'2"} {
+		_, err := cube.ParseMove(notation)
+		assert.ErrorIs(t, err, cube.ErrInvalidMove, notation)
+	}
+
+	_, err := cube.ParseAlgorithm("R U R' X U'")
+	assert.ErrorContains(t, err, "move=X: at position=4")
+}
+
+func TestMoveString(t *testing.T) {
+	for notation, expected := range map[string]string{"2Rw2'": "Rw2", "3Uw'": "3Uw'", "M": "M", "x2": "x2"} {
+		move, err := cube.ParseMove(notation)
+		require.NoError(t, err)
+		assert.Equal(t, expected, move.String())
+		assert.Equal(t, (4-move.Turns)%4, move.Inverse().Turns)
+	}
+}
+
+func applied(t *testing.T, size int, algorithm string) [6][][]int {
+	c := cube.New(size)
+	require.NoError(t, c.ApplyAlgorithm(algorithm))
+	return c.State
+}
+
+func TestTurn(t *testing.T) {
+	// R brings the front stickers to the right column of U and the back
+	// stickers to the right column of D
+	state := applied(t, 3, "R")
+	assert.Equal(t, []int{0, 0, 2}, state[0][0])
+	assert.Equal(t, []int{5, 5, 4}, state[5][2])
+
+	for _, size := range []int{2, 3, 4, 5, 6, 7} {
+		c := cube.New(size)
+		require.NoError(t, c.ApplyAlgorithm(strings.Repeat("R U R' U' ", 6)))
+		assert.True(t, c.Solved(), size)
+		assert.Equal(t, applied(t, size, "x"), applied(t, size, "R L'"+wideInner(size)), size)
+	}
+
+	// slice moves are wide moves without the outer layer
+	assert.Equal(t, applied(t, 3, "Lw L'"), applied(t, 3, "M"))
+	assert.Equal(t, applied(t, 3, "Dw D'"), applied(t, 3, "E"))
+	assert.Equal(t, applied(t, 3, "Fw F'"), applied(t, 3, "S"))
+	assert.Equal(t, applied(t, 5, "4Lw L'"), applied(t, 5, "M"))
+
+	// on a 5x5 a 3Rw is an x rotation with the two left layers turned back
+	assert.Equal(t, applied(t, 5, "x Lw"), applied(t, 5, "3Rw"))
+	assert.Equal(t, applied(t, 4, "x"), applied(t, 4, "4Rw"))
+
+	c := cube.New(4)
+	assert.ErrorIs(t, c.ApplyMove("5Rw"), cube.ErrInvalidMove)
+	assert.ErrorIs(t, cube.New(2).ApplyMove("M"), cube.ErrInvalidMove)
+}
+
+// wideInner returns the moves turning all inner layers of the cube like R
+func wideInner(size int) string {
+	if size < 3 {
+		return ""
+	}
+	return " M'"
+}
+
+func TestParseFMCSolutionToMilliseconds(t *testing.T) {
+	scramble := "R U R' F2"
+
+	assert.Equal(t, 4000, cube.ParseFMCSolutionToMilliseconds(scramble, "F2 R U' R'"))
+	assert.Equal(t, 4000, cube.ParseFMCSolutionToMilliseconds(scramble, "x U2 x' R U' R'"))
+	assert.Equal(t, 4000, cube.ParseFMCSolutionToMilliseconds(scramble, "y y' F2 R U' R'"))
+	assert.Equal(t, constants.DNF, cube.ParseFMCSolutionToMilliseconds(scramble, "F2 R U' R"))
+	assert.Equal(t, constants.DNF, cube.ParseFMCSolutionToMilliseconds(scramble, "F2 R U' R' M M'"))
+	assert.Equal(t, constants.DNF, cube.ParseFMCSolutionToMilliseconds(scramble, ""))
+}
@@ -0,0 +1,137 @@
+package cube
+
+import (
+	"strings"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+)
+
+const (
+	FMC_MAX_SOLUTION_LENGTH = 80
+	// a solution sharing this many consecutive moves with the inverse scramble
+	// is considered copied, shorter scrambles are never flagged
+	FMC_COPIED_MOVES_LIMIT = 8
+)
+
+type FMCMove struct {
+	Position int    `json:"position"`
+	Move     string `json:"move"`
+	Counted  bool   `json:"counted"`
+}
+
+type FMCIllegalMove struct {
+	Position int    `json:"position"`
+	Move     string `json:"move"`
+	Reason   string `json:"reason"`
+}
+
+// FMCReport explains the result of a fewest moves solution, Result is the
+// move count in milliseconds like the other results or DNF. Copying the
+// inverse scramble is only a hint for the admins checking the results, it
+// does not change Result.
+type FMCReport struct {
+	Moves                 []FMCMove        `json:"moves"`
+	MoveCount             int              `json:"moveCount"`
+	TotalLength           int              `json:"totalLength"`
+	Solved                bool             `json:"solved"`
+	IllegalMoves          []FMCIllegalMove `json:"illegalMoves"`
+	TooLong               bool             `json:"tooLong"`
+	CopiesInverseScramble bool             `json:"copiesInverseScramble"`
+	Result                int              `json:"result"`
+}
+
+// VerifyFMCSolution checks the solution according to the WCA regulations: only
+// face moves, outer block moves and rotations are allowed, rotations are not
+// counted, the solution has at most 80 moves including rotations and must not
+// be derived from the scramble, which is only reported.
+func VerifyFMCSolution(scramble string, solution string) FMCReport {
+	report := FMCReport{Moves: make([]FMCMove, 0), IllegalMoves: make([]FMCIllegalMove, 0), Result: constants.DNF}
+
+	moves := make([]Move, 0)
+	for idx, token := range strings.Fields(solution) {
+		position := idx + 1
+		report.TotalLength++
+
+		move, err := ParseMove(token)
+		if err != nil {
+			report.IllegalMoves = append(report.IllegalMoves, FMCIllegalMove{position, token, "Unknown move."})
+			continue
+		}
+		if IndexFunc(VALID_MOVES, func(m string) bool { return m == token }) == -1 {
+			report.IllegalMoves = append(report.IllegalMoves, FMCIllegalMove{position, token, "Move not allowed in fewest moves."})
+			continue
+		}
+
+		moves = append(moves, move)
+		report.Moves = append(report.Moves, FMCMove{position, token, !move.IsRotation()})
+		if !move.IsRotation() {
+			report.MoveCount++
+		}
+	}
+
+	report.TooLong = report.TotalLength > FMC_MAX_SOLUTION_LENGTH
+	if len(report.IllegalMoves) > 0 {
+		return report
+	}
+
+	c := New(3)
+	if err := c.ApplyAlgorithm(scramble); err != nil {
+		return report
+	}
+	for _, move := range moves {
+		if err := c.Turn(move); err != nil {
+			return report
+		}
+	}
+	report.Solved = report.TotalLength > 0 && c.Solved()
+
+	scrambleMoves, err := ParseAlgorithm(scramble)
+	if err == nil {
+		report.CopiesInverseScramble = copiesInverse(scrambleMoves, moves)
+	}
+
+	if report.Solved && !report.TooLong {
+		report.Result = report.MoveCount * 1000
+	}
+
+	return report
+}
+
+// copiesInverse reports whether the solution without rotations contains a long
+// enough part of the inverse scramble
+func copiesInverse(scramble []Move, solution []Move) bool {
+	inverse := make([]string, 0, len(scramble))
+	for i := len(scramble) - 1; i >= 0; i-- {
+		if !scramble[i].IsRotation() {
+			inverse = append(inverse, scramble[i].Inverse().String())
+		}
+	}
+
+	turns := make([]string, 0, len(solution))
+	for _, move := range solution {
+		if !move.IsRotation() {
+			turns = append(turns, move.String())
+		}
+	}
+
+	if len(inverse) < FMC_COPIED_MOVES_LIMIT {
+		return false
+	}
+
+	// longest common run of consecutive moves
+	longest := make([]int, len(inverse)+1)
+	for i := range turns {
+		for j := len(inverse) - 1; j >= 0; j-- {
+			if turns[i] == inverse[j] {
+				longest[j+1] = longest[j] + 1
+				if longest[j+1] >= FMC_COPIED_MOVES_LIMIT {
+					return true
+				}
+			} else {
+				longest[j+1] = 0
+			}
+		}
+	}
+
+	return false
+}
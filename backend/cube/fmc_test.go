@@ -0,0 +1,69 @@
+package cube_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/cube"
+)
+
+func TestVerifyFMCSolution(t *testing.T) {
+	// the sexy move has order 6 so five more of them solve it, Lw x is the same
+	// as R
+	scramble := "R U R' U'"
+
+	t.Run("solved", func(t *testing.T) {
+		// rotations are free and a wide move is a single move
+		report := cube.VerifyFMCSolution(scramble, "Lw x U R' U' R U R' U' R U R' U' R U R' U' R U R' U'")
+		assert.True(t, report.Solved)
+		assert.Empty(t, report.IllegalMoves)
+		assert.Equal(t, 20, report.MoveCount)
+		assert.Equal(t, 21, report.TotalLength)
+		assert.True(t, report.Moves[0].Counted)
+		assert.False(t, report.Moves[1].Counted)
+		assert.False(t, report.CopiesInverseScramble)
+		assert.Equal(t, 20000, report.Result)
+	})
+
+	t.Run("copied inverse scramble", func(t *testing.T) {
+		report := cube.VerifyFMCSolution("R U R' U' F2 D L2 B", "B' L2 D' F2 U R U' R'")
+		assert.True(t, report.Solved)
+		assert.True(t, report.CopiesInverseScramble)
+		assert.Equal(t, 8000, report.Result)
+
+		// the inverse of a short scramble is often the best solution
+		report = cube.VerifyFMCSolution(scramble, "y y' U R U' R'")
+		assert.True(t, report.Solved)
+		assert.False(t, report.CopiesInverseScramble)
+		assert.Equal(t, 4000, report.Result)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		report := cube.VerifyFMCSolution(scramble, strings.Repeat("x x' ", 40)+"U R U' R'")
+		assert.True(t, report.TooLong)
+		assert.Equal(t, constants.DNF, report.Result)
+	})
+
+	t.Run("illegal moves", func(t *testing.T) {
+		report := cube.VerifyFMCSolution("R U", "U' M r R'")
+		assert.Equal(t, []cube.FMCIllegalMove{
+			{Position: 2, Move: "M", Reason: "Move not allowed in fewest moves."},
+			{Position: 3, Move: "r", Reason: "Unknown move."},
+		}, report.IllegalMoves)
+		assert.False(t, report.Solved)
+		assert.Equal(t, constants.DNF, report.Result)
+	})
+
+	t.Run("not solved", func(t *testing.T) {
+		report := cube.VerifyFMCSolution("R U", "U R")
+		assert.False(t, report.Solved)
+		assert.Equal(t, constants.DNF, report.Result)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, constants.DNF, cube.ParseFMCSolutionToMilliseconds("", ""))
+	})
+}
@@ -0,0 +1,118 @@
+package cube
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidMove = errors.New("invalid move")
+
+// Move is a single move in WCA notation: outer block moves (R, Rw, 3Rw),
+// slice moves (M, E, S) and rotations (x, y, z).
+type Move struct {
+	// U, D, R, L, F or B for outer block moves, M, E or S for slice moves and
+	// x, y or z for rotations
+	Face string
+	// number of outer layers turned, 1 for face moves, 0 for slice moves and
+	// rotations
+	Width int
+	// clockwise quarter turns as seen from the face, slice moves follow L (M),
+	// D (E) and F (S), rotations follow R (x), U (y) and F (z)
+	Turns int
+}
+
+var (
+	outerMoveRegexp = regexp.MustCompile(`^(\d*)([UDRLFB])(w?)(2'|2|')?$`)
+	otherMoveRegexp = regexp.MustCompile(`^([MESxyz])(2'|2|')?$`)
+)
+
+func turnsFromSuffix(suffix string) int {
+	switch suffix {
+	case "'":
+		return 3
+	case "2", "2'":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ParseMove parses a single move, a number in front of the face is only
+// allowed for wide moves, so 3Rw turns the three outer right layers.
+func ParseMove(move string) (Move, error) {
+	if m := otherMoveRegexp.FindStringSubmatch(move); m != nil {
+		return Move{Face: m[1], Turns: turnsFromSuffix(m[2])}, nil
+	}
+
+	m := outerMoveRegexp.FindStringSubmatch(move)
+	if m == nil {
+		return Move{}, fmt.Errorf("%w: move=%s", ErrInvalidMove, move)
+	}
+
+	width := 1
+	if m[3] == "w" {
+		width = 2
+	}
+	if m[1] != "" {
+		w, err := strconv.Atoi(m[1])
+		if err != nil || m[3] != "w" || w < 1 {
+			return Move{}, fmt.Errorf("%w: move=%s", ErrInvalidMove, move)
+		}
+		width = w
+	}
+
+	return Move{Face: m[2], Width: width, Turns: turnsFromSuffix(m[4])}, nil
+}
+
+// ParseAlgorithm parses whitespace separated moves, the error names the first
+// invalid move and its position counted from 1.
+func ParseAlgorithm(algorithm string) ([]Move, error) {
+	tokens := strings.Fields(algorithm)
+	moves := make([]Move, 0, len(tokens))
+	for idx, token := range tokens {
+		move, err := ParseMove(token)
+		if err != nil {
+			return []Move{}, fmt.Errorf("%w: at position=%d", err, idx+1)
+		}
+		moves = append(moves, move)
+	}
+
+	return moves, nil
+}
+
+func (m Move) IsRotation() bool {
+	return m.Face == "x" || m.Face == "y" || m.Face == "z"
+}
+
+func (m Move) IsSlice() bool {
+	return m.Face == "M" || m.Face == "E" || m.Face == "S"
+}
+
+func (m Move) Inverse() Move {
+	m.Turns = (4 - m.Turns) % 4
+	return m
+}
+
+// String returns the move in canonical notation, eg. 2Rw2' becomes Rw2.
+func (m Move) String() string {
+	var sb strings.Builder
+	if m.Width > 2 {
+		sb.WriteString(strconv.Itoa(m.Width))
+	}
+	sb.WriteString(m.Face)
+	if m.Width > 1 {
+		sb.WriteString("w")
+	}
+
+	switch m.Turns {
+	case 2:
+		sb.WriteString("2")
+	case 3:
+		sb.WriteString("'")
+	}
+
+	return sb.String()
+}
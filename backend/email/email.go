@@ -1,21 +1,64 @@
 package email
 
 import (
-	"gopkg.in/gomail.v2"
+	"context"
+	"fmt"
+	"strconv"
 )
 
-func SendMail(from string, to string, subject string, msg string, envMap map[string]string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", from)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", msg)
+// Message is one email to one recipient, the body is HTML
+type Message struct {
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	Subject string            `json:"subject"`
+	Body    string            `json:"body"`
+	Headers map[string]string `json:"headers"`
+}
 
-	d := gomail.NewDialer("smtp.gmail.com", 587, envMap["MAIL_USERNAME"], envMap["MAIL_PASSWORD"])
+// Mailer delivers the messages, the transport is chosen by MAIL_TRANSPORT
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
 
-	if err := d.DialAndSend(m); err != nil {
-		return err
-	}
+const (
+	TRANSPORT_SMTP   = "smtp"
+	TRANSPORT_FILE   = "file"
+	TRANSPORT_MEMORY = "memory"
+
+	DEFAULT_SMTP_HOST = "smtp.gmail.com"
+	DEFAULT_SMTP_PORT = 587
+	DEFAULT_MAIL_DIR  = "mail"
+)
 
-	return nil
+// NewMailer returns the mailer configured by the environment, SMTP by default
+func NewMailer(envMap map[string]string) (Mailer, error) {
+	switch envMap["MAIL_TRANSPORT"] {
+	case "", TRANSPORT_SMTP:
+		host := envMap["MAIL_HOST"]
+		if host == "" {
+			host = DEFAULT_SMTP_HOST
+		}
+
+		port := DEFAULT_SMTP_PORT
+		if envMap["MAIL_PORT"] != "" {
+			var err error
+			port, err = strconv.Atoi(envMap["MAIL_PORT"])
+			if err != nil {
+				return nil, fmt.Errorf("%w: when parsing MAIL_PORT", err)
+			}
+		}
+
+		return NewSMTPMailer(host, port, envMap["MAIL_USERNAME"], envMap["MAIL_PASSWORD"]), nil
+	case TRANSPORT_FILE:
+		dir := envMap["MAIL_DIR"]
+		if dir == "" {
+			dir = DEFAULT_MAIL_DIR
+		}
+
+		return NewFileMailer(dir), nil
+	case TRANSPORT_MEMORY:
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", envMap["MAIL_TRANSPORT"])
+	}
 }
@@ -0,0 +1,65 @@
+package email_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+)
+
+func TestNewMailer(t *testing.T) {
+	mailer, err := email.NewMailer(map[string]string{})
+	require.NoError(t, err)
+	require.IsType(t, &email.SMTPMailer{}, mailer)
+
+	mailer, err = email.NewMailer(map[string]string{"MAIL_TRANSPORT": email.TRANSPORT_FILE})
+	require.NoError(t, err)
+	require.IsType(t, &email.FileMailer{}, mailer)
+
+	mailer, err = email.NewMailer(map[string]string{"MAIL_TRANSPORT": email.TRANSPORT_MEMORY})
+	require.NoError(t, err)
+	require.IsType(t, &email.MemoryMailer{}, mailer)
+
+	_, err = email.NewMailer(map[string]string{"MAIL_PORT": "port"})
+	require.Error(t, err)
+
+	_, err = email.NewMailer(map[string]string{"MAIL_TRANSPORT": "pigeon"})
+	require.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := email.NewFileMailer(dir)
+
+	msg := email.Message{From: "a@example.com", To: "b@example.com", Subject: "Hello", Body: "<b>Hi</b>", Headers: map[string]string{"X-Category": "test"}}
+	require.NoError(t, mailer.Send(t.Context(), msg))
+	require.NoError(t, mailer.Send(t.Context(), msg))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(content), "To: b@example.com\r\n"))
+	require.True(t, strings.Contains(string(content), "X-Category: test\r\n"))
+	require.True(t, strings.HasSuffix(string(content), "<b>Hi</b>"))
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := email.NewMemoryMailer()
+
+	require.NoError(t, mailer.Send(t.Context(), email.Message{To: "a@example.com"}))
+
+	mailer.SetErr(errors.New("down"))
+	require.Error(t, mailer.Send(t.Context(), email.Message{To: "b@example.com"}))
+
+	sent := mailer.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, "a@example.com", sent[0].To)
+}
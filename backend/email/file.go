@@ -0,0 +1,49 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every message into its own file in the directory instead
+// of sending it, for development without a mail server
+type FileMailer struct {
+	dir   string
+	count atomic.Int64
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("%w: when creating mail directory %s", err, m.dir)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\nTo: %s\r\nSubject: %s\r\n", msg.From, msg.To, msg.Subject)
+	for _, key := range slices.Sorted(maps.Keys(msg.Headers)) {
+		fmt.Fprintf(&sb, "%s: %s\r\n", key, msg.Headers[key])
+	}
+	sb.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	sb.WriteString(msg.Body)
+
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102-150405"), m.count.Add(1))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf("%w: when writing mail to %s", err, path)
+	}
+
+	slog.Info("mail written to file", "to", msg.To, "subject", msg.Subject, "path", path)
+
+	return nil
+}
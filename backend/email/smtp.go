@@ -0,0 +1,28 @@
+package email
+
+import (
+	"context"
+
+	"gopkg.in/gomail.v2"
+)
+
+type SMTPMailer struct {
+	dialer *gomail.Dialer
+}
+
+func NewSMTPMailer(host string, port int, username string, password string) *SMTPMailer {
+	return &SMTPMailer{dialer: gomail.NewDialer(host, port, username, password)}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	gm := gomail.NewMessage()
+	gm.SetHeader("From", msg.From)
+	gm.SetHeader("To", msg.To)
+	gm.SetHeader("Subject", msg.Subject)
+	for key, value := range msg.Headers {
+		gm.SetHeader(key, value)
+	}
+	gm.SetBody("text/html", msg.Body)
+
+	return m.dialer.DialAndSend(gm)
+}
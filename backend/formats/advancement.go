@@ -0,0 +1,92 @@
+package formats
+
+import (
+	"errors"
+	"fmt"
+)
+
+// at most this share of the competitors of a round can advance to the next one
+const MAX_ADVANCING_PERCENT = 75
+
+var ErrInvalidAdvancement = errors.New("invalid advancement")
+
+type AdvancementType string
+
+const (
+	// the best Level competitors advance
+	AdvanceByRanking AdvancementType = "ranking"
+	// the best Level percent of competitors advance
+	AdvanceByPercent AdvancementType = "percent"
+	// competitors with a result better than Level (in milliseconds) advance
+	AdvanceByResult AdvancementType = "result"
+)
+
+// Advancement decides who of the previous round competes in a round.
+type Advancement struct {
+	Type  AdvancementType `json:"type"`
+	Level int             `json:"level"`
+}
+
+// Standing is the place of a competitor in a round and the result deciding it.
+type Standing struct {
+	Place  int
+	Result int
+}
+
+func (a Advancement) Validate() error {
+	valid := false
+	switch a.Type {
+	case AdvanceByRanking, AdvanceByResult:
+		valid = a.Level > 0
+	case AdvanceByPercent:
+		valid = a.Level > 0 && a.Level <= MAX_ADVANCING_PERCENT
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: type=%s level=%d", ErrInvalidAdvancement, a.Type, a.Level)
+	}
+
+	return nil
+}
+
+// Advancing returns how many of the competitors advance, standings have to be
+// sorted by place. Competitors without a valid result never advance and
+// competitors tied for the last advancing place advance together, unless
+// there would be more than MAX_ADVANCING_PERCENT of them.
+func (a Advancement) Advancing(standings []Standing) int {
+	maxAdvancing := len(standings) * MAX_ADVANCING_PERCENT / 100
+
+	cnt := 0
+	for _, standing := range standings {
+		if bad(standing.Result) {
+			break
+		}
+
+		advances := false
+		switch a.Type {
+		case AdvanceByRanking:
+			advances = standing.Place <= a.Level
+		case AdvanceByPercent:
+			advances = standing.Place <= len(standings)*a.Level/100
+		case AdvanceByResult:
+			advances = standing.Result < a.Level
+		}
+		if !advances {
+			break
+		}
+
+		cnt++
+	}
+
+	if cnt <= maxAdvancing {
+		return cnt
+	}
+
+	// drop the whole group tied for the last place that still fits
+	cnt = maxAdvancing
+	for cnt > 0 && cnt < len(standings) && standings[cnt-1].Place == standings[cnt].Place {
+		cnt--
+	}
+
+	return cnt
+}
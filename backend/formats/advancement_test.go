@@ -0,0 +1,49 @@
+package formats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+)
+
+func standings(results ...int) []formats.Standing {
+	res := make([]formats.Standing, len(results))
+	for idx, result := range results {
+		res[idx] = formats.Standing{Place: idx + 1, Result: result}
+		if idx > 0 && result == results[idx-1] {
+			res[idx].Place = res[idx-1].Place
+		}
+	}
+
+	return res
+}
+
+func TestAdvancing(t *testing.T) {
+	for _, tc := range []struct {
+		advancement formats.Advancement
+		standings   []formats.Standing
+		advancing   int
+	}{
+		{formats.Advancement{Type: formats.AdvanceByRanking, Level: 3}, standings(1, 2, 3, 4, 5, 6), 3},
+		{formats.Advancement{Type: formats.AdvanceByRanking, Level: 3}, standings(1, 2, 3, 3, 5, 6), 4},
+		{formats.Advancement{Type: formats.AdvanceByRanking, Level: 5}, standings(1, 2, 3, 4, 5, 6), 4},
+		{formats.Advancement{Type: formats.AdvanceByRanking, Level: 3}, standings(1, 2, 2, 2, 5), 1},
+		{formats.Advancement{Type: formats.AdvanceByRanking, Level: 3}, standings(1, DNF, DNF, DNF, DNF), 1},
+		{formats.Advancement{Type: formats.AdvanceByPercent, Level: 50}, standings(1, 2, 3, 4, 5, 6, 7, 8), 4},
+		{formats.Advancement{Type: formats.AdvanceByResult, Level: 4}, standings(1, 2, 3, 4, 5, 6, 7, 8), 3},
+		{formats.Advancement{Type: formats.AdvanceByResult, Level: 100}, standings(1, 2, 3, 4), 3},
+		{formats.Advancement{Type: formats.AdvanceByRanking, Level: 1}, standings(), 0},
+	} {
+		assert.Equal(t, tc.advancing, tc.advancement.Advancing(tc.standings), tc.advancement, tc.standings)
+	}
+}
+
+func TestValidateAdvancement(t *testing.T) {
+	assert.NoError(t, formats.Advancement{Type: formats.AdvanceByRanking, Level: 8}.Validate())
+	assert.NoError(t, formats.Advancement{Type: formats.AdvanceByPercent, Level: 75}.Validate())
+	assert.ErrorIs(t, formats.Advancement{Type: formats.AdvanceByPercent, Level: 80}.Validate(), formats.ErrInvalidAdvancement)
+	assert.ErrorIs(t, formats.Advancement{Type: formats.AdvanceByResult}.Validate(), formats.ErrInvalidAdvancement)
+	assert.ErrorIs(t, formats.Advancement{Type: "best"}.Validate(), formats.ErrInvalidAdvancement)
+}
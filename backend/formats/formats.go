@@ -0,0 +1,196 @@
+// Package formats describes the competition formats, how many attempts they
+// have, how the average is computed and which result decides the ranking.
+//
+// Attempts are passed around in milliseconds as returned by
+// utils.ParseSolveToMilliseconds, so DNF and DNS are constants.DNF and
+// constants.DNS.
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+type Ranking int
+
+const (
+	RankBySingle Ranking = iota
+	RankByAverage
+)
+
+type AverageKind int
+
+const (
+	NoAverage AverageKind = iota
+	// mean of the attempts left after dropping Trim best and Trim worst ones
+	TrimmedMean
+	// sum of all attempts
+	Cumulative
+)
+
+type Format struct {
+	Id          string
+	Name        string
+	Attempts    int
+	RankBy      Ranking
+	AverageKind AverageKind
+	Trim        int
+}
+
+// ids end with the number of attempts, the frontend relies on it
+var formats = map[string]Format{
+	"bo1":  {Id: "bo1", Name: "Best of 1", Attempts: 1, RankBy: RankBySingle, AverageKind: NoAverage},
+	"bo2":  {Id: "bo2", Name: "Best of 2", Attempts: 2, RankBy: RankBySingle, AverageKind: NoAverage},
+	"bo3":  {Id: "bo3", Name: "Best of 3", Attempts: 3, RankBy: RankBySingle, AverageKind: TrimmedMean},
+	"bo5":  {Id: "bo5", Name: "Best of 5", Attempts: 5, RankBy: RankBySingle, AverageKind: TrimmedMean, Trim: 1},
+	"mo3":  {Id: "mo3", Name: "Mean of 3", Attempts: 3, RankBy: RankByAverage, AverageKind: TrimmedMean},
+	"ao5":  {Id: "ao5", Name: "Average of 5", Attempts: 5, RankBy: RankByAverage, AverageKind: TrimmedMean, Trim: 1},
+	"ao12": {Id: "ao12", Name: "Average of 12", Attempts: 12, RankBy: RankByAverage, AverageKind: TrimmedMean, Trim: 1},
+	"cu3":  {Id: "cu3", Name: "Cumulative of 3", Attempts: 3, RankBy: RankByAverage, AverageKind: Cumulative},
+}
+
+func Get(id string) (Format, error) {
+	f, ok := formats[id]
+	if !ok {
+		return Format{}, fmt.Errorf("%w: format=%s", ErrUnknownFormat, id)
+	}
+
+	return f, nil
+}
+
+func All() []Format {
+	res := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		res = append(res, f)
+	}
+	slices.SortFunc(res, func(a, b Format) int {
+		if a.Attempts != b.Attempts {
+			return a.Attempts - b.Attempts
+		}
+		if a.Id < b.Id {
+			return -1
+		}
+		return 1
+	})
+
+	return res
+}
+
+func (f Format) HasAverage() bool {
+	return f.AverageKind != NoAverage
+}
+
+// ShowsPossibleAverages reports whether the best and worst possible averages
+// make sense before the last attempt, which is only the case for trimmed
+// averages.
+func (f Format) ShowsPossibleAverages() bool {
+	return f.RankBy == RankByAverage && f.AverageKind == TrimmedMean && f.Trim > 0
+}
+
+func bad(attempt int) bool {
+	return attempt >= constants.VERY_SLOW
+}
+
+// competed reports whether any of the attempts is not a DNS
+func competed(attempts []int) bool {
+	for _, attempt := range attempts {
+		if attempt != constants.DNS {
+			return true
+		}
+	}
+
+	return false
+}
+
+// attempts returns the first f.Attempts attempts, missing ones are DNS
+func (f Format) attempts(attempts []int) []int {
+	res := make([]int, f.Attempts)
+	for idx := range res {
+		res[idx] = constants.DNS
+		if idx < len(attempts) {
+			res[idx] = attempts[idx]
+		}
+	}
+
+	return res
+}
+
+func (f Format) Single(attempts []int) int {
+	res := constants.DNS
+	for _, attempt := range f.attempts(attempts) {
+		res = min(res, attempt)
+	}
+
+	return res
+}
+
+// Average returns the average (or mean or sum) of the attempts, DNS if the
+// format has none or nothing was attempted.
+func (f Format) Average(attempts []int) int {
+	values := f.attempts(attempts)
+	if !f.HasAverage() || !competed(values) {
+		return constants.DNS
+	}
+
+	slices.Sort(values)
+
+	cntBad := 0
+	for _, value := range values {
+		if bad(value) {
+			cntBad++
+		}
+	}
+
+	switch f.AverageKind {
+	case Cumulative:
+		if cntBad > 0 {
+			return constants.DNF
+		}
+
+		sum := 0
+		for _, value := range values {
+			sum += value
+		}
+		return sum
+	default:
+		if cntBad > f.Trim {
+			return constants.DNF
+		}
+
+		sum := 0
+		counted := values[f.Trim : len(values)-f.Trim]
+		for _, value := range counted {
+			sum += value
+		}
+		return sum / len(counted)
+	}
+}
+
+// BestPossibleAverage returns the average if the last attempt matched the
+// best single so far.
+func (f Format) BestPossibleAverage(attempts []int) int {
+	values := f.attempts(attempts)
+	values[f.Attempts-1] = f.Single(values[:f.Attempts-1])
+	return f.Average(values)
+}
+
+// WorstPossibleAverage returns the average if the last attempt was a DNF.
+func (f Format) WorstPossibleAverage(attempts []int) int {
+	values := f.attempts(attempts)
+	values[f.Attempts-1] = constants.DNF
+	return f.Average(values)
+}
+
+// RankingValues returns the result deciding the ranking and the tiebreaker.
+func (f Format) RankingValues(single, average int) (int, int) {
+	if f.RankBy == RankBySingle {
+		return single, average
+	}
+
+	return average, single
+}
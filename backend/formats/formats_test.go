@@ -0,0 +1,77 @@
+package formats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+)
+
+const (
+	DNF = constants.DNF
+	DNS = constants.DNS
+)
+
+func get(t *testing.T, id string) formats.Format {
+	f, err := formats.Get(id)
+	require.NoError(t, err)
+	return f
+}
+
+func TestGet(t *testing.T) {
+	for _, f := range formats.All() {
+		assert.Equal(t, f, get(t, f.Id))
+	}
+
+	_, err := formats.Get("ao3")
+	assert.ErrorIs(t, err, formats.ErrUnknownFormat)
+}
+
+func TestAverage(t *testing.T) {
+	for _, tc := range []struct {
+		format   string
+		attempts []int
+		single   int
+		average  int
+	}{
+		{"ao5", []int{1000, 2000, 3000, 4000, 5000}, 1000, 3000},
+		{"ao5", []int{1000, DNF, 3000, 4000, 5000}, 1000, 4000},
+		{"ao5", []int{1000, DNF, 3000, DNF, 5000}, 1000, DNF},
+		{"ao5", []int{DNS, DNS, DNS, DNS, DNS}, DNS, DNS},
+		{"ao5", []int{1000, 2000}, 1000, DNF},
+		{"mo3", []int{1000, 2000, 4500}, 1000, 2500},
+		{"mo3", []int{1000, 2000, DNF}, 1000, DNF},
+		{"bo1", []int{1000, 500}, 1000, DNS},
+		{"bo3", []int{DNF, 3000, 2000}, 2000, DNF},
+		{"bo5", []int{DNF, 3000, 2000, 1000, 4000}, 1000, 3000},
+		{"ao12", []int{1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000, 9000, 10000, 11000, DNF}, 1000, 6500},
+		{"cu3", []int{1000, 2000, 4500}, 1000, 7500},
+		{"cu3", []int{1000, DNF, 4500}, 1000, DNF},
+	} {
+		f := get(t, tc.format)
+		assert.Equal(t, tc.single, f.Single(tc.attempts), tc.format, tc.attempts)
+		assert.Equal(t, tc.average, f.Average(tc.attempts), tc.format, tc.attempts)
+	}
+}
+
+func TestPossibleAverages(t *testing.T) {
+	f := get(t, "ao5")
+	require.True(t, f.ShowsPossibleAverages())
+	assert.False(t, get(t, "mo3").ShowsPossibleAverages())
+	assert.False(t, get(t, "bo5").ShowsPossibleAverages())
+
+	attempts := []int{2000, 3000, 4000, 5000, DNS}
+	assert.Equal(t, 3000, f.BestPossibleAverage(attempts))
+	assert.Equal(t, 4000, f.WorstPossibleAverage(attempts))
+}
+
+func TestRankingValues(t *testing.T) {
+	primary, secondary := get(t, "bo3").RankingValues(1000, 2000)
+	assert.Equal(t, []int{1000, 2000}, []int{primary, secondary})
+
+	primary, secondary = get(t, "ao5").RankingValues(1000, 2000)
+	assert.Equal(t, []int{2000, 1000}, []int{primary, secondary})
+}
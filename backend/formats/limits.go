@@ -0,0 +1,124 @@
+package formats
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+)
+
+var (
+	ErrCutoffNotMet     = errors.New("attempt after a failed cutoff")
+	ErrInvalidCutoff    = errors.New("invalid cutoff")
+	ErrInvalidTimeLimit = errors.New("invalid time limit")
+)
+
+// Cutoff lets the competitor continue only if one of the first Attempts
+// attempts is faster than Time (in milliseconds), zero Attempts means there
+// is no cutoff.
+type Cutoff struct {
+	Attempts int `json:"attempts"`
+	Time     int `json:"time"`
+}
+
+// TimeLimit turns attempts reaching Time (in milliseconds) into DNFs. If
+// Cumulative is set, the limit is shared by all attempts. Zero Time means
+// there is no time limit.
+type TimeLimit struct {
+	Time       int  `json:"time"`
+	Cumulative bool `json:"cumulative"`
+}
+
+// ValidateCutoff checks the cutoff leaves some attempts to continue with.
+func (f Format) ValidateCutoff(c Cutoff) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Attempts >= f.Attempts || c.Time <= 0 {
+		return fmt.Errorf("%w: format=%s attempts=%d time=%d", ErrInvalidCutoff, f.Id, c.Attempts, c.Time)
+	}
+
+	return nil
+}
+
+func (c Cutoff) Enabled() bool {
+	return c.Attempts > 0
+}
+
+// Failed reports whether all cutoff attempts are done and none of them made
+// the cutoff.
+func (c Cutoff) Failed(attempts []int) bool {
+	if !c.Enabled() {
+		return false
+	}
+
+	for idx := range c.Attempts {
+		if idx >= len(attempts) || attempts[idx] == constants.DNS {
+			return false
+		}
+		if attempts[idx] < c.Time {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Check returns ErrCutoffNotMet if there is an attempt after a failed cutoff.
+func (c Cutoff) Check(attempts []int) error {
+	if !c.Failed(attempts) {
+		return nil
+	}
+
+	for idx := c.Attempts; idx < len(attempts); idx++ {
+		if attempts[idx] != constants.DNS {
+			return fmt.Errorf("%w: attempt=%d", ErrCutoffNotMet, idx+1)
+		}
+	}
+
+	return nil
+}
+
+func (t TimeLimit) Validate() error {
+	if t.Time < 0 {
+		return fmt.Errorf("%w: time=%d", ErrInvalidTimeLimit, t.Time)
+	}
+
+	return nil
+}
+
+func (t TimeLimit) Enabled() bool {
+	return t.Time > 0
+}
+
+// Apply returns the attempts with the ones over the time limit replaced by
+// DNF. With a cumulative limit, every attempt after the limit was used up is
+// a DNF too.
+func (t TimeLimit) Apply(attempts []int) []int {
+	res := make([]int, len(attempts))
+	copy(res, attempts)
+	if !t.Enabled() {
+		return res
+	}
+
+	used := 0
+	for idx, attempt := range res {
+		if bad(attempt) {
+			continue
+		}
+
+		if !t.Cumulative {
+			if attempt >= t.Time {
+				res[idx] = constants.DNF
+			}
+			continue
+		}
+
+		used += attempt
+		if used >= t.Time {
+			res[idx] = constants.DNF
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,61 @@
+package formats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+)
+
+func TestCutoff(t *testing.T) {
+	cutoff := formats.Cutoff{Attempts: 2, Time: 60000}
+
+	for _, tc := range []struct {
+		attempts []int
+		failed   bool
+		err      bool
+	}{
+		{[]int{65000, 59000, 70000, DNS, DNS}, false, false},
+		{[]int{65000, DNS, DNS, DNS, DNS}, false, false},
+		{[]int{65000, DNF, DNS, DNS, DNS}, true, false},
+		{[]int{65000, 60000, 50000, DNS, DNS}, true, true},
+		{[]int{65000}, false, false},
+	} {
+		assert.Equal(t, tc.failed, cutoff.Failed(tc.attempts), tc.attempts)
+		err := cutoff.Check(tc.attempts)
+		if tc.err {
+			assert.ErrorIs(t, err, formats.ErrCutoffNotMet, tc.attempts)
+		} else {
+			assert.NoError(t, err, tc.attempts)
+		}
+	}
+
+	assert.NoError(t, formats.Cutoff{}.Check([]int{DNF, DNF, 1000}))
+
+	assert.NoError(t, get(t, "ao5").ValidateCutoff(cutoff))
+	assert.NoError(t, get(t, "bo1").ValidateCutoff(formats.Cutoff{}))
+	assert.ErrorIs(t, get(t, "mo3").ValidateCutoff(formats.Cutoff{Attempts: 3, Time: 1000}), formats.ErrInvalidCutoff)
+	assert.ErrorIs(t, get(t, "ao5").ValidateCutoff(formats.Cutoff{Attempts: 2}), formats.ErrInvalidCutoff)
+}
+
+func TestTimeLimit(t *testing.T) {
+	attempts := []int{50000, 70000, DNF, 30000, DNS}
+
+	assert.Equal(t, attempts, formats.TimeLimit{}.Apply(attempts))
+	assert.Equal(
+		t,
+		[]int{50000, DNF, DNF, 30000, DNS},
+		formats.TimeLimit{Time: 60000}.Apply(attempts),
+	)
+	assert.Equal(
+		t,
+		[]int{50000, DNF, DNF, DNF, DNS},
+		formats.TimeLimit{Time: 120000, Cumulative: true}.Apply(attempts),
+	)
+	assert.Equal(
+		t,
+		[]int{50000, 70000, DNF, DNF, DNS},
+		formats.TimeLimit{Time: 150000, Cumulative: true}.Apply(attempts),
+	)
+}
@@ -0,0 +1,124 @@
+// Package ical writes the calendars in the iCalendar format (RFC 5545), so the
+// competitions can be subscribed to from Google, Apple or other calendars.
+package ical
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	CONTENT_TYPE = "text/calendar; charset=utf-8"
+
+	STATUS_CONFIRMED = "CONFIRMED"
+	STATUS_CANCELLED = "CANCELLED"
+
+	// the lines longer than this many octets are folded
+	MAX_LINE_LENGTH = 75
+
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+)
+
+// Event is a VEVENT, the all day events use only the dates of the start and
+// the end, both inclusive
+type Event struct {
+	Uid         string
+	Summary     string
+	Description string
+	Location    string
+	Url         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Status      string
+}
+
+type Calendar struct {
+	// ProdId identifies the product which created the calendar
+	ProdId string
+	Name   string
+	Events []Event
+}
+
+// Escape escapes the characters with special meaning in the TEXT values
+func Escape(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(text)
+}
+
+// fold splits the line into the lines of at most MAX_LINE_LENGTH octets, the
+// continuation lines start with a space, multi-byte characters are not split
+func fold(line string) string {
+	var b strings.Builder
+	limit := MAX_LINE_LENGTH
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space counts into the length of the continuation line
+		limit = MAX_LINE_LENGTH - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+func (e Event) write(b *strings.Builder, stamp time.Time) {
+	b.WriteString(fold("BEGIN:VEVENT"))
+	b.WriteString(fold("UID:" + e.Uid))
+	b.WriteString(fold("DTSTAMP:" + stamp.UTC().Format(dateTimeLayout)))
+	if e.AllDay {
+		b.WriteString(fold("DTSTART;VALUE=DATE:" + e.Start.Format(dateLayout)))
+		// the end of the all day events is exclusive
+		b.WriteString(fold("DTEND;VALUE=DATE:" + e.End.AddDate(0, 0, 1).Format(dateLayout)))
+	} else {
+		b.WriteString(fold("DTSTART:" + e.Start.UTC().Format(dateTimeLayout)))
+		b.WriteString(fold("DTEND:" + e.End.UTC().Format(dateTimeLayout)))
+	}
+	b.WriteString(fold("SUMMARY:" + Escape(e.Summary)))
+	if e.Description != "" {
+		b.WriteString(fold("DESCRIPTION:" + Escape(e.Description)))
+	}
+	if e.Location != "" {
+		b.WriteString(fold("LOCATION:" + Escape(e.Location)))
+	}
+	if e.Url != "" {
+		b.WriteString(fold("URL:" + e.Url))
+	}
+	if e.Status != "" {
+		b.WriteString(fold("STATUS:" + e.Status))
+	}
+	b.WriteString(fold("END:VEVENT"))
+}
+
+// String returns the calendar with the events stamped at the time
+func (c Calendar) String(stamp time.Time) string {
+	var b strings.Builder
+	b.WriteString(fold("BEGIN:VCALENDAR"))
+	b.WriteString(fold("VERSION:2.0"))
+	b.WriteString(fold("PRODID:" + c.ProdId))
+	b.WriteString(fold("CALSCALE:GREGORIAN"))
+	b.WriteString(fold("METHOD:PUBLISH"))
+	if c.Name != "" {
+		b.WriteString(fold("X-WR-CALNAME:" + Escape(c.Name)))
+	}
+	for _, event := range c.Events {
+		event.write(&b, stamp)
+	}
+	b.WriteString(fold("END:VCALENDAR"))
+
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package ical_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/ical"
+)
+
+func TestEscape(t *testing.T) {
+	require.Equal(t, `Hotel\, Main St. 1\; Bratislava\nSlovakia \\o/`, ical.Escape("Hotel, Main St. 1; Bratislava\r\nSlovakia \\o/"))
+}
+
+func TestCalendarString(t *testing.T) {
+	stamp := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	calendar := ical.Calendar{
+		ProdId: "-//Speedcubing Slovakia//Calendar//EN",
+		Name:   "Competitions",
+		Events: []ical.Event{
+			{
+				Uid:     "WeeklyCompetition1@example.com",
+				Summary: "Weekly Competition 1",
+				Url:     "https://example.com/competition/WeeklyCompetition1",
+				Start:   time.Date(2026, 3, 2, 20, 0, 0, 0, time.FixedZone("CET", 3600)),
+				End:     time.Date(2026, 3, 9, 20, 0, 0, 0, time.FixedZone("CET", 3600)),
+			},
+			{
+				Uid:      "BratislavaOpen2026@example.com",
+				Summary:  "Bratislava Open 2026",
+				Location: "Hotel, Bratislava",
+				Start:    time.Date(2026, 4, 4, 0, 0, 0, 0, time.UTC),
+				End:      time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC),
+				AllDay:   true,
+				Status:   ical.STATUS_CANCELLED,
+			},
+		},
+	}
+
+	lines := strings.Split(strings.TrimSuffix(calendar.String(stamp), "\r\n"), "\r\n")
+	require.Equal(t, []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Speedcubing Slovakia//Calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Competitions",
+		"BEGIN:VEVENT",
+		"UID:WeeklyCompetition1@example.com",
+		"DTSTAMP:20260301T120000Z",
+		"DTSTART:20260302T190000Z",
+		"DTEND:20260309T190000Z",
+		"SUMMARY:Weekly Competition 1",
+		"URL:https://example.com/competition/WeeklyCompetition1",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:BratislavaOpen2026@example.com",
+		"DTSTAMP:20260301T120000Z",
+		"DTSTART;VALUE=DATE:20260404",
+		"DTEND;VALUE=DATE:20260406",
+		"SUMMARY:Bratislava Open 2026",
+		`LOCATION:Hotel\, Bratislava`,
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, lines)
+}
+
+func TestFolding(t *testing.T) {
+	summary := strings.Repeat("Žilina ", 30)
+	calendar := ical.Calendar{Events: []ical.Event{{Uid: "uid", Summary: summary, AllDay: true}}}
+
+	unfolded := ""
+	for _, line := range strings.Split(calendar.String(time.Now()), "\r\n") {
+		require.LessOrEqual(t, len(line), ical.MAX_LINE_LENGTH)
+		require.True(t, strings.ToValidUTF8(line, "") == line, "folding split a character: %q", line)
+		if strings.HasPrefix(line, " ") {
+			unfolded += line[1:]
+		} else {
+			unfolded += "\n" + line
+		}
+	}
+	require.Contains(t, unfolded, "\nSUMMARY:"+summary+"\n")
+}
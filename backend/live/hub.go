@@ -0,0 +1,121 @@
+package live
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DEFAULT_BUFFER_SIZE is the number of messages a subscriber can fall behind
+// before the oldest ones are dropped
+const DEFAULT_BUFFER_SIZE = 8
+
+// Topic identifies the results of one round of an event in a competition
+type Topic struct {
+	CompetitionId string
+	EventId       int
+	Round         int
+}
+
+// Message is one server-sent event, Data is already serialized
+type Message struct {
+	Event string
+	Data  []byte
+}
+
+// Subscriber receives the messages published to its topic until it is
+// unsubscribed. A slow subscriber does not block the publisher, when its
+// buffer is full the oldest message is dropped, the newer results supersede
+// it anyway.
+type Subscriber struct {
+	topic   Topic
+	ch      chan Message
+	dropped atomic.Int64
+}
+
+func (s *Subscriber) Messages() <-chan Message {
+	return s.ch
+}
+
+// Dropped returns the number of messages dropped because the subscriber was
+// not reading fast enough
+func (s *Subscriber) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscriber) send(msg Message) {
+	for {
+		select {
+		case s.ch <- msg:
+			return
+		default:
+		}
+
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Hub fans out the published messages to the subscribers of the topic
+type Hub struct {
+	mu          sync.RWMutex
+	bufferSize  int
+	subscribers map[Topic]map[*Subscriber]struct{}
+}
+
+func NewHub(bufferSize int) *Hub {
+	if bufferSize < 1 {
+		bufferSize = DEFAULT_BUFFER_SIZE
+	}
+
+	return &Hub{bufferSize: bufferSize, subscribers: make(map[Topic]map[*Subscriber]struct{})}
+}
+
+func (h *Hub) Subscribe(topic Topic) *Subscriber {
+	s := &Subscriber{topic: topic, ch: make(chan Message, h.bufferSize)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[*Subscriber]struct{})
+	}
+	h.subscribers[topic][s] = struct{}{}
+
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers[s.topic], s)
+	if len(h.subscribers[s.topic]) == 0 {
+		delete(h.subscribers, s.topic)
+	}
+}
+
+// HasSubscribers tells whether it is worth preparing a message for the topic
+func (h *Hub) HasSubscribers(topic Topic) bool {
+	return h.NoOfSubscribers(topic) > 0
+}
+
+func (h *Hub) NoOfSubscribers(topic Topic) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subscribers[topic])
+}
+
+// Publish sends the message to every subscriber of the topic without waiting
+// for any of them
+func (h *Hub) Publish(topic Topic, msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for s := range h.subscribers[topic] {
+		s.send(msg)
+	}
+}
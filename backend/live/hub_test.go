@@ -0,0 +1,114 @@
+package live_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/live"
+)
+
+var topic = live.Topic{CompetitionId: "WeeklyCompetition1", EventId: 1, Round: 1}
+
+func TestHub(t *testing.T) {
+	t.Run("fan out", func(t *testing.T) {
+		hub := live.NewHub(live.DEFAULT_BUFFER_SIZE)
+		s1 := hub.Subscribe(topic)
+		s2 := hub.Subscribe(topic)
+		other := hub.Subscribe(live.Topic{CompetitionId: "WeeklyCompetition1", EventId: 2, Round: 1})
+		require.Equal(t, 2, hub.NoOfSubscribers(topic))
+
+		hub.Publish(topic, live.Message{Event: "results", Data: []byte("1")})
+
+		assert.Equal(t, "1", string((<-s1.Messages()).Data))
+		assert.Equal(t, "1", string((<-s2.Messages()).Data))
+		assert.Empty(t, other.Messages())
+
+		hub.Unsubscribe(s1)
+		hub.Unsubscribe(s2)
+		assert.False(t, hub.HasSubscribers(topic))
+	})
+
+	t.Run("slow subscriber keeps the newest messages", func(t *testing.T) {
+		hub := live.NewHub(2)
+		slow := hub.Subscribe(topic)
+		fast := hub.Subscribe(topic)
+
+		received := make([]string, 0)
+		for _, data := range []string{"1", "2", "3", "4", "5"} {
+			hub.Publish(topic, live.Message{Data: []byte(data)})
+			received = append(received, string((<-fast.Messages()).Data))
+		}
+
+		assert.Equal(t, []string{"1", "2", "3", "4", "5"}, received)
+		assert.Equal(t, int64(0), fast.Dropped())
+		assert.Equal(t, int64(3), slow.Dropped())
+		assert.Equal(t, "4", string((<-slow.Messages()).Data))
+		assert.Equal(t, "5", string((<-slow.Messages()).Data))
+	})
+}
+
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	var event, data string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if data != "" {
+				return event, data
+			}
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestServeSSE(t *testing.T) {
+	hub := live.NewHub(live.DEFAULT_BUFFER_SIZE)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := hub.ServeSSE(w, r, topic, live.Message{Event: "results", Data: []byte("initial")}, 10*time.Millisecond)
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	event, data := readEvent(t, reader)
+	assert.Equal(t, "results", event)
+	assert.Equal(t, "initial", data)
+
+	hub.Publish(topic, live.Message{Event: "results", Data: []byte("update")})
+	event, data = readEvent(t, reader)
+	assert.Equal(t, "results", event)
+	assert.Equal(t, "update", data)
+
+	// the heartbeat comment keeps the stream open
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": heartbeat\n", line)
+
+	cancel()
+	require.Eventually(t, func() bool { return !hub.HasSubscribers(topic) }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,67 @@
+package live
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HEARTBEAT_INTERVAL keeps idle streams from being closed by proxies
+const HEARTBEAT_INTERVAL = 15 * time.Second
+
+var ErrStreamingUnsupported = errors.New("streaming unsupported")
+
+func writeMessage(w http.ResponseWriter, msg Message) error {
+	if msg.Event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", msg.Event); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "data: %s\n\n", msg.Data)
+	return err
+}
+
+// ServeSSE streams the initial message followed by every message published
+// to the topic until the client disconnects
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request, topic Topic, initial Message, heartbeat time.Duration) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return ErrStreamingUnsupported
+	}
+
+	s := h.Subscribe(topic)
+	defer h.Unsubscribe(s)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeMessage(w, initial); err != nil {
+		return fmt.Errorf("%w: when writing initial message", err)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case msg := <-s.Messages():
+			if err := writeMessage(w, msg); err != nil {
+				return fmt.Errorf("%w: when writing message", err)
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return fmt.Errorf("%w: when writing heartbeat", err)
+			}
+			flusher.Flush()
+		}
+	}
+}
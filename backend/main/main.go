@@ -14,10 +14,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/controllers"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/live"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/logging"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/metrics"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/middlewares"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
 )
 
 func main() {
@@ -40,6 +44,26 @@ func main() {
 
 	metrics.Register()
 
+	mailer, err := email.NewMailer(envMap)
+	if err != nil {
+		slog.Error("unable to create mailer", "error", err)
+		os.Exit(1)
+	}
+	go models.RunEmailWorker(context.Background(), db, mailer, models.EMAIL_WORKER_INTERVAL)
+	go models.RunWCAReminderScheduler(context.Background(), db, envMap, models.WCA_REMINDER_MAX_WAIT)
+	go func() {
+		inserted, err := models.RebuildRankingsIfEmpty(context.Background(), db)
+		if err != nil {
+			slog.Error("unable to fill empty rankings", "error", err)
+		} else if inserted > 0 {
+			slog.Info("filled empty rankings", "entries", inserted)
+		}
+	}()
+
+	scrambleGenerator := scrambler.New()
+	wcaClient := wca.NewClient(envMap, 0)
+	liveHub := live.NewHub(live.DEFAULT_BUFFER_SIZE)
+
 	router := gin.New()
 
 	router.Use(cors.New(cors.Config{
@@ -68,19 +92,19 @@ func main() {
 		stats.GET(
 			"/dashboard",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_STATS_VIEW),
 			controllers.GetAdminStats(db),
 		)
 		stats.GET(
 			"/subscriptions/details",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_STATS_VIEW),
 			gin.WrapH(controllers.GetUserSubscriptionDetails(db, models.GetUserSubscriptionDetails)),
 		)
 		stats.GET(
 			"/subscriptions",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_STATS_VIEW),
 			gin.WrapH(controllers.GetSubscriptionStats(db, models.GetSubscriptionStats)),
 		)
 	}
@@ -90,30 +114,35 @@ func main() {
 		results.GET(
 			"/edit/:uname/:cname/:eid/:rsname",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_RESULTS_EDIT),
 			controllers.GetResultsQuery(db),
 		)
 		results.GET(
 			"/compete/:cid/:eid",
-			middlewares.AuthMiddleWare(),
+			middlewares.ScopedAuthMiddleWare(models.SCOPE_RESULTS_READ),
 			controllers.GetResultsByIdAndEvent(db),
 		)
 		results.POST(
 			"/save",
-			middlewares.AuthMiddleWare(),
-			controllers.PostResults(db, envMap),
+			middlewares.ScopedAuthMiddleWare(models.SCOPE_RESULTS_WRITE),
+			controllers.PostResults(db, envMap, liveHub),
 		)
 		results.POST(
 			"/save-validation",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
-			controllers.PostResultsValidation(db),
+			middlewares.PermissionMiddleWare(models.PERMISSION_RESULTS_VALIDATE),
+			controllers.PostResultsValidation(db, liveHub, envMap),
 		)
 		results.GET(
 			"/save-validation",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
-			controllers.GetResultsValidation(db),
+			middlewares.PermissionMiddleWare(models.PERMISSION_RESULTS_VALIDATE),
+			controllers.GetResultsValidation(db, liveHub, envMap),
+		)
+		results.GET(
+			"/fmc-verification/:cid/:eid",
+			middlewares.AuthMiddleWare(),
+			controllers.GetFMCVerification(db),
 		)
 		results.GET("/rankings", controllers.GetRankings(db))
 		results.GET("/records", controllers.GetRecords(db))
@@ -131,6 +160,27 @@ func main() {
 		)
 	}
 
+	emails := api_v1.Group("/emails")
+	{
+		emails.GET(
+			"",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_STATS_VIEW),
+			controllers.GetOutboxEmails(db),
+		)
+		emails.POST(
+			"/:id/retry",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
+			controllers.PostRetryOutboxEmail(db),
+		)
+	}
+
+	notifications := api_v1.Group("/notifications")
+	{
+		notifications.POST("/unsubscribe", controllers.PostUnsubscribe(db, envMap))
+	}
+
 	events := api_v1.Group("/events")
 	{
 		events.GET("/", controllers.GetEvents(db))
@@ -152,6 +202,16 @@ func main() {
 			middlewares.AuthMiddleWare(),
 			controllers.GetWCACompAnnouncementsPositionSubscriptions(db),
 		)
+		competitions.GET(
+			"/wca/subscriptions/polygons",
+			middlewares.AuthMiddleWare(),
+			controllers.GetWCACompAnnouncementsPolygonSubscriptions(db),
+		)
+		competitions.PUT(
+			"/wca/subscriptions/polygons",
+			middlewares.AuthMiddleWare(),
+			controllers.PutWCACompAnnouncementsPolygonSubscriptions(db),
+		)
 		competitions.GET(
 			"/wca/subscriptions",
 			middlewares.AuthMiddleWare(),
@@ -172,19 +232,39 @@ func main() {
 			middlewares.AuthMiddleWare(),
 			controllers.DeleteWCAAnnouncementsPositionSubscriptions(db),
 		)
+		competitions.GET(
+			"/wca/watches",
+			middlewares.AuthMiddleWare(),
+			controllers.GetWCACompetitionWatches(db),
+		)
+		competitions.POST(
+			"/wca/watch",
+			middlewares.AuthMiddleWare(),
+			controllers.PostWCACompetitionWatch(db),
+		)
 		competitions.POST(
 			"/",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
-			controllers.PostCompetition(db, envMap),
+			middlewares.PermissionMiddleWare(models.PERMISSION_COMPETITIONS_MANAGE),
+			controllers.PostCompetition(db, scrambleGenerator, envMap),
 		)
 		competitions.PUT(
 			"/",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
-			controllers.PutCompetition(db, envMap),
+			middlewares.PermissionMiddleWare(models.PERMISSION_COMPETITIONS_MANAGE),
+			controllers.PutCompetition(db, scrambleGenerator, envMap),
 		)
 		competitions.GET("/results/:cid/:eid", controllers.GetResultsFromCompetition(db))
+		competitions.GET(
+			"/live",
+			gin.WrapH(controllers.GetLiveResults(db, liveHub, models.GetResultsFromCompetitionByEventName)),
+		)
+	}
+
+	calendar := api_v1.Group("/calendar")
+	{
+		calendar.GET("/public.ics", controllers.GetPublicCalendar(db, envMap))
+		calendar.GET("/me.ics", controllers.GetMyCalendar(db, envMap))
 	}
 
 	users := api_v1.Group("/users")
@@ -192,35 +272,105 @@ func main() {
 		users.GET(
 			"/manage-roles",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
 			controllers.GetManageUsers(db),
 		)
 		users.POST(
 			"/manage-roles",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
 			controllers.ManageUserRole(db),
 		)
 		users.GET(
 			"/find-duplicate/:id",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
 			controllers.FindDuplicateUser(db),
 		)
+		users.GET(
+			"/duplicates",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
+			controllers.GetDuplicateUsers(db),
+		)
 		users.POST(
 			"/merge",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
 			controllers.MergeUsers(db),
 		)
-		users.POST("/login", controllers.PostLogIn(db, envMap))
+		users.GET(
+			"/merges",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
+			controllers.GetUserMerges(db),
+		)
+		users.GET(
+			"/merges/:id",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
+			controllers.GetUserMerge(db),
+		)
+		users.POST(
+			"/merges/:id/unmerge",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
+			controllers.UnmergeUsers(db),
+		)
+		users.POST("/login", controllers.PostLogIn(db, envMap, models.NewWCALoginProvider(wcaClient)))
+		users.POST("/login/email", controllers.PostLogIn(db, envMap, models.EmailLoginProvider{}))
+		users.POST("/login/email/link", controllers.PostLoginLink(db, envMap))
+		users.POST(
+			"/link/wca",
+			middlewares.AuthMiddleWare(),
+			controllers.PostLinkWCAAccount(db, envMap, wcaClient),
+		)
+		users.POST("/refresh", controllers.PostRefreshToken(db, envMap))
+		users.POST("/logout", middlewares.AuthMiddleWare(), controllers.PostLogOut(db))
+		users.POST(
+			"/logout-all",
+			middlewares.AuthMiddleWare(),
+			controllers.PostLogOutEverywhere(db),
+		)
+		users.GET("/sessions", middlewares.AuthMiddleWare(), controllers.GetMySessions(db))
+		users.DELETE(
+			"/sessions/:id",
+			middlewares.AuthMiddleWare(),
+			controllers.DeleteMySession(db),
+		)
+		users.POST(
+			"/revoke-sessions/:id",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
+			controllers.PostRevokeUserSessions(db),
+		)
+		users.GET("/tokens", middlewares.AuthMiddleWare(), controllers.GetApiTokens(db))
+		users.POST("/tokens", middlewares.AuthMiddleWare(), controllers.PostApiToken(db))
+		users.DELETE(
+			"/tokens/:id",
+			middlewares.AuthMiddleWare(),
+			controllers.DeleteApiToken(db),
+		)
+		users.GET(
+			"/me",
+			middlewares.ScopedAuthMiddleWare(models.SCOPE_PROFILE_READ),
+			controllers.GetMe(db),
+		)
+		users.POST("/language", middlewares.AuthMiddleWare(), controllers.PostMyLanguage(db))
+		users.GET("/notifications", middlewares.AuthMiddleWare(), controllers.GetMyNotificationPreferences(db))
+		users.POST("/notifications", middlewares.AuthMiddleWare(), controllers.PostMyNotificationPreference(db))
 		users.GET("/search", controllers.GetSearchUsers(db))
 		users.GET("/map", controllers.GetUserMapData(db))
 		users.GET(
 			"/auth/admin",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
-			func(c *gin.Context) { c.IndentedJSON(http.StatusAccepted, "authorized") },
+			controllers.GetMyPermissions(),
+		)
+		users.GET(
+			"/roles",
+			middlewares.AuthMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_USERS_MANAGE),
+			controllers.GetRoles(db),
 		)
 	}
 
@@ -245,25 +395,48 @@ func main() {
 		announcements.DELETE(
 			"/delete/:id",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_ANNOUNCEMENTS_MANAGE),
 			controllers.DeleteAnnouncement(db),
 		)
 		announcements.GET("/", controllers.GetAnnouncements(db, envMap))
 		announcements.POST(
 			"/",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_ANNOUNCEMENTS_MANAGE),
 			controllers.PostAnnouncement(db, envMap),
 		)
 		announcements.PUT(
 			"/",
 			middlewares.AuthMiddleWare(),
-			middlewares.AdminMiddleWare(),
+			middlewares.PermissionMiddleWare(models.PERMISSION_ANNOUNCEMENTS_MANAGE),
 			controllers.PutAnnouncement(db, envMap),
 		)
 		announcements.GET("/noOfNew", controllers.GetNoOfNewAnnouncements(db, envMap))
 	}
 
+	battles := api_v1.Group("/battles")
+	{
+		battles.GET("/", middlewares.AuthMiddleWare(), controllers.GetMyBattles(db))
+		battles.POST("/", middlewares.AuthMiddleWare(), controllers.PostBattle(db))
+		battles.GET("/id/:id", controllers.GetBattle(db))
+		battles.POST(
+			"/id/:id/accept",
+			middlewares.AuthMiddleWare(),
+			controllers.PostBattleAccept(db, scrambleGenerator, envMap),
+		)
+		battles.POST(
+			"/id/:id/decline",
+			middlewares.AuthMiddleWare(),
+			controllers.PostBattleDecline(db),
+		)
+		battles.POST(
+			"/id/:id/solve",
+			middlewares.AuthMiddleWare(),
+			controllers.PostBattleSolve(db),
+		)
+		battles.GET("/history/:id", controllers.GetBattleHistory(db))
+	}
+
 	if err := router.Run(":8000"); err != nil {
 		slog.Error("failed to start server", "error", err)
 	}
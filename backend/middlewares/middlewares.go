@@ -2,8 +2,10 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 
@@ -11,11 +13,12 @@ import (
 	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
 )
 
-func AdminMiddleWare() gin.HandlerFunc {
+// PermissionMiddleWare lets through only users having one of their roles
+// grant the permission, it has to come after AuthMiddleWare
+func PermissionMiddleWare(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isadmin := c.MustGet("isadmin").(bool)
-		if !isadmin {
-			c.IndentedJSON(http.StatusUnauthorized, "unauthorized")
+		if !HasPermission(c, permission) {
+			c.IndentedJSON(http.StatusForbidden, "forbidden")
 			c.Abort()
 			return
 		}
@@ -24,6 +27,15 @@ func AdminMiddleWare() gin.HandlerFunc {
 	}
 }
 
+func HasPermission(c *gin.Context, permission string) bool {
+	permissions, ok := c.Get("permissions")
+	if !ok {
+		return false
+	}
+
+	return slices.Contains(permissions.([]string), permission)
+}
+
 func MarkAuthorization(
 	c *gin.Context,
 	db interfaces.DB,
@@ -31,22 +43,50 @@ func MarkAuthorization(
 ) {
 	c.Set("authorized", false)
 
-	authDetails, err := models.GetAuthDetailsFromHeader(c, envMap["JWT_SECRET_KEY"])
+	token, err := models.GetTokenFromHeader(c)
 	if err != nil {
 		// we can only get here, if the status code should be unauthorized
 		c.Set("unauthorization_reason", err)
 		return
 	}
 
+	if models.IsApiToken(token) {
+		markApiTokenAuthorization(c, db, token)
+		return
+	}
+
+	authDetails, err := models.VerifyJWTToken(token, envMap["JWT_SECRET_KEY"])
+	if err != nil {
+		c.Set("unauthorization_reason", err)
+		return
+	}
+
+	err = models.CheckSession(c.Request.Context(), db, authDetails.SessionId, authDetails.UserId)
+	if errors.Is(err, models.ErrSessionRevoked) {
+		c.Set("unauthorization_reason", err)
+		return
+	}
+	if err != nil {
+		c.Set("user_id_error", err)
+		return
+	}
+
 	user, err := models.GetUserById(db, authDetails.UserId)
 	if err != nil {
 		c.Set("user_id_error", err)
 		return
 	}
 
+	permissions, err := models.GetUserPermissions(c.Request.Context(), db, user.Id)
+	if err != nil {
+		c.Set("user_id_error", err)
+		return
+	}
+
 	c.Set("authorized", true)
 	c.Set("uid", user.Id)
-	c.Set("isadmin", user.IsAdmin)
+	c.Set("sid", authDetails.SessionId)
+	c.Set("permissions", permissions)
 }
 
 func Authorization(db interfaces.DB, envMap map[string]string) gin.HandlerFunc {
@@ -57,38 +97,94 @@ func Authorization(db interfaces.DB, envMap map[string]string) gin.HandlerFunc {
 	}
 }
 
+// markApiTokenAuthorization authorizes requests made with a personal access
+// token, those never carry the permissions of the user and only pass the
+// routes allowing one of their scopes
+func markApiTokenAuthorization(c *gin.Context, db interfaces.DB, token string) {
+	apiToken, err := models.UseApiToken(c.Request.Context(), db, token)
+	if errors.Is(err, models.ErrApiTokenNotFound) {
+		c.Set("unauthorization_reason", err)
+		return
+	}
+	if err != nil {
+		c.Set("user_id_error", err)
+		return
+	}
+
+	c.Set("authorized", true)
+	c.Set("uid", apiToken.UserId)
+	c.Set("permissions", []string{})
+	c.Set("scopes", apiToken.Scopes)
+}
+
+// abortUnauthorized responds and aborts if the request is not authorized,
+// returns whether it did
+func abortUnauthorized(c *gin.Context) bool {
+	if authorized := c.GetBool("authorized"); authorized {
+		return false
+	}
+
+	unauthorizationReason, unauthorizationReasonExists := c.Get("unauthorization_reason")
+	if unauthorizationReasonExists {
+		slog.LogAttrs(
+			context.Background(),
+			slog.LevelWarn,
+			"ERR models.GetTokenFromHeader in AuthMiddleWare in MarkAuthorization",
+			slog.Any("unauthorization_reason", unauthorizationReason),
+		)
+		c.IndentedJSON(http.StatusUnauthorized, unauthorizationReason)
+		c.Abort()
+		return true
+	}
+
+	userIdError, userIdErrorExists := c.Get("user_id_error")
+	if userIdErrorExists {
+		slog.LogAttrs(
+			context.Background(),
+			slog.LevelWarn,
+			"ERR models.GetUserById in MarkAuthorization in AuthMiddleware",
+			slog.Any("error",
+				userIdError),
+		)
+		c.IndentedJSON(http.StatusInternalServerError, userIdError)
+		c.Abort()
+		return true
+	}
+
+	slog.LogAttrs(context.Background(), slog.LevelError, "Should never get here?")
+	c.IndentedJSON(http.StatusUnauthorized, "Unauthorized.")
+	c.Abort()
+	return true
+}
+
+// AuthMiddleWare lets through users logged in through the website, personal
+// access tokens are refused
 func AuthMiddleWare() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if authorized := c.GetBool("authorized"); !authorized {
-			unauthorizationReason, unauthorizationReasonExists := c.Get("unauthorization_reason")
-			if unauthorizationReasonExists {
-				slog.LogAttrs(
-					context.Background(),
-					slog.LevelWarn,
-					"ERR models.GetAuthDetailsFromHeader in AuthMiddleWare in MarkAuthorization",
-					slog.Any("unauthorization_reason", unauthorizationReason),
-				)
-				c.IndentedJSON(http.StatusUnauthorized, unauthorizationReason)
-				c.Abort()
-				return
-			}
-
-			userIdError, userIdErrorExists := c.Get("user_id_error")
-			if userIdErrorExists {
-				slog.LogAttrs(
-					context.Background(),
-					slog.LevelWarn,
-					"ERR models.GetUserById in MarkAuthorization in AuthMiddleware",
-					slog.Any("error",
-						userIdError),
-				)
-				c.IndentedJSON(http.StatusInternalServerError, userIdError)
-				c.Abort()
-				return
-			}
-
-			slog.LogAttrs(context.Background(), slog.LevelError, "Should never get here?")
-			c.IndentedJSON(http.StatusUnauthorized, "Unauthorized.")
+		if abortUnauthorized(c) {
+			return
+		}
+
+		if _, isApiToken := c.Get("scopes"); isApiToken {
+			c.IndentedJSON(http.StatusForbidden, "Personal access tokens can not be used here.")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ScopedAuthMiddleWare lets through users logged in through the website and
+// personal access tokens having the scope
+func ScopedAuthMiddleWare(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if abortUnauthorized(c) {
+			return
+		}
+
+		if scopes, isApiToken := c.Get("scopes"); isApiToken && !slices.Contains(scopes.([]string), scope) {
+			c.IndentedJSON(http.StatusForbidden, "The token is missing the "+scope+" scope.")
 			c.Abort()
 			return
 		}
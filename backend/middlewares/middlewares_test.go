@@ -0,0 +1,92 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/middlewares"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+)
+
+func TestPermissionMiddleWare(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name                 string
+		permissions          []string
+		expectedResponseCode int
+	}{
+		{"not authorized", nil, http.StatusForbidden},
+		{"no permissions", []string{}, http.StatusForbidden},
+		{"other permission", []string{models.PERMISSION_ANNOUNCEMENTS_MANAGE}, http.StatusForbidden},
+		{"permitted", []string{models.PERMISSION_ANNOUNCEMENTS_MANAGE, models.PERMISSION_RESULTS_VALIDATE}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET(
+				"/",
+				func(c *gin.Context) {
+					if tt.permissions != nil {
+						c.Set("permissions", tt.permissions)
+					}
+				},
+				middlewares.PermissionMiddleWare(models.PERMISSION_RESULTS_VALIDATE),
+				func(c *gin.Context) { c.Status(http.StatusOK) },
+			)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.expectedResponseCode, rec.Code)
+		})
+	}
+}
+
+func TestScopedAuthMiddleWare(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name                     string
+		authorized               bool
+		scopes                   []string
+		expectedAuthResponseCode int
+		expectedResponseCode     int
+	}{
+		{"unauthorized", false, nil, http.StatusUnauthorized, http.StatusUnauthorized},
+		{"browser session", true, nil, http.StatusOK, http.StatusOK},
+		{"token without scope", true, []string{models.SCOPE_PROFILE_READ}, http.StatusForbidden, http.StatusForbidden},
+		{"token with scope", true, []string{models.SCOPE_RESULTS_READ}, http.StatusForbidden, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			markAuthorization := func(c *gin.Context) {
+				c.Set("authorized", tt.authorized)
+				if !tt.authorized {
+					c.Set("unauthorization_reason", "no token")
+				}
+				if tt.scopes != nil {
+					c.Set("scopes", tt.scopes)
+				}
+			}
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			router.GET("/auth", markAuthorization, middlewares.AuthMiddleWare(), ok)
+			router.GET("/scoped", markAuthorization, middlewares.ScopedAuthMiddleWare(models.SCOPE_RESULTS_READ), ok)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth", nil))
+			assert.Equal(t, tt.expectedAuthResponseCode, rec.Code)
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scoped", nil))
+			assert.Equal(t, tt.expectedResponseCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+// personal access tokens let third-party tools (timers, bots) act for the
+// user, only on the routes allowing their scopes
+const (
+	API_TOKEN_PREFIX         = "scs_"
+	API_TOKEN_DISPLAY_LENGTH = 8
+	MAX_API_TOKENS_PER_USER  = 20
+
+	SCOPE_RESULTS_READ  = "results:read"
+	SCOPE_RESULTS_WRITE = "results:write"
+	SCOPE_PROFILE_READ  = "profile:read"
+	// calendar apps cannot send headers, the calendar feed takes the token
+	// from the query
+	SCOPE_CALENDAR_READ = "calendar:read"
+)
+
+var API_TOKEN_SCOPES = []string{SCOPE_RESULTS_READ, SCOPE_RESULTS_WRITE, SCOPE_PROFILE_READ, SCOPE_CALENDAR_READ}
+
+var (
+	ErrApiTokenNotFound  = errors.New("api token not found")
+	ErrUnknownScope      = errors.New("unknown scope")
+	ErrNoScopes          = errors.New("api token needs at least one scope")
+	ErrTooManyApiTokens  = errors.New("too many api tokens")
+	ErrApiTokenNameEmpty = errors.New("api token name is empty")
+)
+
+type ApiToken struct {
+	Id         int        `json:"id"`
+	UserId     int        `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+}
+
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(token, API_TOKEN_PREFIX)
+}
+
+func (t *ApiToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+const apiTokenColumns = `t.api_token_id, t.user_id, t.name, t.token_prefix, t.scopes, t.created_at, t.last_used_at, t.expires_at`
+
+func scanApiToken(row pgx.Row, t *ApiToken) error {
+	return row.Scan(&t.Id, &t.UserId, &t.Name, &t.Prefix, &t.Scopes, &t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt)
+}
+
+// CreateApiToken mints a token for the user, it is returned only here, the
+// database keeps its hash. Tokens with expiresIn 0 do not expire.
+func CreateApiToken(ctx context.Context, db interfaces.DB, uid int, name string, scopes []string, expiresIn time.Duration) (ApiToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ApiToken{}, "", ErrApiTokenNameEmpty
+	}
+
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+	if len(scopes) == 0 {
+		return ApiToken{}, "", ErrNoScopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(API_TOKEN_SCOPES, scope) {
+			return ApiToken{}, "", fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	var noOfTokens int
+	err := db.QueryRow(ctx, `SELECT COUNT(*) FROM api_tokens t WHERE t.user_id = $1 AND t.revoked_at IS NULL;`, uid).Scan(&noOfTokens)
+	if err != nil {
+		return ApiToken{}, "", fmt.Errorf("%w: when counting api tokens of user with id=%d", err, uid)
+	}
+	if noOfTokens >= MAX_API_TOKENS_PER_USER {
+		return ApiToken{}, "", ErrTooManyApiTokens
+	}
+
+	secret, err := newSecretToken()
+	if err != nil {
+		return ApiToken{}, "", err
+	}
+	token := API_TOKEN_PREFIX + secret
+
+	var expiresInSeconds *float64
+	if expiresIn > 0 {
+		seconds := expiresIn.Seconds()
+		expiresInSeconds = &seconds
+	}
+
+	var apiToken ApiToken
+	err = scanApiToken(db.QueryRow(
+		ctx,
+		`INSERT INTO api_tokens AS t (user_id, name, token_hash, token_prefix, scopes, expires_at) VALUES ($1,$2,$3,$4,$5,CURRENT_TIMESTAMP + make_interval(secs => $6)) RETURNING `+apiTokenColumns+`;`,
+		uid,
+		name,
+		hashToken(token),
+		token[:len(API_TOKEN_PREFIX)+API_TOKEN_DISPLAY_LENGTH],
+		scopes,
+		expiresInSeconds,
+	), &apiToken)
+	if err != nil {
+		return ApiToken{}, "", fmt.Errorf("%w: when inserting api token of user with id=%d", err, uid)
+	}
+
+	return apiToken, token, nil
+}
+
+// UseApiToken returns the active token and marks it as used
+func UseApiToken(ctx context.Context, db interfaces.DB, token string) (ApiToken, error) {
+	var apiToken ApiToken
+	err := scanApiToken(db.QueryRow(
+		ctx,
+		`UPDATE api_tokens t SET last_used_at = CURRENT_TIMESTAMP WHERE t.token_hash = $1 AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > CURRENT_TIMESTAMP) RETURNING `+apiTokenColumns+`;`,
+		hashToken(token),
+	), &apiToken)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ApiToken{}, ErrApiTokenNotFound
+	}
+	if err != nil {
+		return ApiToken{}, fmt.Errorf("%w: when using api token", err)
+	}
+
+	return apiToken, nil
+}
+
+// GetApiTokens returns the not revoked tokens of the user, newest first
+func GetApiTokens(ctx context.Context, db interfaces.DB, uid int) ([]ApiToken, error) {
+	rows, err := db.Query(ctx, `SELECT `+apiTokenColumns+` FROM api_tokens t WHERE t.user_id = $1 AND t.revoked_at IS NULL ORDER BY t.created_at DESC, t.api_token_id DESC;`, uid)
+	if err != nil {
+		return []ApiToken{}, fmt.Errorf("%w: when querying api tokens of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	apiTokens := make([]ApiToken, 0)
+	for rows.Next() {
+		var apiToken ApiToken
+		if err = scanApiToken(rows, &apiToken); err != nil {
+			return []ApiToken{}, fmt.Errorf("%w: when scanning api token", err)
+		}
+		apiTokens = append(apiTokens, apiToken)
+	}
+
+	return apiTokens, nil
+}
+
+func RevokeApiToken(ctx context.Context, db interfaces.DB, id int, uid int) error {
+	tag, err := db.Exec(ctx, `UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE api_token_id = $1 AND user_id = $2 AND revoked_at IS NULL;`, id, uid)
+	if err != nil {
+		return fmt.Errorf("%w: when revoking api token with id=%d", err, id)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: id=%d", ErrApiTokenNotFound, id)
+	}
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 type AuthDetails struct {
 	UserId    int   `json:"userid"`
+	SessionId int   `json:"sid"`
 	ExpiresIn int64 `json:"expiresin"`
 }
 
@@ -27,6 +28,12 @@ func ExtractPayload(tokenString *jwt.Token) (AuthDetails, error) {
 	}
 	authDetails.UserId = int(uidFloat)
 
+	sidFloat, ok := claims["sid"].(float64)
+	if !ok {
+		return AuthDetails{}, fmt.Errorf("failed to parse sid")
+	}
+	authDetails.SessionId = int(sidFloat)
+
 	return authDetails, nil
 }
 
@@ -50,32 +57,29 @@ func VerifyJWTToken(tokenString string, secretKey string) (AuthDetails, error) {
 	return authDetails, nil
 }
 
-func GetAuthDetailsFromHeader(c *gin.Context, secretKey string) (AuthDetails, error) {
+// GetTokenFromHeader returns the bearer token of the request, browsers may
+// send it in a cookie and links in emails in the atoken query parameter
+func GetTokenFromHeader(c *gin.Context) (string, error) {
 	headers := c.Request.Header["Authorization"]
 	if len(headers) <= 0 {
 		token, err := c.Cookie("token")
 		if err != nil {
 			token = c.DefaultQuery("atoken", "")
 			if token == "" {
-				return AuthDetails{}, fmt.Errorf("could not get cookie from gin.Context")
+				return "", fmt.Errorf("could not get cookie from gin.Context")
 			}
 		}
 		headers = []string{"Bearer " + token}
 	}
 
 	if len(headers) <= 0 {
-		return AuthDetails{}, fmt.Errorf("auth header missing")
+		return "", fmt.Errorf("auth header missing")
 	}
 
 	header := strings.Split(headers[0], " ")
 	if len(header) < 2 || header[0] != "Bearer" {
-		return AuthDetails{}, fmt.Errorf("bad auth header")
-	}
-
-	authDetails, err := VerifyJWTToken(header[1], secretKey)
-	if err != nil {
-		return AuthDetails{}, err
+		return "", fmt.Errorf("bad auth header")
 	}
 
-	return authDetails, nil
+	return header[1], nil
 }
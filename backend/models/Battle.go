@@ -0,0 +1,448 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+const MAX_BATTLE_ATTEMPTS = 12
+
+type BattleState string
+
+const (
+	// the opponent did not answer the challenge yet
+	BattlePending BattleState = "pending"
+	// both users are solving
+	BattleActive   BattleState = "active"
+	BattleDeclined BattleState = "declined"
+	BattleFinished BattleState = "finished"
+)
+
+var (
+	ErrBattleNotFound          = errors.New("battle not found")
+	ErrInvalidBattle           = errors.New("invalid battle")
+	ErrNotInBattle             = errors.New("user is not in the battle")
+	ErrBattleNotActive         = errors.New("battle is not active")
+	ErrBattleNotPending        = errors.New("battle is not pending")
+	ErrAttemptAlreadySubmitted = errors.New("attempt already submitted")
+)
+
+// BattleAttempt is one scramble of a battle with the solves of both users, an
+// empty solve was not submitted yet. WinnerId is 0 for a tie or when the
+// attempt is not finished.
+type BattleAttempt struct {
+	Order           int    `json:"order"`
+	Scramble        string `json:"scramble"`
+	Img             string `json:"img"`
+	ChallengerSolve string `json:"challengerSolve"`
+	OpponentSolve   string `json:"opponentSolve"`
+	WinnerId        int    `json:"winnerId"`
+}
+
+// Battle is a head-to-head match of two users in one event. Both users get
+// the same scrambles one at a time, the next scramble is revealed once both
+// of them submitted their solve of the current one.
+type Battle struct {
+	Id             int              `json:"id"`
+	ChallengerId   int              `json:"challengerId"`
+	ChallengerName string           `json:"challengerName"`
+	OpponentId     int              `json:"opponentId"`
+	OpponentName   string           `json:"opponentName"`
+	Event          CompetitionEvent `json:"event"`
+	NoOfAttempts   int              `json:"noOfAttempts"`
+	State          BattleState      `json:"state"`
+	WinnerId       int              `json:"winnerId"`
+	ChallengerWins int              `json:"challengerWins"`
+	OpponentWins   int              `json:"opponentWins"`
+	FinishedAt     *time.Time       `json:"finishedAt"`
+	Attempts       []BattleAttempt  `json:"attempts"`
+}
+
+func (b *Battle) Validate() error {
+	if b.ChallengerId == b.OpponentId {
+		return fmt.Errorf("%w: challenger and opponent are the same user", ErrInvalidBattle)
+	}
+	if b.NoOfAttempts < 1 || b.NoOfAttempts > MAX_BATTLE_ATTEMPTS {
+		return fmt.Errorf("%w: attempts=%d", ErrInvalidBattle, b.NoOfAttempts)
+	}
+
+	return nil
+}
+
+func (b *Battle) IsParticipant(uid int) bool {
+	return uid == b.ChallengerId || uid == b.OpponentId
+}
+
+// CurrentAttempt returns the index of the first attempt not solved by both
+// users, len(b.Attempts) if all of them are done
+func (b *Battle) CurrentAttempt() int {
+	for idx, attempt := range b.Attempts {
+		if attempt.ChallengerSolve == "" || attempt.OpponentSolve == "" {
+			return idx
+		}
+	}
+
+	return len(b.Attempts)
+}
+
+// ParseSolve parses the solve of the attempt the same way results are parsed,
+// solves in a bad format are DNFs
+func (b *Battle) ParseSolve(attemptIdx int, solve string) (string, int) {
+	resultEntry := ResultEntry{Iconcode: b.Event.Iconcode, Format: "bo1", Solves: []string{solve}}
+	if resultEntry.IsMBLD() {
+		resultEntry.Solves[0] = resultEntry.ValidateMultiEntry(solve)
+	} else {
+		resultEntry.CheckFormats(resultEntry.IsFMC())
+	}
+
+	return resultEntry.Solves[0], resultEntry.Single(resultEntry.IsFMC(), []string{b.Attempts[attemptIdx].Scramble})
+}
+
+// ComputeResults decides the winners of the finished attempts and, if all
+// of them are finished, of the whole battle, the user winning more attempts
+// wins the battle
+func (b *Battle) ComputeResults() {
+	b.ChallengerWins, b.OpponentWins, b.WinnerId = 0, 0, 0
+
+	current := b.CurrentAttempt()
+	for idx := range b.Attempts {
+		b.Attempts[idx].WinnerId = 0
+		if idx >= current {
+			continue
+		}
+
+		_, challenger := b.ParseSolve(idx, b.Attempts[idx].ChallengerSolve)
+		_, opponent := b.ParseSolve(idx, b.Attempts[idx].OpponentSolve)
+		if challenger < opponent {
+			b.Attempts[idx].WinnerId = b.ChallengerId
+			b.ChallengerWins++
+		} else if opponent < challenger {
+			b.Attempts[idx].WinnerId = b.OpponentId
+			b.OpponentWins++
+		}
+	}
+
+	if current < len(b.Attempts) || len(b.Attempts) == 0 {
+		return
+	}
+
+	if b.ChallengerWins > b.OpponentWins {
+		b.WinnerId = b.ChallengerId
+	} else if b.OpponentWins > b.ChallengerWins {
+		b.WinnerId = b.OpponentId
+	}
+}
+
+// HideFor hides what the user is not allowed to see yet, the scrambles after
+// the current attempt and the solve of the other user in the current attempt
+func (b *Battle) HideFor(uid int) {
+	if b.State == BattleFinished {
+		return
+	}
+
+	current := b.CurrentAttempt()
+	if current >= len(b.Attempts) {
+		return
+	}
+
+	b.Attempts = b.Attempts[:current+1]
+	attempt := &b.Attempts[current]
+	if !b.IsParticipant(uid) {
+		attempt.Scramble, attempt.Img = "", ""
+	}
+	if uid != b.ChallengerId {
+		attempt.ChallengerSolve = ""
+	}
+	if uid != b.OpponentId {
+		attempt.OpponentSolve = ""
+	}
+}
+
+func (b *Battle) Insert(ctx context.Context, db interfaces.DB) error {
+	err := db.QueryRow(
+		ctx,
+		`INSERT INTO battles (challenger_id, opponent_id, event_id, attempts, state)
+			VALUES ($1,$2,$3,$4,$5)
+			RETURNING battle_id;
+		`,
+		b.ChallengerId,
+		b.OpponentId,
+		b.Event.Id,
+		b.NoOfAttempts,
+		BattlePending,
+	).Scan(&b.Id)
+	if err != nil {
+		return fmt.Errorf("%w: when inserting battle=%+v", err, *b)
+	}
+
+	b.State = BattlePending
+	b.Attempts = []BattleAttempt{}
+
+	return nil
+}
+
+func (b *Battle) Get(ctx context.Context, db interfaces.DB, id int) error {
+	err := db.QueryRow(
+		ctx,
+		`SELECT b.battle_id, b.challenger_id, uc.name, b.opponent_id, uo.name, e.event_id, e.displayname, e.iconcode, e.scramblingcode, b.attempts, b.state, b.finished_at
+			FROM battles b
+			JOIN users uc ON uc.user_id = b.challenger_id
+			JOIN users uo ON uo.user_id = b.opponent_id
+			JOIN events e ON e.event_id = b.event_id
+			WHERE b.battle_id = $1;
+		`,
+		id,
+	).Scan(
+		&b.Id,
+		&b.ChallengerId,
+		&b.ChallengerName,
+		&b.OpponentId,
+		&b.OpponentName,
+		&b.Event.Id,
+		&b.Event.Displayname,
+		&b.Event.Iconcode,
+		&b.Event.Scramblingcode,
+		&b.NoOfAttempts,
+		&b.State,
+		&b.FinishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: id=%d", ErrBattleNotFound, id)
+		}
+		return fmt.Errorf("%w: when querying battle with id=%d", err, id)
+	}
+	b.Event.Format = "bo1"
+	b.Event.Rounds = []CompetitionRound{}
+
+	err = b.loadAttempts(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	b.ComputeResults()
+
+	return nil
+}
+
+func (b *Battle) loadAttempts(ctx context.Context, db interfaces.DB) error {
+	rows, err := db.Query(
+		ctx,
+		`SELECT ba."order", ba.scramble, ba.img, COALESCE(ba.challenger_solve, ''), COALESCE(ba.opponent_solve, '')
+			FROM battle_attempts ba
+			WHERE ba.battle_id = $1
+			ORDER BY ba."order";
+		`,
+		b.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when querying attempts of battle with id=%d", err, b.Id)
+	}
+	defer rows.Close()
+
+	b.Attempts = make([]BattleAttempt, 0)
+	for rows.Next() {
+		var attempt BattleAttempt
+		err = rows.Scan(&attempt.Order, &attempt.Scramble, &attempt.Img, &attempt.ChallengerSolve, &attempt.OpponentSolve)
+		if err != nil {
+			return fmt.Errorf("%w: when scanning attempt of battle with id=%d", err, b.Id)
+		}
+
+		b.Attempts = append(b.Attempts, attempt)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%w: when iterating attempts of battle with id=%d", err, b.Id)
+	}
+
+	return nil
+}
+
+// Accept starts the pending battle with the scrambles (and their images) for
+// all of its attempts
+func (b *Battle) Accept(ctx context.Context, db interfaces.DB, scrambles []string, images []string) error {
+	if b.State != BattlePending {
+		return fmt.Errorf("%w: id=%d state=%s", ErrBattleNotPending, b.Id, b.State)
+	}
+	if len(scrambles) != b.NoOfAttempts || len(images) != b.NoOfAttempts {
+		return fmt.Errorf("%w: got %d scrambles for %d attempts", ErrInvalidBattle, len(scrambles), b.NoOfAttempts)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE battles SET state = $1, timestamp = CURRENT_TIMESTAMP WHERE battle_id = $2 AND state = $3;`, BattleActive, b.Id, BattlePending)
+	if err != nil {
+		return fmt.Errorf("%w: when accepting battle with id=%d", err, b.Id)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: id=%d", ErrBattleNotPending, b.Id)
+	}
+
+	b.Attempts = make([]BattleAttempt, 0, len(scrambles))
+	for idx, scramble := range scrambles {
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO battle_attempts (battle_id, "order", scramble, img) VALUES ($1,$2,$3,$4);`,
+			b.Id,
+			idx+1,
+			scramble,
+			images[idx],
+		)
+		if err != nil {
+			return fmt.Errorf("%w: when inserting attempt %d of battle with id=%d", err, idx+1, b.Id)
+		}
+
+		b.Attempts = append(b.Attempts, BattleAttempt{Order: idx + 1, Scramble: scramble, Img: images[idx]})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	b.State = BattleActive
+
+	return nil
+}
+
+func (b *Battle) Decline(ctx context.Context, db interfaces.DB) error {
+	tag, err := db.Exec(ctx, `UPDATE battles SET state = $1, timestamp = CURRENT_TIMESTAMP WHERE battle_id = $2 AND state = $3;`, BattleDeclined, b.Id, BattlePending)
+	if err != nil {
+		return fmt.Errorf("%w: when declining battle with id=%d", err, b.Id)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: id=%d", ErrBattleNotPending, b.Id)
+	}
+
+	b.State = BattleDeclined
+
+	return nil
+}
+
+// Submit saves the solve of the user in the current attempt and finishes the
+// battle after the last one
+func (b *Battle) Submit(ctx context.Context, db interfaces.DB, uid int, solve string) error {
+	if !b.IsParticipant(uid) {
+		return fmt.Errorf("%w: uid=%d battle_id=%d", ErrNotInBattle, uid, b.Id)
+	}
+	if b.State != BattleActive {
+		return fmt.Errorf("%w: id=%d state=%s", ErrBattleNotActive, b.Id, b.State)
+	}
+
+	current := b.CurrentAttempt()
+	if current >= len(b.Attempts) {
+		return fmt.Errorf("%w: id=%d has no attempts left", ErrBattleNotActive, b.Id)
+	}
+
+	column := "challenger_solve"
+	submitted := b.Attempts[current].ChallengerSolve
+	if uid == b.OpponentId {
+		column = "opponent_solve"
+		submitted = b.Attempts[current].OpponentSolve
+	}
+	if submitted != "" {
+		return fmt.Errorf("%w: battle_id=%d attempt=%d", ErrAttemptAlreadySubmitted, b.Id, current+1)
+	}
+
+	// an empty solve would be stored as '' and the attempt could never be
+	// submitted again
+	solve, _ = b.ParseSolve(current, solve)
+	if strings.TrimSpace(solve) == "" {
+		return fmt.Errorf("%w: empty solve in battle_id=%d attempt=%d", ErrInvalidBattle, b.Id, current+1)
+	}
+	tag, err := db.Exec(
+		ctx,
+		`UPDATE battle_attempts SET `+column+` = $1, timestamp = CURRENT_TIMESTAMP WHERE battle_id = $2 AND "order" = $3 AND `+column+` IS NULL;`,
+		solve,
+		b.Id,
+		current+1,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when submitting attempt %d of battle with id=%d", err, current+1, b.Id)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: battle_id=%d attempt=%d", ErrAttemptAlreadySubmitted, b.Id, current+1)
+	}
+
+	// the other user could have submitted in the meantime
+	err = b.Get(ctx, db, b.Id)
+	if err != nil {
+		return err
+	}
+
+	if b.CurrentAttempt() < len(b.Attempts) {
+		return nil
+	}
+
+	var winnerId *int
+	if b.WinnerId != 0 {
+		winnerId = &b.WinnerId
+	}
+	_, err = db.Exec(
+		ctx,
+		`UPDATE battles SET state = $1, winner_id = $2, finished_at = CURRENT_TIMESTAMP, timestamp = CURRENT_TIMESTAMP WHERE battle_id = $3 AND state = $4;`,
+		BattleFinished,
+		winnerId,
+		b.Id,
+		BattleActive,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when finishing battle with id=%d", err, b.Id)
+	}
+
+	return b.Get(ctx, db, b.Id)
+}
+
+// GetUserBattles returns the battles of the user, newest first, only
+// finished ones if finished is set
+func GetUserBattles(ctx context.Context, db interfaces.DB, uid int, finished bool) ([]Battle, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT b.battle_id
+			FROM battles b
+			WHERE (b.challenger_id = $1 OR b.opponent_id = $1) AND (NOT $2 OR b.state = $3)
+			ORDER BY b.timestamp DESC;
+		`,
+		uid,
+		finished,
+		BattleFinished,
+	)
+	if err != nil {
+		return []Battle{}, fmt.Errorf("%w: when querying battles of user with id=%d", err, uid)
+	}
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return []Battle{}, fmt.Errorf("%w: when scanning battle id", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return []Battle{}, fmt.Errorf("%w: when iterating battles of user with id=%d", err, uid)
+	}
+
+	battles := make([]Battle, 0, len(ids))
+	for _, id := range ids {
+		var battle Battle
+		if err = battle.Get(ctx, db, id); err != nil {
+			return []Battle{}, err
+		}
+		battle.HideFor(uid)
+		battles = append(battles, battle)
+	}
+
+	return battles, nil
+}
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/ical"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+// the calendars keep the weekly competitions which ended in the history, the
+// openings of the registrations are short events at the opening time
+const (
+	CALENDAR_PRODID              = "-//Speedcubing Slovakia//Competitions//EN"
+	CALENDAR_HISTORY             = 90 * 24 * time.Hour
+	CALENDAR_REGISTRATION_LENGTH = 15 * time.Minute
+)
+
+// GetCalendarCompetitions returns the weekly competitions ending after since,
+// ordered by startdate
+func GetCalendarCompetitions(ctx context.Context, db interfaces.DB, since time.Time) ([]CompetitionData, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT c.competition_id, c.name, c.startdate, c.enddate FROM competitions c WHERE c.competition_id LIKE ('WeeklyCompetition%') AND c.enddate >= $1 ORDER BY c.startdate;`,
+		since.UTC(),
+	)
+	if err != nil {
+		return []CompetitionData{}, fmt.Errorf("%w: when querying calendar competitions", err)
+	}
+	defer rows.Close()
+
+	competitions := make([]CompetitionData, 0)
+	for rows.Next() {
+		var competition CompetitionData
+		if err = rows.Scan(&competition.Id, &competition.Name, &competition.Startdate, &competition.Enddate); err != nil {
+			return []CompetitionData{}, fmt.Errorf("%w: when scanning competition", err)
+		}
+		competitions = append(competitions, competition)
+	}
+	if err = rows.Err(); err != nil {
+		return []CompetitionData{}, fmt.Errorf("%w: when iterating through competitions", err)
+	}
+
+	return competitions, nil
+}
+
+func getUserWCACompAnnouncementsSubscriptions(ctx context.Context, db interfaces.DB, uid int) ([]WCACompAnnouncementsSubscription, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT s.wca_competitions_announcements_subscription_id, s.user_id, s.country_id, s.state FROM wca_competitions_announcements_subscriptions s WHERE s.user_id = $1;`,
+		uid,
+	)
+	if err != nil {
+		return []WCACompAnnouncementsSubscription{}, fmt.Errorf("%w: when querying subscriptions of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]WCACompAnnouncementsSubscription, 0)
+	for rows.Next() {
+		var s WCACompAnnouncementsSubscription
+		if err = rows.Scan(&s.Id, &s.UserId, &s.CountryId, &s.State); err != nil {
+			return []WCACompAnnouncementsSubscription{}, fmt.Errorf("%w: when scanning subscription", err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return []WCACompAnnouncementsSubscription{}, fmt.Errorf("%w: when iterating through subscriptions", err)
+	}
+
+	return subscriptions, nil
+}
+
+func getUserWCACompAnnouncementsPositionSubscriptions(ctx context.Context, db interfaces.DB, uid int) ([]WCACompAnnouncementsPositionSubscription, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT ps.wca_competitions_announcements_position_subscription_id, ps.user_id, ps.latitude_degrees, ps.longitude_degrees, ps.radius FROM wca_competitions_announcements_position_subscriptions ps WHERE ps.user_id = $1;`,
+		uid,
+	)
+	if err != nil {
+		return []WCACompAnnouncementsPositionSubscription{}, fmt.Errorf("%w: when querying position subscriptions of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]WCACompAnnouncementsPositionSubscription, 0)
+	for rows.Next() {
+		var s WCACompAnnouncementsPositionSubscription
+		if err = rows.Scan(&s.Id, &s.UserId, &s.LatitudeDegrees, &s.LongitudeDegrees, &s.Radius); err != nil {
+			return []WCACompAnnouncementsPositionSubscription{}, fmt.Errorf("%w: when scanning position subscription", err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return []WCACompAnnouncementsPositionSubscription{}, fmt.Errorf("%w: when iterating through position subscriptions", err)
+	}
+
+	return subscriptions, nil
+}
+
+// uniqueWCACompetitions returns the competitions ordered by startdate, the
+// ones saved for more countries only once
+func uniqueWCACompetitions(comps []UpcomingWCACompetition) []UpcomingWCACompetition {
+	slices.SortFunc(comps, func(a, b UpcomingWCACompetition) int {
+		return cmp.Or(a.Startdate.Compare(b.Startdate), strings.Compare(a.Id, b.Id), strings.Compare(a.CountryId, b.CountryId))
+	})
+
+	return slices.CompactFunc(comps, func(a, b UpcomingWCACompetition) bool { return a.Id == b.Id })
+}
+
+// GetFollowedWCACompetitions returns the saved WCA competitions in the
+// countries (states) the user subscribed to, inside the subscribed circles and
+// areas or watched by the user, including the cancelled ones so the calendars
+// can cancel their events
+func GetFollowedWCACompetitions(ctx context.Context, db interfaces.DB, uid int) ([]UpcomingWCACompetition, error) {
+	subscriptions, err := getUserWCACompAnnouncementsSubscriptions(ctx, db, uid)
+	if err != nil {
+		return []UpcomingWCACompetition{}, err
+	}
+
+	positionSubscriptions, err := getUserWCACompAnnouncementsPositionSubscriptions(ctx, db, uid)
+	if err != nil {
+		return []UpcomingWCACompetition{}, err
+	}
+
+	polygonSubscriptions, err := GetWCACompAnnouncementsPolygonSubscriptions(ctx, db, uid)
+	if err != nil {
+		return []UpcomingWCACompetition{}, err
+	}
+
+	watched, err := GetWatchedWCACompetitionIds(ctx, db, uid)
+	if err != nil {
+		return []UpcomingWCACompetition{}, err
+	}
+
+	known, err := GetKnownUpcomingWCACompetitions(ctx, db)
+	if err != nil {
+		return []UpcomingWCACompetition{}, err
+	}
+
+	followed := make([]UpcomingWCACompetition, 0)
+	for _, comp := range known {
+		if slices.Contains(watched, comp.Id) ||
+			slices.ContainsFunc(subscriptions, func(s WCACompAnnouncementsSubscription) bool {
+				return s.CountryId == comp.CountryId && (s.State == "" || s.State == comp.State)
+			}) ||
+			slices.ContainsFunc(positionSubscriptions, func(s WCACompAnnouncementsPositionSubscription) bool {
+				return utils.PointInsideCircle(comp.LatitudeDegrees, comp.LongitudeDegrees, float64(s.Radius), s.LatitudeDegrees, s.LongitudeDegrees)
+			}) ||
+			slices.ContainsFunc(polygonSubscriptions, func(s WCACompAnnouncementsPolygonSubscription) bool {
+				return s.Contains(comp.LatitudeDegrees, comp.LongitudeDegrees)
+			}) {
+			followed = append(followed, comp)
+		}
+	}
+
+	return uniqueWCACompetitions(followed), nil
+}
+
+// GetRegionWCACompetitions returns the saved WCA competitions in the country,
+// only in its state if the state is not empty
+func GetRegionWCACompetitions(ctx context.Context, db interfaces.DB, countryId string, state string) ([]UpcomingWCACompetition, error) {
+	known, err := GetKnownUpcomingWCACompetitions(ctx, db)
+	if err != nil {
+		return []UpcomingWCACompetition{}, err
+	}
+
+	comps := make([]UpcomingWCACompetition, 0)
+	for _, comp := range known {
+		if comp.CountryId == countryId && (state == "" || comp.State == state) {
+			comps = append(comps, comp)
+		}
+	}
+
+	return uniqueWCACompetitions(comps), nil
+}
+
+// CalendarEvents returns the events of the weekly competitions, of the WCA
+// competitions and of the openings of their registrations
+func CalendarEvents(envMap map[string]string, competitions []CompetitionData, wcaCompetitions []UpcomingWCACompetition) []ical.Event {
+	host := "speedcubingslovakia.sk"
+	if website, err := url.Parse(envMap["WEBSITE_HOME"]); err == nil && website.Hostname() != "" {
+		host = website.Hostname()
+	}
+
+	events := make([]ical.Event, 0, len(competitions)+2*len(wcaCompetitions))
+	for _, competition := range competitions {
+		events = append(events, ical.Event{
+			Uid:     competition.Id + "@" + host,
+			Summary: competition.Name,
+			Url:     envMap["WEBSITE_HOME"] + "/competition/" + url.PathEscape(competition.Id),
+			Start:   competition.Startdate,
+			End:     competition.Enddate,
+			Status:  ical.STATUS_CONFIRMED,
+		})
+	}
+
+	for _, comp := range wcaCompetitions {
+		status := ical.STATUS_CONFIRMED
+		if comp.Cancelled {
+			status = ical.STATUS_CANCELLED
+		}
+
+		description := fmt.Sprintf("Competitor limit: %d", comp.CompetitorLimit)
+		if events := eventIconcodes(comp.Events); events != "" {
+			description += "\nEvents: " + events
+		}
+
+		events = append(events, ical.Event{
+			Uid:         "wca-" + comp.Id + "@" + host,
+			Summary:     comp.Name,
+			Description: description,
+			Location:    comp.VenueAddress,
+			Url:         comp.Url,
+			Start:       comp.Startdate,
+			End:         comp.Enddate,
+			AllDay:      true,
+			Status:      status,
+		})
+
+		if comp.RegistrationOpen.IsZero() {
+			continue
+		}
+		events = append(events, ical.Event{
+			Uid:     "wca-registration-" + comp.Id + "@" + host,
+			Summary: "Registration opens: " + comp.Name,
+			Url:     comp.Url,
+			Start:   comp.RegistrationOpen,
+			End:     comp.RegistrationOpen.Add(CALENDAR_REGISTRATION_LENGTH),
+			Status:  status,
+		})
+	}
+
+	return events
+}
@@ -2,11 +2,7 @@ package models
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"errors"
 	"strings"
 	"time"
 
@@ -14,6 +10,9 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambleimage"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
 )
 
@@ -44,53 +43,49 @@ func (c *CompetitionData) RemoveAllEvents(db *pgxpool.Pool, tx pgx.Tx) ([]int, e
 		event_ids = append(event_ids, event_id)
 	}
 
+	// the rounds are updated in place by AddEvents, so the saved advancement
+	// of the running rounds stays
 	_, err = tx.Exec(context.Background(), `DELETE FROM competition_events WHERE competition_id = $1;`, c.Id)
 	return event_ids, err
 }
 
-func (c *CompetitionData) AddEvents(db *pgxpool.Pool, tx pgx.Tx, event_ids []int, envMap map[string]string) error {
+func (c *CompetitionData) AddEvents(db *pgxpool.Pool, tx pgx.Tx, event_ids []int, s scrambler.Scrambler, envMap map[string]string) error {
 	for _, event := range c.Events {
 		if event.Id < 0 {
 			continue
 		}
-		_, err := tx.Exec(context.Background(), `INSERT INTO competition_events (competition_id, event_id, format) VALUES ($1, $2, $3);`, c.Id, event.Id, event.Format)
+		_, err := tx.Exec(context.Background(), `INSERT INTO competition_events (competition_id, event_id, format, cutoff_attempts, cutoff_time, time_limit, time_limit_cumulative) VALUES ($1, $2, $3, $4, $5, $6, $7);`, c.Id, event.Id, event.Format, event.Cutoff.Attempts, event.Cutoff.Time, event.TimeLimit.Time, event.TimeLimit.Cumulative)
 		if err != nil {
 			return err
 		}
 
-		has, err := event.HasScrambles(db, tx, c.Id)
+		err = event.SaveRounds(tx, c.Id)
 		if err != nil {
 			return err
 		}
 
-		if !has {
-			noOfSolves, err := utils.GetNoOfSolves(event.Format)
+		for round := 1; round <= event.NoOfRounds(); round++ {
+			has, err := event.HasScrambles(db, tx, c.Id, round)
 			if err != nil {
 				return err
 			}
+			if has {
+				continue
+			}
 
-			ismbld := event.Iconcode == "333mbf"
-
-			scrambles, err := GenerateScramblesForEvent(event.Scramblingcode, noOfSolves, ismbld, envMap)
+			scrambleSet, err := GenerateScrambleSet(s, event, round, envMap)
 			if err != nil {
 				return err
 			}
 
-			images, err := GenerateImagesForScrambles(scrambles, event.Scramblingcode, ismbld, envMap)
+			err = scrambleSet.Insert(tx, c.Id)
 			if err != nil {
 				return err
 			}
-
-			for scrambleIdx, scramble := range scrambles {
-				_, err := tx.Exec(context.Background(), `INSERT INTO scrambles (scramble, event_id, competition_id, "order", img) VALUES ($1,$2,$3,$4,$5);`, scramble, event.Id, c.Id, scrambleIdx+1, images[scrambleIdx])
-				if err != nil {
-					return err
-				}
-			}
 		}
 	}
 
-	return nil
+	return DeleteRemovedRounds(tx, c.Id)
 }
 
 func (competition *CompetitionData) RecomputeCompetitionId() {
@@ -102,29 +97,44 @@ func (c *CompetitionData) GetScrambles(db *pgxpool.Pool) error {
 	scrambleSets := make([]ScrambleSet, 0)
 
 	for _, event := range c.Events {
-		rows, err := db.Query(context.Background(), `SELECT s.scramble_id, s.scramble, e.event_id, e.displayname, ce.format, e.iconcode, e.scramblingcode, s.img FROM scrambles s LEFT JOIN events e ON s.event_id = e.event_id LEFT JOIN competition_events ce ON ce.competition_id = s.competition_id AND ce.event_id = s.event_id WHERE s.competition_id = $1 AND s.event_id = $2 ORDER BY e.event_id, s."order";`, c.Id, event.Id)
-		if err != nil {
-			return err
-		}
+		for round := 1; round <= event.NoOfRounds(); round++ {
+			startdate := c.Startdate
+			if event.Id > 0 {
+				var err error
+				startdate, _, err = event.RoundPeriod(*c, round)
+				if err != nil {
+					return err
+				}
+			}
 
-		var scrambleSet ScrambleSet
-		for rows.Next() {
-			var scramble Scramble
-			var scrambleId int
-			err := rows.Scan(&scrambleId, &scramble.Scramble, &scrambleSet.Event.Id, &scrambleSet.Event.Displayname, &scrambleSet.Event.Format, &scrambleSet.Event.Iconcode, &scrambleSet.Event.Scramblingcode, &scramble.Img)
+			rows, err := db.Query(context.Background(), `SELECT s.scramble_id, s.scramble, e.event_id, e.displayname, ce.format, e.iconcode, e.scramblingcode, s.img FROM scrambles s LEFT JOIN events e ON s.event_id = e.event_id LEFT JOIN competition_events ce ON ce.competition_id = s.competition_id AND ce.event_id = s.event_id WHERE s.competition_id = $1 AND s.event_id = $2 AND s.round = $3 ORDER BY e.event_id, s."order";`, c.Id, event.Id, round)
 			if err != nil {
 				return err
 			}
 
-			if time.Now().Before(c.Startdate) {
-				scramble.Scramble = "Competition has not started yet ;)"
-				scramble.Img = ""
+			scrambleSet := ScrambleSet{Round: round}
+			for rows.Next() {
+				var scramble Scramble
+				var scrambleId int
+				err := rows.Scan(&scrambleId, &scramble.Scramble, &scrambleSet.Event.Id, &scrambleSet.Event.Displayname, &scrambleSet.Event.Format, &scrambleSet.Event.Iconcode, &scrambleSet.Event.Scramblingcode, &scramble.Img)
+				if err != nil {
+					return err
+				}
+
+				if time.Now().Before(startdate) {
+					if round == 1 {
+						scramble.Scramble = "Competition has not started yet ;)"
+					} else {
+						scramble.Scramble = "Round has not started yet ;)"
+					}
+					scramble.Img = ""
+				}
+
+				scrambleSet.AddScramble(scramble)
 			}
 
-			scrambleSet.AddScramble(scramble)
+			scrambleSets = append(scrambleSets, scrambleSet)
 		}
-
-		scrambleSets = append(scrambleSets, scrambleSet)
 	}
 
 	c.Scrambles = scrambleSets
@@ -133,22 +143,34 @@ func (c *CompetitionData) GetScrambles(db *pgxpool.Pool) error {
 }
 
 func (c *CompetitionData) GetEvents(db *pgxpool.Pool) error {
-	events := []CompetitionEvent{{-1, "", "Overall", "", "overall", ""}}
+	events := []CompetitionEvent{{Id: -1, Displayname: "Overall", Iconcode: "overall"}}
 
-	rows, err := db.Query(context.Background(), `SELECT e.event_id, e.displayname, ce.format, e.iconcode, e.scramblingcode FROM competition_events ce JOIN events e ON ce.event_id = e.event_id WHERE ce.competition_id = $1 ORDER BY e.event_id`, c.Id)
+	rows, err := db.Query(context.Background(), `SELECT e.event_id, e.displayname, ce.format, e.iconcode, e.scramblingcode, ce.cutoff_attempts, ce.cutoff_time, ce.time_limit, ce.time_limit_cumulative FROM competition_events ce JOIN events e ON ce.event_id = e.event_id WHERE ce.competition_id = $1 ORDER BY e.event_id`, c.Id)
 	if err != nil {
 		return err
 	}
 
 	for rows.Next() {
 		var event CompetitionEvent
-		err := rows.Scan(&event.Id, &event.Displayname, &event.Format, &event.Iconcode, &event.Scramblingcode)
+		err := rows.Scan(&event.Id, &event.Displayname, &event.Format, &event.Iconcode, &event.Scramblingcode, &event.Cutoff.Attempts, &event.Cutoff.Time, &event.TimeLimit.Time, &event.TimeLimit.Cumulative)
 		if err != nil {
 			return err
 		}
 		events = append(events, event)
 	}
 
+	rounds, err := GetCompetitionRounds(db, c.Id)
+	if err != nil {
+		return err
+	}
+
+	for idx := range events {
+		events[idx].Rounds = rounds[events[idx].Id]
+		if events[idx].Rounds == nil {
+			events[idx].Rounds = []CompetitionRound{}
+		}
+	}
+
 	c.Events = events
 
 	return nil
@@ -198,29 +220,14 @@ func GetAllCompetitions(db *pgxpool.Pool) ([]CompetitionData, error) {
 	return competitions, nil
 }
 
-func GetScrambles(scramblingcode string, noOfSolves int, envMap map[string]string) ([]string, error) {
-	url := fmt.Sprintf("%s/api/v0/scramble/%s?numScrambles=%d", envMap["SCRAMBLING_SERVICE_URL"], scramblingcode, noOfSolves)
-	resp, err := http.Get(url)
-	if err != nil {
-		return []string{}, err
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	scrambles := make([]string, 0)
-	json.Unmarshal(respBody, &scrambles)
-
-	return scrambles, nil
-}
-
-func GenerateScramblesForEvent(scramblingcode string, noOfSolves int, ismbld bool, envMap map[string]string) ([]string, error) {
+func GenerateScramblesForEvent(s scrambler.Scrambler, scramblingcode string, noOfSolves int, ismbld bool) ([]string, error) {
 	if !ismbld {
-		return GetScrambles(scramblingcode, noOfSolves, envMap)
+		return s.Scrambles(scramblingcode, noOfSolves)
 	}
 
 	scrambles := make([]string, 0)
 	for range noOfSolves {
-		currentScrambles, err := GetScrambles(scramblingcode, constants.MBLD_MAX_CUBES_PER_ATTEMPT, envMap)
+		currentScrambles, err := s.Scrambles(scramblingcode, constants.MBLD_MAX_CUBES_PER_ATTEMPT)
 		if err != nil {
 			return []string{}, err
 		}
@@ -231,6 +238,8 @@ func GenerateScramblesForEvent(scramblingcode string, noOfSolves int, ismbld boo
 	return scrambles, nil
 }
 
+// GenerateImagesForScrambles renders and saves the image of every scramble,
+// events without a renderer get an empty image
 func GenerateImagesForScrambles(scrambles []string, scramblingcode string, ismbld bool, envMap map[string]string) ([]string, error) {
 	images := make([]string, 0)
 
@@ -238,25 +247,12 @@ func GenerateImagesForScrambles(scrambles []string, scramblingcode string, ismbl
 		if ismbld {
 			scramble = ""
 		}
-		url := fmt.Sprintf("%s/api/v0/view/%s/svg?scramble=%s", envMap["SCRAMBLING_SERVICE_URL"], scramblingcode, url.QueryEscape(scramble))
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return []string{}, err
-		}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return []string{}, err
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return []string{}, err
+		img_id, err := utils.GenerateScrambleImg(envMap["SCRAMBLE_IMAGES_PATH"], scramblingcode, scramble)
+		if errors.Is(err, scrambleimage.ErrUnsupported) {
+			images = append(images, "")
+			continue
 		}
-
-		img_id := utils.RandSeq(64) + ".svg" // with extension
-		err = utils.SaveScrambleImg(img_id, string(respBody))
 		if err != nil {
 			return []string{}, err
 		}
@@ -267,34 +263,48 @@ func GenerateImagesForScrambles(scrambles []string, scramblingcode string, ismbl
 	return images, nil
 }
 
-func (c *CompetitionData) GenerateScrambles(envMap map[string]string) error {
-	for _, event := range c.Events {
-		noOfSolves, err := utils.GetNoOfSolves(event.Format)
-		if err != nil {
-			return err
-		}
+// GenerateScrambleSet generates the scrambles with images for one round of
+// the event
+func GenerateScrambleSet(s scrambler.Scrambler, event CompetitionEvent, round int, envMap map[string]string) (ScrambleSet, error) {
+	format, err := formats.Get(event.Format)
+	if err != nil {
+		return ScrambleSet{}, err
+	}
+	noOfSolves := format.Attempts
 
-		ismbld := event.Iconcode == "333mbf"
+	ismbld := event.Iconcode == "333mbf"
 
-		scrambles, err := GenerateScramblesForEvent(event.Scramblingcode, noOfSolves, ismbld, envMap)
-		if err != nil {
-			return err
-		}
+	scrambles, err := GenerateScramblesForEvent(s, event.Scramblingcode, noOfSolves, ismbld)
+	if err != nil {
+		return ScrambleSet{}, err
+	}
 
-		images, err := GenerateImagesForScrambles(scrambles, event.Scramblingcode, ismbld, envMap)
-		if err != nil {
-			return err
-		}
+	images, err := GenerateImagesForScrambles(scrambles, event.Scramblingcode, ismbld, envMap)
+	if err != nil {
+		return ScrambleSet{}, err
+	}
+
+	scrambleSet := ScrambleSet{Event: event, Round: round}
+	for idx, scrambleText := range scrambles {
+		var scramble Scramble
+		scramble.Scramble = scrambleText
+		scramble.Img = images[idx]
+		scrambleSet.Scrambles = append(scrambleSet.Scrambles, scramble)
+	}
+
+	return scrambleSet, nil
+}
+
+func (c *CompetitionData) GenerateScrambles(s scrambler.Scrambler, envMap map[string]string) error {
+	for _, event := range c.Events {
+		for round := 1; round <= event.NoOfRounds(); round++ {
+			scrambleSet, err := GenerateScrambleSet(s, event, round, envMap)
+			if err != nil {
+				return err
+			}
 
-		var scrambleSet ScrambleSet
-		scrambleSet.Event = event
-		for idx, scrambleText := range scrambles {
-			var scramble Scramble
-			scramble.Scramble = scrambleText
-			scramble.Img = images[idx]
-			scrambleSet.Scrambles = append(scrambleSet.Scrambles, scramble)
+			c.Scrambles = append(c.Scrambles, scrambleSet)
 		}
-		c.Scrambles = append(c.Scrambles, scrambleSet)
 	}
 
 	return nil
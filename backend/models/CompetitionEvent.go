@@ -6,19 +6,24 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
 )
 
 type CompetitionEvent struct {
-	Id              int    `json:"id"`
-	Fulldisplayname string `json:"fulldisplayname"`
-	Displayname     string `json:"displayname"`
-	Format          string `json:"format"`
-	Iconcode        string `json:"iconcode"`
-	Scramblingcode  string `json:"scramblingcode"`
+	Id              int                `json:"id"`
+	Fulldisplayname string             `json:"fulldisplayname"`
+	Displayname     string             `json:"displayname"`
+	Format          string             `json:"format"`
+	Iconcode        string             `json:"iconcode"`
+	Scramblingcode  string             `json:"scramblingcode"`
+	Cutoff          formats.Cutoff     `json:"cutoff"`
+	TimeLimit       formats.TimeLimit  `json:"timeLimit"`
+	Rounds          []CompetitionRound `json:"rounds"`
 }
 
 func GetCompetitionEventById(db *pgxpool.Pool, competitionID string, eventID int) (CompetitionEvent, error) {
-	rows, err := db.Query(context.Background(), "SELECT e.event_id, e.displayname, ce.format, e.iconcode, e.scramblingcode FROM competition_events ce JOIN events e ON e.event_id = ce.event_id WHERE ce.competition_id = $1 AND ce.event_id = $2;", competitionID, eventID)
+	rows, err := db.Query(context.Background(), "SELECT e.event_id, e.displayname, ce.format, e.iconcode, e.scramblingcode, ce.cutoff_attempts, ce.cutoff_time, ce.time_limit, ce.time_limit_cumulative FROM competition_events ce JOIN events e ON e.event_id = ce.event_id WHERE ce.competition_id = $1 AND ce.event_id = $2;", competitionID, eventID)
 	if err != nil {
 		return CompetitionEvent{}, err
 	}
@@ -26,7 +31,7 @@ func GetCompetitionEventById(db *pgxpool.Pool, competitionID string, eventID int
 	var event CompetitionEvent
 	found := false
 	for rows.Next() {
-		err = rows.Scan(&event.Id, &event.Displayname, &event.Format, &event.Iconcode, &event.Scramblingcode)
+		err = rows.Scan(&event.Id, &event.Displayname, &event.Format, &event.Iconcode, &event.Scramblingcode, &event.Cutoff.Attempts, &event.Cutoff.Time, &event.TimeLimit.Time, &event.TimeLimit.Cumulative)
 		if err != nil {
 			return CompetitionEvent{}, err
 		}
@@ -48,7 +53,7 @@ func GetAvailableEvents(db *pgxpool.Pool) ([]CompetitionEvent, error) {
 
 	var events []CompetitionEvent
 	for rows.Next() {
-		var event CompetitionEvent
+		event := CompetitionEvent{Rounds: []CompetitionRound{}}
 		err = rows.Scan(&event.Id, &event.Fulldisplayname, &event.Displayname, &event.Format, &event.Iconcode, &event.Scramblingcode)
 		if err != nil {
 			return []CompetitionEvent{}, err
@@ -59,8 +64,8 @@ func GetAvailableEvents(db *pgxpool.Pool) ([]CompetitionEvent, error) {
 	return events, nil
 }
 
-func (e *CompetitionEvent) HasScrambles(db *pgxpool.Pool, tx pgx.Tx, cid string) (bool, error) {
-	rows, err := tx.Query(context.Background(), `SELECT COUNT(*) FROM scrambles WHERE competition_id = $1 AND event_id = $2;`, cid, e.Id)
+func (e *CompetitionEvent) HasScrambles(db *pgxpool.Pool, tx pgx.Tx, cid string, round int) (bool, error) {
+	rows, err := tx.Query(context.Background(), `SELECT COUNT(*) FROM scrambles WHERE competition_id = $1 AND event_id = $2 AND round = $3;`, cid, e.Id, round)
 	if err != nil {
 		return true, err
 	}
@@ -75,3 +80,18 @@ func (e *CompetitionEvent) HasScrambles(db *pgxpool.Pool, tx pgx.Tx, cid string)
 
 	return cnt > 0, nil
 }
+
+// ValidateRoundRules checks the cutoff and time limit make sense for the format
+func (e *CompetitionEvent) ValidateRoundRules() error {
+	format, err := formats.Get(e.Format)
+	if err != nil {
+		return err
+	}
+
+	err = format.ValidateCutoff(e.Cutoff)
+	if err != nil {
+		return err
+	}
+
+	return e.TimeLimit.Validate()
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
 )
 
 type CompetitionEvents struct {
@@ -11,14 +13,14 @@ type CompetitionEvents struct {
 	Event_id       int
 }
 
-func UpdateCompetitionEvents(competition *CompetitionData, db *pgxpool.Pool, tx pgx.Tx, envMap map[string]string) error {
+func UpdateCompetitionEvents(competition *CompetitionData, db *pgxpool.Pool, tx pgx.Tx, s scrambler.Scrambler, envMap map[string]string) error {
 	var err error
 	var event_ids []int
 
 	if event_ids, err = competition.RemoveAllEvents(db, tx); err != nil {
 		return err
 	}
-	if err := competition.AddEvents(db, tx, event_ids, envMap); err != nil {
+	if err := competition.AddEvents(db, tx, event_ids, s, envMap); err != nil {
 		return err
 	}
 
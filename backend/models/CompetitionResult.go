@@ -13,6 +13,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
 )
 
@@ -30,6 +31,7 @@ type CompetitionResult struct {
 	UserId      int          `json:"-"`
 	EventId     int          `json:"-"`
 	Comment     string       `json:"comment"`
+	Advancing   bool         `json:"advancing"`
 }
 
 type KinchScore struct {
@@ -51,17 +53,14 @@ type CompetitionResultStruct struct {
 func GetNewBest(
 	pBest BestEntry,
 	resultEntry ResultEntry,
-	noOfSolves int,
 	scrambles []string,
-) BestEntry {
-	single, average := resultEntry.Single(
-		resultEntry.IsFMC(),
-		scrambles,
-	), resultEntry.Average(
-		noOfSolves,
-		resultEntry.IsFMC(),
-		scrambles,
-	)
+) (BestEntry, error) {
+	single := resultEntry.Single(resultEntry.IsFMC(), scrambles)
+	average, err := resultEntry.Average(resultEntry.IsFMC(), scrambles)
+	if err != nil {
+		return BestEntry{}, err
+	}
+
 	if single < pBest.Single {
 		pBest.Single = single
 	}
@@ -69,7 +68,7 @@ func GetNewBest(
 		pBest.Average = average
 	}
 
-	return pBest
+	return pBest, nil
 }
 
 func ComputeBests(bests map[int]BestEntry, rows []KinchQueryRow) error {
@@ -82,11 +81,11 @@ func ComputeBests(bests map[int]BestEntry, rows []KinchQueryRow) error {
 
 		eid := resultEntry.Eventid
 
-		noOfSolves, err := utils.GetNoOfSolves(resultEntry.Format)
+		best, err := GetNewBest(bests[eid], resultEntry, resultEntry.Scrambles)
 		if err != nil {
 			return err
 		}
-		bests[eid] = GetNewBest(bests[eid], resultEntry, noOfSolves, resultEntry.Scrambles)
+		bests[eid] = best
 	}
 
 	return nil
@@ -136,12 +135,13 @@ func GetScores(
 			continue
 		}
 
-		noOfSolves, err := utils.GetNoOfSolves(resultEntry.Format)
+		format, err := formats.Get(resultEntry.Format)
 		if err != nil {
-			return []CompetitionResult{}, nil
+			return []CompetitionResult{}, err
 		}
 
 		// KINCH RANKS - 4bld, 5bld, mbld sa berie single, 3bld a fmc lepsi z single,average a ostatne average
+		// formaty bez priemeru sa beru single, formaty zoradene podla singlu lepsi z single,average
 		single := resultEntry.Single(resultEntry.IsFMC(), resultEntry.Scrambles)
 		singleContrib := float64(bests[resultEntry.Eventid].Single) / float64(single)
 		ismbld := resultEntry.Iconcode == "333mbf"
@@ -152,7 +152,10 @@ func GetScores(
 			singleContrib = 0.
 		}
 
-		average := resultEntry.Average(noOfSolves, resultEntry.IsFMC(), resultEntry.Scrambles)
+		average, err := resultEntry.Average(resultEntry.IsFMC(), resultEntry.Scrambles)
+		if err != nil {
+			return []CompetitionResult{}, err
+		}
 		averageContrib := float64(bests[resultEntry.Eventid].Average) / float64(average)
 		if average >= constants.VERY_SLOW {
 			averageContrib = 0.
@@ -160,9 +163,10 @@ func GetScores(
 
 		var finalContrib float64 = averageContrib
 		if resultEntry.Iconcode == "444bf" || resultEntry.Iconcode == "555bf" || ismbld ||
-			resultEntry.Format == "bo1" {
+			!format.HasAverage() {
 			finalContrib = singleContrib
-		} else if resultEntry.Iconcode == "333bf" || resultEntry.Iconcode == "333fm" || resultEntry.Iconcode == "unofficial-222bf" {
+		} else if resultEntry.Iconcode == "333bf" || resultEntry.Iconcode == "333fm" || resultEntry.Iconcode == "unofficial-222bf" ||
+			format.RankBy == formats.RankBySingle {
 			finalContrib = math.Max(finalContrib, singleContrib)
 		}
 		eventMap[resultEntry.Eventid] = resultEntry.Iconcode
@@ -233,17 +237,14 @@ func GetKinchQueryRows(rawRows pgx.Rows, db *pgxpool.Pool) ([]KinchQueryRow, err
 			&competitionResult.Username,
 			&competitionResult.CountryName,
 			&competitionResult.CountryIso2,
-			&resultEntry.Solve1,
-			&resultEntry.Solve2,
-			&resultEntry.Solve3,
-			&resultEntry.Solve4,
-			&resultEntry.Solve5,
+			&resultEntry.Solves,
 			&resultEntry.Format,
 			&resultEntry.Status.Visible,
 			&resultEntry.Eventid,
 			&resultEntry.Iconcode,
 			&resultEntry.Eventid,
 			&resultEntry.Competitionid,
+			&resultEntry.Round,
 		)
 		if err != nil {
 			return []KinchQueryRow{}, err
@@ -252,14 +253,16 @@ func GetKinchQueryRows(rawRows pgx.Rows, db *pgxpool.Pool) ([]KinchQueryRow, err
 		if competitionResult.WcaId == "" {
 			competitionResult.WcaId = competitionResult.Username
 		}
+		competitionResult.UserId = resultEntry.Userid
 		competitionResult.EventId = resultEntry.Eventid
 
-		scrambles := make([]string, 5)
+		scrambles := []string{}
 		if resultEntry.IsFMC() {
 			scrambles, err = utils.GetScramblesByResultEntryId(
 				db,
 				resultEntry.Eventid,
 				resultEntry.Competitionid,
+				resultEntry.Round,
 			)
 			if err != nil {
 				return []KinchQueryRow{}, err
@@ -273,6 +276,8 @@ func GetKinchQueryRows(rawRows pgx.Rows, db *pgxpool.Pool) ([]KinchQueryRow, err
 	return rows, nil
 }
 
+const overallResultsQuery = `SELECT u.user_id, u.wcaid, u.name, c.name, c.iso2, r.solves, ce.format, rs.visible, e.event_id, e.iconcode, r.event_id, r.competition_id, r.round FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN continents cont ON c.continent_id = cont.continent_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN events e ON e.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id`
+
 type OverallQueryStruct struct {
 	Query string
 	Args  []any
@@ -280,7 +285,7 @@ type OverallQueryStruct struct {
 
 func ConstructOverallResultsQuery(cid, regionGroup, region string) OverallQueryStruct {
 	var queryStruct OverallQueryStruct
-	queryStruct.Query = `SELECT u.user_id, u.wcaid, u.name, c.name, c.iso2, r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, ce.format, rs.visible, e.event_id, e.iconcode, r.event_id, r.competition_id FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN continents cont ON c.continent_id = cont.continent_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN events e ON e.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id`
+	queryStruct.Query = overallResultsQuery
 	var toAppend string
 	if cid != "" {
 		toAppend += ` WHERE r.competition_id = $1`
@@ -374,52 +379,72 @@ func GetOverallResults(
 	return competitionResults, nil
 }
 
+// GetOverallScoresUntil returns the overall (all competitions) Kinch score by
+// user id, counting only the competitions which ended until the date, without
+// the excluded one
+func GetOverallScoresUntil(db *pgxpool.Pool, until time.Time, excludedCid string) (map[int]string, error) {
+	rawRows, err := db.Query(
+		context.Background(),
+		overallResultsQuery+` JOIN competitions comp ON comp.competition_id = r.competition_id WHERE comp.enddate <= $1 AND r.competition_id <> $2;`,
+		until,
+		excludedCid,
+	)
+	if err != nil {
+		return map[int]string{}, err
+	}
+	rows, err := GetKinchQueryRows(rawRows, db)
+	if err != nil {
+		return map[int]string{}, err
+	}
+
+	events, err := GetAvailableEvents(db)
+	if err != nil {
+		return map[int]string{}, err
+	}
+
+	bests := make(map[int]BestEntry)
+	for _, ev := range events {
+		bests[ev.Id] = BestEntry{constants.DNS, constants.DNS}
+	}
+
+	err = ComputeBests(bests, rows)
+	if err != nil {
+		return map[int]string{}, err
+	}
+
+	competitionResults, err := GetScores(rows, bests, events, len(events), db)
+	if err != nil {
+		return map[int]string{}, err
+	}
+
+	scores := make(map[int]string, len(competitionResults))
+	for _, competitionResult := range competitionResults {
+		scores[competitionResult.UserId] = competitionResult.Score
+	}
+
+	return scores, nil
+}
+
 // compares competition results by format
 // returns:   0 - tie
 //
 //	< 0 - first is smaller
 //	> 0 - second is smaller
 func CompareCompetitionResults(res1 CompetitionResult, res2 CompetitionResult, format string) int {
-	val1, val2 := utils.ParseSolveToMilliseconds(
-		res1.Average,
-		false,
-		"",
-	), utils.ParseSolveToMilliseconds(
-		res2.Average,
-		false,
-		"",
+	f, err := formats.Get(format)
+	if err != nil {
+		return 0
+	}
+
+	val1, tmp1 := f.RankingValues(
+		utils.ParseSolveToMilliseconds(res1.Single, false, ""),
+		utils.ParseSolveToMilliseconds(res1.Average, false, ""),
 	)
-	tmp1, tmp2 := utils.ParseSolveToMilliseconds(
-		res1.Single,
-		false,
-		"",
-	), utils.ParseSolveToMilliseconds(
-		res2.Single,
-		false,
-		"",
+	val2, tmp2 := f.RankingValues(
+		utils.ParseSolveToMilliseconds(res2.Single, false, ""),
+		utils.ParseSolveToMilliseconds(res2.Average, false, ""),
 	)
 
-	if format[0] == 'b' {
-		val1, val2 = utils.ParseSolveToMilliseconds(
-			res1.Single,
-			false,
-			"",
-		), utils.ParseSolveToMilliseconds(
-			res2.Single,
-			false,
-			"",
-		)
-		tmp1, tmp2 = utils.ParseSolveToMilliseconds(
-			res1.Average,
-			false,
-			"",
-		), utils.ParseSolveToMilliseconds(
-			res2.Average,
-			false,
-			"",
-		)
-	}
-
 	if val1 == val2 {
 		val1, val2 = tmp1, tmp2
 	}
@@ -457,6 +482,7 @@ func GetResultsFromCompetitionByEventName(
 	db *pgxpool.Pool,
 	cid string,
 	eid int,
+	round int,
 ) (CompetitionResultStruct, error) {
 	if eid == -1 {
 		competitionResults, err := GetOverallResults(db, cid, "World", "World")
@@ -468,9 +494,10 @@ func GetResultsFromCompetitionByEventName(
 
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT u.name, u.wcaid, c.name, c.iso2, r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, ce.format, rs.visible, e.iconcode, r.event_id, r.competition_id, r.comment, comp.enddate FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN events e ON e.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id JOIN competitions comp ON r.competition_id = comp.competition_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id WHERE r.competition_id = $1 AND r.event_id = $2;`,
+		`SELECT u.user_id, u.name, u.wcaid, c.name, c.iso2, r.solves, ce.format, rs.visible, e.iconcode, r.event_id, r.competition_id, r.round, r.comment, comp.enddate FROM results r JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN events e ON e.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id JOIN competitions comp ON r.competition_id = comp.competition_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id WHERE r.competition_id = $1 AND r.event_id = $2 AND r.round = $3;`,
 		cid,
 		eid,
+		round,
 	)
 	if err != nil {
 		return CompetitionResultStruct{}, nil
@@ -486,20 +513,18 @@ func GetResultsFromCompetitionByEventName(
 		var competitionEnddate time.Time
 
 		err = rows.Scan(
+			&competitionResult.UserId,
 			&competitionResult.Username,
 			&competitionResult.WcaId,
 			&competitionResult.CountryName,
 			&competitionResult.CountryIso2,
-			&resultEntry.Solve1,
-			&resultEntry.Solve2,
-			&resultEntry.Solve3,
-			&resultEntry.Solve4,
-			&resultEntry.Solve5,
+			&resultEntry.Solves,
 			&resultEntry.Format,
 			&resultEntry.Status.Visible,
 			&resultEntry.Iconcode,
 			&resultEntry.Eventid,
 			&resultEntry.Competitionid,
+			&resultEntry.Round,
 			&competitionResult.Comment,
 			&competitionEnddate,
 		)
@@ -519,6 +544,7 @@ func GetResultsFromCompetitionByEventName(
 			db,
 			resultEntry.Eventid,
 			resultEntry.Competitionid,
+			resultEntry.Round,
 		)
 		if err != nil {
 			return CompetitionResultStruct{}, nil
@@ -560,6 +586,19 @@ func GetResultsFromCompetitionByEventName(
 		})
 
 		AddPlacementToCompetitionResults(competitionResults, format)
+
+		rounds, err := GetCompetitionRounds(db, cid)
+		if err != nil {
+			return CompetitionResultStruct{}, err
+		}
+		for _, nextRound := range rounds[eid] {
+			if nextRound.Number == round+1 {
+				err = MarkAdvancing(competitionResults, format, nextRound.Advancement)
+				if err != nil {
+					return CompetitionResultStruct{}, err
+				}
+			}
+		}
 	}
 
 	return CompetitionResultStruct{Results: competitionResults, AnyComment: anyComment}, nil
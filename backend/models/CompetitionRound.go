@@ -0,0 +1,427 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+var (
+	ErrRoundNotFound = errors.New("round not found")
+	ErrInvalidRounds = errors.New("invalid rounds")
+	ErrNotAdvanced   = errors.New("competitor did not advance to the round")
+)
+
+// CompetitionRound is a round of an event after the first one, the first round
+// is the competition event itself. A round is open from its startdate until
+// the next round starts or the competition ends. Advancement decides who of
+// the previous round competes in it.
+type CompetitionRound struct {
+	Number      int                 `json:"number"`
+	Startdate   time.Time           `json:"startdate"`
+	Advancement formats.Advancement `json:"advancement"`
+	Cutoff      formats.Cutoff      `json:"cutoff"`
+	TimeLimit   formats.TimeLimit   `json:"timeLimit"`
+}
+
+func (e *CompetitionEvent) NoOfRounds() int {
+	return len(e.Rounds) + 1
+}
+
+// GetCompetitionRounds returns the rounds after the first one of the events of
+// the competition by event id
+func GetCompetitionRounds(db *pgxpool.Pool, cid string) (map[int][]CompetitionRound, error) {
+	rows, err := db.Query(
+		context.Background(),
+		`SELECT event_id, round, startdate, advancement_type, advancement_level, cutoff_attempts, cutoff_time, time_limit, time_limit_cumulative FROM competition_rounds WHERE competition_id = $1 ORDER BY event_id, round;`,
+		cid,
+	)
+	if err != nil {
+		return map[int][]CompetitionRound{}, err
+	}
+	defer rows.Close()
+
+	rounds := make(map[int][]CompetitionRound)
+	for rows.Next() {
+		var eid int
+		var round CompetitionRound
+		err = rows.Scan(
+			&eid,
+			&round.Number,
+			&round.Startdate,
+			&round.Advancement.Type,
+			&round.Advancement.Level,
+			&round.Cutoff.Attempts,
+			&round.Cutoff.Time,
+			&round.TimeLimit.Time,
+			&round.TimeLimit.Cumulative,
+		)
+		if err != nil {
+			return map[int][]CompetitionRound{}, err
+		}
+
+		rounds[eid] = append(rounds[eid], round)
+	}
+
+	return rounds, rows.Err()
+}
+
+func (e *CompetitionEvent) LoadRounds(db *pgxpool.Pool, cid string) error {
+	rounds, err := GetCompetitionRounds(db, cid)
+	if err != nil {
+		return err
+	}
+
+	e.Rounds = rounds[e.Id]
+	if e.Rounds == nil {
+		e.Rounds = []CompetitionRound{}
+	}
+
+	return nil
+}
+
+// SaveRounds updates the rounds of the event in place and inserts the new
+// ones. The saved advancement to a round is kept unless the round got another
+// startdate or advancement, then it is saved again when it is needed.
+func (e *CompetitionEvent) SaveRounds(tx pgx.Tx, cid string) error {
+	for _, round := range e.Rounds {
+		var roundId int
+		var saved bool
+		err := tx.QueryRow(
+			context.Background(),
+			`INSERT INTO competition_rounds (competition_id, event_id, round, startdate, advancement_type, advancement_level, cutoff_attempts, cutoff_time, time_limit, time_limit_cumulative) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+			ON CONFLICT ON CONSTRAINT competition_event_round_unique DO UPDATE SET
+				advancement_saved = competition_rounds.advancement_saved AND competition_rounds.startdate = EXCLUDED.startdate AND competition_rounds.advancement_type = EXCLUDED.advancement_type AND competition_rounds.advancement_level = EXCLUDED.advancement_level,
+				startdate = EXCLUDED.startdate,
+				advancement_type = EXCLUDED.advancement_type,
+				advancement_level = EXCLUDED.advancement_level,
+				cutoff_attempts = EXCLUDED.cutoff_attempts,
+				cutoff_time = EXCLUDED.cutoff_time,
+				time_limit = EXCLUDED.time_limit,
+				time_limit_cumulative = EXCLUDED.time_limit_cumulative
+			RETURNING competition_round_id, advancement_saved;`,
+			cid,
+			e.Id,
+			round.Number,
+			round.Startdate,
+			round.Advancement.Type,
+			round.Advancement.Level,
+			round.Cutoff.Attempts,
+			round.Cutoff.Time,
+			round.TimeLimit.Time,
+			round.TimeLimit.Cumulative,
+		).Scan(&roundId, &saved)
+		if err != nil {
+			return err
+		}
+
+		if !saved {
+			_, err = tx.Exec(context.Background(), `DELETE FROM competition_round_advancements WHERE competition_round_id = $1;`, roundId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := tx.Exec(context.Background(), `DELETE FROM competition_rounds WHERE competition_id = $1 AND event_id = $2 AND round > $3;`, cid, e.Id, e.NoOfRounds())
+	return err
+}
+
+// DeleteRemovedRounds deletes the rounds of the events removed from the
+// competition and the scrambles and results of all the removed rounds, the
+// first rounds are the competition events themselves and are kept
+func DeleteRemovedRounds(tx pgx.Tx, cid string) error {
+	_, err := tx.Exec(
+		context.Background(),
+		`DELETE FROM competition_rounds cr WHERE cr.competition_id = $1 AND NOT EXISTS (SELECT 1 FROM competition_events ce WHERE ce.competition_id = cr.competition_id AND ce.event_id = cr.event_id);`,
+		cid,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when deleting rounds of removed events", err)
+	}
+
+	for _, table := range []string{"scrambles", "results"} {
+		_, err = tx.Exec(
+			context.Background(),
+			`DELETE FROM `+table+` t WHERE t.competition_id = $1 AND t.round > 1 AND NOT EXISTS (SELECT 1 FROM competition_rounds cr WHERE cr.competition_id = t.competition_id AND cr.event_id = t.event_id AND cr.round = t.round);`,
+			cid,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: when deleting %s of removed rounds", err, table)
+		}
+	}
+
+	return nil
+}
+
+// ValidateRounds checks the rounds are numbered from 2 and start one after
+// another while the competition is running
+func (e *CompetitionEvent) ValidateRounds(startdate time.Time, enddate time.Time) error {
+	format, err := formats.Get(e.Format)
+	if err != nil {
+		return err
+	}
+
+	previousStart := startdate
+	for idx, round := range e.Rounds {
+		if round.Number != idx+2 {
+			return fmt.Errorf("%w: round=%d expected=%d", ErrInvalidRounds, round.Number, idx+2)
+		}
+		if !round.Startdate.After(previousStart) || !round.Startdate.Before(enddate) {
+			return fmt.Errorf("%w: round=%d starts at %s", ErrInvalidRounds, round.Number, round.Startdate)
+		}
+		if err = round.Advancement.Validate(); err != nil {
+			return err
+		}
+		if err = format.ValidateCutoff(round.Cutoff); err != nil {
+			return err
+		}
+		if err = round.TimeLimit.Validate(); err != nil {
+			return err
+		}
+
+		previousStart = round.Startdate
+	}
+
+	return nil
+}
+
+// InRound returns the event with the cutoff and time limit of the round
+func (e CompetitionEvent) InRound(round int) (CompetitionEvent, error) {
+	if round == 1 {
+		return e, nil
+	}
+
+	for _, r := range e.Rounds {
+		if r.Number == round {
+			e.Cutoff = r.Cutoff
+			e.TimeLimit = r.TimeLimit
+			return e, nil
+		}
+	}
+
+	return CompetitionEvent{}, fmt.Errorf("%w: event_id=%d round=%d", ErrRoundNotFound, e.Id, round)
+}
+
+// RoundPeriod returns when the round of the event is open, rounds are loaded
+// into e.Rounds
+func (e *CompetitionEvent) RoundPeriod(competition CompetitionData, round int) (time.Time, time.Time, error) {
+	if round < 1 || round > e.NoOfRounds() {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: event_id=%d round=%d", ErrRoundNotFound, e.Id, round)
+	}
+
+	startdate, enddate := competition.Startdate, competition.Enddate
+	if round > 1 {
+		startdate = e.Rounds[round-2].Startdate
+	}
+	if round < e.NoOfRounds() {
+		enddate = e.Rounds[round-1].Startdate
+	}
+
+	return startdate, enddate, nil
+}
+
+// GetCompetitionEventInRound returns the event of the competition with its
+// rounds and the cutoff and time limit of the round
+func GetCompetitionEventInRound(db *pgxpool.Pool, cid string, eid int, round int) (CompetitionEvent, error) {
+	event, err := GetCompetitionEventById(db, cid, eid)
+	if err != nil {
+		return CompetitionEvent{}, err
+	}
+
+	err = event.LoadRounds(db, cid)
+	if err != nil {
+		return CompetitionEvent{}, err
+	}
+
+	return event.InRound(round)
+}
+
+// MarkAdvancing marks the competitors advancing to the next round, results
+// have to be sorted by placement
+func MarkAdvancing(results []CompetitionResult, format string, advancement formats.Advancement) error {
+	f, err := formats.Get(format)
+	if err != nil {
+		return err
+	}
+
+	standings := make([]formats.Standing, len(results))
+	for idx, result := range results {
+		place, err := strconv.Atoi(strings.TrimSuffix(result.Place, "."))
+		if err != nil || result.Place == "" {
+			// tied with the previous competitor
+			place = idx + 1
+			if idx > 0 {
+				place = standings[idx-1].Place
+			}
+		}
+
+		primary, _ := f.RankingValues(
+			utils.ParseSolveToMilliseconds(result.Single, false, ""),
+			utils.ParseSolveToMilliseconds(result.Average, false, ""),
+		)
+		standings[idx] = formats.Standing{Place: place, Result: primary}
+	}
+
+	advancing := advancement.Advancing(standings)
+	for idx := range results {
+		results[idx].Advancing = idx < advancing
+	}
+
+	return nil
+}
+
+// advancing returns the ids of the competitors of the previous round who
+// advance to the round as marked by MarkAdvancing
+func advancing(db *pgxpool.Pool, cid string, eid int, round int) ([]int, error) {
+	results, err := GetResultsFromCompetitionByEventName(db, cid, eid, round-1)
+	if err != nil {
+		return []int{}, err
+	}
+
+	uids := make([]int, 0)
+	for _, result := range results.Results {
+		if result.Advancing {
+			uids = append(uids, result.UserId)
+		}
+	}
+
+	return uids, nil
+}
+
+// saveAdvancement saves the competitors advancing to the round unless another
+// request saved them first
+func saveAdvancement(db *pgxpool.Pool, roundId int, uids []int) error {
+	ctx := context.Background()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE competition_rounds SET advancement_saved = TRUE WHERE competition_round_id = $1 AND NOT advancement_saved;`, roundId)
+	if err != nil {
+		return fmt.Errorf("%w: when marking advancement of round with id=%d saved", err, roundId)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	for _, uid := range uids {
+		_, err = tx.Exec(ctx, `INSERT INTO competition_round_advancements (competition_round_id, user_id) VALUES ($1, $2);`, roundId, uid)
+		if err != nil {
+			return fmt.Errorf("%w: when inserting advancement of user with id=%d to round with id=%d", err, uid, roundId)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return nil
+}
+
+// ResetAdvancement drops the saved advancement to the round after the results
+// of the previous round changed, it is saved again when it is needed
+func ResetAdvancement(db *pgxpool.Pool, cid string, eid int, round int) error {
+	ctx := context.Background()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var roundId int
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE competition_rounds SET advancement_saved = FALSE WHERE competition_id = $1 AND event_id = $2 AND round = $3 RETURNING competition_round_id;`,
+		cid,
+		eid,
+		round,
+	).Scan(&roundId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: when resetting advancement to round=%d of event_id=%d", err, round, eid)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM competition_round_advancements WHERE competition_round_id = $1;`, roundId)
+	if err != nil {
+		return fmt.Errorf("%w: when deleting advancements to round with id=%d", err, roundId)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return nil
+}
+
+// HasAdvanced reports whether the competitor advanced from the previous round
+// to the round, everybody can compete in the first round. The advancement is
+// saved the first time it is needed after the round opened, before that the
+// previous round can still change and it is computed every time.
+func HasAdvanced(db *pgxpool.Pool, uid int, cid string, eid int, round int) (bool, error) {
+	if round <= 1 {
+		return true, nil
+	}
+
+	ctx := context.Background()
+
+	var roundId int
+	var startdate time.Time
+	var saved bool
+	err := db.QueryRow(
+		ctx,
+		`SELECT competition_round_id, startdate, advancement_saved FROM competition_rounds WHERE competition_id = $1 AND event_id = $2 AND round = $3;`,
+		cid,
+		eid,
+		round,
+	).Scan(&roundId, &startdate, &saved)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("%w: event_id=%d round=%d", ErrRoundNotFound, eid, round)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: when querying round=%d of event_id=%d", err, round, eid)
+	}
+
+	if !saved {
+		uids, err := advancing(db, cid, eid, round)
+		if err != nil {
+			return false, err
+		}
+		if time.Now().Before(startdate) {
+			return slices.Contains(uids, uid), nil
+		}
+
+		if err = saveAdvancement(db, roundId, uids); err != nil {
+			return false, err
+		}
+	}
+
+	var advanced bool
+	err = db.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM competition_round_advancements WHERE competition_round_id = $1 AND user_id = $2);`,
+		roundId,
+		uid,
+	).Scan(&advanced)
+	if err != nil {
+		return false, fmt.Errorf("%w: when querying advancement of user with id=%d to round with id=%d", err, uid, roundId)
+	}
+
+	return advanced, nil
+}
@@ -0,0 +1,269 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+// emails are queued in the outbox and delivered by the worker of the server,
+// failed deliveries are retried with exponential backoff until they run out of
+// attempts
+const (
+	EMAIL_STATUS_PENDING = "pending"
+	EMAIL_STATUS_SENT    = "sent"
+	EMAIL_STATUS_FAILED  = "failed"
+
+	EMAIL_MAX_ATTEMPTS     = 8
+	EMAIL_RETRY_BASE_DELAY = time.Minute
+	EMAIL_RETRY_MAX_DELAY  = 6 * time.Hour
+	// claimed emails are not claimed again for this long, so a crashed worker
+	// does not keep them forever
+	EMAIL_CLAIM_LEASE     = 5 * time.Minute
+	EMAIL_BATCH_SIZE      = 20
+	EMAIL_WORKER_INTERVAL = 10 * time.Second
+)
+
+var ErrOutboxEmailNotFound = errors.New("outbox email not found")
+
+type OutboxEmail struct {
+	Id            int           `json:"id"`
+	UserId        *int          `json:"userId"`
+	Message       email.Message `json:"message"`
+	Status        string        `json:"status"`
+	Attempts      int           `json:"attempts"`
+	LastError     string        `json:"lastError"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	NextAttemptAt time.Time     `json:"nextAttemptAt"`
+	SentAt        *time.Time    `json:"sentAt"`
+}
+
+const outboxEmailColumns = `o.email_id, o.user_id, o.sender, o.recipient, o.subject, o.body, o.headers, o.status, o.attempts, o.last_error, o.created_at, o.next_attempt_at, o.sent_at`
+
+func scanOutboxEmail(row pgx.Row, e *OutboxEmail) error {
+	return row.Scan(
+		&e.Id,
+		&e.UserId,
+		&e.Message.From,
+		&e.Message.To,
+		&e.Message.Subject,
+		&e.Message.Body,
+		&e.Message.Headers,
+		&e.Status,
+		&e.Attempts,
+		&e.LastError,
+		&e.CreatedAt,
+		&e.NextAttemptAt,
+		&e.SentAt,
+	)
+}
+
+// QueueEmail puts the message into the outbox, uid is the recipient user or 0
+// for emails to the admins
+func QueueEmail(ctx context.Context, db interfaces.DB, uid int, msg email.Message) (int, error) {
+	if msg.Headers == nil {
+		msg.Headers = map[string]string{}
+	}
+
+	var id int
+	err := db.QueryRow(
+		ctx,
+		`INSERT INTO email_outbox (user_id, sender, recipient, subject, body, headers) VALUES (NULLIF($1, 0),$2,$3,$4,$5,$6) RETURNING email_id;`,
+		uid,
+		msg.From,
+		msg.To,
+		msg.Subject,
+		msg.Body,
+		msg.Headers,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when queueing email to %s", err, msg.To)
+	}
+
+	return id, nil
+}
+
+// EmailRetryDelay returns how long to wait after the attempt-th failed
+// delivery, the delay doubles with every attempt
+func EmailRetryDelay(attempt int) time.Duration {
+	return utils.Backoff(attempt, EMAIL_RETRY_BASE_DELAY, EMAIL_RETRY_MAX_DELAY)
+}
+
+// claimDueEmails leases the pending emails due for delivery, the lease keeps
+// other workers from sending them at the same time
+func claimDueEmails(ctx context.Context, db interfaces.DB, limit int) ([]OutboxEmail, error) {
+	rows, err := db.Query(
+		ctx,
+		`UPDATE email_outbox o SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $1)
+		WHERE o.email_id IN (
+			SELECT e.email_id FROM email_outbox e
+			WHERE e.status = $2 AND e.next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY e.next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+outboxEmailColumns+`;`,
+		EMAIL_CLAIM_LEASE.Seconds(),
+		EMAIL_STATUS_PENDING,
+		limit,
+	)
+	if err != nil {
+		return []OutboxEmail{}, fmt.Errorf("%w: when claiming due emails", err)
+	}
+	defer rows.Close()
+
+	emails := make([]OutboxEmail, 0)
+	for rows.Next() {
+		var e OutboxEmail
+		if err = scanOutboxEmail(rows, &e); err != nil {
+			return []OutboxEmail{}, fmt.Errorf("%w: when scanning outbox email", err)
+		}
+		emails = append(emails, e)
+	}
+
+	return emails, nil
+}
+
+func markEmailSent(ctx context.Context, db interfaces.DB, id int) error {
+	_, err := db.Exec(
+		ctx,
+		`UPDATE email_outbox SET status = $1, attempts = attempts + 1, last_error = '', sent_at = CURRENT_TIMESTAMP WHERE email_id = $2;`,
+		EMAIL_STATUS_SENT,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when marking email with id=%d as sent", err, id)
+	}
+
+	return nil
+}
+
+// markEmailFailed schedules the next attempt or gives up on the email after
+// EMAIL_MAX_ATTEMPTS
+func markEmailFailed(ctx context.Context, db interfaces.DB, e OutboxEmail, sendErr error) error {
+	attempts := e.Attempts + 1
+	status := EMAIL_STATUS_PENDING
+	if attempts >= EMAIL_MAX_ATTEMPTS {
+		status = EMAIL_STATUS_FAILED
+	}
+
+	_, err := db.Exec(
+		ctx,
+		`UPDATE email_outbox SET status = $1, attempts = $2, last_error = $3, next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $4) WHERE email_id = $5;`,
+		status,
+		attempts,
+		sendErr.Error(),
+		EmailRetryDelay(attempts).Seconds(),
+		e.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when marking email with id=%d as failed", err, e.Id)
+	}
+
+	return nil
+}
+
+// DeliverDueEmails sends the emails due for delivery, a failed email does not
+// stop the others. Returns the number of sent and failed emails.
+func DeliverDueEmails(ctx context.Context, db interfaces.DB, mailer email.Mailer) (int, int, error) {
+	emails, err := claimDueEmails(ctx, db, EMAIL_BATCH_SIZE)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sent, failed := 0, 0
+	for _, e := range emails {
+		if sendErr := mailer.Send(ctx, e.Message); sendErr != nil {
+			slog.Warn("failed to send email", "email_id", e.Id, "to", e.Message.To, "attempt", e.Attempts+1, "error", sendErr)
+			failed++
+			if err = markEmailFailed(ctx, db, e, sendErr); err != nil {
+				return sent, failed, err
+			}
+			continue
+		}
+
+		sent++
+		if err = markEmailSent(ctx, db, e.Id); err != nil {
+			return sent, failed, err
+		}
+	}
+
+	return sent, failed, nil
+}
+
+// RunEmailWorker delivers the outbox until the context is cancelled, full
+// batches are followed by the next one right away
+func RunEmailWorker(ctx context.Context, db interfaces.DB, mailer email.Mailer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sent, failed, err := DeliverDueEmails(ctx, db, mailer)
+		if err != nil {
+			slog.Error("failed to deliver emails", "error", err)
+		}
+
+		if err == nil && sent+failed == EMAIL_BATCH_SIZE && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetOutboxEmails returns the newest emails in the outbox, all of them when
+// the status is empty
+func GetOutboxEmails(ctx context.Context, db interfaces.DB, status string, limit int) ([]OutboxEmail, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT `+outboxEmailColumns+` FROM email_outbox o WHERE $1 = '' OR o.status = $1 ORDER BY o.created_at DESC, o.email_id DESC LIMIT $2;`,
+		status,
+		limit,
+	)
+	if err != nil {
+		return []OutboxEmail{}, fmt.Errorf("%w: when querying outbox emails", err)
+	}
+	defer rows.Close()
+
+	emails := make([]OutboxEmail, 0)
+	for rows.Next() {
+		var e OutboxEmail
+		if err = scanOutboxEmail(rows, &e); err != nil {
+			return []OutboxEmail{}, fmt.Errorf("%w: when scanning outbox email", err)
+		}
+		emails = append(emails, e)
+	}
+
+	return emails, nil
+}
+
+// RetryOutboxEmail queues the failed email again with fresh attempts
+func RetryOutboxEmail(ctx context.Context, db interfaces.DB, id int) error {
+	tag, err := db.Exec(
+		ctx,
+		`UPDATE email_outbox SET status = $1, attempts = 0, next_attempt_at = CURRENT_TIMESTAMP WHERE email_id = $2 AND status = $3;`,
+		EMAIL_STATUS_PENDING,
+		id,
+		EMAIL_STATUS_FAILED,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when retrying email with id=%d", err, id)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: failed email with id=%d", ErrOutboxEmailNotFound, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
+)
+
+// login links let people without a WCA account log in by the email, the link
+// carries a one-time token
+const (
+	LOGIN_LINK_TTL             = 15 * time.Minute
+	MAX_LOGIN_LINKS_PER_HOUR   = 5
+	DEFAULT_LOGIN_LINK_COUNTRY = "Slovakia"
+)
+
+var (
+	ErrInvalidEmail      = errors.New("invalid email")
+	ErrNameRequired      = errors.New("name is required for new users")
+	ErrUserNameTaken     = errors.New("user name is taken")
+	ErrTooManyLoginLinks = errors.New("too many login links")
+	ErrLoginLinkInvalid  = errors.New("login link is invalid, used or expired")
+)
+
+func parseEmail(address string) (string, error) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil || parsed.Name != "" {
+		return "", ErrInvalidEmail
+	}
+
+	return parsed.Address, nil
+}
+
+// getUserByEmail prefers the user with the linked WCA account, emails of the
+// users are not unique
+func getUserByEmail(ctx context.Context, db interfaces.DB, email string) (User, bool, error) {
+	var user User
+	err := db.QueryRow(
+		ctx,
+		`SELECT u.user_id, u.name, u.country_id, u.sex, u.wcaid, u.isadmin, u.url, u.avatarurl, u.email, u.language FROM users u WHERE lower(u.email) = lower($1) ORDER BY u.wcaid = '', u.user_id LIMIT 1;`,
+		email,
+	).Scan(&user.Id, &user.Name, &user.CountryId, &user.Sex, &user.WcaId, &user.IsAdmin, &user.Url, &user.AvatarUrl, &user.Email, &user.Language)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, false, nil
+	}
+	if err != nil {
+		return User{}, false, fmt.Errorf("%w: when getting user by email", err)
+	}
+
+	return user, true, nil
+}
+
+// CreateLoginLink returns the token of a new login link for the email and the
+// language to send it in, the same for the registered emails and the new ones.
+// New users fill in their name after opening the link.
+func CreateLoginLink(ctx context.Context, db interfaces.DB, email string, language string) (string, string, error) {
+	email, err := parseEmail(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, exists, err := getUserByEmail(ctx, db, email)
+	if err != nil {
+		return "", "", err
+	}
+
+	if exists {
+		language = user.Language
+	} else {
+		language = templates.Language(language)
+	}
+
+	var noOfLinks int
+	err = db.QueryRow(
+		ctx,
+		`SELECT COUNT(*) FROM login_links l WHERE lower(l.email) = lower($1) AND l.created_at > CURRENT_TIMESTAMP - INTERVAL '1 hour';`,
+		email,
+	).Scan(&noOfLinks)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: when counting login links", err)
+	}
+	if noOfLinks >= MAX_LOGIN_LINKS_PER_HOUR {
+		return "", "", ErrTooManyLoginLinks
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = db.Exec(
+		ctx,
+		`INSERT INTO login_links (email, language, token_hash, expires_at) VALUES ($1,$2,$3,CURRENT_TIMESTAMP + make_interval(secs => $4));`,
+		email,
+		language,
+		hashToken(token),
+		LOGIN_LINK_TTL.Seconds(),
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: when inserting login link", err)
+	}
+
+	return token, language, nil
+}
+
+// LoginLinkCredentials are sent when opening the login link, the name (and
+// the country) only when the email has no user yet
+type LoginLinkCredentials struct {
+	Token     string `json:"token"`
+	Name      string `json:"name"`
+	CountryId string `json:"countryId"`
+}
+
+// EmailLoginProvider logs in by the login links sent to the email, the
+// credentials are LoginLinkCredentials in JSON
+type EmailLoginProvider struct{}
+
+// useLoginLink marks the link used, it fails if it was used in the meantime
+func useLoginLink(ctx context.Context, db interfaces.DB, tokenHash string) error {
+	tag, err := db.Exec(
+		ctx,
+		`UPDATE login_links SET used_at = CURRENT_TIMESTAMP WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP;`,
+		tokenHash,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when using login link", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLoginLinkInvalid
+	}
+
+	return nil
+}
+
+// Login logs in the user with the email of the link. A new user is registered
+// with the name from the credentials, without it ErrNameRequired is returned
+// and the link stays valid.
+func (EmailLoginProvider) Login(ctx context.Context, db interfaces.DB, credentials string) (User, bool, error) {
+	var creds LoginLinkCredentials
+	if err := json.Unmarshal([]byte(credentials), &creds); err != nil {
+		return User{}, false, ErrLoginLinkInvalid
+	}
+	tokenHash := hashToken(strings.TrimSpace(creds.Token))
+
+	var user User
+	err := db.QueryRow(
+		ctx,
+		`SELECT email, language FROM login_links WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP;`,
+		tokenHash,
+	).Scan(&user.Email, &user.Language)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, false, ErrLoginLinkInvalid
+	}
+	if err != nil {
+		return User{}, false, fmt.Errorf("%w: when getting login link", err)
+	}
+
+	existingUser, exists, err := getUserByEmail(ctx, db, user.Email)
+	if err != nil {
+		return User{}, false, err
+	}
+	if exists {
+		if err = useLoginLink(ctx, db, tokenHash); err != nil {
+			return User{}, false, err
+		}
+		return existingUser, false, nil
+	}
+
+	user.Name = strings.Join(strings.Fields(creds.Name), " ")
+	if user.Name == "" {
+		return User{}, false, ErrNameRequired
+	}
+	user.CountryId = creds.CountryId
+	if user.CountryId == "" {
+		user.CountryId = DEFAULT_LOGIN_LINK_COUNTRY
+	}
+	user.LoginProvider = LOGIN_PROVIDER_EMAIL
+
+	var taken bool
+	err = db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users u WHERE u.wcaid = '' AND u.name = $1);`, user.Name).Scan(&taken)
+	if err != nil {
+		return User{}, false, fmt.Errorf("%w: when checking if name %s is taken", err, user.Name)
+	}
+	if taken {
+		return User{}, false, ErrUserNameTaken
+	}
+
+	if err = useLoginLink(ctx, db, tokenHash); err != nil {
+		return User{}, false, err
+	}
+
+	if err = user.Insert(ctx, db); err != nil {
+		return User{}, false, fmt.Errorf("%w: when inserting user=%+v", err, user)
+	}
+
+	return user, true, nil
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
+)
+
+const (
+	LOGIN_PROVIDER_WCA   = "wca"
+	LOGIN_PROVIDER_EMAIL = "email"
+)
+
+var (
+	ErrUserNotFound            = errors.New("user not found")
+	ErrWCAAccountAlreadyLinked = errors.New("user already has a linked wca account")
+	ErrWCAAccountInUse         = errors.New("wca account belongs to another user")
+)
+
+// LoginProvider turns the credentials sent to the login endpoint of the
+// provider into the user, the session is then started the same way for all of
+// them
+type LoginProvider interface {
+	// Login returns the user and whether they were registered just now
+	Login(ctx context.Context, db interfaces.DB, credentials string) (User, bool, error)
+}
+
+// WCALoginProvider logs in through the WCA OAuth, the credentials are the
+// authorization code
+type WCALoginProvider struct {
+	client wca.Client
+}
+
+func NewWCALoginProvider(client wca.Client) WCALoginProvider {
+	return WCALoginProvider{client: client}
+}
+
+func (p WCALoginProvider) Login(ctx context.Context, db interfaces.DB, code string) (User, bool, error) {
+	user, err := GetWCAUser(ctx, p.client, code)
+	if err != nil {
+		return User{}, false, err
+	}
+
+	exists, err := user.Exists(ctx, db)
+	if err != nil {
+		return User{}, false, fmt.Errorf("%w: when checking existance of user=%+v", err, user)
+	}
+
+	if exists {
+		if err = user.Update(db); err != nil {
+			return User{}, false, fmt.Errorf("%w: when updating user=%+v", err, user)
+		}
+		return user, false, nil
+	}
+
+	if err = user.Insert(ctx, db); err != nil {
+		return User{}, false, fmt.Errorf("%w: when inserting user=%+v", err, user)
+	}
+
+	return user, true, nil
+}
+
+// GetWCAUser exchanges the authorization code for the WCA profile of the user
+func GetWCAUser(ctx context.Context, client wca.Client, code string) (User, error) {
+	token, err := client.ExchangeCode(ctx, code)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: when getting auth info from wca", err)
+	}
+
+	me, err := client.GetMe(ctx, token.AccessToken)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: when getting user info from wca", err)
+	}
+	if me.Name == "" {
+		return User{}, fmt.Errorf("empty user info from wca")
+	}
+
+	return User{
+		Name:          me.Name,
+		CountryId:     me.Country.Id,
+		Sex:           me.Gender,
+		WcaId:         me.WcaId,
+		Url:           me.Url,
+		AvatarUrl:     me.Avatar.Url,
+		Email:         me.Email,
+		LoginProvider: LOGIN_PROVIDER_WCA,
+	}, nil
+}
+
+// LinkWCAAccount attaches the WCA profile to the user registered without it,
+// the next WCA login then finds the same user. The email of the user is kept,
+// so they can still log in by the email.
+func LinkWCAAccount(ctx context.Context, db interfaces.DB, uid int, wcaUser User) (User, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var wcaId string
+	err = tx.QueryRow(ctx, `SELECT u.wcaid FROM users u WHERE u.user_id = $1 FOR UPDATE;`, uid).Scan(&wcaId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, fmt.Errorf("%w: id=%d", ErrUserNotFound, uid)
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("%w: when getting user with id=%d", err, uid)
+	}
+	if wcaId != "" {
+		return User{}, ErrWCAAccountAlreadyLinked
+	}
+
+	var inUse bool
+	err = tx.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM users u WHERE u.user_id <> $1 AND ((u.wcaid = $2 AND u.name = $3 AND u.login_provider = $4) OR (u.wcaid <> '' AND u.wcaid = $2)));`,
+		uid,
+		wcaUser.WcaId,
+		wcaUser.Name,
+		LOGIN_PROVIDER_WCA,
+	).Scan(&inUse)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: when checking owner of wca account %s", err, wcaUser.WcaId)
+	}
+	if inUse {
+		return User{}, ErrWCAAccountInUse
+	}
+
+	user := wcaUser
+	user.Id = uid
+	user.LoginProvider = LOGIN_PROVIDER_WCA
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE users SET name = $1, country_id = $2, sex = $3, wcaid = $4, url = $5, avatarurl = $6, email = COALESCE(NULLIF(email, ''), $7), login_provider = $9, timestamp = CURRENT_TIMESTAMP WHERE user_id = $8 RETURNING isadmin, email;`,
+		wcaUser.Name,
+		wcaUser.CountryId,
+		wcaUser.Sex,
+		wcaUser.WcaId,
+		wcaUser.Url,
+		wcaUser.AvatarUrl,
+		wcaUser.Email,
+		uid,
+		LOGIN_PROVIDER_WCA,
+	).Scan(&user.IsAdmin, &user.Email)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: when linking wca account of user with id=%d", err, uid)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return User{}, fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return user, nil
+}
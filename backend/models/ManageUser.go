@@ -8,22 +8,18 @@ import (
 )
 
 type ManageUser struct {
-	Id          int    `json:"id"`
-	Name        string `json:"name"`
-	WcaId       string `json:"wca_id"`
-	IsAdmin     bool   `json:"is_admin"`
-	CountryName string `json:"country_name"`
-	CountryIso2 string `json:"country_iso2"`
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	WcaId       string   `json:"wca_id"`
+	IsAdmin     bool     `json:"is_admin"`
+	Roles       []string `json:"roles"`
+	CountryName string   `json:"country_name"`
+	CountryIso2 string   `json:"country_iso2"`
 }
 
 func (u *ManageUser) UpdateRole(ctx context.Context, db interfaces.DB) error {
-	_, err := db.Exec(
-		ctx,
-		`UPDATE users SET isadmin = $1 WHERE user_id = $2;`,
-		u.IsAdmin, u.Id,
-	)
-	if err != nil {
-		return fmt.Errorf("%w: when executing updating user role, user_id=%d, isadmin=%t", err, u.Id, u.IsAdmin)
+	if err := SetUserRoles(ctx, db, u.Id, u.Roles); err != nil {
+		return fmt.Errorf("%w: when executing updating user roles, user_id=%d, roles=%v", err, u.Id, u.Roles)
 	}
 
 	return nil
@@ -31,10 +27,13 @@ func (u *ManageUser) UpdateRole(ctx context.Context, db interfaces.DB) error {
 
 func ViewManageUsers(ctx context.Context, db interfaces.DB) ([]ManageUser, error) {
 	rows, err := db.Query(ctx, `
-		SELECT u.user_id, u.name, u.wcaid, u.isadmin, c.name, c.iso2 
+		SELECT u.user_id, u.name, u.wcaid, u.isadmin, COALESCE(array_agg(r.name ORDER BY r.role_id) FILTER (WHERE r.name IS NOT NULL), '{}'), c.name, c.iso2
 		FROM users u
 		JOIN countries c ON c.country_id = u.country_id
-		ORDER BY timestamp`,
+		LEFT JOIN user_roles ur ON ur.user_id = u.user_id
+		LEFT JOIN roles r ON r.role_id = ur.role_id
+		GROUP BY u.user_id, c.name, c.iso2
+		ORDER BY u.timestamp`,
 	)
 	if err != nil {
 		return []ManageUser{}, fmt.Errorf("%w: when querying users", err)
@@ -44,7 +43,7 @@ func ViewManageUsers(ctx context.Context, db interfaces.DB) ([]ManageUser, error
 	manageUsers := make([]ManageUser, 0)
 	for rows.Next() {
 		manageUser := ManageUser{}
-		err := rows.Scan(&manageUser.Id, &manageUser.Name, &manageUser.WcaId, &manageUser.IsAdmin, &manageUser.CountryName, &manageUser.CountryIso2)
+		err := rows.Scan(&manageUser.Id, &manageUser.Name, &manageUser.WcaId, &manageUser.IsAdmin, &manageUser.Roles, &manageUser.CountryName, &manageUser.CountryIso2)
 		if err != nil {
 			return []ManageUser{}, fmt.Errorf("%w: when scanning user", err)
 		}
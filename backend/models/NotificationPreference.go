@@ -0,0 +1,253 @@
+package models
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+// notification emails are sent only for the categories the user has on, the
+// opt in categories are off until the user turns them on. Every email carries
+// a signed link turning its category off.
+const (
+	NOTIFICATION_WCA_COMPETITIONS   = "wca_competitions"
+	NOTIFICATION_WEEKLY_COMPETITION = "weekly_competition"
+	NOTIFICATION_RESULTS_APPROVED   = "results_approved"
+	NOTIFICATION_PERSONAL_RECORD    = "personal_record"
+	NOTIFICATION_WEEKLY_DIGEST      = "weekly_digest"
+	NOTIFICATION_WCA_REMINDERS      = "wca_reminders"
+)
+
+var NOTIFICATION_CATEGORIES = []string{
+	NOTIFICATION_WCA_COMPETITIONS,
+	NOTIFICATION_WEEKLY_COMPETITION,
+	NOTIFICATION_RESULTS_APPROVED,
+	NOTIFICATION_PERSONAL_RECORD,
+	NOTIFICATION_WEEKLY_DIGEST,
+	NOTIFICATION_WCA_REMINDERS,
+}
+
+var OPT_IN_NOTIFICATION_CATEGORIES = []string{
+	NOTIFICATION_WEEKLY_COMPETITION,
+	NOTIFICATION_PERSONAL_RECORD,
+	NOTIFICATION_WEEKLY_DIGEST,
+}
+
+var (
+	ErrUnknownNotificationCategory = errors.New("unknown notification category")
+	ErrInvalidUnsubscribeToken     = errors.New("invalid unsubscribe token")
+)
+
+type NotificationPreference struct {
+	Category string `json:"category"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// IsNotificationEnabledByDefault tells whether the category is on for the
+// users who did not choose
+func IsNotificationEnabledByDefault(category string) bool {
+	return !slices.Contains(OPT_IN_NOTIFICATION_CATEGORIES, category)
+}
+
+// GetNotificationPreferences returns the preference of the user for every
+// category, in the order of NOTIFICATION_CATEGORIES. Users merged together may
+// have more choices of the same category, the latest one wins.
+func GetNotificationPreferences(ctx context.Context, db interfaces.DB, uid int) ([]NotificationPreference, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT DISTINCT ON (category) category, enabled FROM notification_preferences WHERE user_id = $1 ORDER BY category, notification_preference_id DESC;`,
+		uid,
+	)
+	if err != nil {
+		return []NotificationPreference{}, fmt.Errorf("%w: when querying notification preferences of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	chosen := make(map[string]bool)
+	for rows.Next() {
+		var preference NotificationPreference
+		if err = rows.Scan(&preference.Category, &preference.Enabled); err != nil {
+			return []NotificationPreference{}, fmt.Errorf("%w: when scanning notification preference", err)
+		}
+		chosen[preference.Category] = preference.Enabled
+	}
+	if err = rows.Err(); err != nil {
+		return []NotificationPreference{}, fmt.Errorf("%w: when iterating through notification preferences", err)
+	}
+
+	preferences := make([]NotificationPreference, 0, len(NOTIFICATION_CATEGORIES))
+	for _, category := range NOTIFICATION_CATEGORIES {
+		enabled, ok := chosen[category]
+		if !ok {
+			enabled = IsNotificationEnabledByDefault(category)
+		}
+		preferences = append(preferences, NotificationPreference{Category: category, Enabled: enabled})
+	}
+
+	return preferences, nil
+}
+
+func IsNotificationEnabled(ctx context.Context, db interfaces.DB, uid int, category string) (bool, error) {
+	var enabled bool
+	err := db.QueryRow(
+		ctx,
+		`SELECT enabled FROM notification_preferences WHERE user_id = $1 AND category = $2 ORDER BY notification_preference_id DESC LIMIT 1;`,
+		uid,
+		category,
+	).Scan(&enabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return IsNotificationEnabledByDefault(category), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: when checking notification preference of user with id=%d", err, uid)
+	}
+
+	return enabled, nil
+}
+
+// GetUsersWithNotificationEnabled returns the users who have the category on,
+// the users who did not choose get the default of the category
+func GetUsersWithNotificationEnabled(ctx context.Context, db interfaces.DB, category string) ([]int, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT u.user_id FROM users u LEFT JOIN LATERAL (SELECT np.enabled FROM notification_preferences np WHERE np.user_id = u.user_id AND np.category = $1 ORDER BY np.notification_preference_id DESC LIMIT 1) p ON TRUE WHERE COALESCE(p.enabled, $2) ORDER BY u.user_id;`,
+		category,
+		IsNotificationEnabledByDefault(category),
+	)
+	if err != nil {
+		return []int{}, fmt.Errorf("%w: when querying users with notification category=%s on", err, category)
+	}
+	defer rows.Close()
+
+	uids := make([]int, 0)
+	for rows.Next() {
+		var uid int
+		if err = rows.Scan(&uid); err != nil {
+			return []int{}, fmt.Errorf("%w: when scanning user", err)
+		}
+		uids = append(uids, uid)
+	}
+	if err = rows.Err(); err != nil {
+		return []int{}, fmt.Errorf("%w: when iterating through users", err)
+	}
+
+	return uids, nil
+}
+
+// SetNotificationPreference turns the category on or off for the user
+func SetNotificationPreference(ctx context.Context, db interfaces.DB, uid int, category string, enabled bool) error {
+	if !slices.Contains(NOTIFICATION_CATEGORIES, category) {
+		return fmt.Errorf("%w: %s", ErrUnknownNotificationCategory, category)
+	}
+
+	_, err := db.Exec(
+		ctx,
+		`WITH deleted AS (DELETE FROM notification_preferences WHERE user_id = $1 AND category = $2)
+		INSERT INTO notification_preferences (user_id, category, enabled) VALUES ($1,$2,$3);`,
+		uid,
+		category,
+		enabled,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when setting notification preference of user with id=%d", err, uid)
+	}
+
+	return nil
+}
+
+func unsubscribeSignature(payload string, secretKey string) string {
+	mac := hmac.New(sha256.New, []byte("unsubscribe:"+secretKey))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CreateUnsubscribeToken signs the user and the category, the token does not
+// expire so the links in old emails keep working
+func CreateUnsubscribeToken(uid int, category string, secretKey string) string {
+	payload := strconv.Itoa(uid) + "." + category
+	return payload + "." + unsubscribeSignature(payload, secretKey)
+}
+
+// ParseUnsubscribeToken returns the user and the category of the token
+func ParseUnsubscribeToken(token string, secretKey string) (int, string, error) {
+	idx := strings.LastIndex(token, ".")
+	if idx == -1 {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	payload, signature := token[:idx], token[idx+1:]
+	if !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(payload, secretKey))) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	uidString, category, ok := strings.Cut(payload, ".")
+	if !ok || !slices.Contains(NOTIFICATION_CATEGORIES, category) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	uid, err := strconv.Atoi(uidString)
+	if err != nil {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	return uid, category, nil
+}
+
+// UnsubscribeUrl is the page linked from the body of the email
+func UnsubscribeUrl(envMap map[string]string, uid int, category string) string {
+	return envMap["WEBSITE_HOME"] + "/unsubscribe?token=" + url.QueryEscape(CreateUnsubscribeToken(uid, category, envMap["JWT_SECRET_KEY"]))
+}
+
+// UnsubscribeHeaders let the mail clients unsubscribe in one click (RFC 8058)
+func UnsubscribeHeaders(envMap map[string]string, uid int, category string) map[string]string {
+	link := envMap["WEBSITE_HOME"] + "/api/notifications/unsubscribe?token=" + url.QueryEscape(CreateUnsubscribeToken(uid, category, envMap["JWT_SECRET_KEY"]))
+
+	return map[string]string{
+		"List-Unsubscribe":      "<" + link + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// QueueNotificationEmail queues the email of the category to the user if they
+// have it on, returns whether the email was queued
+func QueueNotificationEmail(
+	ctx context.Context,
+	db interfaces.DB,
+	envMap map[string]string,
+	uid int,
+	category string,
+	msg email.Message,
+) (bool, error) {
+	enabled, err := IsNotificationEnabled(ctx, db, uid, category)
+	if err != nil {
+		return false, err
+	}
+	if !enabled {
+		return false, nil
+	}
+
+	if msg.Headers == nil {
+		msg.Headers = map[string]string{}
+	}
+	for key, value := range UnsubscribeHeaders(envMap, uid, category) {
+		msg.Headers[key] = value
+	}
+
+	if _, err = QueueEmail(ctx, db, uid, msg); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
 )
 
@@ -59,6 +60,7 @@ type ProfileTypeResultHistoryEntry struct {
 	CompetitionId      string    `json:"competitionId"`
 	CompetitionName    string    `json:"competitionName"`
 	CompetitionEnddate time.Time `json:"-"`
+	Round              int       `json:"round"`
 	Place              string    `json:"place"`
 	Single             string    `json:"single"`
 	Average            string    `json:"average"`
@@ -88,7 +90,7 @@ type ProfileType struct {
 func GetNoOfCompetitions(db *pgxpool.Pool, uid int) (int, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT COUNT(*) FROM (SELECT r.competition_id FROM results r WHERE r.user_id = $1 AND ((r.solve1 NOT LIKE 'DNS' AND r.solve1 NOT LIKE 'DNF') OR (r.solve2 NOT LIKE 'DNS' AND r.solve2 NOT LIKE 'DNF') OR (r.solve3 NOT LIKE 'DNS' AND r.solve3 NOT LIKE 'DNF') OR (r.solve4 NOT LIKE 'DNS' AND r.solve4 NOT LIKE 'DNF') OR (r.solve5 NOT LIKE 'DNS' AND r.solve5 NOT LIKE 'DNF')) GROUP BY r.competition_id);`,
+		`SELECT COUNT(*) FROM (SELECT r.competition_id FROM results r WHERE r.user_id = $1 AND EXISTS (SELECT 1 FROM unnest(r.solves) s WHERE s NOT LIKE 'DNS' AND s NOT LIKE 'DNF') GROUP BY r.competition_id);`,
 		uid,
 	)
 	if err != nil {
@@ -109,7 +111,7 @@ func GetNoOfCompetitions(db *pgxpool.Pool, uid int) (int, error) {
 func GetCompletedSolves(db *pgxpool.Pool, uid int) (int, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT solve1, solve2, solve3, solve4, solve5 FROM results r WHERE r.user_id = $1;`,
+		`SELECT solves FROM results r WHERE r.user_id = $1;`,
 		uid,
 	)
 	if err != nil {
@@ -118,8 +120,8 @@ func GetCompletedSolves(db *pgxpool.Pool, uid int) (int, error) {
 
 	completedSolves := 0
 	for rows.Next() {
-		solves := make([]string, 5)
-		err = rows.Scan(&solves[0], &solves[1], &solves[2], &solves[3], &solves[4])
+		var solves []string
+		err = rows.Scan(&solves)
 		if err != nil {
 			return 0, err
 		}
@@ -192,12 +194,13 @@ func LoadBestSingleAndAverage(
 
 	for _, resultEntry := range *resultEntries {
 		isfmc := resultEntry.IsFMC()
-		scrambles := make([]string, 5)
+		scrambles := []string{}
 		if isfmc {
 			scrambles, err = utils.GetScramblesByResultEntryId(
 				db,
 				resultEntry.Eventid,
 				resultEntry.Competitionid,
+				resultEntry.RoundNumber(),
 			)
 			if err != nil {
 				return "", "", err
@@ -245,12 +248,13 @@ func ProcessResultEntryToLoadRank(
 	var err error
 
 	isfmc := resultEntry.IsFMC()
-	scrambles := make([]string, 5)
+	scrambles := []string{}
 	if isfmc {
 		scrambles, err = utils.GetScramblesByResultEntryId(
 			db,
 			resultEntry.Eventid,
 			resultEntry.Competitionid,
+			resultEntry.RoundNumber(),
 		)
 		if err != nil {
 			return err
@@ -406,7 +410,7 @@ type EventResultsRow struct {
 func LoadEventRows(db *pgxpool.Pool, eid int) ([]EventResultsRow, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT r.user_id, r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, c.enddate, ce.format, e.iconcode, r.event_id, r.competition_id, countries.continent_id, u.country_id, c.name, u.name, u.wcaid, countries.country_id, countries.iso2, rs.visible FROM results r JOIN competitions c ON c.competition_id = r.competition_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN users u ON u.user_id = r.user_id JOIN events e ON e.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id JOIN countries countries ON countries.country_id = u.country_id WHERE rs.visible IS TRUE AND r.event_id = $1 ORDER BY c.enddate DESC;`,
+		`SELECT r.user_id, r.round, r.solves, c.enddate, ce.format, e.iconcode, r.event_id, r.competition_id, countries.continent_id, u.country_id, c.name, u.name, u.wcaid, countries.country_id, countries.iso2, rs.visible FROM results r JOIN competitions c ON c.competition_id = r.competition_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN users u ON u.user_id = r.user_id JOIN events e ON e.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id JOIN countries countries ON countries.country_id = u.country_id WHERE rs.visible IS TRUE AND r.event_id = $1 ORDER BY c.enddate DESC, r.competition_id, r.round DESC;`,
 		eid,
 	)
 	if err != nil {
@@ -418,11 +422,8 @@ func LoadEventRows(db *pgxpool.Pool, eid int) ([]EventResultsRow, error) {
 		var eventResultsRow EventResultsRow
 		err := rows.Scan(
 			&eventResultsRow.ResultEntry.Userid,
-			&eventResultsRow.ResultEntry.Solve1,
-			&eventResultsRow.ResultEntry.Solve2,
-			&eventResultsRow.ResultEntry.Solve3,
-			&eventResultsRow.ResultEntry.Solve4,
-			&eventResultsRow.ResultEntry.Solve5,
+			&eventResultsRow.ResultEntry.Round,
+			&eventResultsRow.ResultEntry.Solves,
 			&eventResultsRow.Date,
 			&eventResultsRow.ResultEntry.Format,
 			&eventResultsRow.ResultEntry.Iconcode,
@@ -515,7 +516,7 @@ func (p *ProfileTypePersonalBests) ClearAverage() {
 func GetPersonalResultEntriesInEvent(db *pgxpool.Pool, uid int, eid int) ([]ResultEntry, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT r.solve1, r.solve2, r.solve3, r.solve4, r.solve5, ce.format, e.iconcode, r.event_id, r.competition_id FROM results r JOIN events e ON e.event_id = r.event_id JOIN competition_events ce ON ce.event_id = e.event_id AND ce.competition_id = r.competition_id JOIN results_status rs ON rs.results_status_id = r.status_id WHERE r.user_id = $1 AND r.event_id = $2 AND rs.visible IS TRUE;`,
+		`SELECT r.solves, ce.format, e.iconcode, r.event_id, r.competition_id, r.round FROM results r JOIN events e ON e.event_id = r.event_id JOIN competition_events ce ON ce.event_id = e.event_id AND ce.competition_id = r.competition_id JOIN results_status rs ON rs.results_status_id = r.status_id WHERE r.user_id = $1 AND r.event_id = $2 AND rs.visible IS TRUE;`,
 		uid,
 		eid,
 	)
@@ -528,15 +529,12 @@ func GetPersonalResultEntriesInEvent(db *pgxpool.Pool, uid int, eid int) ([]Resu
 	for rows.Next() {
 		var resultEntry ResultEntry
 		err = rows.Scan(
-			&resultEntry.Solve1,
-			&resultEntry.Solve2,
-			&resultEntry.Solve3,
-			&resultEntry.Solve4,
-			&resultEntry.Solve5,
+			&resultEntry.Solves,
 			&resultEntry.Format,
 			&resultEntry.Iconcode,
 			&resultEntry.Eventid,
 			&resultEntry.Competitionid,
+			&resultEntry.Round,
 		)
 		if err != nil {
 			return []ResultEntry{}, err
@@ -665,12 +663,13 @@ func GetResultsFromCompetitionFromRows(
 			continue
 		}
 
-		scrambles := make([]string, 5)
+		scrambles := []string{}
 		if resultEntry.IsFMC() {
 			scrambles, err = utils.GetScramblesByResultEntryId(
 				db,
 				resultEntry.Eventid,
 				resultEntry.Competitionid,
+				resultEntry.RoundNumber(),
 			)
 			if err != nil {
 				return []CompetitionResult{}, err
@@ -858,16 +857,17 @@ func (p *ProfileType) CreateEventHistoryForUser(
 	for curIdx, row := range rows {
 		resultEntry := row.ResultEntry
 
-		hasAverage := resultEntry.Iconcode == "333mbf" || resultEntry.Format == "bo1"
+		hasAverage := resultEntry.HasAverage()
 		hasUser = hasUser || resultEntry.Userid == user.Id
 		if resultEntry.Userid == user.Id {
 			isfmc := resultEntry.IsFMC()
-			scrambles := make([]string, 5)
+			scrambles := []string{}
 			if isfmc {
 				scrambles, err = utils.GetScramblesByResultEntryId(
 					db,
 					resultEntry.Eventid,
 					resultEntry.Competitionid,
+					resultEntry.RoundNumber(),
 				)
 				if err != nil {
 					return err
@@ -876,12 +876,13 @@ func (p *ProfileType) CreateEventHistoryForUser(
 
 			historyEntry.CompetitionId = resultEntry.Competitionid
 			historyEntry.CompetitionName = resultEntry.Competitionname
+			historyEntry.Round = resultEntry.RoundNumber()
 			historyEntry.Single = resultEntry.SingleFormatted(resultEntry.IsFMC(), scrambles)
 			if historyEntry.Single == "DNS" {
 				hasUser = false
 				continue
 			}
-			if !hasAverage {
+			if hasAverage {
 				historyEntry.Average, err = resultEntry.AverageFormatted(
 					resultEntry.IsFMC(),
 					scrambles,
@@ -897,7 +898,12 @@ func (p *ProfileType) CreateEventHistoryForUser(
 		}
 
 		if curIdx == len(rows)-1 ||
-			resultEntry.Competitionid != rows[curIdx+1].ResultEntry.Competitionid {
+			resultEntry.Competitionid != rows[curIdx+1].ResultEntry.Competitionid ||
+			resultEntry.Round != rows[curIdx+1].ResultEntry.Round {
+			// rounds of a competition go from the last one, only the last round
+			// the user competed in decides the medals
+			isFinal := len(history.History) == 0 ||
+				history.History[len(history.History)-1].CompetitionId != resultEntry.Competitionid
 			if hasUser {
 				historyEntry.Place, err = ComputePlacementForCompetition(
 					&rows,
@@ -911,8 +917,18 @@ func (p *ProfileType) CreateEventHistoryForUser(
 					return err
 				}
 
-				canIncreaseMedalCount := (len(resultEntry.Format) > 0 && resultEntry.Format[0] == 'b' && utils.ParseSolveToMilliseconds(historyEntry.Single, false, "") < constants.VERY_SLOW) ||
-					(!hasAverage && len(resultEntry.Format) > 0 && resultEntry.Format[0] != 'b' && utils.ParseSolveToMilliseconds(historyEntry.Average, false, "") < constants.VERY_SLOW)
+				var format formats.Format
+				format, err = formats.Get(resultEntry.Format)
+				if err != nil {
+					return err
+				}
+
+				ranked := historyEntry.Single
+				if format.RankBy == formats.RankByAverage {
+					ranked = historyEntry.Average
+				}
+				canIncreaseMedalCount := isFinal && (format.RankBy == formats.RankBySingle || hasAverage) &&
+					utils.ParseSolveToMilliseconds(ranked, false, "") < constants.VERY_SLOW
 				if canIncreaseMedalCount {
 					switch historyEntry.Place {
 					case "1":
@@ -1141,12 +1157,13 @@ func (p *ProfileType) CountRecordsInEventFromRows(
 		currentCountryId := eventResultRow.CountryId
 
 		isfmc := resultEntry.IsFMC()
-		scrambles := make([]string, 5)
+		scrambles := []string{}
 		if isfmc {
 			scrambles, err = utils.GetScramblesByResultEntryId(
 				db,
 				resultEntry.Eventid,
 				resultEntry.Competitionid,
+				resultEntry.RoundNumber(),
 			)
 			if err != nil {
 				return Recorders{}, err
@@ -1157,7 +1174,7 @@ func (p *ProfileType) CountRecordsInEventFromRows(
 		single := resultEntry.SingleFormatted(resultEntry.IsFMC(), resultEntry.Scrambles)
 		singleMili := utils.ParseSolveToMilliseconds(single, false, "")
 
-		checkAverage = resultEntry.Iconcode == "333mbf" || resultEntry.Format == "bo1"
+		checkAverage = !resultEntry.HasAverage()
 
 		var averageMili int
 		if !checkAverage {
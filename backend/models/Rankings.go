@@ -0,0 +1,341 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+// ranking_entries keep the precomputed singles (one per solve) and averages
+// of the visible results, everything about the competitor and competition is
+// joined when querying so merges and country changes need no refresh
+const (
+	RANKING_SINGLE  = "single"
+	RANKING_AVERAGE = "average"
+)
+
+type RankingRow struct {
+	Type   string
+	Value  int
+	Result string
+	Times  []string
+}
+
+// RankingRows computes what the result contributes to the rankings, the
+// format and iconcode of the entry must be set
+func (r *ResultEntry) RankingRows(scrambles []string) ([]RankingRow, error) {
+	rows := make([]RankingRow, 0)
+	if !r.Status.Visible {
+		return rows, nil
+	}
+
+	isfmc := utils.IsFMC(r.Iconcode)
+	for idx, solve := range r.GetSolvesInMiliseconds(isfmc, scrambles) {
+		result := utils.FormatTime(solve, isfmc)
+		if r.IsMBLD() {
+			result = r.Solves[idx]
+		}
+
+		value := utils.ParseSolveToMilliseconds(result, false, "")
+		if value >= constants.VERY_SLOW {
+			continue
+		}
+
+		rows = append(rows, RankingRow{Type: RANKING_SINGLE, Value: value, Result: result, Times: []string{}})
+	}
+
+	if !r.HasAverage() {
+		return rows, nil
+	}
+
+	result, err := r.AverageFormatted(isfmc, scrambles)
+	if err != nil {
+		return []RankingRow{}, fmt.Errorf("%w: when formatting average of result with id=%d", err, r.Id)
+	}
+
+	value := utils.ParseSolveToMilliseconds(result, false, "")
+	if value >= constants.VERY_SLOW {
+		return rows, nil
+	}
+
+	times, _ := r.GetFormattedTimes(isfmc, scrambles)
+	rows = append(rows, RankingRow{Type: RANKING_AVERAGE, Value: value, Result: result, Times: times})
+
+	return rows, nil
+}
+
+const rankingResultsQuery = `SELECT r.result_id, r.competition_id, r.event_id, r.round, r.solves, ce.format, e.iconcode, rs.visible FROM results r JOIN events e ON e.event_id = r.event_id JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id JOIN results_status rs ON rs.results_status_id = r.status_id`
+
+func loadRankingResults(ctx context.Context, db *pgxpool.Pool, where string, args ...any) ([]ResultEntry, error) {
+	rows, err := db.Query(ctx, rankingResultsQuery+` `+where+`;`, args...)
+	if err != nil {
+		return []ResultEntry{}, fmt.Errorf("%w: when querying results for rankings", err)
+	}
+	defer rows.Close()
+
+	results := make([]ResultEntry, 0)
+	for rows.Next() {
+		var r ResultEntry
+		err = rows.Scan(&r.Id, &r.Competitionid, &r.Eventid, &r.Round, &r.Solves, &r.Format, &r.Iconcode, &r.Status.Visible)
+		if err != nil {
+			return []ResultEntry{}, fmt.Errorf("%w: when scanning result for rankings", err)
+		}
+		results = append(results, r)
+	}
+	if err = rows.Err(); err != nil {
+		return []ResultEntry{}, fmt.Errorf("%w: when iterating through results for rankings", err)
+	}
+
+	return results, nil
+}
+
+// insertRankingRows computes and inserts the rows of the results, scrambles
+// are only needed (and loaded once per round) for fmc
+func insertRankingRows(ctx context.Context, db *pgxpool.Pool, tx pgx.Tx, results []ResultEntry) (int, error) {
+	scrambles := make(map[string][]string)
+	inserted := 0
+
+	for _, r := range results {
+		roundScrambles := []string{}
+		if r.Status.Visible && r.IsFMC() {
+			key := fmt.Sprintf("%s/%d/%d", r.Competitionid, r.Eventid, r.RoundNumber())
+			if _, ok := scrambles[key]; !ok {
+				s, err := utils.GetScramblesByResultEntryId(db, r.Eventid, r.Competitionid, r.RoundNumber())
+				if err != nil {
+					return 0, fmt.Errorf("%w: when getting scrambles of %s", err, key)
+				}
+				scrambles[key] = s
+			}
+			roundScrambles = scrambles[key]
+		}
+
+		rows, err := r.RankingRows(roundScrambles)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, row := range rows {
+			_, err = tx.Exec(
+				ctx,
+				`INSERT INTO ranking_entries (result_id, event_id, type, value, result, times) VALUES ($1,$2,$3,$4,$5,$6);`,
+				r.Id,
+				r.Eventid,
+				row.Type,
+				row.Value,
+				row.Result,
+				row.Times,
+			)
+			if err != nil {
+				return 0, fmt.Errorf("%w: when inserting ranking entry of result with id=%d", err, r.Id)
+			}
+			inserted++
+		}
+	}
+
+	return inserted, nil
+}
+
+// RefreshRankings replaces the ranking entries of the result, called after
+// every change of its solves or status
+func RefreshRankings(ctx context.Context, db *pgxpool.Pool, resultId int) error {
+	results, err := loadRankingResults(ctx, db, `WHERE r.result_id = $1`, resultId)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM ranking_entries WHERE result_id = $1;`, resultId)
+	if err != nil {
+		return fmt.Errorf("%w: when deleting ranking entries of result with id=%d", err, resultId)
+	}
+
+	_, err = insertRankingRows(ctx, db, tx, results)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return nil
+}
+
+// RebuildRankings recomputes all ranking entries from the results, the old
+// ones stay visible until it finishes
+func RebuildRankings(ctx context.Context, db *pgxpool.Pool) (int, error) {
+	results, err := loadRankingResults(ctx, db, `WHERE rs.visible IS TRUE`)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM ranking_entries;`)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when deleting ranking entries", err)
+	}
+
+	inserted, err := insertRankingRows(ctx, db, tx, results)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return inserted, nil
+}
+
+// RebuildRankingsIfEmpty fills the ranking entries after the ranking_entries
+// table was created, it does nothing once there are some
+func RebuildRankingsIfEmpty(ctx context.Context, db *pgxpool.Pool) (int, error) {
+	var exists bool
+	err := db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM ranking_entries);`).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when checking for ranking entries", err)
+	}
+	if exists {
+		return 0, nil
+	}
+
+	return RebuildRankings(ctx, db)
+}
+
+// rankingRegionCondition filters by the World, Country or (otherwise)
+// Continent region group, it expects the group in $2 and region in $3
+const rankingRegionCondition = `($2 = 'World' OR ($2 = 'Country' AND c.name = $3) OR ($2 NOT IN ('World', 'Country') AND cont.name = $3))`
+
+const rankingEntriesFrom = `FROM ranking_entries re JOIN results r ON r.result_id = re.result_id JOIN users u ON u.user_id = r.user_id JOIN countries c ON c.country_id = u.country_id JOIN continents cont ON cont.continent_id = c.continent_id JOIN competitions comp ON comp.competition_id = r.competition_id`
+
+// GetRankings returns the best limit entries of the event in the region,
+// sorted and without places. Persons rankings contain only the best entry of
+// every competitor.
+func GetRankings(ctx context.Context, db *pgxpool.Pool, eid int, rankingType string, persons bool, regionType string, regionPrecise string, limit int) ([]RankingsEntry, error) {
+	columns := `u.name AS username, u.wcaid, c.iso2, c.name AS country_name, r.competition_id, comp.name AS competition_name, re.result, re.times, re.value ` + rankingEntriesFrom + ` WHERE re.event_id = $1 AND re.type = $4 AND ` + rankingRegionCondition
+	query := `SELECT ` + columns
+	if persons {
+		query = `SELECT DISTINCT ON (r.user_id) ` + columns + ` ORDER BY r.user_id, re.value`
+	}
+	query = `SELECT * FROM (` + query + `) entries ORDER BY value, username LIMIT $5;`
+
+	rows, err := db.Query(ctx, query, eid, regionType, regionPrecise, rankingType, limit)
+	if err != nil {
+		return []RankingsEntry{}, fmt.Errorf("%w: when querying rankings of event with id=%d", err, eid)
+	}
+	defer rows.Close()
+
+	rankings := make([]RankingsEntry, 0)
+	for rows.Next() {
+		var entry RankingsEntry
+		var value int
+		err = rows.Scan(&entry.Username, &entry.WcaId, &entry.CountryISO2, &entry.CountryName, &entry.CompetitionId, &entry.CompetitionName, &entry.Result, &entry.Times, &value)
+		if err != nil {
+			return []RankingsEntry{}, fmt.Errorf("%w: when scanning rankings entry", err)
+		}
+
+		if entry.WcaId == "" {
+			entry.WcaId = entry.Username
+		}
+		rankings = append(rankings, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return []RankingsEntry{}, fmt.Errorf("%w: when iterating through rankings entries", err)
+	}
+
+	return rankings, nil
+}
+
+type RecordEntry struct {
+	RankingsEntry
+	Type               string
+	EventId            int
+	EventName          string
+	Iconcode           string
+	CompetitionEndDate time.Time
+}
+
+// GetRecords returns the entries holding the single and average records of
+// the event (of all events if eid is -1) in the region, ties included, sorted
+// by event, type and the date they were set
+func GetRecords(ctx context.Context, db *pgxpool.Pool, eid int, regionType string, regionPrecise string) ([]RecordEntry, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT DISTINCT ON (result_id, type) * FROM (
+			SELECT re.result_id, re.type, re.value, MIN(re.value) OVER (PARTITION BY re.event_id, re.type) AS best, re.result, re.times, u.name, u.wcaid, c.iso2, c.name AS country_name, r.competition_id, comp.name AS competition_name, comp.enddate, e.event_id, e.fulldisplayname, e.iconcode, ce.format
+			`+rankingEntriesFrom+`
+			JOIN events e ON e.event_id = re.event_id
+			JOIN competition_events ce ON ce.competition_id = r.competition_id AND ce.event_id = r.event_id
+			WHERE ($1 = -1 OR re.event_id = $1) AND `+rankingRegionCondition+`
+		) regional WHERE value = best;`,
+		eid,
+		regionType,
+		regionPrecise,
+	)
+	if err != nil {
+		return []RecordEntry{}, fmt.Errorf("%w: when querying records of event with id=%d", err, eid)
+	}
+	defer rows.Close()
+
+	records := make([]RecordEntry, 0)
+	for rows.Next() {
+		var record RecordEntry
+		var resultId, value, best int
+		var format string
+		err = rows.Scan(&resultId, &record.Type, &value, &best, &record.Result, &record.Times, &record.Username, &record.WcaId, &record.CountryISO2, &record.CountryName, &record.CompetitionId, &record.CompetitionName, &record.CompetitionEndDate, &record.EventId, &record.EventName, &record.Iconcode, &format)
+		if err != nil {
+			return []RecordEntry{}, fmt.Errorf("%w: when scanning record entry", err)
+		}
+
+		if record.WcaId == "" {
+			record.WcaId = record.Username
+		}
+		switch record.Type {
+		case RANKING_SINGLE:
+			record.Type = "Single"
+		case RANKING_AVERAGE:
+			record.Type = "Average"
+			if f, err := formats.Get(format); err == nil && f.Trim == 0 {
+				record.Type = "Mean"
+			}
+		}
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return []RecordEntry{}, fmt.Errorf("%w: when iterating through record entries", err)
+	}
+
+	sort.SliceStable(records, func(i int, j int) bool {
+		a, b := records[i], records[j]
+		if a.EventId != b.EventId {
+			return a.EventId < b.EventId
+		}
+		if (a.Type == "Single") != (b.Type == "Single") {
+			return a.Type == "Single"
+		}
+		if !a.CompetitionEndDate.Equal(b.CompetitionEndDate) {
+			return a.CompetitionEndDate.Before(b.CompetitionEndDate)
+		}
+		return a.Username < b.Username
+	})
+
+	return records, nil
+}
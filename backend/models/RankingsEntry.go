@@ -30,14 +30,8 @@ func (rankingsEntry *RankingsEntry) Load(single, persons bool, resultsEntry Resu
 		} else {
 			rankingsEntry.Times = make([]string, 0)
 
-			noOfSolves, _ := utils.GetNoOfSolves(resultsEntry.Format)
-
-			for idx, solve := range []string{resultsEntry.Solve1, resultsEntry.Solve2, resultsEntry.Solve3, resultsEntry.Solve4, resultsEntry.Solve5} {
-				if idx >= noOfSolves {
-					break
-				}
-
-				result := utils.ParseSolveToMilliseconds(solve, isfmc, scrambles[idx])
+			for idx, solve := range resultsEntry.Solves {
+				result := utils.ParseSolveToMilliseconds(solve, isfmc, scrambleAt(scrambles, idx))
 				if ismbld {
 					rankingsEntry.Result = solve
 				} else {
@@ -48,7 +42,7 @@ func (rankingsEntry *RankingsEntry) Load(single, persons bool, resultsEntry Resu
 				}
 			}
 		}
-	} else if !ismbld && resultsEntry.Format != "bo1" {
+	} else if resultsEntry.HasAverage() {
 		resultFormatted, err := resultsEntry.AverageFormatted(isfmc, scrambles)
 		if err != nil {
 			return "ERR AverageFormatted in rankingsEntry.Load (" + regionType + "+" + regionPrecise + "): " + err.Error(), "Failed to calculate average in rankings entry.", err
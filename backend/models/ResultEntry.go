@@ -2,9 +2,9 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"math"
 	"os"
 	"regexp"
 	"sort"
@@ -16,9 +16,13 @@ import (
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/formats"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
 )
 
+var ErrTooManyAttempts = errors.New("more attempts than the format has")
+
 type ResultEntry struct {
 	Id              int           `json:"id"`
 	Userid          int           `json:"userid"`
@@ -30,11 +34,8 @@ type ResultEntry struct {
 	Eventname       string        `json:"eventname"`
 	Iconcode        string        `json:"iconcode"`
 	Format          string        `json:"format"`
-	Solve1          string        `json:"solve1"`
-	Solve2          string        `json:"solve2"`
-	Solve3          string        `json:"solve3"`
-	Solve4          string        `json:"solve4"`
-	Solve5          string        `json:"solve5"`
+	Round           int           `json:"round"`
+	Solves          []string      `json:"solves"`
 	Comment         string        `json:"comment"`
 	Status          ResultsStatus `json:"status"`
 	BadFormat       bool          `json:"badFormat"`
@@ -45,15 +46,12 @@ type ResultEntry struct {
 func (r *ResultEntry) Insert(db *pgxpool.Pool) error {
 	_, err := db.Exec(
 		context.Background(),
-		`INSERT INTO results (competition_id, user_id, event_id, solve1, solve2, solve3, solve4, solve5, comment, status_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`,
+		`INSERT INTO results (competition_id, user_id, event_id, round, solves, comment, status_id) VALUES ($1, $2, $3, $4, $5, $6, $7);`,
 		r.Competitionid,
 		r.Userid,
 		r.Eventid,
-		r.Solve1,
-		r.Solve2,
-		r.Solve3,
-		r.Solve4,
-		r.Solve5,
+		r.RoundNumber(),
+		r.Solves,
 		r.Comment,
 		r.Status.Id,
 	)
@@ -71,25 +69,11 @@ func (r *ResultEntry) CheckFormats(isfmc bool) {
 		return
 	}
 
-	if !utils.CheckFormat(r.Solve1) {
-		r.Solve1 = "DNF"
-		r.BadFormat = true
-	}
-	if !utils.CheckFormat(r.Solve2) {
-		r.Solve2 = "DNF"
-		r.BadFormat = true
-	}
-	if !utils.CheckFormat(r.Solve3) {
-		r.Solve3 = "DNF"
-		r.BadFormat = true
-	}
-	if !utils.CheckFormat(r.Solve4) {
-		r.Solve4 = "DNF"
-		r.BadFormat = true
-	}
-	if !utils.CheckFormat(r.Solve5) {
-		r.Solve5 = "DNF"
-		r.BadFormat = true
+	for idx, solve := range r.Solves {
+		if !utils.CheckFormat(solve) {
+			r.Solves[idx] = "DNF"
+			r.BadFormat = true
+		}
 	}
 }
 
@@ -123,13 +107,36 @@ func IsValidTimePeriod(db *pgxpool.Pool, competitionId string) (bool, Competitio
 		time.Now().Before(competition.Enddate), competition, nil
 }
 
+// IsValidRoundTimePeriod reports whether the round of the event is open now
+// and returns when it starts, rounds of the event have to be loaded
+func IsValidRoundTimePeriod(db *pgxpool.Pool, competitionId string, event CompetitionEvent, round int) (bool, time.Time, error) {
+	competition, err := GetCompetitionByIdObject(db, competitionId)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	startdate, enddate, err := event.RoundPeriod(competition, round)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	return startdate.Before(time.Now()) && time.Now().Before(enddate), startdate, nil
+}
+
+// RoundNumber returns the round of the entry, entries without one are from the
+// first round
+func (r *ResultEntry) RoundNumber() int {
+	return max(r.Round, 1)
+}
+
 func (r *ResultEntry) LoadId(db *pgxpool.Pool) error {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT result_id FROM results WHERE user_id = $1 AND competition_id = $2 AND event_id = $3;`,
+		`SELECT result_id FROM results WHERE user_id = $1 AND competition_id = $2 AND event_id = $3 AND round = $4;`,
 		r.Userid,
 		r.Competitionid,
 		r.Eventid,
+		r.RoundNumber(),
 	)
 	if err != nil {
 		return err
@@ -178,20 +185,50 @@ func (r *ResultEntry) Update(db *pgxpool.Pool, isadmin bool, isfmc bool, valid .
 	}
 
 	if isfmc {
-		r.Scrambles, err = utils.GetScramblesByResultEntryId(db, r.Eventid, r.Competitionid)
+		r.Scrambles, err = utils.GetScramblesByResultEntryId(db, r.Eventid, r.Competitionid, r.RoundNumber())
 		if err != nil {
 			return err
 		}
 	} else {
-		r.Scrambles = make([]string, 5)
+		r.Scrambles = []string{}
 	}
 
 	if r.Iconcode == "333mbf" {
-		r.Solve1 = r.ValidateMultiEntry(r.Solve1)
-		r.Solve2 = r.ValidateMultiEntry(r.Solve2)
-		r.Solve3 = r.ValidateMultiEntry(r.Solve3)
-		r.Solve4 = r.ValidateMultiEntry(r.Solve4)
-		r.Solve5 = r.ValidateMultiEntry(r.Solve5)
+		for idx, solve := range r.Solves {
+			r.Solves[idx] = r.ValidateMultiEntry(solve)
+		}
+	}
+
+	event, err := GetCompetitionEventInRound(db, r.Competitionid, r.Eventid, r.RoundNumber())
+	if err != nil {
+		return err
+	}
+
+	format, err := formats.Get(event.Format)
+	if err != nil {
+		return err
+	}
+	if len(r.Solves) > format.Attempts {
+		return fmt.Errorf("%w: %d attempts, format=%s", ErrTooManyAttempts, len(r.Solves), format.Id)
+	}
+	for len(r.Solves) < format.Attempts {
+		r.Solves = append(r.Solves, "DNS")
+	}
+	r.Format = event.Format
+
+	if !isadmin {
+		advanced, err := HasAdvanced(db, r.Userid, r.Competitionid, r.Eventid, r.RoundNumber())
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return fmt.Errorf("%w: round=%d", ErrNotAdvanced, r.RoundNumber())
+		}
+	}
+
+	err = r.ApplyRoundRules(event, isfmc, r.Scrambles)
+	if err != nil {
+		return err
 	}
 
 	if len(valid) == 0 || (len(valid) > 0 && !valid[0]) {
@@ -201,42 +238,98 @@ func (r *ResultEntry) Update(db *pgxpool.Pool, isadmin bool, isfmc bool, valid .
 		}
 	}
 
-	ok, competition, err := IsValidTimePeriod(db, r.Competitionid)
+	ok, startdate, err := IsValidRoundTimePeriod(db, r.Competitionid, event, r.RoundNumber())
 	if err != nil {
 		return err
 	}
 
-	if ok || (isadmin && competition.Startdate.Before(time.Now())) {
+	if ok || (isadmin && startdate.Before(time.Now())) {
 		_, err := db.Exec(
 			context.Background(),
-			`UPDATE results SET solve1 = $1, solve2 = $2, solve3 = $3, solve4 = $4, solve5 = $5, comment = $6, status_id = $7, timestamp = CURRENT_TIMESTAMP WHERE user_id = $8 AND competition_id = $9 AND event_id = $10;`,
-			r.Solve1,
-			r.Solve2,
-			r.Solve3,
-			r.Solve4,
-			r.Solve5,
+			`UPDATE results SET solves = $1, comment = $2, status_id = $3, timestamp = CURRENT_TIMESTAMP WHERE user_id = $4 AND competition_id = $5 AND event_id = $6 AND round = $7;`,
+			r.Solves,
 			r.Comment,
 			r.Status.Id,
 			r.Userid,
 			r.Competitionid,
 			r.Eventid,
+			r.RoundNumber(),
 		)
 		if err != nil {
 			return err
 		}
+
+		err = RefreshRankings(context.Background(), db, r.Id)
+		if err != nil {
+			return err
+		}
+
+		// only admins change results after the round closed, the advancement
+		// to the next round may have been saved from the old ones
+		if isadmin {
+			err = ResetAdvancement(db, r.Competitionid, r.Eventid, r.RoundNumber()+1)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// ApplyRoundRules turns the attempts over the time limit of the event into
+// DNFs and rejects attempts after a failed cutoff. Multi-blind has its own
+// time limit per number of cubes, so neither applies to it.
+func (r *ResultEntry) ApplyRoundRules(event CompetitionEvent, isfmc bool, scrambles []string) error {
+	if r.IsMBLD() {
+		return nil
+	}
+
+	if !isfmc {
+		limited := event.TimeLimit.Apply(r.GetSolvesInMiliseconds(isfmc, scrambles))
+		for idx, value := range limited {
+			if value == constants.DNF {
+				r.Solves[idx] = "DNF"
+			}
+		}
+	}
+
+	return event.Cutoff.Check(r.GetSolvesInMiliseconds(isfmc, scrambles))
+}
+
+// CutoffFailed reports whether the attempts did not make the cutoff of the
+// event, so the competitor is done with the event.
+func (r *ResultEntry) CutoffFailed(event CompetitionEvent, isfmc bool, scrambles []string) bool {
+	return !r.IsMBLD() && event.Cutoff.Failed(r.GetSolvesInMiliseconds(isfmc, scrambles))
+}
+
+// scrambleAt returns the scramble of the attempt or "" if the scrambles were
+// not loaded, they are only needed for FMC
+func scrambleAt(scrambles []string, idx int) string {
+	if idx < len(scrambles) {
+		return scrambles[idx]
+	}
+
+	return ""
+}
+
+// attempts returns the solves without the ones over the attempts of the
+// format, all of them if the format is not set
+func (r *ResultEntry) attempts() []string {
+	format, err := formats.Get(r.Format)
+	if err != nil || len(r.Solves) <= format.Attempts {
+		return r.Solves
+	}
+
+	return r.Solves[:format.Attempts]
+}
+
 func (r *ResultEntry) Single(isfmc bool, scrambles []string) int {
-	res := math.MaxInt
+	res := constants.DNS
 
-	utils.CompareSolves(&res, r.Solve1, isfmc, scrambles[0])
-	utils.CompareSolves(&res, r.Solve2, isfmc, scrambles[1])
-	utils.CompareSolves(&res, r.Solve3, isfmc, scrambles[2])
-	utils.CompareSolves(&res, r.Solve4, isfmc, scrambles[3])
-	utils.CompareSolves(&res, r.Solve5, isfmc, scrambles[4])
+	for idx, solve := range r.attempts() {
+		utils.CompareSolves(&res, solve, isfmc, scrambleAt(scrambles, idx))
+	}
 
 	return res
 }
@@ -247,12 +340,11 @@ func (r *ResultEntry) IsSuspicous(isfmc bool, scrambles []string) bool {
 	//return false
 	//}
 
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	curSingle := r.Single(isfmc, scrambles)
+	curAverage, err := r.Average(isfmc, scrambles)
 	if err != nil {
 		return false
 	}
-
-	curSingle, curAverage := r.Single(isfmc, scrambles), r.Average(noOfSolves, isfmc, scrambles)
 	if isfmc {
 		curSingle = utils.ParseSolveToMilliseconds(utils.FormatTime(curSingle, true), false, "")
 		curAverage = utils.ParseSolveToMilliseconds(utils.FormatTime(curAverage, true), false, "")
@@ -275,63 +367,54 @@ func (r *ResultEntry) IsMBLD() bool {
 	return r.Iconcode == "333mbf"
 }
 
+// HasAverage reports whether the average of the entry is worth keeping, which
+// is never the case for multi-blind or formats without one
+func (r *ResultEntry) HasAverage() bool {
+	format, err := formats.Get(r.Format)
+	return err == nil && format.HasAverage() && !r.IsMBLD()
+}
+
 func (r *ResultEntry) GetSolvesInMiliseconds(isfmc bool, scrambles []string) []int {
-	values := make([]int, 0)
+	solves := r.attempts()
+	values := make([]int, 0, len(solves))
 
-	values = append(values, utils.ParseSolveToMilliseconds(r.Solve1, isfmc, scrambles[0]))
-	values = append(values, utils.ParseSolveToMilliseconds(r.Solve2, isfmc, scrambles[1]))
-	values = append(values, utils.ParseSolveToMilliseconds(r.Solve3, isfmc, scrambles[2]))
-	values = append(values, utils.ParseSolveToMilliseconds(r.Solve4, isfmc, scrambles[3]))
-	values = append(values, utils.ParseSolveToMilliseconds(r.Solve5, isfmc, scrambles[4]))
+	for idx, solve := range solves {
+		values = append(values, utils.ParseSolveToMilliseconds(solve, isfmc, scrambleAt(scrambles, idx)))
+	}
 
 	return values
 }
 
 func (r *ResultEntry) GetSolves(isfmc bool, scrambles []string) []string {
-	values := make([]string, 0)
+	solves := r.attempts()
+	values := make([]string, 0, len(solves))
 
-	values = append(values, utils.GetSolve(r.Solve1, isfmc, scrambles[0]))
-	values = append(values, utils.GetSolve(r.Solve2, isfmc, scrambles[1]))
-	values = append(values, utils.GetSolve(r.Solve3, isfmc, scrambles[2]))
-	values = append(values, utils.GetSolve(r.Solve4, isfmc, scrambles[3]))
-	values = append(values, utils.GetSolve(r.Solve5, isfmc, scrambles[4]))
+	for idx, solve := range solves {
+		values = append(values, utils.GetSolve(solve, isfmc, scrambleAt(scrambles, idx)))
+	}
 
 	return values
 }
 
-func (r *ResultEntry) Average(noOfSolves int, isfmc bool, scrambles []string) int {
-	solves := r.GetSolvesInMiliseconds(isfmc, scrambles)
-	sort.Ints(solves)
-
-	sum := 0
-	cntBad := 0
-
-	for idx, solve := range solves {
-		if idx >= noOfSolves {
-			break
-		}
-
-		if solve >= constants.VERY_SLOW {
-			cntBad++
-			if (noOfSolves == 5 && cntBad > 1) || (noOfSolves == 3 && cntBad > 0) {
-				if !r.Competed() {
-					return constants.DNS
-				}
-				return constants.DNF
-			}
-		}
-
-		if noOfSolves == 3 || (noOfSolves == 5 && idx > 0 && idx < 4) {
-			sum += solve
-		}
+// Average computes the average, mean or sum according to the format, r.Format
+// must be set
+func (r *ResultEntry) Average(isfmc bool, scrambles []string) (int, error) {
+	format, err := formats.Get(r.Format)
+	if err != nil {
+		return 0, err
 	}
 
-	return sum / 3
+	return format.Average(r.GetSolvesInMiliseconds(isfmc, scrambles)), nil
 }
 
 func (r *ResultEntry) Competed() bool {
-	return r.Solve1 != "DNS" || r.Solve2 != "DNS" || r.Solve3 != "DNS" || r.Solve4 != "DNS" ||
-		r.Solve5 != "DNS"
+	for _, solve := range r.Solves {
+		if solve != "DNS" {
+			return true
+		}
+	}
+
+	return false
 }
 
 func GetResultEntry(
@@ -339,13 +422,15 @@ func GetResultEntry(
 	competitorId int,
 	competitionId string,
 	eventId int,
+	round int,
 ) (ResultEntry, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT re.result_id, re.competition_id, re.user_id, re.event_id, re.solve1, re.solve2, re.solve3, re.solve4, re.solve5, re.comment, re.status_id FROM results re WHERE re.user_id = $1 AND re.competition_id = $2 AND re.event_id = $3;`,
+		`SELECT re.result_id, re.competition_id, re.user_id, re.event_id, re.round, re.solves, re.comment, re.status_id FROM results re WHERE re.user_id = $1 AND re.competition_id = $2 AND re.event_id = $3 AND re.round = $4;`,
 		competitorId,
 		competitionId,
 		eventId,
+		round,
 	)
 	if err != nil {
 		return ResultEntry{}, err
@@ -359,11 +444,8 @@ func GetResultEntry(
 			&resultEntry.Competitionid,
 			&resultEntry.Userid,
 			&resultEntry.Eventid,
-			&resultEntry.Solve1,
-			&resultEntry.Solve2,
-			&resultEntry.Solve3,
-			&resultEntry.Solve4,
-			&resultEntry.Solve5,
+			&resultEntry.Round,
+			&resultEntry.Solves,
 			&resultEntry.Comment,
 			&resultEntry.Status.Id,
 		)
@@ -383,7 +465,7 @@ func GetResultEntry(
 func GetResultEntryById(db *pgxpool.Pool, resultId int) (ResultEntry, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT re.result_id, re.competition_id, re.user_id, re.event_id, re.solve1, re.solve2, re.solve3, re.solve4, re.solve5, re.comment, re.status_id, c.name, e.displayname, rs.approvalfinished, rs.approved, rs.visible, rs.displayname, u.name, ce.format, e.iconcode FROM results re JOIN competitions c ON c.competition_id = re.competition_id JOIN competition_events ce ON ce.competition_id = re.competition_id AND ce.event_id = re.event_id JOIN events e ON e.event_id = re.event_id JOIN results_status rs ON results_status_id = re.status_id JOIN users u ON u.user_id = re.user_id WHERE re.result_id = $1;`,
+		`SELECT re.result_id, re.competition_id, re.user_id, re.event_id, re.round, re.solves, re.comment, re.status_id, c.name, e.displayname, rs.approvalfinished, rs.approved, rs.visible, rs.displayname, u.name, ce.format, e.iconcode FROM results re JOIN competitions c ON c.competition_id = re.competition_id JOIN competition_events ce ON ce.competition_id = re.competition_id AND ce.event_id = re.event_id JOIN events e ON e.event_id = re.event_id JOIN results_status rs ON results_status_id = re.status_id JOIN users u ON u.user_id = re.user_id WHERE re.result_id = $1;`,
 		resultId,
 	)
 	if err != nil {
@@ -398,11 +480,8 @@ func GetResultEntryById(db *pgxpool.Pool, resultId int) (ResultEntry, error) {
 			&resultEntry.Competitionid,
 			&resultEntry.Userid,
 			&resultEntry.Eventid,
-			&resultEntry.Solve1,
-			&resultEntry.Solve2,
-			&resultEntry.Solve3,
-			&resultEntry.Solve4,
-			&resultEntry.Solve5,
+			&resultEntry.Round,
+			&resultEntry.Solves,
 			&resultEntry.Comment,
 			&resultEntry.Status.Id,
 			&resultEntry.Competitionname,
@@ -429,20 +508,10 @@ func GetResultEntryById(db *pgxpool.Pool, resultId int) (ResultEntry, error) {
 }
 
 func (r *ResultEntry) FormatMultiSingle(single int) string {
-	if utils.ParseMultiToMilliseconds(r.Solve1) == single {
-		return r.Solve1
-	}
-	if utils.ParseMultiToMilliseconds(r.Solve2) == single {
-		return r.Solve2
-	}
-	if utils.ParseMultiToMilliseconds(r.Solve3) == single {
-		return r.Solve3
-	}
-	if utils.ParseMultiToMilliseconds(r.Solve4) == single {
-		return r.Solve4
-	}
-	if utils.ParseMultiToMilliseconds(r.Solve5) == single {
-		return r.Solve5
+	for _, solve := range r.Solves {
+		if utils.ParseMultiToMilliseconds(solve) == single {
+			return solve
+		}
 	}
 
 	return "DNS"
@@ -463,23 +532,26 @@ func (r *ResultEntry) SingleFormatted(isfmc bool, scrambles []string) string {
 }
 
 func (r *ResultEntry) AverageFormatted(isfmc bool, scrambles []string) (string, error) {
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	average, err := r.Average(isfmc, scrambles)
 	if err != nil {
 		return "", err
 	}
 
-	return utils.FormatTime(r.Average(noOfSolves, isfmc, scrambles), isfmc), nil
+	return utils.FormatTime(average, isfmc), nil
 }
 
 func (r *ResultEntry) GetFormattedTimes(isfmc bool, scrambles []string) ([]string, error) {
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	format, err := formats.Get(r.Format)
 	if err != nil {
 		return []string{}, err
 	}
 
 	solves := r.GetSolves(isfmc, scrambles)
-	solves = solves[:noOfSolves]
-	if noOfSolves <= 3 {
+	for len(solves) < format.Attempts {
+		solves = append(solves, "DNS")
+	}
+	solves = solves[:format.Attempts]
+	if format.AverageKind != formats.TrimmedMean || format.Trim == 0 {
 		if r.Iconcode == "333mbf" {
 			return utils.FormatMultiTimes(solves), nil
 		}
@@ -500,12 +572,16 @@ func (r *ResultEntry) GetFormattedTimes(isfmc bool, scrambles []string) ([]strin
 		)
 	}
 
-	sort.Slice(
+	sort.SliceStable(
 		sortedSolves,
 		func(i int, j int) bool { return sortedSolves[i].TimeInMiliseconds < sortedSolves[j].TimeInMiliseconds },
 	)
-	solves[sortedSolves[0].Index] = "(" + solves[sortedSolves[0].Index] + ")"
-	solves[sortedSolves[len(sortedSolves)-1].Index] = "(" + solves[sortedSolves[len(sortedSolves)-1].Index] + ")"
+	// the attempts dropped from the average are in parentheses
+	for idx := range format.Trim {
+		best, worst := sortedSolves[idx].Index, sortedSolves[len(sortedSolves)-1-idx].Index
+		solves[best] = "(" + solves[best] + ")"
+		solves[worst] = "(" + solves[worst] + ")"
+	}
 
 	return solves, nil
 }
@@ -513,11 +589,7 @@ func (r *ResultEntry) GetFormattedTimes(isfmc bool, scrambles []string) ([]strin
 func GetFormattedTimes(times []string, format string, scrambles []string) ([]string, error) {
 	resultEntry := ResultEntry{
 		Format: format,
-		Solve1: times[0],
-		Solve2: times[1],
-		Solve3: times[2],
-		Solve4: times[3],
-		Solve5: times[4],
+		Solves: times,
 	}
 	return resultEntry.GetFormattedTimes(resultEntry.IsFMC(), scrambles)
 }
@@ -527,138 +599,86 @@ func (r *ResultEntry) IsFMC() bool {
 }
 
 func (r *ResultEntry) GetSolveIdx(s string) int {
-	if r.Solve1 == s {
-		return 0
-	}
-	if r.Solve2 == s {
-		return 1
-	}
-	if r.Solve3 == s {
-		return 2
-	}
-	if r.Solve4 == s {
-		return 3
-	}
-	if r.Solve5 == s {
-		return 4
+	for idx, solve := range r.Solves {
+		if solve == s {
+			return idx
+		}
 	}
 
 	return -1
 }
 
-func (r *ResultEntry) IsAverageOfX() bool {
-	return len(r.Format) > 0 && r.Format[0] == 'a'
-}
-
 // r.Format must be set
 func (r *ResultEntry) ShowPossibleAverages() (bool, error) {
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	format, err := formats.Get(r.Format)
 	if err != nil {
 		return false, err
 	}
 
-	if noOfSolves == 1 || !r.IsAverageOfX() {
+	if !format.ShowsPossibleAverages() {
 		return false, nil
 	}
 
-	ok := true
-	for idx, solve := range []string{r.Solve1, r.Solve2, r.Solve3, r.Solve4, r.Solve5} {
-		if idx < noOfSolves-1 {
-			ok = ok && solve != "DNS"
-		} else {
-			ok = ok && solve == "DNS"
-			break
+	for solveNo := 1; solveNo < format.Attempts; solveNo++ {
+		if r.GetNthSolve(solveNo) == "DNS" {
+			return false, nil
 		}
 	}
 
-	return ok, nil
+	return r.GetNthSolve(format.Attempts) == "DNS", nil
 }
 
-func (r *ResultEntry) GetNthSolve(noOfSolves int) string {
-	if noOfSolves == 1 {
-		return r.Solve1
-	}
-	if noOfSolves == 2 {
-		return r.Solve2
-	}
-	if noOfSolves == 3 {
-		return r.Solve3
-	}
-	if noOfSolves == 4 {
-		return r.Solve4
+// GetNthSolve returns the solve numbered from 1, DNS if it is not stored
+func (r *ResultEntry) GetNthSolve(solveNo int) string {
+	if solveNo < 1 || solveNo > len(r.Solves) {
+		return "DNS"
 	}
-	return r.Solve5
+
+	return r.Solves[solveNo-1]
 }
 
-func (r *ResultEntry) SetNthSolve(noOfSolves int, newSolveValue string) {
-	if noOfSolves == 1 {
-		r.Solve1 = newSolveValue
-	}
-	if noOfSolves == 2 {
-		r.Solve2 = newSolveValue
-	}
-	if noOfSolves == 3 {
-		r.Solve3 = newSolveValue
+func (r *ResultEntry) SetNthSolve(solveNo int, newSolveValue string) {
+	for len(r.Solves) < solveNo {
+		r.Solves = append(r.Solves, "DNS")
 	}
-	if noOfSolves == 4 {
-		r.Solve4 = newSolveValue
-	}
-	r.Solve5 = newSolveValue
+
+	r.Solves[solveNo-1] = newSolveValue
 }
 
 // load scrambles first into resultEntry.Scrambles
 func (r *ResultEntry) GetBPA() (string, error) {
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	format, err := formats.Get(r.Format)
 	if err != nil {
 		return "", err
 	}
 	if ok, _ := r.ShowPossibleAverages(); !ok {
-		return "", fmt.Errorf("did not finish first %d solves", noOfSolves)
-	}
-
-	oldSolveN := r.GetNthSolve(noOfSolves)
-	single := r.SingleFormatted(r.IsFMC(), r.Scrambles)
-	r.SetNthSolve(noOfSolves, single)
-
-	average, err := r.AverageFormatted(r.IsFMC(), r.Scrambles)
-	if err != nil {
-		return "", err
+		return "", fmt.Errorf("did not finish first %d solves", format.Attempts-1)
 	}
 
-	r.SetNthSolve(noOfSolves, oldSolveN)
-
-	return average, nil
+	bpa := format.BestPossibleAverage(r.GetSolvesInMiliseconds(r.IsFMC(), r.Scrambles))
+	return utils.FormatTime(bpa, r.IsFMC()), nil
 }
 
 func (r *ResultEntry) GetWPA() (string, error) {
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	format, err := formats.Get(r.Format)
 	if err != nil {
 		return "", err
 	}
 	if ok, _ := r.ShowPossibleAverages(); !ok {
-		return "", fmt.Errorf("did not finish first %d solves", noOfSolves)
-	}
-
-	oldSolveN := r.GetNthSolve(noOfSolves)
-	r.SetNthSolve(noOfSolves, "DNF")
-
-	average, err := r.AverageFormatted(r.IsFMC(), r.Scrambles)
-	if err != nil {
-		return "", err
+		return "", fmt.Errorf("did not finish first %d solves", format.Attempts-1)
 	}
 
-	r.SetNthSolve(noOfSolves, oldSolveN)
-
-	return average, nil
+	wpa := format.WorstPossibleAverage(r.GetSolvesInMiliseconds(r.IsFMC(), r.Scrambles))
+	return utils.FormatTime(wpa, r.IsFMC()), nil
 }
 
 func (r *ResultEntry) FinishedCompeting() (bool, error) {
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	format, err := formats.Get(r.Format)
 	if err != nil {
 		return false, err
 	}
 
-	for solveNo := 1; solveNo <= noOfSolves; solveNo++ {
+	for solveNo := 1; solveNo <= format.Attempts; solveNo++ {
 		if r.GetNthSolve(solveNo) == "DNS" {
 			return false, nil
 		}
@@ -667,8 +687,19 @@ func (r *ResultEntry) FinishedCompeting() (bool, error) {
 	return true, nil
 }
 
+// FinishedCompetingInEvent is FinishedCompeting, but a failed cutoff of the
+// event ends the competing early, load scrambles first into r.Scrambles
+func (r *ResultEntry) FinishedCompetingInEvent(event CompetitionEvent) (bool, error) {
+	finished, err := r.FinishedCompeting()
+	if err != nil {
+		return false, err
+	}
+
+	return finished || r.CutoffFailed(event, r.IsFMC(), r.Scrambles), nil
+}
+
 func (r *ResultEntry) GetCompetitionPlace(db *pgxpool.Pool) (string, error) {
-	results, err := GetResultsFromCompetitionByEventName(db, r.Competitionid, r.Eventid)
+	results, err := GetResultsFromCompetitionByEventName(db, r.Competitionid, r.Eventid, r.RoundNumber())
 	if err != nil {
 		return "", err
 	}
@@ -683,12 +714,11 @@ func (r *ResultEntry) GetCompetitionPlace(db *pgxpool.Pool) (string, error) {
 }
 
 func (r *ResultEntry) SuspicousChangeInResults(previouslySavedTimes []string, noOfSolves int) bool {
-	for idx, newTime := range []string{r.Solve1, r.Solve2, r.Solve3, r.Solve4, r.Solve5} {
-		if idx >= noOfSolves {
-			continue
+	for idx := range noOfSolves {
+		oldTime, newTime := "DNS", r.GetNthSolve(idx+1)
+		if idx < len(previouslySavedTimes) {
+			oldTime = previouslySavedTimes[idx]
 		}
-
-		oldTime := previouslySavedTimes[idx]
 		if oldTime != "DNS" && oldTime != newTime {
 			return true
 		}
@@ -697,35 +727,29 @@ func (r *ResultEntry) SuspicousChangeInResults(previouslySavedTimes []string, no
 	return false
 }
 
-func (r *ResultEntry) GetSuspicousChangeTimesHTML(
+// GetSuspicousTimeChanges pairs the previous and the current formatted times,
+// marking the changed ones
+func (r *ResultEntry) GetSuspicousTimeChanges(
 	previouslySavedTimes []string,
 	noOfSolves int,
 	oldTimesFormatted, newTimesFormatted []string,
-) string {
-	prevItems, currItems := make([]string, noOfSolves), make([]string, noOfSolves)
+) []templates.TimeChange {
+	changes := make([]templates.TimeChange, noOfSolves)
 
-	for idx, newTime := range []string{r.Solve1, r.Solve2, r.Solve3, r.Solve4, r.Solve5} {
-		if idx >= noOfSolves {
-			continue
+	for idx := range noOfSolves {
+		oldTime, newTime := "DNS", r.GetNthSolve(idx+1)
+		if idx < len(previouslySavedTimes) {
+			oldTime = previouslySavedTimes[idx]
 		}
 
-		oldTime := previouslySavedTimes[idx]
-		color := "black"
-		if oldTime != "DNS" && oldTime != newTime {
-			color = "red"
+		changes[idx] = templates.TimeChange{
+			Old:     oldTimesFormatted[idx],
+			New:     newTimesFormatted[idx],
+			Changed: oldTime != "DNS" && oldTime != newTime,
 		}
-
-		prevItems[idx] = "<td style=\"text-align: center; border: 1px solid black; color:" + color + ";\">" + oldTimesFormatted[idx] + "</td>"
-		currItems[idx] = "<td style=\"text-align: center; border: 1px solid black; color:" + color + ";\">" + newTimesFormatted[idx] + "</td>"
 	}
 
-	return "<table style=\"border: 1px solid black;\"><tr><th style=\"border: 1px solid black;\">Previous times:</th>" + strings.Join(
-		prevItems,
-		"",
-	) + "</tr><tr><th style=\"border: 1px solid black;\">Current times:</th>" + strings.Join(
-		currItems,
-		"",
-	) + "</tr></table>"
+	return changes
 }
 
 func (r *ResultEntry) SendSuspicousMailAsync(
@@ -752,13 +776,12 @@ func (r *ResultEntry) SendSuspicousMailAsync(
 		return
 	}
 
-	noOfSolves, err := utils.GetNoOfSolves(r.Format)
+	format, err := formats.Get(r.Format)
 	if err != nil {
-		log.Println(
-			"ERR utils.GetScramGetNoOfSolvesblesByResultEntryId in r.SendSuspicousMailAsync: " + err.Error(),
-		)
+		log.Println("ERR formats.Get in r.SendSuspicousMailAsync: " + err.Error())
 		return
 	}
+	noOfSolves := format.Attempts
 
 	suspicousChangeInResults := r.SuspicousChangeInResults(previouslySavedTimes, noOfSolves)
 	suspicousResult := !r.Status.ApprovalFinished
@@ -766,7 +789,7 @@ func (r *ResultEntry) SendSuspicousMailAsync(
 	if suspicousResult || suspicousChangeInResults {
 		log.Println("Sending email...")
 
-		scrambles, err := utils.GetScramblesByResultEntryId(db, r.Eventid, r.Competitionid)
+		scrambles, err := utils.GetScramblesByResultEntryId(db, r.Eventid, r.Competitionid, r.RoundNumber())
 		if err != nil {
 			log.Println(
 				"ERR utils.GetScramblesByResultEntryId in r.SendSuspicousMailAsync: " + err.Error(),
@@ -792,94 +815,84 @@ func (r *ResultEntry) SendSuspicousMailAsync(
 			return
 		}
 
+		// admin token for a day, revoked with the other sessions of the admin
+		adminSession, _, err := CreateSession(ctx, db, 1, "Suspicious result email", 24*time.Hour, false)
+		if err != nil {
+			log.Println("ERR CreateSession in r.SendSuspicousMailAsync: " + err.Error())
+			return
+		}
+
 		adminToken, err := utils.CreateToken(
 			1,
+			adminSession.Id,
 			envMap["JWT_SECRET_KEY"],
-			60*24,
-		) // admin token for a day
+			24*time.Hour,
+		)
 		if err != nil {
 			log.Println("ERR utils.CreateToken in r.SendSuspicousMailAsync: " + err.Error())
 			return
 		}
 
-		mailSubject := "Suspicous"
-		if suspicousResult {
-			mailSubject += " result"
+		r.Email, err = GetEmailByWCAID(db, r.WcaId)
+		if err != nil {
+			log.Println("ERR GetEmailByWCAID in r.SendSuspicousMail: " + err.Error())
+			return
 		}
-		if suspicousChangeInResults {
-			if suspicousResult {
-				mailSubject += " and"
-			}
-			mailSubject += " change in results"
+
+		// the email goes to the mailbox of the admin
+		language := templates.DEFAULT_LANGUAGE
+		if admin, err := GetUserById(db, 1); err == nil {
+			language = admin.Language
+		}
+
+		validateUrl := envMap["MAIL_VALIDATE_URL"] + "?resultId=" + strconv.Itoa(r.Id) + "&atoken=" + adminToken
+		mailSubject, content, err := templates.RenderEmail(templates.EMAIL_SUSPICIOUS_RESULT, language, templates.SuspiciousResultData{
+			SuspiciousResult: suspicousResult,
+			SuspiciousChange: suspicousChangeInResults,
+			Username:         r.Username,
+			ProfileUrl:       envMap["WEBSITE_HOME"] + "/profile/" + r.WcaId,
+			Email:            r.Email,
+			CompetitionName:  r.Competitionname,
+			CompetitionUrl:   envMap["WEBSITE_HOME"] + "/competition/" + r.Competitionid,
+			Event:            r.Eventname,
+			Single:           r.SingleFormatted(r.IsFMC(), scrambles),
+			Average:          average,
+			Times:            newTimesFormatted,
+			TimeChanges:      r.GetSuspicousTimeChanges(previouslySavedTimes, noOfSolves, oldTimesFormatted, newTimesFormatted),
+			Comment:          r.Comment,
+			DenyUrl:          validateUrl + "&verdict=false",
+			AllowUrl:         validateUrl + "&verdict=true",
+		})
+		if err != nil {
+			log.Println("ERR templates.RenderEmail in r.SendSuspicousMailAsync: " + err.Error())
+			return
 		}
-		mailSubject += " detected !!!"
 
 		backendEnv := os.Getenv("SPEEDCUBINGSLOVAKIA_BACKEND_ENV")
 		if backendEnv == "development" {
 			mailSubject = "DEVELOPMENT: " + mailSubject
 		}
 
-		r.Email, err = GetEmailByWCAID(db, r.WcaId)
-		if err != nil {
-			log.Println("ERR GetEmailByWCAID in r.SendSuspicousMail: " + err.Error())
-			return
-		}
-
-		content := "<html>" +
-			"<head>" +
-			"<style>" +
-			`.mui-joy-btn { font-size: 0.875rem; box-sizing: border-box; border-radius: 6px; border: none; background-color: transparent; display: inline-flex; align-items: center; justify-content: center; position: relative; text-decoration: none; font-weight: 600; }
-					.mui-joy-btn-soft-success { color: #0a470a; background-color: #e3fbe3; }
-					.mui-joy-btn-soft-danger { color: #7d1212; background-color: #fce4e4; }` +
-			"</style></head><body>" +
-			"<b>Username:</b> <a href=\"" + envMap["WEBSITE_HOME"] + "/profile/" + r.WcaId + "\">" + r.Username + "</a><br>" +
-			"<b>Email:</b> " + r.Email + "<br>" +
-			"<b>Competition:</b> <a href=\"" + envMap["WEBSITE_HOME"] + "/competition/" + r.Competitionid + "\">" + r.Competitionname + "</a><br>" +
-			"<b>Event:</b> " + r.Eventname + "<br>" +
-			"<b>Single:</b> " + r.SingleFormatted(r.IsFMC(), scrambles) + "<br>" +
-			"<b>Average:</b> " + average + "<br>"
-
-		if suspicousChangeInResults {
-			suspicousChangeTimesHTML := r.GetSuspicousChangeTimesHTML(
-				previouslySavedTimes,
-				noOfSolves,
-				oldTimesFormatted,
-				newTimesFormatted,
-			)
-			content += suspicousChangeTimesHTML
-		} else {
-			content += "<b>Times:</b> " + strings.Join(newTimesFormatted, ", ") + "<br>"
-		}
-		content +=
-			"<b>Comment:</b> " + r.Comment + "<br>" +
-				"<a class=\"mui-joy-btn mui-joy-btn-soft-danger\" style=\"padding:10px;\" " +
-				"href=\"" + envMap["MAIL_VALIDATE_URL"] + "?resultId=" + strconv.Itoa(r.Id) + "&verdict=false&atoken=" + adminToken + "\">Deny</a>&nbsp;" +
-				"<a class=\"mui-joy-btn mui-joy-btn-soft-success\" style=\"padding:10px;\" " +
-				"href=\"" + envMap["MAIL_VALIDATE_URL"] + "?resultId=" + strconv.Itoa(r.Id) + "&verdict=true&atoken=" + adminToken + "\">Allow</a><br>" +
-				"<span style=\"font-size: 0.5rem\">Token for validating these results will expire in 24 hours.</span>" +
-				"</body></html>"
-
-		err = email.SendMail(
-			envMap["MAIL_USERNAME"],
-			envMap["MAIL_USERNAME"],
-			mailSubject,
-			content,
-			envMap,
-		)
+		_, err = QueueEmail(ctx, db, 0, email.Message{
+			From:    envMap["MAIL_USERNAME"],
+			To:      envMap["MAIL_USERNAME"],
+			Subject: mailSubject,
+			Body:    content,
+		})
 		if err != nil {
-			log.Println("ERR email.SendMail in r.SendSuspicousMailAsync: " + err.Error())
+			log.Println("ERR QueueEmail in r.SendSuspicousMailAsync: " + err.Error())
 			return
 		}
 
-		log.Println("Successfully sent mail about suspicous result.")
+		log.Println("Successfully queued mail about suspicous result.")
 	}
 }
 
 func (r *ResultEntry) GetPreviouslySavedTimes(db *pgxpool.Pool) ([]string, error) {
-	times := make([]string, 5)
+	times := make([]string, 0)
 
-	err := db.QueryRow(context.Background(), `SELECT solve1, solve2, solve3, solve4, solve5 FROM results r WHERE r.result_id = $1;`, r.Id).
-		Scan(&times[0], &times[1], &times[2], &times[3], &times[4])
+	err := db.QueryRow(context.Background(), `SELECT solves FROM results r WHERE r.result_id = $1;`, r.Id).
+		Scan(&times)
 	if err != nil {
 		return []string{}, err
 	}
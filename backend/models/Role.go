@@ -0,0 +1,126 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+// roles and permissions are seeded by the migrations, routes check the
+// permissions, users are given the roles
+const (
+	ROLE_SUPER_ADMIN           = "super_admin"
+	ROLE_RESULTS_MODERATOR     = "results_moderator"
+	ROLE_ANNOUNCEMENT_EDITOR   = "announcement_editor"
+	ROLE_COMPETITION_ORGANIZER = "competition_organizer"
+
+	PERMISSION_RESULTS_VALIDATE     = "results.validate"
+	PERMISSION_RESULTS_EDIT         = "results.edit"
+	PERMISSION_ANNOUNCEMENTS_MANAGE = "announcements.manage"
+	PERMISSION_COMPETITIONS_MANAGE  = "competitions.manage"
+	PERMISSION_USERS_MANAGE         = "users.manage"
+	PERMISSION_STATS_VIEW           = "stats.view"
+)
+
+var ErrUnknownRole = errors.New("unknown role")
+
+type Role struct {
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Permissions []string `json:"permissions"`
+}
+
+func GetRoles(ctx context.Context, db interfaces.DB) ([]Role, error) {
+	rows, err := db.Query(ctx, `
+		SELECT r.role_id, r.name, r.display_name, COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.role_id
+		LEFT JOIN permissions p ON p.permission_id = rp.permission_id
+		GROUP BY r.role_id
+		ORDER BY r.role_id;
+	`)
+	if err != nil {
+		return []Role{}, fmt.Errorf("%w: when querying roles", err)
+	}
+	defer rows.Close()
+
+	roles := make([]Role, 0)
+	for rows.Next() {
+		var role Role
+		if err = rows.Scan(&role.Id, &role.Name, &role.DisplayName, &role.Permissions); err != nil {
+			return []Role{}, fmt.Errorf("%w: when scanning role", err)
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+// GetUserPermissions returns the permissions of all roles of the user
+func GetUserPermissions(ctx context.Context, db interfaces.DB, uid int) ([]string, error) {
+	rows, err := db.Query(ctx, `
+		SELECT DISTINCT p.name
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.permission_id = rp.permission_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name;
+	`, uid)
+	if err != nil {
+		return []string{}, fmt.Errorf("%w: when querying permissions of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	permissions := make([]string, 0)
+	for rows.Next() {
+		var permission string
+		if err = rows.Scan(&permission); err != nil {
+			return []string{}, fmt.Errorf("%w: when scanning permission", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, nil
+}
+
+// SetUserRoles replaces the roles of the user, users.isadmin is kept in sync
+// with the super admin role for the code still reading it
+func SetUserRoles(ctx context.Context, db interfaces.DB, uid int, roles []string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	roles = slices.Compact(slices.Sorted(slices.Values(roles)))
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1;`, uid)
+	if err != nil {
+		return fmt.Errorf("%w: when deleting roles of user with id=%d", err, uid)
+	}
+
+	for _, role := range roles {
+		tag, err := tx.Exec(ctx, `INSERT INTO user_roles (user_id, role_id) SELECT $1, r.role_id FROM roles r WHERE r.name = $2;`, uid, role)
+		if err != nil {
+			return fmt.Errorf("%w: when inserting role %s of user with id=%d", err, role, uid)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: %s", ErrUnknownRole, role)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE users SET isadmin = $1 WHERE user_id = $2;`, slices.Contains(roles, ROLE_SUPER_ADMIN), uid)
+	if err != nil {
+		return fmt.Errorf("%w: when updating isadmin of user with id=%d", err, uid)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return nil
+}
@@ -1,7 +1,23 @@
 package models
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambleimage"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
 type ScrambleSet struct {
 	Event     CompetitionEvent `json:"event"`
+	Round     int              `json:"round"`
 	Scrambles []Scramble       `json:"scrambles"`
 }
 
@@ -13,3 +29,75 @@ type Scramble struct {
 func (s *ScrambleSet) AddScramble(scramble Scramble) {
 	s.Scrambles = append(s.Scrambles, scramble)
 }
+
+func (s *ScrambleSet) Insert(tx pgx.Tx, cid string) error {
+	for scrambleIdx, scramble := range s.Scrambles {
+		_, err := tx.Exec(context.Background(), `INSERT INTO scrambles (scramble, event_id, competition_id, round, "order", img) VALUES ($1,$2,$3,$4,$5,$6);`, scramble.Scramble, s.Event.Id, cid, max(s.Round, 1), scrambleIdx+1, scramble.Img)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type storedScramble struct {
+	id             int
+	scramble       string
+	img            string
+	scramblingcode string
+	ismbld         bool
+}
+
+// RegenerateScrambleImages renders the images of all stored scrambles again
+// and deletes the replaced files, scrambles of events without a renderer keep
+// their image. Returns the number of regenerated images.
+func RegenerateScrambleImages(ctx context.Context, db interfaces.DB, envMap map[string]string) (int, error) {
+	rows, err := db.Query(ctx, `SELECT s.scramble_id, s.scramble, s.img, e.scramblingcode, e.iconcode = '333mbf' FROM scrambles s JOIN events e ON e.event_id = s.event_id ORDER BY s.scramble_id;`)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when querying scrambles", err)
+	}
+	defer rows.Close()
+
+	scrambles := make([]storedScramble, 0)
+	for rows.Next() {
+		var s storedScramble
+		err = rows.Scan(&s.id, &s.scramble, &s.img, &s.scramblingcode, &s.ismbld)
+		if err != nil {
+			return 0, fmt.Errorf("%w: when scanning scramble", err)
+		}
+
+		scrambles = append(scrambles, s)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("%w: when iterating scrambles", err)
+	}
+
+	regenerated := 0
+	for _, s := range scrambles {
+		if !scrambleimage.Supports(s.scramblingcode) {
+			continue
+		}
+
+		scramble := s.scramble
+		if s.ismbld {
+			scramble = ""
+		}
+
+		_, err = utils.RegenerateImageForScramble(ctx, db, envMap, s.id, scramble, s.scramblingcode)
+		if err != nil {
+			return regenerated, fmt.Errorf("%w: when regenerating image of scramble_id=%d", err, s.id)
+		}
+		regenerated++
+
+		if s.img == "" {
+			continue
+		}
+		err = os.Remove(filepath.Join(envMap["SCRAMBLE_IMAGES_PATH"], s.img))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Failed to remove old scramble image.", "img", s.img, "error", err)
+		}
+	}
+
+	return regenerated, nil
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+// access tokens are short lived so a revoked session stops working soon even
+// for requests not checking it, refresh tokens keep the user logged in
+const (
+	ACCESS_TOKEN_TTL  = 15 * time.Minute
+	REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionRevoked     = errors.New("session revoked or expired")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+type Session struct {
+	Id          int        `json:"id"`
+	UserId      int        `json:"userId"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastUsedAt  time.Time  `json:"lastUsedAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func newSecretToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("%w: when generating secret token", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CreateSession starts a session of the user valid for ttl, only its hash is
+// stored so the returned refresh token can not be recovered later. Sessions
+// without a refresh token (e.g. for links in emails) can not be extended.
+func CreateSession(ctx context.Context, db interfaces.DB, uid int, description string, ttl time.Duration, withRefreshToken bool) (Session, string, error) {
+	var refreshToken string
+	var refreshTokenHash *string
+	if withRefreshToken {
+		token, err := newSecretToken()
+		if err != nil {
+			return Session{}, "", err
+		}
+		hash := hashToken(token)
+		refreshToken, refreshTokenHash = token, &hash
+	}
+
+	session := Session{UserId: uid, Description: description}
+	err := db.QueryRow(
+		ctx,
+		`INSERT INTO sessions (user_id, refresh_token_hash, description, expires_at) VALUES ($1,$2,$3,CURRENT_TIMESTAMP + make_interval(secs => $4)) RETURNING session_id, created_at, last_used_at, expires_at;`,
+		uid,
+		refreshTokenHash,
+		description,
+		ttl.Seconds(),
+	).Scan(&session.Id, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+	if err != nil {
+		return Session{}, "", fmt.Errorf("%w: when inserting session of user with id=%d", err, uid)
+	}
+
+	return session, refreshToken, nil
+}
+
+const sessionColumns = `s.session_id, s.user_id, s.description, s.created_at, s.last_used_at, s.expires_at, s.revoked_at`
+
+func scanSession(row pgx.Row, s *Session) error {
+	return row.Scan(&s.Id, &s.UserId, &s.Description, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt)
+}
+
+// RotateRefreshToken exchanges the refresh token for a new one and extends
+// the session. Presenting an already exchanged token means it was stolen (or
+// the client misbehaves), so the whole session is revoked.
+func RotateRefreshToken(ctx context.Context, db interfaces.DB, refreshToken string) (Session, string, error) {
+	hash := hashToken(refreshToken)
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return Session{}, "", fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var session Session
+	var active bool
+	err = tx.QueryRow(
+		ctx,
+		`SELECT s.session_id, s.user_id, s.revoked_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP FROM sessions s WHERE s.refresh_token_hash = $1 FOR UPDATE;`,
+		hash,
+	).Scan(&session.Id, &session.UserId, &active)
+	if errors.Is(err, pgx.ErrNoRows) {
+		tag, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE previous_refresh_token_hash = $1;`, hash)
+		if err != nil {
+			return Session{}, "", fmt.Errorf("%w: when revoking session of reused refresh token", err)
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return Session{}, "", fmt.Errorf("%w: when commiting transaction", err)
+		}
+		if tag.RowsAffected() > 0 {
+			return Session{}, "", ErrRefreshTokenReused
+		}
+		return Session{}, "", ErrSessionNotFound
+	}
+	if err != nil {
+		return Session{}, "", fmt.Errorf("%w: when getting session by refresh token", err)
+	}
+	if !active {
+		return Session{}, "", fmt.Errorf("%w: id=%d", ErrSessionRevoked, session.Id)
+	}
+
+	newToken, err := newSecretToken()
+	if err != nil {
+		return Session{}, "", err
+	}
+
+	err = scanSession(tx.QueryRow(
+		ctx,
+		`UPDATE sessions s SET refresh_token_hash = $1, previous_refresh_token_hash = $2, last_used_at = CURRENT_TIMESTAMP, expires_at = CURRENT_TIMESTAMP + make_interval(secs => $3) WHERE s.session_id = $4 RETURNING `+sessionColumns+`;`,
+		hashToken(newToken),
+		hash,
+		REFRESH_TOKEN_TTL.Seconds(),
+		session.Id,
+	), &session)
+	if err != nil {
+		return Session{}, "", fmt.Errorf("%w: when rotating refresh token of session with id=%d", err, session.Id)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return Session{}, "", fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return session, newToken, nil
+}
+
+// CheckSession returns ErrSessionRevoked unless the session of the user is
+// still active
+func CheckSession(ctx context.Context, db interfaces.DB, sid int, uid int) error {
+	var active bool
+	err := db.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM sessions s WHERE s.session_id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP);`,
+		sid,
+		uid,
+	).Scan(&active)
+	if err != nil {
+		return fmt.Errorf("%w: when checking session with id=%d", err, sid)
+	}
+	if !active {
+		return fmt.Errorf("%w: id=%d", ErrSessionRevoked, sid)
+	}
+
+	return nil
+}
+
+func RevokeSession(ctx context.Context, db interfaces.DB, sid int, uid int) error {
+	tag, err := db.Exec(ctx, `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL;`, sid, uid)
+	if err != nil {
+		return fmt.Errorf("%w: when revoking session with id=%d", err, sid)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: id=%d", ErrSessionNotFound, sid)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions logs the user out everywhere, returns the number of
+// revoked sessions
+func RevokeUserSessions(ctx context.Context, db interfaces.DB, uid int) (int64, error) {
+	tag, err := db.Exec(ctx, `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL;`, uid)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when revoking sessions of user with id=%d", err, uid)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// GetActiveSessions returns the active sessions of the user, most recently
+// used first
+func GetActiveSessions(ctx context.Context, db interfaces.DB, uid int) ([]Session, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT `+sessionColumns+` FROM sessions s WHERE s.user_id = $1 AND s.revoked_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP ORDER BY s.last_used_at DESC;`,
+		uid,
+	)
+	if err != nil {
+		return []Session{}, fmt.Errorf("%w: when querying sessions of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		var s Session
+		if err = scanSession(rows, &s); err != nil {
+			return []Session{}, fmt.Errorf("%w: when scanning session", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
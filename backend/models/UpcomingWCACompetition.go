@@ -2,7 +2,6 @@ package models
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"slices"
@@ -13,7 +12,9 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
 )
 
 type UpcomingWCACompetition struct {
@@ -30,6 +31,7 @@ type UpcomingWCACompetition struct {
 	VenueAddress      string             `json:"venueAddress"`
 	Url               string             `json:"url"`
 	Events            []CompetitionEvent `json:"events"`
+	Cancelled         bool               `json:"cancelled"`
 	CountryId         string             `json:"-"`
 	CountryName       string             `json:"-"`
 	CountryIso2       string             `json:"-"`
@@ -37,56 +39,116 @@ type UpcomingWCACompetition struct {
 	City              string             `json:"-"`
 }
 
-type GetWCACompetitionsResponse struct {
-	Id                string    `json:"id"`
-	Name              string    `json:"name"`
-	Startdate         string    `json:"start_date"`
-	Enddate           string    `json:"end_date"`
-	RegistrationOpen  time.Time `json:"registration_open"`
-	RegistrationClose time.Time `json:"registration_close"`
-	LatitudeDegrees   float64   `json:"latitude_degrees"`
-	LongitudeDegrees  float64   `json:"longitude_degrees"`
-	CompetitorLimit   int       `json:"competitor_limit"`
-	Url               string    `json:"url"`
-	CountryIso2       string    `json:"country_iso2"`
-	VenueAddress      string    `json:"venue_address"`
-	City              string    `json:"city"`
-	EventIds          []string  `json:"event_ids"`
+// UpcomingWCACompetitionKey identifies the saved competition, the competition
+// is saved once for every country with its iso2
+type UpcomingWCACompetitionKey struct {
+	Id        string
+	CountryId string
 }
 
-type UpcomingWCACompetitionRegistration struct {
-	Id int `json:"id"`
+func (c *UpcomingWCACompetition) Key() UpcomingWCACompetitionKey {
+	return UpcomingWCACompetitionKey{Id: c.Id, CountryId: c.CountryId}
 }
 
-func (c *UpcomingWCACompetition) GetRegistered(db pgx.Tx) error {
-	url := fmt.Sprintf(
-		"https://www.worldcubeassociation.org/api/v0/competitions/%s/registrations",
-		c.Id,
+// GetKnownUpcomingWCACompetitions returns all the saved competitions, including
+// the cancelled ones, as they were saved by the last sync
+func GetKnownUpcomingWCACompetitions(ctx context.Context, db interfaces.DB) (map[UpcomingWCACompetitionKey]UpcomingWCACompetition, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT c.upcoming_wca_competition_id, c.country_id, c.name, c.startdate, c.enddate, c.registered, c.competitor_limit, c.venue_address, c.url, c.registration_open, c.registration_close, c.latitude_degrees, c.longitude_degrees, c.state, c.cancelled, COALESCE(ARRAY_AGG(e.iconcode ORDER BY e.event_id) FILTER (WHERE e.event_id IS NOT NULL), '{}') FROM upcoming_wca_competitions c LEFT JOIN upcoming_wca_competition_events ce ON ce.upcoming_wca_competition_id = c.upcoming_wca_competition_id AND ce.country_id = c.country_id LEFT JOIN events e ON e.event_id = ce.event_id GROUP BY c.upcoming_wca_competition_id, c.country_id;`,
 	)
-	body, err := utils.GetRequest(url)
 	if err != nil {
-		log.Println(
-			"ERR utils.GetRequest(" + url + ") in UpcomingWCACompetition.GetRegistered: " + err.Error(),
-		)
-		return err
+		return nil, fmt.Errorf("%w: when querying upcoming wca competitions", err)
 	}
+	defer rows.Close()
 
-	regs := []UpcomingWCACompetitionRegistration{}
-	err = json.Unmarshal(body, &regs)
-	if err != nil && string(body) != "" {
-		log.Println(
-			"ERR json.Unmarshal(" + string(
-				body,
-			) + ") in UpcomingWCACompetition.GetRegistered: " + err.Error(),
+	comps := make(map[UpcomingWCACompetitionKey]UpcomingWCACompetition)
+	for rows.Next() {
+		var comp UpcomingWCACompetition
+		var iconcodes []string
+		err = rows.Scan(
+			&comp.Id,
+			&comp.CountryId,
+			&comp.Name,
+			&comp.Startdate,
+			&comp.Enddate,
+			&comp.Registered,
+			&comp.CompetitorLimit,
+			&comp.VenueAddress,
+			&comp.Url,
+			&comp.RegistrationOpen,
+			&comp.RegistrationClose,
+			&comp.LatitudeDegrees,
+			&comp.LongitudeDegrees,
+			&comp.State,
+			&comp.Cancelled,
+			&iconcodes,
 		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: when scanning upcoming wca competition", err)
+		}
+
+		comp.Events = utils.Map(iconcodes, func(iconcode string) CompetitionEvent { return CompetitionEvent{Iconcode: iconcode} })
+		comps[comp.Key()] = comp
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: when iterating through upcoming wca competitions", err)
+	}
+
+	return comps, nil
+}
+
+// GetRegistered loads the number of the registrations from the WCA
+func (c *UpcomingWCACompetition) GetRegistered(ctx context.Context, client wca.Client) error {
+	registrations, err := client.GetCompetitionRegistrations(ctx, c.Id)
+	if err != nil {
 		return err
 	}
 
-	c.Registered = len(regs)
+	c.Registered = len(registrations)
+
+	return nil
+}
+
+// UpdateRegistered saves only the registered count, for the competitions
+// without other changes
+func (c *UpcomingWCACompetition) UpdateRegistered(ctx context.Context, db interfaces.DB) error {
+	_, err := db.Exec(
+		ctx,
+		`UPDATE upcoming_wca_competitions SET registered = $1 WHERE upcoming_wca_competition_id = $2 AND country_id = $3;`,
+		c.Registered,
+		c.Id,
+		c.CountryId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when updating registered of upcoming wca competition %s", err, c.Id)
+	}
 
 	return nil
 }
 
+// Cancel marks the competition cancelled and records it in the history, the
+// competition stays saved until it would have ended
+func (c *UpcomingWCACompetition) Cancel(ctx context.Context, db interfaces.DB) (UpcomingWCACompetitionChange, error) {
+	_, err := db.Exec(
+		ctx,
+		`UPDATE upcoming_wca_competitions SET cancelled = TRUE WHERE upcoming_wca_competition_id = $1 AND country_id = $2;`,
+		c.Id,
+		c.CountryId,
+	)
+	if err != nil {
+		return UpcomingWCACompetitionChange{}, fmt.Errorf("%w: when cancelling upcoming wca competition %s", err, c.Id)
+	}
+	c.Cancelled = true
+
+	change := UpcomingWCACompetitionChange{CompetitionId: c.Id, CountryId: c.CountryId, Field: WCA_COMPETITION_CHANGE_CANCELLED}
+	if err = change.Insert(ctx, db); err != nil {
+		return UpcomingWCACompetitionChange{}, err
+	}
+
+	return change, nil
+}
+
 func (c *UpcomingWCACompetition) SaveEvents(db pgx.Tx) error {
 	for _, event := range c.Events {
 		_, err := db.Exec(
@@ -179,7 +241,7 @@ func (c *UpcomingWCACompetition) Save(db pgx.Tx) (pgconn.CommandTag, error) {
 			return pgconn.CommandTag{}, err
 		}
 	} else {
-		_, err := db.Exec(context.Background(), `UPDATE upcoming_wca_competitions SET name = $1, startdate = $2, enddate = $3, registered = $4, competitor_limit = $5, venue_address = $6, url = $7, registration_open = $8, registration_close = $9, latitude_degrees = $10, longitude_degrees = $11, state = $12 WHERE upcoming_wca_competition_id = $13 AND country_id = $14;`, c.Name, c.Startdate, c.Enddate, c.Registered, c.CompetitorLimit, c.VenueAddress, c.Url, c.RegistrationOpen, c.RegistrationClose, c.LatitudeDegrees, c.LongitudeDegrees, c.State, c.Id, c.CountryId)
+		_, err := db.Exec(context.Background(), `UPDATE upcoming_wca_competitions SET name = $1, startdate = $2, enddate = $3, registered = $4, competitor_limit = $5, venue_address = $6, url = $7, registration_open = $8, registration_close = $9, latitude_degrees = $10, longitude_degrees = $11, state = $12, cancelled = FALSE WHERE upcoming_wca_competition_id = $13 AND country_id = $14;`, c.Name, c.Startdate, c.Enddate, c.Registered, c.CompetitorLimit, c.VenueAddress, c.Url, c.RegistrationOpen, c.RegistrationClose, c.LatitudeDegrees, c.LongitudeDegrees, c.State, c.Id, c.CountryId)
 		if err != nil {
 			log.Println("ERR db.Exec(update upcoming_wca_competitions) in UpcomingWCACompetition.Save: " + err.Error())
 			return pgconn.CommandTag{}, err
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+// the fields of the upcoming WCA competitions whose changes are kept in the
+// history, the registration_reopened is the registration_close moved after it
+// already passed
+const (
+	WCA_COMPETITION_CHANGE_NAME                  = "name"
+	WCA_COMPETITION_CHANGE_DATES                 = "dates"
+	WCA_COMPETITION_CHANGE_VENUE                 = "venue"
+	WCA_COMPETITION_CHANGE_COMPETITOR_LIMIT      = "competitor_limit"
+	WCA_COMPETITION_CHANGE_EVENTS                = "events"
+	WCA_COMPETITION_CHANGE_REGISTRATION_OPEN     = "registration_open"
+	WCA_COMPETITION_CHANGE_REGISTRATION_CLOSE    = "registration_close"
+	WCA_COMPETITION_CHANGE_REGISTRATION_REOPENED = "registration_reopened"
+	WCA_COMPETITION_CHANGE_CANCELLED             = "cancelled"
+	WCA_COMPETITION_CHANGE_REINSTATED            = "reinstated"
+
+	WCA_REGISTRATION_TIME_LAYOUT = "02 Jan 2006 15:04:05 MST"
+)
+
+// NOTIFIED_WCA_COMPETITION_CHANGES are the changes worth an email to the
+// subscribers, the rest is only kept in the history
+var NOTIFIED_WCA_COMPETITION_CHANGES = []string{
+	WCA_COMPETITION_CHANGE_DATES,
+	WCA_COMPETITION_CHANGE_VENUE,
+	WCA_COMPETITION_CHANGE_COMPETITOR_LIMIT,
+	WCA_COMPETITION_CHANGE_REGISTRATION_OPEN,
+	WCA_COMPETITION_CHANGE_REGISTRATION_REOPENED,
+	WCA_COMPETITION_CHANGE_CANCELLED,
+	WCA_COMPETITION_CHANGE_REINSTATED,
+}
+
+type UpcomingWCACompetitionChange struct {
+	Id            int       `json:"id"`
+	CompetitionId string    `json:"competitionId"`
+	CountryId     string    `json:"countryId"`
+	Field         string    `json:"field"`
+	OldValue      string    `json:"oldValue"`
+	NewValue      string    `json:"newValue"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+func (c UpcomingWCACompetitionChange) Notify() bool {
+	return slices.Contains(NOTIFIED_WCA_COMPETITION_CHANGES, c.Field)
+}
+
+func (c *UpcomingWCACompetitionChange) Insert(ctx context.Context, db interfaces.DB) error {
+	err := db.QueryRow(
+		ctx,
+		`INSERT INTO upcoming_wca_competition_changes (upcoming_wca_competition_id, country_id, field, old_value, new_value) VALUES ($1, $2, $3, $4, $5) RETURNING upcoming_wca_competition_change_id, timestamp;`,
+		c.CompetitionId,
+		c.CountryId,
+		c.Field,
+		c.OldValue,
+		c.NewValue,
+	).Scan(&c.Id, &c.Timestamp)
+	if err != nil {
+		return fmt.Errorf("%w: when inserting %s change of upcoming wca competition %s", err, c.Field, c.CompetitionId)
+	}
+
+	return nil
+}
+
+// GetUpcomingWCACompetitionChanges returns the history of the competition in
+// the country, oldest first
+func GetUpcomingWCACompetitionChanges(ctx context.Context, db interfaces.DB, competitionId string, countryId string) ([]UpcomingWCACompetitionChange, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT c.upcoming_wca_competition_change_id, c.upcoming_wca_competition_id, c.country_id, c.field, c.old_value, c.new_value, c.timestamp FROM upcoming_wca_competition_changes c WHERE c.upcoming_wca_competition_id = $1 AND c.country_id = $2 ORDER BY c.upcoming_wca_competition_change_id;`,
+		competitionId,
+		countryId,
+	)
+	if err != nil {
+		return []UpcomingWCACompetitionChange{}, fmt.Errorf("%w: when querying changes of upcoming wca competition %s", err, competitionId)
+	}
+	defer rows.Close()
+
+	changes := make([]UpcomingWCACompetitionChange, 0)
+	for rows.Next() {
+		var change UpcomingWCACompetitionChange
+		if err = rows.Scan(&change.Id, &change.CompetitionId, &change.CountryId, &change.Field, &change.OldValue, &change.NewValue, &change.Timestamp); err != nil {
+			return []UpcomingWCACompetitionChange{}, fmt.Errorf("%w: when scanning change", err)
+		}
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return []UpcomingWCACompetitionChange{}, fmt.Errorf("%w: when iterating through changes", err)
+	}
+
+	return changes, nil
+}
+
+func eventIconcodes(events []CompetitionEvent) string {
+	iconcodes := make([]string, 0, len(events))
+	for _, event := range events {
+		iconcodes = append(iconcodes, event.Iconcode)
+	}
+	slices.Sort(iconcodes)
+
+	return strings.Join(slices.Compact(iconcodes), ", ")
+}
+
+// Changes compares the competition loaded from the WCA with its previous
+// state saved in the db, the registered count changes all the time and is not
+// tracked
+func (c *UpcomingWCACompetition) Changes(previous UpcomingWCACompetition, now time.Time) []UpcomingWCACompetitionChange {
+	changes := make([]UpcomingWCACompetitionChange, 0)
+	add := func(field string, oldValue string, newValue string) {
+		changes = append(changes, UpcomingWCACompetitionChange{
+			CompetitionId: c.Id,
+			CountryId:     c.CountryId,
+			Field:         field,
+			OldValue:      oldValue,
+			NewValue:      newValue,
+		})
+	}
+
+	if previous.Cancelled {
+		add(WCA_COMPETITION_CHANGE_REINSTATED, "", "")
+	}
+	if c.Name != previous.Name {
+		add(WCA_COMPETITION_CHANGE_NAME, previous.Name, c.Name)
+	}
+	if !c.Startdate.Equal(previous.Startdate) || !c.Enddate.Equal(previous.Enddate) {
+		add(WCA_COMPETITION_CHANGE_DATES, previous.DateFormatted(), c.DateFormatted())
+	}
+	if c.VenueAddress != previous.VenueAddress {
+		add(WCA_COMPETITION_CHANGE_VENUE, previous.VenueAddress, c.VenueAddress)
+	}
+	if c.CompetitorLimit != previous.CompetitorLimit {
+		add(WCA_COMPETITION_CHANGE_COMPETITOR_LIMIT, strconv.Itoa(previous.CompetitorLimit), strconv.Itoa(c.CompetitorLimit))
+	}
+	if oldEvents, newEvents := eventIconcodes(previous.Events), eventIconcodes(c.Events); oldEvents != newEvents {
+		add(WCA_COMPETITION_CHANGE_EVENTS, oldEvents, newEvents)
+	}
+	if !c.RegistrationOpen.Equal(previous.RegistrationOpen) {
+		add(
+			WCA_COMPETITION_CHANGE_REGISTRATION_OPEN,
+			previous.RegistrationOpen.UTC().Format(WCA_REGISTRATION_TIME_LAYOUT),
+			c.RegistrationOpen.UTC().Format(WCA_REGISTRATION_TIME_LAYOUT),
+		)
+	}
+	if !c.RegistrationClose.Equal(previous.RegistrationClose) {
+		field := WCA_COMPETITION_CHANGE_REGISTRATION_CLOSE
+		if previous.RegistrationClose.Before(now) && c.RegistrationClose.After(now) {
+			field = WCA_COMPETITION_CHANGE_REGISTRATION_REOPENED
+		}
+		add(
+			field,
+			previous.RegistrationClose.UTC().Format(WCA_REGISTRATION_TIME_LAYOUT),
+			c.RegistrationClose.UTC().Format(WCA_REGISTRATION_TIME_LAYOUT),
+		)
+	}
+
+	return changes
+}
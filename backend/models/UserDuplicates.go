@@ -0,0 +1,251 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+// weights of the signals making up the score of a duplicate candidate pair,
+// a pair of identical names from the same country whose accounts never met at
+// a competition scores 70 plus up to 10 for the events they share
+const (
+	DUPLICATE_NAME_WEIGHT           = 50
+	DUPLICATE_SAME_WCA_ID_WEIGHT    = 40
+	DUPLICATE_SAME_COUNTRY_WEIGHT   = 10
+	DUPLICATE_EVENT_OVERLAP_WEIGHT  = 10
+	DUPLICATE_NEVER_MET_WEIGHT      = 10
+	DUPLICATE_MET_PENALTY           = 40
+	DUPLICATE_MIN_NAME_SIMILARITY   = 0.75
+	DUPLICATE_DEFAULT_MIN_SCORE     = 50
+	DUPLICATE_DEFAULT_REPORT_LENGTH = 100
+)
+
+type DuplicateCandidate struct {
+	Id           int
+	Name         string
+	WcaId        string
+	CountryId    string
+	CountryName  string
+	CountryIso2  string
+	Normalized   string
+	Competitions map[string]struct{}
+	Events       map[int]struct{}
+}
+
+func (c *DuplicateCandidate) manageUser() ManageUser {
+	return ManageUser{Id: c.Id, Name: c.Name, WcaId: c.WcaId, CountryName: c.CountryName, CountryIso2: c.CountryIso2}
+}
+
+// DuplicateUserPair is one entry of the duplicates report, OldUser has the
+// lower id, so it is the one removed when the pair is merged
+type DuplicateUserPair struct {
+	OldUser            ManageUser `json:"oldUser"`
+	NewUser            ManageUser `json:"newUser"`
+	Score              float64    `json:"score"`
+	NameSimilarity     float64    `json:"nameSimilarity"`
+	SameCountry        bool       `json:"sameCountry"`
+	SameWcaId          bool       `json:"sameWcaId"`
+	EventOverlap       float64    `json:"eventOverlap"`
+	SharedCompetitions int        `json:"sharedCompetitions"`
+}
+
+func levenshtein(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func containsAllWords(words []string, of []string) bool {
+	for _, w := range of {
+		if !slices.Contains(words, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// NameSimilarity compares two already unaccented and lowercased names, one
+// containing the other or all of its words (e.g. a missing middle name)
+// counts almost as a match
+func NameSimilarity(a string, b string) float64 {
+	wa, wb := strings.Fields(a), strings.Fields(b)
+	a, b = strings.Join(wa, " "), strings.Join(wb, " ")
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) || containsAllWords(wa, wb) || containsAllWords(wb, wa) {
+		return 0.9
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	return 1 - float64(levenshtein(ra, rb))/float64(max(len(ra), len(rb)))
+}
+
+// ScoreDuplicatePair scores how likely the two users are the same person. The
+// name is the main signal, accounts competing in the same competition are most
+// likely different people, while accounts that never met but compete in the
+// same events are likely an abandoned account and its replacement.
+func ScoreDuplicatePair(a *DuplicateCandidate, b *DuplicateCandidate) DuplicateUserPair {
+	if a.Id > b.Id {
+		a, b = b, a
+	}
+
+	pair := DuplicateUserPair{
+		OldUser:        a.manageUser(),
+		NewUser:        b.manageUser(),
+		NameSimilarity: NameSimilarity(a.Normalized, b.Normalized),
+		SameCountry:    a.CountryId != "" && a.CountryId == b.CountryId,
+		SameWcaId:      a.WcaId != "" && strings.EqualFold(a.WcaId, b.WcaId),
+	}
+
+	for cid := range a.Competitions {
+		if _, ok := b.Competitions[cid]; ok {
+			pair.SharedCompetitions++
+		}
+	}
+
+	sharedEvents := 0
+	for eid := range a.Events {
+		if _, ok := b.Events[eid]; ok {
+			sharedEvents++
+		}
+	}
+	if union := len(a.Events) + len(b.Events) - sharedEvents; union > 0 {
+		pair.EventOverlap = float64(sharedEvents) / float64(union)
+	}
+
+	pair.Score = pair.NameSimilarity * DUPLICATE_NAME_WEIGHT
+	if pair.SameWcaId {
+		pair.Score += DUPLICATE_SAME_WCA_ID_WEIGHT
+	}
+	if pair.SameCountry {
+		pair.Score += DUPLICATE_SAME_COUNTRY_WEIGHT
+	}
+	pair.Score += pair.EventOverlap * DUPLICATE_EVENT_OVERLAP_WEIGHT
+	if pair.SharedCompetitions > 0 {
+		pair.Score -= DUPLICATE_MET_PENALTY
+	} else if len(a.Competitions) > 0 && len(b.Competitions) > 0 {
+		pair.Score += DUPLICATE_NEVER_MET_WEIGHT
+	}
+
+	return pair
+}
+
+// FindDuplicatePairs scores every pair of candidates with similar names or
+// the same wca id and returns the ones scoring at least minScore, best first
+func FindDuplicatePairs(candidates []DuplicateCandidate, minScore float64, limit int) []DuplicateUserPair {
+	pairs := make([]DuplicateUserPair, 0)
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			a, b := &candidates[i], &candidates[j]
+			sameWcaId := a.WcaId != "" && strings.EqualFold(a.WcaId, b.WcaId)
+			if !sameWcaId && NameSimilarity(a.Normalized, b.Normalized) < DUPLICATE_MIN_NAME_SIMILARITY {
+				continue
+			}
+
+			pair := ScoreDuplicatePair(a, b)
+			if pair.Score >= minScore {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i int, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].OldUser.Id != pairs[j].OldUser.Id {
+			return pairs[i].OldUser.Id < pairs[j].OldUser.Id
+		}
+		return pairs[i].NewUser.Id < pairs[j].NewUser.Id
+	})
+
+	if limit > 0 && len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+
+	return pairs
+}
+
+func GetDuplicateCandidates(ctx context.Context, db interfaces.DB) ([]DuplicateCandidate, error) {
+	rows, err := db.Query(ctx, `
+		SELECT u.user_id, u.name, u.wcaid, u.country_id, COALESCE(c.name, ''), COALESCE(c.iso2, ''), lower(unaccent(u.name))
+		FROM users u
+		LEFT JOIN countries c ON c.country_id = u.country_id
+		ORDER BY u.user_id;
+	`)
+	if err != nil {
+		return []DuplicateCandidate{}, fmt.Errorf("%w: when querying duplicate candidates", err)
+	}
+	defer rows.Close()
+
+	candidates := make([]DuplicateCandidate, 0)
+	idxById := make(map[int]int)
+	for rows.Next() {
+		c := DuplicateCandidate{Competitions: make(map[string]struct{}), Events: make(map[int]struct{})}
+		err = rows.Scan(&c.Id, &c.Name, &c.WcaId, &c.CountryId, &c.CountryName, &c.CountryIso2, &c.Normalized)
+		if err != nil {
+			return []DuplicateCandidate{}, fmt.Errorf("%w: when scanning duplicate candidate", err)
+		}
+		idxById[c.Id] = len(candidates)
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+
+	rows, err = db.Query(ctx, `SELECT DISTINCT r.user_id, r.competition_id, r.event_id FROM results r;`)
+	if err != nil {
+		return []DuplicateCandidate{}, fmt.Errorf("%w: when querying competition participation of duplicate candidates", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var uid, eid int
+		var cid string
+		if err = rows.Scan(&uid, &cid, &eid); err != nil {
+			return []DuplicateCandidate{}, fmt.Errorf("%w: when scanning competition participation", err)
+		}
+
+		idx, ok := idxById[uid]
+		if !ok {
+			continue
+		}
+		candidates[idx].Competitions[cid] = struct{}{}
+		candidates[idx].Events[eid] = struct{}{}
+	}
+
+	return candidates, nil
+}
+
+// GetDuplicateUsersReport ranks the likely duplicate accounts among all users
+func GetDuplicateUsersReport(ctx context.Context, db interfaces.DB, minScore float64, limit int) ([]DuplicateUserPair, error) {
+	candidates, err := GetDuplicateCandidates(ctx, db)
+	if err != nil {
+		return []DuplicateUserPair{}, err
+	}
+
+	return FindDuplicatePairs(candidates, minScore, limit), nil
+}
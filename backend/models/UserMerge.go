@@ -0,0 +1,282 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+)
+
+var (
+	ErrUserMergeNotFound = errors.New("user merge not found")
+	ErrAlreadyUnmerged   = errors.New("user merge already undone")
+	ErrMergeNotLatest    = errors.New("merged user was merged again since")
+)
+
+// UserMergeRow is one row of a child table moved from the old user to the new
+// one, identified by its primary key
+type UserMergeRow struct {
+	TableName  string `json:"tableName"`
+	ColumnName string `json:"columnName"`
+	RowId      string `json:"rowId"`
+}
+
+type UserMerge struct {
+	Id         int             `json:"id"`
+	OldUserId  int             `json:"oldUserId"`
+	NewUserId  int             `json:"newUserId"`
+	OldUser    json.RawMessage `json:"oldUser"`
+	MergedBy   int             `json:"mergedBy"`
+	MergedAt   time.Time       `json:"mergedAt"`
+	UnmergedBy *int            `json:"unmergedBy"`
+	UnmergedAt *time.Time      `json:"unmergedAt"`
+	Rows       []UserMergeRow  `json:"rows"`
+}
+
+type userForeignKey struct {
+	ChildTable  string
+	ChildColumn string
+	PrimaryKey  string
+}
+
+// getUserForeignKeys finds every column referencing users together with the
+// primary key of its table, which is needed to record the moved rows
+func getUserForeignKeys(ctx context.Context, tx pgx.Tx) ([]userForeignKey, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			c.conrelid::regclass::text AS child_table,
+			a.attname AS child_column,
+			(
+				SELECT string_agg(pa.attname, ',')
+				FROM pg_index i
+				JOIN pg_attribute pa ON pa.attrelid = i.indrelid AND pa.attnum = ANY(i.indkey)
+				WHERE i.indrelid = c.conrelid AND i.indisprimary
+			) AS primary_key
+		FROM
+			pg_constraint AS c
+		JOIN pg_attribute AS a ON a.attrelid = c.conrelid AND a.attnum = ANY(c.conkey)
+		WHERE
+			c.contype = 'f'
+			AND c.confrelid = 'users'::regclass
+	`)
+	if err != nil {
+		return []userForeignKey{}, fmt.Errorf("%w: when querying foreign key constraints", err)
+	}
+	defer rows.Close()
+
+	fks := make([]userForeignKey, 0)
+	for rows.Next() {
+		var fk userForeignKey
+		var primaryKey *string
+		if err := rows.Scan(&fk.ChildTable, &fk.ChildColumn, &primaryKey); err != nil {
+			return []userForeignKey{}, fmt.Errorf("%w: when scanning fk record", err)
+		}
+
+		// without a single column primary key the moved rows could not be told
+		// apart from the rows of the new user when unmerging
+		if primaryKey == nil || *primaryKey == "" || strings.Contains(*primaryKey, ",") {
+			return []userForeignKey{}, fmt.Errorf("table %s referencing users has no single column primary key", fk.ChildTable)
+		}
+		fk.PrimaryKey = *primaryKey
+
+		fks = append(fks, fk)
+	}
+	if err = rows.Err(); err != nil {
+		return []userForeignKey{}, fmt.Errorf("%w: when iteraing through rows", err)
+	}
+
+	return fks, nil
+}
+
+func (m *UserMerge) insert(ctx context.Context, tx pgx.Tx) error {
+	err := tx.QueryRow(
+		ctx,
+		`INSERT INTO user_merges (old_user_id, new_user_id, old_user, merged_by) VALUES ($1,$2,$3,$4) RETURNING user_merge_id, merged_at;`,
+		m.OldUserId,
+		m.NewUserId,
+		m.OldUser,
+		m.MergedBy,
+	).Scan(&m.Id, &m.MergedAt)
+	if err != nil {
+		return fmt.Errorf("%w: when inserting user merge", err)
+	}
+
+	for _, row := range m.Rows {
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO user_merge_rows (user_merge_id, table_name, column_name, row_id) VALUES ($1,$2,$3,$4);`,
+			m.Id,
+			row.TableName,
+			row.ColumnName,
+			row.RowId,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: when inserting moved row of user merge with id=%d", err, m.Id)
+		}
+	}
+
+	return nil
+}
+
+const userMergeColumns = `m.user_merge_id, m.old_user_id, m.new_user_id, m.old_user, m.merged_by, m.merged_at, m.unmerged_by, m.unmerged_at`
+
+func scanUserMerge(row pgx.Row, m *UserMerge) error {
+	return row.Scan(&m.Id, &m.OldUserId, &m.NewUserId, &m.OldUser, &m.MergedBy, &m.MergedAt, &m.UnmergedBy, &m.UnmergedAt)
+}
+
+func (m *UserMerge) Get(ctx context.Context, db interfaces.DB, id int) error {
+	err := scanUserMerge(db.QueryRow(ctx, `SELECT `+userMergeColumns+` FROM user_merges m WHERE m.user_merge_id = $1;`, id), m)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: id=%d", ErrUserMergeNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: when getting user merge with id=%d", err, id)
+	}
+
+	rows, err := db.Query(ctx, `SELECT table_name, column_name, row_id FROM user_merge_rows WHERE user_merge_id = $1 ORDER BY user_merge_row_id;`, id)
+	if err != nil {
+		return fmt.Errorf("%w: when querying moved rows of user merge with id=%d", err, id)
+	}
+	defer rows.Close()
+
+	m.Rows = make([]UserMergeRow, 0)
+	for rows.Next() {
+		var row UserMergeRow
+		if err = rows.Scan(&row.TableName, &row.ColumnName, &row.RowId); err != nil {
+			return fmt.Errorf("%w: when scanning moved row of user merge with id=%d", err, id)
+		}
+		m.Rows = append(m.Rows, row)
+	}
+
+	return nil
+}
+
+// GetUserMerges returns the merges, newest first, without the moved rows
+func GetUserMerges(ctx context.Context, db interfaces.DB) ([]UserMerge, error) {
+	rows, err := db.Query(ctx, `SELECT `+userMergeColumns+` FROM user_merges m ORDER BY m.merged_at DESC, m.user_merge_id DESC;`)
+	if err != nil {
+		return []UserMerge{}, fmt.Errorf("%w: when querying user merges", err)
+	}
+	defer rows.Close()
+
+	merges := make([]UserMerge, 0)
+	for rows.Next() {
+		var m UserMerge
+		if err = scanUserMerge(rows, &m); err != nil {
+			return []UserMerge{}, fmt.Errorf("%w: when scanning user merge", err)
+		}
+		m.Rows = []UserMergeRow{}
+		merges = append(merges, m)
+	}
+
+	return merges, nil
+}
+
+// UnmergeUsers restores the old user of the merge and moves back exactly the
+// rows the merge moved. Merges have to be undone newest first, a merge whose
+// new user was merged into someone else since can not be undone.
+func UnmergeUsers(ctx context.Context, db interfaces.DB, mergeId int, adminID int) error {
+	var merge UserMerge
+	if err := merge.Get(ctx, db, mergeId); err != nil {
+		return err
+	}
+	if merge.UnmergedAt != nil {
+		return fmt.Errorf("%w: id=%d", ErrAlreadyUnmerged, mergeId)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var mergedSince bool
+	err = tx.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM user_merges m WHERE m.old_user_id = $1 AND m.user_merge_id > $2 AND m.unmerged_at IS NULL);`,
+		merge.NewUserId,
+		merge.Id,
+	).Scan(&mergedSince)
+	if err != nil {
+		return fmt.Errorf("%w: when checking later merges of user with id=%d", err, merge.NewUserId)
+	}
+	if mergedSince {
+		return fmt.Errorf("%w: user with id=%d", ErrMergeNotLatest, merge.NewUserId)
+	}
+
+	// only the columns in the snapshot are restored, the ones added since the
+	// merge get their defaults
+	var columns string
+	err = tx.QueryRow(
+		ctx,
+		`SELECT COALESCE(string_agg(quote_ident(a.attname), ', '), '') FROM pg_attribute a WHERE a.attrelid = 'users'::regclass AND a.attnum > 0 AND NOT a.attisdropped AND a.attname IN (SELECT jsonb_object_keys($1));`,
+		merge.OldUser,
+	).Scan(&columns)
+	if err != nil {
+		return fmt.Errorf("%w: when getting restored columns of user with id=%d", err, merge.OldUserId)
+	}
+	if columns == "" {
+		return fmt.Errorf("snapshot of user with id=%d has no columns of users", merge.OldUserId)
+	}
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(`INSERT INTO users (%s) SELECT %s FROM jsonb_populate_record(NULL::users, $1);`, columns, columns), merge.OldUser)
+	if err != nil {
+		return fmt.Errorf("%w: when restoring user with id=%d", err, merge.OldUserId)
+	}
+
+	fks, err := getUserForeignKeys(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	primaryKeys := make(map[string]string)
+	for _, fk := range fks {
+		primaryKeys[fk.ChildTable] = fk.PrimaryKey
+	}
+
+	moved := make(map[[2]string][]string)
+	for _, row := range merge.Rows {
+		key := [2]string{row.TableName, row.ColumnName}
+		moved[key] = append(moved[key], row.RowId)
+	}
+
+	for key, ids := range moved {
+		primaryKey, ok := primaryKeys[key[0]]
+		if !ok {
+			return fmt.Errorf("table %s of the merge no longer references users", key[0])
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE %s = $2 AND %s::text = ANY($3)`, key[0], key[1], key[1], primaryKey),
+			merge.OldUserId,
+			merge.NewUserId,
+			ids,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: when moving back rows of %s", err, key[0])
+		}
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE user_merges SET unmerged_by = $1, unmerged_at = CURRENT_TIMESTAMP WHERE user_merge_id = $2;`,
+		adminID,
+		merge.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when marking user merge with id=%d as undone", err, merge.Id)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,339 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
+)
+
+// the watchers of the competition are reminded before its registration opens
+// and when it is almost full, every reminder at most once per registration
+// opening
+const (
+	WCA_REMINDER_REGISTRATION_24H = "registration_24h"
+	WCA_REMINDER_REGISTRATION_15M = "registration_15m"
+	WCA_REMINDER_ALMOST_FULL      = "almost_full"
+
+	// the competition is almost full when this percentage of the competitor
+	// limit is registered
+	WCA_ALMOST_FULL_PERCENT = 90
+
+	// the scheduler checks at least this often, the registered counts and the
+	// new watches do not wake it up
+	WCA_REMINDER_MAX_WAIT = time.Minute
+)
+
+// WCA_REMINDERS are in the order they become due
+var WCA_REMINDERS = []string{WCA_REMINDER_REGISTRATION_24H, WCA_REMINDER_REGISTRATION_15M, WCA_REMINDER_ALMOST_FULL}
+
+var ErrUpcomingWCACompetitionNotFound = errors.New("upcoming wca competition not found")
+
+// WCAReminderDue returns whether the reminder of the competition should be
+// sent at the time, the registration reminders are not sent after the next one
+// is due
+func WCAReminderDue(kind string, comp UpcomingWCACompetition, now time.Time) bool {
+	switch kind {
+	case WCA_REMINDER_REGISTRATION_24H:
+		return !now.Before(comp.RegistrationOpen.Add(-24*time.Hour)) && now.Before(comp.RegistrationOpen.Add(-15*time.Minute))
+	case WCA_REMINDER_REGISTRATION_15M:
+		return !now.Before(comp.RegistrationOpen.Add(-15*time.Minute)) && now.Before(comp.RegistrationOpen)
+	case WCA_REMINDER_ALMOST_FULL:
+		return !now.Before(comp.RegistrationOpen) &&
+			now.Before(comp.RegistrationClose) &&
+			comp.CompetitorLimit > 0 &&
+			comp.Registered < comp.CompetitorLimit &&
+			comp.Registered*100 >= comp.CompetitorLimit*WCA_ALMOST_FULL_PERCENT
+	}
+
+	return false
+}
+
+// WatchWCACompetition makes the user get the reminders of the competition,
+// watching it again does nothing
+func WatchWCACompetition(ctx context.Context, db interfaces.DB, uid int, competitionId string) error {
+	var exists bool
+	err := db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM upcoming_wca_competitions c WHERE c.upcoming_wca_competition_id = $1);`, competitionId).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%w: when checking upcoming wca competition %s", err, competitionId)
+	}
+	if !exists {
+		return fmt.Errorf("%w: id=%s", ErrUpcomingWCACompetitionNotFound, competitionId)
+	}
+
+	_, err = db.Exec(
+		ctx,
+		`INSERT INTO wca_competition_watches (user_id, upcoming_wca_competition_id) SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM wca_competition_watches w WHERE w.user_id = $1 AND w.upcoming_wca_competition_id = $2);`,
+		uid,
+		competitionId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: when watching upcoming wca competition %s by user with id=%d", err, competitionId, uid)
+	}
+
+	return nil
+}
+
+// UnwatchWCACompetition removes all the watches, the merged users may have
+// more of them
+func UnwatchWCACompetition(ctx context.Context, db interfaces.DB, uid int, competitionId string) error {
+	_, err := db.Exec(ctx, `DELETE FROM wca_competition_watches WHERE user_id = $1 AND upcoming_wca_competition_id = $2;`, uid, competitionId)
+	if err != nil {
+		return fmt.Errorf("%w: when unwatching upcoming wca competition %s by user with id=%d", err, competitionId, uid)
+	}
+
+	return nil
+}
+
+func GetWatchedWCACompetitionIds(ctx context.Context, db interfaces.DB, uid int) ([]string, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT DISTINCT w.upcoming_wca_competition_id FROM wca_competition_watches w WHERE w.user_id = $1 ORDER BY w.upcoming_wca_competition_id;`,
+		uid,
+	)
+	if err != nil {
+		return []string{}, fmt.Errorf("%w: when querying watches of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return []string{}, fmt.Errorf("%w: when scanning watch", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return []string{}, fmt.Errorf("%w: when iterating through watches", err)
+	}
+
+	return ids, nil
+}
+
+// DeleteUnusedWCACompetitionWatches deletes the watches of the competitions
+// which are not saved anymore
+func DeleteUnusedWCACompetitionWatches(ctx context.Context, db interfaces.DB) (int64, error) {
+	res, err := db.Exec(
+		ctx,
+		`DELETE FROM wca_competition_watches w WHERE NOT EXISTS (SELECT 1 FROM upcoming_wca_competitions c WHERE c.upcoming_wca_competition_id = w.upcoming_wca_competition_id);`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%w: when deleting unused wca competition watches", err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// WCACompetitionReminder is the reminder due to be sent to the watcher
+type WCACompetitionReminder struct {
+	WatchId     int
+	UserId      int
+	Kind        string
+	Competition UpcomingWCACompetition
+}
+
+// GetDueWCACompetitionReminders returns the reminders due at the time which
+// were not sent yet, at most one per watched competition
+func GetDueWCACompetitionReminders(ctx context.Context, db interfaces.DB, now time.Time) ([]WCACompetitionReminder, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT DISTINCT ON (w.user_id, w.upcoming_wca_competition_id) w.wca_competition_watch_id, w.user_id, c.upcoming_wca_competition_id, c.country_id, c.name, c.startdate, c.enddate, c.registered, c.competitor_limit, c.venue_address, c.url, c.registration_open, c.registration_close, ARRAY(SELECT r.kind FROM wca_competition_watch_reminders r JOIN wca_competition_watches ww ON ww.wca_competition_watch_id = r.wca_competition_watch_id WHERE ww.user_id = w.user_id AND ww.upcoming_wca_competition_id = w.upcoming_wca_competition_id AND r.registration_open = c.registration_open) FROM wca_competition_watches w JOIN upcoming_wca_competitions c ON c.upcoming_wca_competition_id = w.upcoming_wca_competition_id WHERE c.cancelled IS FALSE AND c.registration_close > $1 AND c.registration_open - INTERVAL '24 hours' <= $1 ORDER BY w.user_id, w.upcoming_wca_competition_id, w.wca_competition_watch_id, c.country_id;`,
+		now.UTC(),
+	)
+	if err != nil {
+		return []WCACompetitionReminder{}, fmt.Errorf("%w: when querying watched competitions", err)
+	}
+	defer rows.Close()
+
+	reminders := make([]WCACompetitionReminder, 0)
+	for rows.Next() {
+		var reminder WCACompetitionReminder
+		var sent []string
+		comp := &reminder.Competition
+		err = rows.Scan(
+			&reminder.WatchId,
+			&reminder.UserId,
+			&comp.Id,
+			&comp.CountryId,
+			&comp.Name,
+			&comp.Startdate,
+			&comp.Enddate,
+			&comp.Registered,
+			&comp.CompetitorLimit,
+			&comp.VenueAddress,
+			&comp.Url,
+			&comp.RegistrationOpen,
+			&comp.RegistrationClose,
+			&sent,
+		)
+		if err != nil {
+			return []WCACompetitionReminder{}, fmt.Errorf("%w: when scanning watched competition", err)
+		}
+
+		for _, kind := range WCA_REMINDERS {
+			if !slices.Contains(sent, kind) && WCAReminderDue(kind, *comp, now) {
+				reminder.Kind = kind
+				reminders = append(reminders, reminder)
+				break
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return []WCACompetitionReminder{}, fmt.Errorf("%w: when iterating through watched competitions", err)
+	}
+
+	return reminders, nil
+}
+
+// NextWCAReminderAt returns when the next registration reminder becomes due,
+// the bool is false if there is none
+func NextWCAReminderAt(ctx context.Context, db interfaces.DB, now time.Time) (time.Time, bool, error) {
+	var next *time.Time
+	err := db.QueryRow(
+		ctx,
+		`SELECT MIN(CASE WHEN c.registration_open - INTERVAL '24 hours' > $1 THEN c.registration_open - INTERVAL '24 hours' ELSE c.registration_open - INTERVAL '15 minutes' END) FROM wca_competition_watches w JOIN upcoming_wca_competitions c ON c.upcoming_wca_competition_id = w.upcoming_wca_competition_id WHERE c.cancelled IS FALSE AND c.registration_open - INTERVAL '15 minutes' > $1;`,
+		now.UTC(),
+	).Scan(&next)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: when querying next wca reminder", err)
+	}
+	if next == nil {
+		return time.Time{}, false, nil
+	}
+
+	return *next, true, nil
+}
+
+// markSent records the reminder, false means it was already sent by someone
+// else
+func (r *WCACompetitionReminder) markSent(ctx context.Context, db interfaces.DB) (bool, error) {
+	res, err := db.Exec(
+		ctx,
+		`INSERT INTO wca_competition_watch_reminders (wca_competition_watch_id, kind, registration_open) VALUES ($1, $2, $3) ON CONFLICT (wca_competition_watch_id, kind, registration_open) DO NOTHING;`,
+		r.WatchId,
+		r.Kind,
+		r.Competition.RegistrationOpen,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: when marking %s reminder of watch with id=%d as sent", err, r.Kind, r.WatchId)
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+// Send queues the reminder email and records it as sent, both or none
+func (r *WCACompetitionReminder) Send(ctx context.Context, db interfaces.DB, envMap map[string]string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	marked, err := r.markSent(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return nil
+	}
+
+	user, err := GetUserById(tx, r.UserId)
+	if err != nil {
+		return fmt.Errorf("%w: when getting user with id=%d", err, r.UserId)
+	}
+
+	comp := r.Competition
+	subject, body, err := templates.RenderEmail(templates.EMAIL_COMP_REMINDER, user.Language, templates.CompReminderData{
+		Username: user.Name,
+		Kind:     r.Kind,
+		Competition: templates.Competition{
+			Name:             comp.Name,
+			Place:            comp.VenueAddress,
+			Date:             comp.DateFormatted(),
+			CompetitorLimit:  comp.CompetitorLimit,
+			RegistrationOpen: comp.RegistrationOpen.UTC().Format(WCA_REGISTRATION_TIME_LAYOUT),
+			Url:              comp.Url,
+		},
+		Registered:     comp.Registered,
+		UnsubscribeUrl: UnsubscribeUrl(envMap, user.Id, NOTIFICATION_WCA_REMINDERS),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: when rendering %s reminder", err, r.Kind)
+	}
+	if os.Getenv("SPEEDCUBINGSLOVAKIA_BACKEND_ENV") == "development" {
+		subject = "DEVELOPMENT: " + subject
+	}
+
+	_, err = QueueNotificationEmail(ctx, tx, envMap, user.Id, NOTIFICATION_WCA_REMINDERS, email.Message{
+		From:    envMap["MAIL_USERNAME"],
+		To:      user.Email,
+		Subject: subject,
+		Body:    body,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return nil
+}
+
+// SendDueWCACompetitionReminders sends the reminders due at the time, a failed
+// reminder does not stop the others
+func SendDueWCACompetitionReminders(ctx context.Context, db interfaces.DB, envMap map[string]string, now time.Time) (int, error) {
+	reminders, err := GetDueWCACompetitionReminders(ctx, db, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, reminder := range reminders {
+		if err = reminder.Send(ctx, db, envMap); err != nil {
+			errs = append(errs, fmt.Errorf("%w: when sending %s reminder of %s to user with id=%d", err, reminder.Kind, reminder.Competition.Id, reminder.UserId))
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// RunWCAReminderScheduler sends the reminders until the context is cancelled,
+// it sleeps until the next registration reminder is due, but at most the
+// maxWait
+func RunWCAReminderScheduler(ctx context.Context, db interfaces.DB, envMap map[string]string, maxWait time.Duration) {
+	for {
+		now := time.Now()
+		if _, err := SendDueWCACompetitionReminders(ctx, db, envMap, now); err != nil {
+			slog.Error("failed to send wca competition reminders", "error", err)
+		}
+
+		wait := maxWait
+		next, ok, err := NextWCAReminderAt(ctx, db, now)
+		if err != nil {
+			slog.Error("failed to get next wca competition reminder", "error", err)
+		} else if ok {
+			wait = min(wait, max(time.Until(next), 0))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
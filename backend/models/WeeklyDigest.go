@@ -0,0 +1,156 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
+)
+
+// GetCompetitionsDueForDigest returns the ended weekly competitions whose
+// digest was not sent yet, oldest first
+func GetCompetitionsDueForDigest(ctx context.Context, db interfaces.DB) ([]CompetitionData, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT c.competition_id, c.name, c.startdate, c.enddate FROM competitions c WHERE c.competition_id LIKE ('WeeklyCompetition%') AND c.enddate <= CURRENT_TIMESTAMP AND c.digest_sent_at IS NULL ORDER BY c.enddate;`,
+	)
+	if err != nil {
+		return []CompetitionData{}, fmt.Errorf("%w: when querying competitions due for digest", err)
+	}
+	defer rows.Close()
+
+	competitions := make([]CompetitionData, 0)
+	for rows.Next() {
+		var competition CompetitionData
+		if err = rows.Scan(&competition.Id, &competition.Name, &competition.Startdate, &competition.Enddate); err != nil {
+			return []CompetitionData{}, fmt.Errorf("%w: when scanning competition", err)
+		}
+		competitions = append(competitions, competition)
+	}
+	if err = rows.Err(); err != nil {
+		return []CompetitionData{}, fmt.Errorf("%w: when iterating through competitions", err)
+	}
+
+	return competitions, nil
+}
+
+func MarkDigestSent(ctx context.Context, db interfaces.DB, cid string) error {
+	_, err := db.Exec(ctx, `UPDATE competitions SET digest_sent_at = CURRENT_TIMESTAMP WHERE competition_id = $1;`, cid)
+	if err != nil {
+		return fmt.Errorf("%w: when marking digest of competition with id=%s as sent", err, cid)
+	}
+
+	return nil
+}
+
+// GetCompetitionParticipants returns the users with visible results in the
+// competition
+func GetCompetitionParticipants(ctx context.Context, db interfaces.DB, cid string) ([]int, error) {
+	rows, err := db.Query(
+		ctx,
+		`SELECT DISTINCT r.user_id FROM results r JOIN results_status rs ON rs.results_status_id = r.status_id WHERE r.competition_id = $1 AND rs.visible IS TRUE ORDER BY r.user_id;`,
+		cid,
+	)
+	if err != nil {
+		return []int{}, fmt.Errorf("%w: when querying participants of competition with id=%s", err, cid)
+	}
+	defer rows.Close()
+
+	uids := make([]int, 0)
+	for rows.Next() {
+		var uid int
+		if err = rows.Scan(&uid); err != nil {
+			return []int{}, fmt.Errorf("%w: when scanning participant", err)
+		}
+		uids = append(uids, uid)
+	}
+	if err = rows.Err(); err != nil {
+		return []int{}, fmt.Errorf("%w: when iterating through participants", err)
+	}
+
+	return uids, nil
+}
+
+// GetNextWeeklyCompetition returns the first weekly competition starting at
+// or after the date, the bool is false if there is none
+func GetNextWeeklyCompetition(ctx context.Context, db interfaces.DB, after time.Time) (CompetitionData, bool, error) {
+	var competition CompetitionData
+	err := db.QueryRow(
+		ctx,
+		`SELECT c.competition_id, c.name, c.startdate, c.enddate FROM competitions c WHERE c.competition_id LIKE ('WeeklyCompetition%') AND c.startdate >= $1 ORDER BY c.startdate LIMIT 1;`,
+		after,
+	).Scan(&competition.Id, &competition.Name, &competition.Startdate, &competition.Enddate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CompetitionData{}, false, nil
+	}
+	if err != nil {
+		return CompetitionData{}, false, fmt.Errorf("%w: when querying next weekly competition", err)
+	}
+
+	return competition, true, nil
+}
+
+func digestRecord(record string, color string) string {
+	if record == "" && color == constants.PR_COLOR {
+		return "PR"
+	}
+
+	return record
+}
+
+// DigestEvents returns the results of the loaded profile in the competition,
+// with the placements and records computed for the profile history
+func (p *ProfileType) DigestEvents(cid string) []templates.DigestEvent {
+	events := make([]templates.DigestEvent, 0)
+	for _, history := range p.ResultsHistory {
+		// the history goes from the last round, the first entry is the one
+		// deciding the placement
+		for _, entry := range history.History {
+			if entry.CompetitionId != cid {
+				continue
+			}
+
+			events = append(events, templates.DigestEvent{
+				Event:         history.EventName,
+				Round:         entry.Round,
+				Place:         entry.Place,
+				Single:        entry.Single,
+				SingleRecord:  digestRecord(entry.SingleRecord, entry.SingleRecordColor),
+				Average:       entry.Average,
+				AverageRecord: digestRecord(entry.AverageRecord, entry.AverageRecordColor),
+			})
+			break
+		}
+	}
+
+	return events
+}
+
+// RecordDigestEvents returns the events with a record in the single or the
+// average
+func RecordDigestEvents(events []templates.DigestEvent) []templates.DigestEvent {
+	records := make([]templates.DigestEvent, 0)
+	for _, event := range events {
+		if event.SingleRecord != "" || event.AverageRecord != "" {
+			records = append(records, event)
+		}
+	}
+
+	return records
+}
+
+// KinchChange formats the difference of the Kinch scores with its sign,
+// missing scores count as zero
+func KinchChange(before string, after string) string {
+	b, _ := strconv.ParseFloat(before, 64)
+	a, _ := strconv.ParseFloat(after, 64)
+
+	return fmt.Sprintf("%+.2f", a-b)
+}
@@ -0,0 +1,58 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiToken(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("create + use + revoke", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		apiToken, token, err := models.CreateApiToken(ctx, testDb, u.Id, " timer ", []string{models.SCOPE_RESULTS_WRITE, models.SCOPE_RESULTS_READ, models.SCOPE_RESULTS_READ}, 0)
+		require.NoError(t, err)
+		require.True(t, models.IsApiToken(token))
+		require.Equal(t, "timer", apiToken.Name)
+		require.Equal(t, []string{models.SCOPE_RESULTS_READ, models.SCOPE_RESULTS_WRITE}, apiToken.Scopes)
+		require.Nil(t, apiToken.ExpiresAt)
+
+		used, err := models.UseApiToken(ctx, testDb, token)
+		require.NoError(t, err)
+		require.Equal(t, apiToken.Id, used.Id)
+		require.NotNil(t, used.LastUsedAt)
+		require.True(t, used.HasScope(models.SCOPE_RESULTS_WRITE))
+		require.False(t, used.HasScope(models.SCOPE_PROFILE_READ))
+
+		tokens, err := models.GetApiTokens(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+
+		require.NoError(t, models.RevokeApiToken(ctx, testDb, apiToken.Id, u.Id))
+		require.ErrorIs(t, models.RevokeApiToken(ctx, testDb, apiToken.Id, u.Id), models.ErrApiTokenNotFound)
+
+		_, err = models.UseApiToken(ctx, testDb, token)
+		require.ErrorIs(t, err, models.ErrApiTokenNotFound)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		_, _, err = models.CreateApiToken(ctx, testDb, u.Id, "  ", []string{models.SCOPE_RESULTS_READ}, 0)
+		require.ErrorIs(t, err, models.ErrApiTokenNameEmpty)
+
+		_, _, err = models.CreateApiToken(ctx, testDb, u.Id, "bot", []string{}, 0)
+		require.ErrorIs(t, err, models.ErrNoScopes)
+
+		_, _, err = models.CreateApiToken(ctx, testDb, u.Id, "bot", []string{"users:manage"}, 0)
+		require.ErrorIs(t, err, models.ErrUnknownScope)
+
+		_, err = models.UseApiToken(ctx, testDb, models.API_TOKEN_PREFIX+"nonsense")
+		require.ErrorIs(t, err, models.ErrApiTokenNotFound)
+	})
+}
@@ -0,0 +1,89 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBattle(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("accept + submit + finish", func(t *testing.T) {
+		b, challenger, opponent, err := models.TestInsertBattle(ctx, testDb)
+		require.NoError(t, err)
+
+		err = b.Submit(ctx, testDb, challenger.Id, "10.00")
+		require.ErrorIs(t, err, models.ErrBattleNotActive)
+
+		err = b.Accept(ctx, testDb, []string{"R U", "F D", "L B"}, []string{"", "", ""})
+		require.NoError(t, err)
+
+		err = b.Accept(ctx, testDb, []string{"R U", "F D", "L B"}, []string{"", "", ""})
+		require.ErrorIs(t, err, models.ErrBattleNotPending)
+
+		err = b.Submit(ctx, testDb, challenger.Id, "  ")
+		require.ErrorIs(t, err, models.ErrInvalidBattle)
+
+		err = b.Submit(ctx, testDb, challenger.Id, "10.00")
+		require.NoError(t, err)
+
+		err = b.Submit(ctx, testDb, challenger.Id, "9.00")
+		require.ErrorIs(t, err, models.ErrAttemptAlreadySubmitted)
+
+		err = b.Submit(ctx, testDb, challenger.Id+opponent.Id, "9.00")
+		require.ErrorIs(t, err, models.ErrNotInBattle)
+
+		hidden := b
+		hidden.Attempts = append([]models.BattleAttempt{}, b.Attempts...)
+		hidden.HideFor(opponent.Id)
+		require.Len(t, hidden.Attempts, 1)
+		require.Empty(t, hidden.Attempts[0].ChallengerSolve)
+		require.Equal(t, "R U", hidden.Attempts[0].Scramble)
+
+		hidden = b
+		hidden.Attempts = append([]models.BattleAttempt{}, b.Attempts...)
+		hidden.HideFor(0)
+		require.Empty(t, hidden.Attempts[0].Scramble)
+
+		require.NoError(t, b.Submit(ctx, testDb, opponent.Id, "11.00"))
+		require.NoError(t, b.Submit(ctx, testDb, opponent.Id, "8.00"))
+		require.NoError(t, b.Submit(ctx, testDb, challenger.Id, "9.00"))
+		require.NoError(t, b.Submit(ctx, testDb, challenger.Id, "DNF"))
+		require.NoError(t, b.Submit(ctx, testDb, opponent.Id, "12.00"))
+
+		var got models.Battle
+		err = got.Get(ctx, testDb, b.Id)
+		require.NoError(t, err)
+		require.Equal(t, models.BattleFinished, got.State)
+		require.Equal(t, challenger.Id, got.Attempts[0].WinnerId)
+		require.Equal(t, opponent.Id, got.Attempts[1].WinnerId)
+		require.Equal(t, opponent.Id, got.Attempts[2].WinnerId)
+		require.Equal(t, opponent.Id, got.WinnerId)
+		require.NotNil(t, got.FinishedAt)
+
+		battles, err := models.GetUserBattles(ctx, testDb, challenger.Id, true)
+		require.NoError(t, err)
+		require.Len(t, battles, 1)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		b, _, _, err := models.TestInsertBattle(ctx, testDb)
+		require.NoError(t, err)
+
+		err = b.Decline(ctx, testDb)
+		require.NoError(t, err)
+
+		var got models.Battle
+		err = got.Get(ctx, testDb, b.Id)
+		require.NoError(t, err)
+		require.Equal(t, models.BattleDeclined, got.State)
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		var b models.Battle
+		err := b.Get(ctx, testDb, -1)
+		require.ErrorIs(t, err, models.ErrBattleNotFound)
+	})
+}
@@ -0,0 +1,134 @@
+package models_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/ical"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+func TestGetFollowedWCACompetitions(t *testing.T) {
+	ctx := t.Context()
+
+	user, _, _, err := models.TestInsertUser(ctx, testDb)
+	require.NoError(t, err)
+	subscribedCountry, _, err := models.TestInsertCountry(ctx, testDb)
+	require.NoError(t, err)
+	otherCountry, _, err := models.TestInsertCountry(ctx, testDb)
+	require.NoError(t, err)
+
+	subscription := models.WCACompAnnouncementsSubscription{UserId: user.Id, CountryId: subscribedCountry.Id, State: "Bratislava"}
+	require.NoError(t, subscription.Insert(ctx, testDb))
+	positionSubscription := models.WCACompAnnouncementsPositionSubscription{UserId: user.Id, LatitudeDegrees: -60, LongitudeDegrees: 120, Radius: 50}
+	require.NoError(t, positionSubscription.Insert(ctx, testDb))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	newComp := func(prefix string, countryId string, state string, lat float64) models.UpcomingWCACompetition {
+		return models.UpcomingWCACompetition{
+			Id:                prefix + uuid.NewString()[:8],
+			Name:              uuid.NewString(),
+			Startdate:         now.AddDate(0, 1, 0),
+			Enddate:           now.AddDate(0, 1, 1),
+			CompetitorLimit:   100,
+			Url:               "https://www.worldcubeassociation.org/competitions/" + prefix,
+			CountryId:         countryId,
+			State:             state,
+			LatitudeDegrees:   lat,
+			LongitudeDegrees:  120,
+			RegistrationOpen:  now.AddDate(0, 0, 7),
+			RegistrationClose: now.AddDate(0, 0, 21),
+		}
+	}
+	inState := newComp("InState", subscribedCountry.Id, "Bratislava", 10)
+	otherState := newComp("OtherState", subscribedCountry.Id, "Košice", 10)
+	inCircle := newComp("InCircle", otherCountry.Id, "", -60.1)
+	outsideCircle := newComp("OutsideCircle", otherCountry.Id, "", -62)
+	watched := newComp("Watched", otherCountry.Id, "", 10)
+	// the competition in two countries is in the calendar once
+	inTwoCountries := newComp("InTwoCountries", subscribedCountry.Id, "Bratislava", -60)
+	inTwoCountriesOther := inTwoCountries
+	inTwoCountriesOther.CountryId = otherCountry.Id
+
+	tx, err := testDb.Begin(ctx)
+	require.NoError(t, err)
+	for _, comp := range []models.UpcomingWCACompetition{inState, otherState, inCircle, outsideCircle, watched, inTwoCountries, inTwoCountriesOther} {
+		_, err = comp.Save(tx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit(ctx))
+	require.NoError(t, models.WatchWCACompetition(ctx, testDb, user.Id, watched.Id))
+
+	followed, err := models.GetFollowedWCACompetitions(ctx, testDb, user.Id)
+	require.NoError(t, err)
+	ids := utils.Map(followed, func(comp models.UpcomingWCACompetition) string { return comp.Id })
+	require.Contains(t, ids, inState.Id)
+	require.Contains(t, ids, inCircle.Id)
+	require.Contains(t, ids, watched.Id)
+	require.NotContains(t, ids, otherState.Id)
+	require.NotContains(t, ids, outsideCircle.Id)
+	require.Len(t, slices.DeleteFunc(ids, func(id string) bool { return id != inTwoCountries.Id }), 1)
+
+	region, err := models.GetRegionWCACompetitions(ctx, testDb, subscribedCountry.Id, "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{inState.Id, otherState.Id, inTwoCountries.Id}, utils.Map(region, func(comp models.UpcomingWCACompetition) string { return comp.Id }))
+
+	region, err = models.GetRegionWCACompetitions(ctx, testDb, subscribedCountry.Id, "Košice")
+	require.NoError(t, err)
+	require.Len(t, region, 1)
+	require.Equal(t, otherState.Id, region[0].Id)
+}
+
+func TestCalendarEvents(t *testing.T) {
+	envMap := map[string]string{"WEBSITE_HOME": "https://speedcubingslovakia.sk"}
+	start := time.Date(2026, 3, 2, 19, 0, 0, 0, time.UTC)
+	competitions := []models.CompetitionData{
+		{Id: "WeeklyCompetition1", Name: "Weekly Competition 1", Startdate: start, Enddate: start.AddDate(0, 0, 7)},
+	}
+	wcaCompetitions := []models.UpcomingWCACompetition{
+		{
+			Id:               "BratislavaOpen2026",
+			Name:             "Bratislava Open 2026",
+			Startdate:        time.Date(2026, 4, 4, 0, 0, 0, 0, time.UTC),
+			Enddate:          time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC),
+			VenueAddress:     "Hotel, Bratislava",
+			CompetitorLimit:  80,
+			Url:              "https://www.worldcubeassociation.org/competitions/BratislavaOpen2026",
+			Events:           []models.CompetitionEvent{{Iconcode: "333"}, {Iconcode: "222"}},
+			RegistrationOpen: time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC),
+			Cancelled:        true,
+		},
+		{Id: "NoRegistration2026", Name: "No Registration 2026"},
+	}
+
+	events := models.CalendarEvents(envMap, competitions, wcaCompetitions)
+	require.Len(t, events, 4)
+
+	require.Equal(t, ical.Event{
+		Uid:     "WeeklyCompetition1@speedcubingslovakia.sk",
+		Summary: "Weekly Competition 1",
+		Url:     "https://speedcubingslovakia.sk/competition/WeeklyCompetition1",
+		Start:   start,
+		End:     start.AddDate(0, 0, 7),
+		Status:  ical.STATUS_CONFIRMED,
+	}, events[0])
+
+	require.Equal(t, "wca-BratislavaOpen2026@speedcubingslovakia.sk", events[1].Uid)
+	require.True(t, events[1].AllDay)
+	require.Equal(t, ical.STATUS_CANCELLED, events[1].Status)
+	require.Equal(t, "Competitor limit: 80\nEvents: 222, 333", events[1].Description)
+	require.Equal(t, "Hotel, Bratislava", events[1].Location)
+
+	require.Equal(t, "wca-registration-BratislavaOpen2026@speedcubingslovakia.sk", events[2].Uid)
+	require.False(t, events[2].AllDay)
+	require.Equal(t, wcaCompetitions[0].RegistrationOpen, events[2].Start)
+	require.Equal(t, wcaCompetitions[0].RegistrationOpen.Add(models.CALENDAR_REGISTRATION_LENGTH), events[2].End)
+	require.Equal(t, ical.STATUS_CANCELLED, events[2].Status)
+
+	require.Equal(t, "wca-NoRegistration2026@speedcubingslovakia.sk", events[3].Uid)
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
 )
 
+var ErrCountryNotFound = errors.New("country not found")
+
 type Country struct {
 	Id          string `json:"id"`
 	Name        string `json:"name"`
@@ -53,7 +55,7 @@ func (c *Country) Get(ctx context.Context, db interfaces.DB, name string) error
 	).Scan(&c.Id, &c.Name, &c.Iso2, &c.ContinentId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("country with name=%s not found", name)
+			return fmt.Errorf("%w: name=%s", ErrCountryNotFound, name)
 		}
 		return fmt.Errorf("%w: when querying country with name=%s", err, name)
 	}
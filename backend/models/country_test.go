@@ -13,7 +13,7 @@ func TestCountry(t *testing.T) {
 	t.Run("get + insert", func(t *testing.T) {
 		c := models.Country{}
 		err := c.Get(ctx, testDb, "invalid")
-		require.Error(t, err)
+		require.ErrorIs(t, err, models.ErrCountryNotFound)
 
 		c2, _, err := models.TestInsertCountry(ctx, testDb)
 		require.NoError(t, err)
@@ -0,0 +1,94 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailRetryDelay(t *testing.T) {
+	require.Equal(t, time.Minute, models.EmailRetryDelay(1))
+	require.Equal(t, 2*time.Minute, models.EmailRetryDelay(2))
+	require.Equal(t, 8*time.Minute, models.EmailRetryDelay(4))
+	require.Equal(t, models.EMAIL_RETRY_MAX_DELAY, models.EmailRetryDelay(100))
+}
+
+func TestEmailOutbox(t *testing.T) {
+	ctx := t.Context()
+
+	getEmail := func(t *testing.T, id int) models.OutboxEmail {
+		emails, err := models.GetOutboxEmails(ctx, testDb, "", 1000)
+		require.NoError(t, err)
+		for _, e := range emails {
+			if e.Id == id {
+				return e
+			}
+		}
+		t.Fatalf("email with id=%d not found", id)
+		return models.OutboxEmail{}
+	}
+
+	t.Run("deliver + retry", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		to := uuid.NewString() + "@example.com"
+		id, err := models.QueueEmail(ctx, testDb, u.Id, email.Message{From: "a@example.com", To: to, Subject: "s", Body: "b"})
+		require.NoError(t, err)
+
+		mailer := email.NewMemoryMailer()
+		mailer.SetErr(errors.New("smtp down"))
+		_, failed, err := models.DeliverDueEmails(ctx, testDb, mailer)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, failed, 1)
+
+		e := getEmail(t, id)
+		require.Equal(t, models.EMAIL_STATUS_PENDING, e.Status)
+		require.Equal(t, 1, e.Attempts)
+		require.Equal(t, "smtp down", e.LastError)
+		require.Equal(t, u.Id, *e.UserId)
+
+		// not due yet
+		mailer.SetErr(nil)
+		_, _, err = models.DeliverDueEmails(ctx, testDb, mailer)
+		require.NoError(t, err)
+		for _, msg := range mailer.Sent() {
+			require.NotEqual(t, to, msg.To)
+		}
+
+		_, err = testDb.Exec(ctx, `UPDATE email_outbox SET next_attempt_at = CURRENT_TIMESTAMP WHERE email_id = $1;`, id)
+		require.NoError(t, err)
+		_, _, err = models.DeliverDueEmails(ctx, testDb, mailer)
+		require.NoError(t, err)
+
+		e = getEmail(t, id)
+		require.Equal(t, models.EMAIL_STATUS_SENT, e.Status)
+		require.Equal(t, 2, e.Attempts)
+		require.NotNil(t, e.SentAt)
+	})
+
+	t.Run("give up + requeue", func(t *testing.T) {
+		id, err := models.QueueEmail(ctx, testDb, 0, email.Message{To: uuid.NewString() + "@example.com"})
+		require.NoError(t, err)
+		_, err = testDb.Exec(ctx, `UPDATE email_outbox SET attempts = $1 WHERE email_id = $2;`, models.EMAIL_MAX_ATTEMPTS-1, id)
+		require.NoError(t, err)
+
+		mailer := email.NewMemoryMailer()
+		mailer.SetErr(errors.New("mailbox full"))
+		_, _, err = models.DeliverDueEmails(ctx, testDb, mailer)
+		require.NoError(t, err)
+
+		e := getEmail(t, id)
+		require.Equal(t, models.EMAIL_STATUS_FAILED, e.Status)
+		require.Nil(t, e.UserId)
+
+		require.NoError(t, models.RetryOutboxEmail(ctx, testDb, id))
+		require.ErrorIs(t, models.RetryOutboxEmail(ctx, testDb, id), models.ErrOutboxEmailNotFound)
+		require.Equal(t, models.EMAIL_STATUS_PENDING, getEmail(t, id).Status)
+	})
+}
@@ -0,0 +1,127 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
+	"github.com/stretchr/testify/require"
+)
+
+func loginLinkCredentials(t *testing.T, token string, name string, countryId string) string {
+	credentials, err := json.Marshal(models.LoginLinkCredentials{Token: token, Name: name, CountryId: countryId})
+	require.NoError(t, err)
+
+	return string(credentials)
+}
+
+// registerByEmail registers a new user by a login link
+func registerByEmail(t *testing.T, countryId string) models.User {
+	token, _, err := models.CreateLoginLink(t.Context(), testDb, uuid.NewString()+"@example.com", "")
+	require.NoError(t, err)
+	user, created, err := models.EmailLoginProvider{}.Login(t.Context(), testDb, loginLinkCredentials(t, token, uuid.NewString(), countryId))
+	require.NoError(t, err)
+	require.True(t, created)
+
+	return user
+}
+
+func TestEmailLogin(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("register + log in again", func(t *testing.T) {
+		country, _, err := models.TestInsertCountry(ctx, testDb)
+		require.NoError(t, err)
+
+		email := uuid.NewString() + "@example.com"
+		name := uuid.NewString()
+
+		token, language, err := models.CreateLoginLink(ctx, testDb, email, templates.LANGUAGE_SK)
+		require.NoError(t, err)
+		require.Equal(t, templates.LANGUAGE_SK, language)
+
+		// the link stays valid until the name is filled in
+		_, _, err = models.EmailLoginProvider{}.Login(ctx, testDb, loginLinkCredentials(t, token, " ", ""))
+		require.ErrorIs(t, err, models.ErrNameRequired)
+
+		user, created, err := models.EmailLoginProvider{}.Login(ctx, testDb, loginLinkCredentials(t, token, " "+name+" ", country.Id))
+		require.NoError(t, err)
+		require.True(t, created)
+		require.Equal(t, name, user.Name)
+		require.Equal(t, "", user.WcaId)
+		require.Equal(t, country.Id, user.CountryId)
+		require.Equal(t, templates.LANGUAGE_SK, user.Language)
+
+		_, _, err = models.EmailLoginProvider{}.Login(ctx, testDb, loginLinkCredentials(t, token, name, country.Id))
+		require.ErrorIs(t, err, models.ErrLoginLinkInvalid)
+
+		otherToken, _, err := models.CreateLoginLink(ctx, testDb, uuid.NewString()+"@example.com", "")
+		require.NoError(t, err)
+		_, _, err = models.EmailLoginProvider{}.Login(ctx, testDb, loginLinkCredentials(t, otherToken, name, country.Id))
+		require.ErrorIs(t, err, models.ErrUserNameTaken)
+
+		token, language, err = models.CreateLoginLink(ctx, testDb, email, templates.LANGUAGE_EN)
+		require.NoError(t, err)
+		require.Equal(t, templates.LANGUAGE_SK, language)
+
+		again, created, err := models.EmailLoginProvider{}.Login(ctx, testDb, loginLinkCredentials(t, token, "", ""))
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, user.Id, again.Id)
+	})
+
+	t.Run("invalid link", func(t *testing.T) {
+		_, _, err := models.EmailLoginProvider{}.Login(ctx, testDb, loginLinkCredentials(t, "nonsense", "jozko", ""))
+		require.ErrorIs(t, err, models.ErrLoginLinkInvalid)
+
+		_, _, err = models.EmailLoginProvider{}.Login(ctx, testDb, "not json")
+		require.ErrorIs(t, err, models.ErrLoginLinkInvalid)
+	})
+
+	t.Run("wca login does not find email users", func(t *testing.T) {
+		country, _, err := models.TestInsertCountry(ctx, testDb)
+		require.NoError(t, err)
+
+		user := registerByEmail(t, country.Id)
+		require.Equal(t, models.LOGIN_PROVIDER_EMAIL, user.LoginProvider)
+
+		// a WCA account without a WCA ID and with the same name
+		wcaUser := models.User{Name: user.Name, CountryId: country.Id, Email: uuid.NewString(), LoginProvider: models.LOGIN_PROVIDER_WCA}
+		exists, err := wcaUser.Exists(ctx, testDb)
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		_, _, err := models.CreateLoginLink(ctx, testDb, "not an email", "")
+		require.ErrorIs(t, err, models.ErrInvalidEmail)
+	})
+
+	t.Run("link wca account", func(t *testing.T) {
+		country, _, err := models.TestInsertCountry(ctx, testDb)
+		require.NoError(t, err)
+
+		user := registerByEmail(t, country.Id)
+
+		other, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+		_, err = models.LinkWCAAccount(ctx, testDb, user.Id, other)
+		require.ErrorIs(t, err, models.ErrWCAAccountInUse)
+
+		wcaUser := models.NewTestUser(country.Id)
+		linked, err := models.LinkWCAAccount(ctx, testDb, user.Id, wcaUser)
+		require.NoError(t, err)
+		require.Equal(t, user.Id, linked.Id)
+		require.Equal(t, user.Email, linked.Email)
+
+		exists, err := wcaUser.Exists(ctx, testDb)
+		require.NoError(t, err)
+		require.True(t, exists)
+		require.Equal(t, user.Id, wcaUser.Id)
+
+		_, err = models.LinkWCAAccount(ctx, testDb, user.Id, models.NewTestUser(country.Id))
+		require.ErrorIs(t, err, models.ErrWCAAccountAlreadyLinked)
+	})
+}
@@ -0,0 +1,111 @@
+package models_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+	ctx := t.Context()
+	envMap := map[string]string{"WEBSITE_HOME": "https://example.com", "JWT_SECRET_KEY": "secret"}
+
+	t.Run("opt out + opt in", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		preferences, err := models.GetNotificationPreferences(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Len(t, preferences, len(models.NOTIFICATION_CATEGORIES))
+		for _, preference := range preferences {
+			require.Equal(t, !slices.Contains(models.OPT_IN_NOTIFICATION_CATEGORIES, preference.Category), preference.Enabled)
+		}
+
+		require.NoError(t, models.SetNotificationPreference(ctx, testDb, u.Id, models.NOTIFICATION_WCA_COMPETITIONS, false))
+		require.NoError(t, models.SetNotificationPreference(ctx, testDb, u.Id, models.NOTIFICATION_WCA_COMPETITIONS, false))
+
+		enabled, err := models.IsNotificationEnabled(ctx, testDb, u.Id, models.NOTIFICATION_WCA_COMPETITIONS)
+		require.NoError(t, err)
+		require.False(t, enabled)
+
+		queued, err := models.QueueNotificationEmail(ctx, testDb, envMap, u.Id, models.NOTIFICATION_WCA_COMPETITIONS, email.Message{To: u.Email})
+		require.NoError(t, err)
+		require.False(t, queued)
+
+		queued, err = models.QueueNotificationEmail(ctx, testDb, envMap, u.Id, models.NOTIFICATION_WCA_REMINDERS, email.Message{To: u.Email})
+		require.NoError(t, err)
+		require.True(t, queued)
+
+		require.NoError(t, models.SetNotificationPreference(ctx, testDb, u.Id, models.NOTIFICATION_WCA_COMPETITIONS, true))
+		enabled, err = models.IsNotificationEnabled(ctx, testDb, u.Id, models.NOTIFICATION_WCA_COMPETITIONS)
+		require.NoError(t, err)
+		require.True(t, enabled)
+
+		require.ErrorIs(t, models.SetNotificationPreference(ctx, testDb, u.Id, "spam", false), models.ErrUnknownNotificationCategory)
+	})
+
+	t.Run("opt in", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		queued, err := models.QueueNotificationEmail(ctx, testDb, envMap, u.Id, models.NOTIFICATION_WEEKLY_DIGEST, email.Message{To: u.Email})
+		require.NoError(t, err)
+		require.False(t, queued)
+
+		require.NoError(t, models.SetNotificationPreference(ctx, testDb, u.Id, models.NOTIFICATION_WEEKLY_DIGEST, true))
+
+		queued, err = models.QueueNotificationEmail(ctx, testDb, envMap, u.Id, models.NOTIFICATION_WEEKLY_DIGEST, email.Message{To: u.Email})
+		require.NoError(t, err)
+		require.True(t, queued)
+
+		preferences, err := models.GetNotificationPreferences(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Contains(t, preferences, models.NotificationPreference{Category: models.NOTIFICATION_WEEKLY_DIGEST, Enabled: true})
+	})
+
+	t.Run("users with the category on", func(t *testing.T) {
+		on, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+		off, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		require.NoError(t, models.SetNotificationPreference(ctx, testDb, on.Id, models.NOTIFICATION_WEEKLY_COMPETITION, true))
+
+		uids, err := models.GetUsersWithNotificationEnabled(ctx, testDb, models.NOTIFICATION_WEEKLY_COMPETITION)
+		require.NoError(t, err)
+		require.Contains(t, uids, on.Id)
+		require.NotContains(t, uids, off.Id)
+
+		require.NoError(t, models.SetNotificationPreference(ctx, testDb, off.Id, models.NOTIFICATION_RESULTS_APPROVED, false))
+
+		uids, err = models.GetUsersWithNotificationEnabled(ctx, testDb, models.NOTIFICATION_RESULTS_APPROVED)
+		require.NoError(t, err)
+		require.Contains(t, uids, on.Id)
+		require.NotContains(t, uids, off.Id)
+	})
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	token := models.CreateUnsubscribeToken(42, models.NOTIFICATION_WCA_REMINDERS, "secret")
+
+	uid, category, err := models.ParseUnsubscribeToken(token, "secret")
+	require.NoError(t, err)
+	require.Equal(t, 42, uid)
+	require.Equal(t, models.NOTIFICATION_WCA_REMINDERS, category)
+
+	_, _, err = models.ParseUnsubscribeToken(token, "other secret")
+	require.ErrorIs(t, err, models.ErrInvalidUnsubscribeToken)
+
+	_, _, err = models.ParseUnsubscribeToken("43"+token[2:], "secret")
+	require.ErrorIs(t, err, models.ErrInvalidUnsubscribeToken)
+
+	_, _, err = models.ParseUnsubscribeToken("nonsense", "secret")
+	require.ErrorIs(t, err, models.ErrInvalidUnsubscribeToken)
+
+	headers := models.UnsubscribeHeaders(map[string]string{"WEBSITE_HOME": "https://example.com", "JWT_SECRET_KEY": "secret"}, 42, models.NOTIFICATION_WCA_REMINDERS)
+	require.Equal(t, "List-Unsubscribe=One-Click", headers["List-Unsubscribe-Post"])
+	require.Contains(t, headers["List-Unsubscribe"], "https://example.com/api/notifications/unsubscribe?token=")
+}
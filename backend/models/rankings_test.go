@@ -0,0 +1,62 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankingRows(t *testing.T) {
+	t.Run("singles and average", func(t *testing.T) {
+		r := models.ResultEntry{Iconcode: "333", Format: "ao5", Solves: []string{"10.00", "DNF", "12.00", "11.00", "9.00"}}
+		r.Status.Visible = true
+
+		rows, err := r.RankingRows([]string{})
+		require.NoError(t, err)
+		require.Len(t, rows, 5)
+
+		singles := make([]string, 0)
+		for _, row := range rows[:4] {
+			require.Equal(t, models.RANKING_SINGLE, row.Type)
+			singles = append(singles, row.Result)
+		}
+		require.Equal(t, []string{"10.00", "12.00", "11.00", "9.00"}, singles)
+
+		require.Equal(t, models.RANKING_AVERAGE, rows[4].Type)
+		require.Equal(t, "11.00", rows[4].Result)
+		require.Len(t, rows[4].Times, 5)
+	})
+
+	t.Run("attempts over the format", func(t *testing.T) {
+		r := models.ResultEntry{Iconcode: "333", Format: "mo3", Solves: []string{"10.00", "11.00", "12.00", "5.00"}}
+		r.Status.Visible = true
+
+		require.Equal(t, 10000, r.Single(false, []string{}))
+
+		rows, err := r.RankingRows([]string{})
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		require.Equal(t, "11.00", rows[3].Result)
+	})
+
+	t.Run("invisible", func(t *testing.T) {
+		r := models.ResultEntry{Iconcode: "333", Format: "ao5", Solves: []string{"10.00", "10.00", "10.00", "10.00", "10.00"}}
+
+		rows, err := r.RankingRows([]string{})
+		require.NoError(t, err)
+		require.Empty(t, rows)
+	})
+
+	t.Run("average over dnf", func(t *testing.T) {
+		r := models.ResultEntry{Iconcode: "333", Format: "ao5", Solves: []string{"DNF", "DNF", "10.00", "10.00", "10.00"}}
+		r.Status.Visible = true
+
+		rows, err := r.RankingRows([]string{})
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		for _, row := range rows {
+			require.Equal(t, models.RANKING_SINGLE, row.Type)
+		}
+	})
+}
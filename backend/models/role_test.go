@@ -0,0 +1,51 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoles(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("set + permissions", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		permissions, err := models.GetUserPermissions(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Empty(t, permissions)
+
+		err = models.SetUserRoles(ctx, testDb, u.Id, []string{models.ROLE_RESULTS_MODERATOR, models.ROLE_ANNOUNCEMENT_EDITOR, models.ROLE_RESULTS_MODERATOR})
+		require.NoError(t, err)
+
+		permissions, err = models.GetUserPermissions(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Equal(t, []string{models.PERMISSION_ANNOUNCEMENTS_MANAGE, models.PERMISSION_RESULTS_VALIDATE}, permissions)
+
+		user, err := models.GetUserById(testDb, u.Id)
+		require.NoError(t, err)
+		require.False(t, user.IsAdmin)
+
+		err = models.SetUserRoles(ctx, testDb, u.Id, []string{models.ROLE_SUPER_ADMIN})
+		require.NoError(t, err)
+
+		user, err = models.GetUserById(testDb, u.Id)
+		require.NoError(t, err)
+		require.True(t, user.IsAdmin)
+
+		permissions, err = models.GetUserPermissions(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Contains(t, permissions, models.PERMISSION_USERS_MANAGE)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		err = models.SetUserRoles(ctx, testDb, u.Id, []string{"nonexistent"})
+		require.ErrorIs(t, err, models.ErrUnknownRole)
+	})
+}
@@ -0,0 +1,67 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("token carries session", func(t *testing.T) {
+		token, err := utils.CreateToken(3, 7, "secret", time.Minute)
+		require.NoError(t, err)
+
+		authDetails, err := models.VerifyJWTToken(token, "secret")
+		require.NoError(t, err)
+		require.Equal(t, 3, authDetails.UserId)
+		require.Equal(t, 7, authDetails.SessionId)
+	})
+
+	t.Run("rotate + reuse", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		session, refreshToken, err := models.CreateSession(ctx, testDb, u.Id, "test", models.REFRESH_TOKEN_TTL, true)
+		require.NoError(t, err)
+		require.NoError(t, models.CheckSession(ctx, testDb, session.Id, u.Id))
+
+		rotated, newRefreshToken, err := models.RotateRefreshToken(ctx, testDb, refreshToken)
+		require.NoError(t, err)
+		require.Equal(t, session.Id, rotated.Id)
+		require.NotEqual(t, refreshToken, newRefreshToken)
+
+		_, _, err = models.RotateRefreshToken(ctx, testDb, refreshToken)
+		require.ErrorIs(t, err, models.ErrRefreshTokenReused)
+		require.ErrorIs(t, models.CheckSession(ctx, testDb, session.Id, u.Id), models.ErrSessionRevoked)
+
+		_, _, err = models.RotateRefreshToken(ctx, testDb, newRefreshToken)
+		require.ErrorIs(t, err, models.ErrSessionRevoked)
+	})
+
+	t.Run("revoke all", func(t *testing.T) {
+		u, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		for range 2 {
+			_, _, err = models.CreateSession(ctx, testDb, u.Id, "test", models.REFRESH_TOKEN_TTL, true)
+			require.NoError(t, err)
+		}
+
+		sessions, err := models.GetActiveSessions(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		revoked, err := models.RevokeUserSessions(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), revoked)
+
+		sessions, err = models.GetActiveSessions(ctx, testDb, u.Id)
+		require.NoError(t, err)
+		require.Empty(t, sessions)
+	})
+}
@@ -25,3 +25,7 @@ func NewTestWCACompAnnouncementsPositionSubscription(userId int) WCACompAnnounce
 func NewTestWCACompAnnouncementsSubscription(userId int, countryId string) WCACompAnnouncementsSubscription {
 	return WCACompAnnouncementsSubscription{UserId: userId, CountryId: countryId, State: uuid.NewString()}
 }
+
+func NewTestBattle(challengerId int, opponentId int) Battle {
+	return Battle{ChallengerId: challengerId, OpponentId: opponentId, Event: CompetitionEvent{Id: 1, Iconcode: "333"}, NoOfAttempts: 3}
+}
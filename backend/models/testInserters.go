@@ -75,3 +75,23 @@ func TestInsertWCACompAnnouncementsSubscription(ctx context.Context, db interfac
 
 	return sub, u, co, ct, nil
 }
+
+func TestInsertBattle(ctx context.Context, db interfaces.DB) (Battle, User, User, error) {
+	challenger, _, _, err := TestInsertUser(ctx, db)
+	if err != nil {
+		return Battle{}, User{}, User{}, err
+	}
+
+	opponent, _, _, err := TestInsertUser(ctx, db)
+	if err != nil {
+		return Battle{}, User{}, User{}, err
+	}
+
+	battle := NewTestBattle(challenger.Id, opponent.Id)
+	err = battle.Insert(ctx, db)
+	if err != nil {
+		return Battle{}, User{}, User{}, err
+	}
+
+	return battle, challenger, opponent, nil
+}
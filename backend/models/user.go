@@ -3,17 +3,17 @@ package models
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/email"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
 )
 
 type User struct {
@@ -27,14 +27,29 @@ type User struct {
 	Url         string `json:"url"`
 	AvatarUrl   string `json:"avatarurl"`
 	Email       string `json:"-"`
+	Language    string `json:"language"`
+	// how the user registered, the WCA login only finds the users registered
+	// through it or who linked their WCA account
+	LoginProvider string `json:"-"`
+}
+
+// loginProvider is the WCA login for the users created before the others
+// existed
+func (u *User) loginProvider() string {
+	if u.LoginProvider == "" {
+		return LOGIN_PROVIDER_WCA
+	}
+
+	return u.LoginProvider
 }
 
 func (u *User) Exists(ctx context.Context, db interfaces.DB) (bool, error) {
 	rows, err := db.Query(
 		ctx,
-		`SELECT u.user_id, u.isadmin FROM users u WHERE u.wcaid = $1 AND u.name = $2;`,
+		`SELECT u.user_id, u.isadmin FROM users u WHERE u.wcaid = $1 AND u.name = $2 AND u.login_provider = $3;`,
 		u.WcaId,
 		u.Name,
+		u.loginProvider(),
 	)
 	if err != nil {
 		return false, err
@@ -52,18 +67,17 @@ func (u *User) Exists(ctx context.Context, db interfaces.DB) (bool, error) {
 	return found, nil
 }
 
-func (u *User) Update(db *pgxpool.Pool) error {
+func (u *User) Update(db interfaces.DB) error {
 	_, err := db.Exec(
 		context.Background(),
-		`UPDATE users SET country_id = $1, sex = $2, url = $3, avatarurl = $4, isadmin = $5, timestamp = CURRENT_TIMESTAMP, email = $6 WHERE wcaid = $7 AND name = $8;`,
+		`UPDATE users SET country_id = $1, sex = $2, url = $3, avatarurl = $4, isadmin = $5, timestamp = CURRENT_TIMESTAMP, email = $6 WHERE user_id = $7;`,
 		u.CountryId,
 		u.Sex,
 		u.Url,
 		u.AvatarUrl,
 		u.IsAdmin,
 		u.Email,
-		u.WcaId,
-		u.Name,
+		u.Id,
 	)
 	if err != nil {
 		return err
@@ -100,7 +114,9 @@ func (u *User) CreateAllAnnouncementReadConnection(ctx context.Context, db inter
 }
 
 func (u *User) Insert(ctx context.Context, db interfaces.DB) error {
-	err := db.QueryRow(context.Background(), `INSERT INTO users (name, country_id, sex, url, avatarurl, wcaid, isadmin, email) VALUES ($1,$2,$3,$4,$5,$6,false,$7) RETURNING user_id;`, u.Name, u.CountryId, u.Sex, u.Url, u.AvatarUrl, u.WcaId, u.Email).
+	u.Language = templates.Language(u.Language)
+	u.LoginProvider = u.loginProvider()
+	err := db.QueryRow(context.Background(), `INSERT INTO users (name, country_id, sex, url, avatarurl, wcaid, isadmin, email, language, login_provider) VALUES ($1,$2,$3,$4,$5,$6,false,$7,$8,$9) RETURNING user_id;`, u.Name, u.CountryId, u.Sex, u.Url, u.AvatarUrl, u.WcaId, u.Email, u.Language, u.LoginProvider).
 		Scan(&u.Id)
 	if err != nil {
 		return fmt.Errorf("%w: failed to insert user=%+v and scan its id", err, *u)
@@ -125,7 +141,7 @@ func (u *User) Insert(ctx context.Context, db interfaces.DB) error {
 func GetUserById(db interfaces.DB, uid int) (User, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT u.user_id, u.name, u.country_id, u.sex, u.wcaid, u.isadmin, u.url, u.avatarurl, u.email FROM users u WHERE u.user_id = $1;`,
+		`SELECT u.user_id, u.name, u.country_id, u.sex, u.wcaid, u.isadmin, u.url, u.avatarurl, u.email, u.language FROM users u WHERE u.user_id = $1;`,
 		uid,
 	)
 	if err != nil {
@@ -145,6 +161,7 @@ func GetUserById(db interfaces.DB, uid int) (User, error) {
 			&user.Url,
 			&user.AvatarUrl,
 			&user.Email,
+			&user.Language,
 		)
 		if err != nil {
 			return User{}, err
@@ -208,59 +225,6 @@ func GetEmailByWCAID(db *pgxpool.Pool, wcaid string) (string, error) {
 	return email, err
 }
 
-func GetUserInfoFromWCA(authInfo *AuthorizationInfo, envMap map[string]string) (User, error) {
-	bearer := "Bearer " + authInfo.AccessToken
-	req, err := http.NewRequest("GET", envMap["WCA_API_ME_URL"], nil)
-	if err != nil {
-		return User{}, err
-	}
-
-	req.Header.Add("Authorization", bearer)
-	req.Header.Add("Content-Type", "application/json")
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil || res.StatusCode != http.StatusOK {
-		return User{}, err
-	}
-	defer res.Body.Close()
-	type Country struct {
-		Id string `json:"id"`
-	}
-	type Avatar struct {
-		Url string `json:"url"`
-	}
-	type ME struct {
-		Name    string  `json:"name"`
-		WcaId   string  `json:"wca_id"`
-		Sex     string  `json:"gender"`
-		Url     string  `json:"url"`
-		Country Country `json:"country"`
-		Avatar  Avatar  `json:"avatar"`
-		Email   string  `json:"email"`
-	}
-	type WCAApiMe struct {
-		Me ME `json:"me"`
-	}
-
-	var apiMe WCAApiMe
-	err = json.NewDecoder(res.Body).Decode(&apiMe)
-	if err != nil {
-		return User{}, err
-	}
-
-	user := User{}
-	user.Name = apiMe.Me.Name
-	user.CountryId = apiMe.Me.Country.Id
-	user.Sex = apiMe.Me.Sex
-	user.WcaId = apiMe.Me.WcaId
-	user.IsAdmin = false
-	user.Url = apiMe.Me.Url
-	user.AvatarUrl = apiMe.Me.Avatar.Url
-	user.Email = apiMe.Me.Email
-
-	return user, nil
-}
-
 func (u *User) LoadContinent(db *pgxpool.Pool) error {
 	rows, err := db.Query(
 		context.Background(),
@@ -318,18 +282,17 @@ func (u User) SendNewUserMailAsync(ctx context.Context, db interfaces.DB, envMap
 			"<b>Country:</b> " + u.CountryId + "<br>" +
 			"<b>User no. " + strconv.Itoa(order) + "</b>"
 
-	err = email.SendMail(
-		envMap["MAIL_USERNAME"],
-		envMap["MAIL_USERNAME"],
-		mailSubject,
-		content,
-		envMap,
-	)
+	_, err = QueueEmail(ctx, db, 0, email.Message{
+		From:    envMap["MAIL_USERNAME"],
+		To:      envMap["MAIL_USERNAME"],
+		Subject: mailSubject,
+		Body:    content,
+	})
 	if err != nil {
-		return fmt.Errorf("%w: when sending email about new user", err)
+		return fmt.Errorf("%w: when queueing email about new user", err)
 	}
 
-	log.Println("Successfully sent mail about new user.")
+	log.Println("Successfully queued mail about new user.")
 
 	return nil
 }
@@ -367,10 +330,13 @@ func FindFuzzyDuplicateUser(ctx context.Context, db interfaces.DB, userID int) (
 	return duplicate, true, nil
 }
 
-func MergeUsers(ctx context.Context, db interfaces.DB, oldUserID, newUserID int) error {
+// MergeUsers moves everything referencing the old user to the new one and
+// deletes the old user, the merge is recorded so it can be undone with
+// UnmergeUsers
+func MergeUsers(ctx context.Context, db interfaces.DB, oldUserID, newUserID, adminID int) (UserMerge, error) {
 	tx, err := db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%w: when starting db transaction", err)
+		return UserMerge{}, fmt.Errorf("%w: when starting db transaction", err)
 	}
 	defer tx.Rollback(ctx)
 
@@ -385,66 +351,60 @@ func MergeUsers(ctx context.Context, db interfaces.DB, oldUserID, newUserID int)
 	err = tx.QueryRow(ctx, validationQuery, oldUserID, newUserID).Scan(&areNamesSimilar)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("validation failed: one or both user IDs (%d, %d) do not exist", oldUserID, newUserID)
+			return UserMerge{}, fmt.Errorf("validation failed: one or both user IDs (%d, %d) do not exist", oldUserID, newUserID)
 		}
-		return fmt.Errorf("%w: when executing validation query", err)
+		return UserMerge{}, fmt.Errorf("%w: when executing validation query", err)
 	}
 
 	if !areNamesSimilar {
-		return fmt.Errorf("validation failed: users %d and %d do not have similar names", oldUserID, newUserID)
+		return UserMerge{}, fmt.Errorf("validation failed: users %d and %d do not have similar names", oldUserID, newUserID)
 	}
 
-	type foreignKeyInfo struct {
-		ChildTable  string
-		ChildColumn string
+	merge := UserMerge{OldUserId: oldUserID, NewUserId: newUserID, MergedBy: adminID}
+	err = tx.QueryRow(ctx, `SELECT to_jsonb(u) FROM users u WHERE u.user_id = $1;`, oldUserID).Scan(&merge.OldUser)
+	if err != nil {
+		return UserMerge{}, fmt.Errorf("%w: when taking snapshot of old user", err)
 	}
 
-	var fks []foreignKeyInfo
-	fkQuery := `
-		SELECT
-			conrelid::regclass AS child_table,
-			a.attname AS child_column
-		FROM
-			pg_constraint AS c
-		JOIN pg_attribute AS a ON a.attrelid = c.conrelid AND a.attnum = ANY(c.conkey)
-		WHERE
-			c.contype = 'f'
-			AND c.confrelid = 'users'::regclass
-	`
-	rows, err := tx.Query(ctx, fkQuery)
+	fks, err := getUserForeignKeys(ctx, tx)
 	if err != nil {
-		return fmt.Errorf("%w: when querying foreign key constraints", err)
+		return UserMerge{}, err
 	}
 
-	for rows.Next() {
-		var fk foreignKeyInfo
-		if err := rows.Scan(&fk.ChildTable, &fk.ChildColumn); err != nil {
-			rows.Close()
-			return fmt.Errorf("%w: when scanning fk record", err)
+	for _, fk := range fks {
+		updateQuery := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE %s = $2 RETURNING %s::text`, fk.ChildTable, fk.ChildColumn, fk.ChildColumn, fk.PrimaryKey)
+		rows, err := tx.Query(ctx, updateQuery, newUserID, oldUserID)
+		if err != nil {
+			return UserMerge{}, fmt.Errorf("%w: when executing update child table %s query", err, fk.ChildTable)
 		}
-		fks = append(fks, fk)
-	}
-	rows.Close()
-	if err = rows.Err(); err != nil {
-		return fmt.Errorf("%w: when iteraing through rows", rows.Err())
-	}
 
-	for _, fk := range fks {
-		updateQuery := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE %s = $2`, fk.ChildTable, fk.ChildColumn, fk.ChildColumn)
-		if _, err := tx.Exec(ctx, updateQuery, newUserID, oldUserID); err != nil {
-			return fmt.Errorf("%w: when executing update child table %s query", err, fk.ChildTable)
+		for rows.Next() {
+			row := UserMergeRow{TableName: fk.ChildTable, ColumnName: fk.ChildColumn}
+			if err := rows.Scan(&row.RowId); err != nil {
+				rows.Close()
+				return UserMerge{}, fmt.Errorf("%w: when scanning moved row of %s", err, fk.ChildTable)
+			}
+			merge.Rows = append(merge.Rows, row)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return UserMerge{}, fmt.Errorf("%w: when updating child table %s", err, fk.ChildTable)
 		}
 	}
 
 	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE user_id = $1`, oldUserID); err != nil {
-		return fmt.Errorf("%w: when executing delete old user", err)
+		return UserMerge{}, fmt.Errorf("%w: when executing delete old user", err)
+	}
+
+	if err := merge.insert(ctx, tx); err != nil {
+		return UserMerge{}, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("%w: when commiting transaction", err)
+		return UserMerge{}, fmt.Errorf("%w: when commiting transaction", err)
 	}
 
-	return nil
+	return merge, nil
 }
 
 func SearchUsers(ctx context.Context, db interfaces.DB, query string) ([]ManageUser, error) {
@@ -497,3 +457,19 @@ func SearchUsers(ctx context.Context, db interfaces.DB, query string) ([]ManageU
 
 	return users, nil
 }
+
+var ErrUnknownLanguage = errors.New("unknown language")
+
+// SetUserLanguage sets the language of the emails sent to the user
+func SetUserLanguage(ctx context.Context, db interfaces.DB, uid int, language string) error {
+	if !slices.Contains(templates.LANGUAGES, language) {
+		return fmt.Errorf("%w: %s", ErrUnknownLanguage, language)
+	}
+
+	_, err := db.Exec(ctx, `UPDATE users SET language = $1 WHERE user_id = $2;`, language, uid)
+	if err != nil {
+		return fmt.Errorf("%w: when setting language of user with id=%d", err, uid)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func newDuplicateCandidate(id int, normalized string, wcaId string, competitions []string, events []int) models.DuplicateCandidate {
+	c := models.DuplicateCandidate{Id: id, Name: normalized, WcaId: wcaId, CountryId: "SK", Normalized: normalized, Competitions: map[string]struct{}{}, Events: map[int]struct{}{}}
+	for _, cid := range competitions {
+		c.Competitions[cid] = struct{}{}
+	}
+	for _, eid := range events {
+		c.Events[eid] = struct{}{}
+	}
+	return c
+}
+
+func TestNameSimilarity(t *testing.T) {
+	require.Equal(t, 1.0, models.NameSimilarity("jozko mrkvicka", "jozko  mrkvicka"))
+	require.Equal(t, 0.9, models.NameSimilarity("jozko mrkvicka", "jozko peter mrkvicka"))
+	require.InDelta(t, 1-1.0/14, models.NameSimilarity("jozko mrkvicka", "jozko mrkvicke"), 1e-9)
+	require.Equal(t, 0.0, models.NameSimilarity("", "jozko"))
+}
+
+func TestFindDuplicatePairs(t *testing.T) {
+	candidates := []models.DuplicateCandidate{
+		newDuplicateCandidate(3, "jozko mrkvicka", "2015MRKV01", []string{"b"}, []int{1, 2}),
+		newDuplicateCandidate(1, "jozko mrkvicka", "", []string{"a"}, []int{1, 2}),
+		newDuplicateCandidate(2, "jozko mrkvicka", "", []string{"b"}, []int{1}),
+		newDuplicateCandidate(4, "ferko hrasko", "", []string{"a"}, []int{1}),
+	}
+
+	pairs := models.FindDuplicatePairs(candidates, 0, 0)
+	require.Len(t, pairs, 3)
+
+	// never met and compete in the same events
+	require.Equal(t, 1, pairs[0].OldUser.Id)
+	require.Equal(t, 3, pairs[0].NewUser.Id)
+	require.Equal(t, 80.0, pairs[0].Score)
+	require.Equal(t, 0, pairs[0].SharedCompetitions)
+
+	require.Equal(t, 1, pairs[1].OldUser.Id)
+	require.Equal(t, 2, pairs[1].NewUser.Id)
+	require.Equal(t, 75.0, pairs[1].Score)
+
+	// met at a competition
+	require.Equal(t, 2, pairs[2].OldUser.Id)
+	require.Equal(t, 3, pairs[2].NewUser.Id)
+	require.Equal(t, 1, pairs[2].SharedCompetitions)
+	require.Equal(t, 25.0, pairs[2].Score)
+
+	require.Len(t, models.FindDuplicatePairs(candidates, 50, 0), 2)
+	require.Len(t, models.FindDuplicatePairs(candidates, 0, 1), 1)
+}
@@ -0,0 +1,89 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserMerge(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("merge + unmerge", func(t *testing.T) {
+		oldUser, country, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		newUser := models.NewTestUser(country.Id)
+		newUser.Name = oldUser.Name
+		err = newUser.Insert(ctx, testDb)
+		require.NoError(t, err)
+
+		oldSub := models.NewTestWCACompAnnouncementsSubscription(oldUser.Id, country.Id)
+		err = oldSub.Insert(ctx, testDb)
+		require.NoError(t, err)
+		newSub := models.NewTestWCACompAnnouncementsSubscription(newUser.Id, country.Id)
+		err = newSub.Insert(ctx, testDb)
+		require.NoError(t, err)
+
+		merge, err := models.MergeUsers(ctx, testDb, oldUser.Id, newUser.Id, newUser.Id)
+		require.NoError(t, err)
+		require.Len(t, merge.Rows, 1)
+
+		sub := models.WCACompAnnouncementsSubscription{}
+		err = sub.Get(ctx, testDb, oldSub.Id)
+		require.NoError(t, err)
+		require.Equal(t, newUser.Id, sub.UserId)
+
+		saved := models.UserMerge{}
+		err = saved.Get(ctx, testDb, merge.Id)
+		require.NoError(t, err)
+		require.Equal(t, merge.Rows, saved.Rows)
+		require.Nil(t, saved.UnmergedAt)
+
+		err = models.UnmergeUsers(ctx, testDb, merge.Id, newUser.Id)
+		require.NoError(t, err)
+
+		err = sub.Get(ctx, testDb, oldSub.Id)
+		require.NoError(t, err)
+		require.Equal(t, oldUser.Id, sub.UserId)
+
+		err = sub.Get(ctx, testDb, newSub.Id)
+		require.NoError(t, err)
+		require.Equal(t, newUser.Id, sub.UserId)
+
+		err = models.UnmergeUsers(ctx, testDb, merge.Id, newUser.Id)
+		require.ErrorIs(t, err, models.ErrAlreadyUnmerged)
+	})
+
+	t.Run("unmerge snapshot missing a column", func(t *testing.T) {
+		oldUser, country, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+		newUser, _, _, err := models.TestInsertUser(ctx, testDb)
+		require.NoError(t, err)
+
+		_, err = testDb.Exec(ctx, `UPDATE users SET language = 'sk' WHERE user_id = $1;`, oldUser.Id)
+		require.NoError(t, err)
+
+		merge, err := models.MergeUsers(ctx, testDb, oldUser.Id, newUser.Id, newUser.Id)
+		require.NoError(t, err)
+
+		// merges recorded before the language and the login provider existed
+		_, err = testDb.Exec(ctx, `UPDATE user_merges SET old_user = old_user - 'language' - 'login_provider' WHERE user_merge_id = $1;`, merge.Id)
+		require.NoError(t, err)
+
+		err = models.UnmergeUsers(ctx, testDb, merge.Id, newUser.Id)
+		require.NoError(t, err)
+
+		restored, err := models.GetUserById(testDb, oldUser.Id)
+		require.NoError(t, err)
+		require.Equal(t, oldUser.Name, restored.Name)
+		require.Equal(t, country.Id, restored.CountryId)
+		require.Equal(t, "en", restored.Language)
+	})
+
+	t.Run("unmerge missing", func(t *testing.T) {
+		err := models.UnmergeUsers(ctx, testDb, -1, 1)
+		require.ErrorIs(t, err, models.ErrUserMergeNotFound)
+	})
+}
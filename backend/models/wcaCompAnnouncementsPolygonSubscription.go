@@ -0,0 +1,286 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+// the polygon subscriptions are edited on the map as a GeoJSON feature
+// collection, every feature is one subscription with a Polygon or a
+// MultiPolygon geometry
+const (
+	GEOJSON_FEATURE_COLLECTION = "FeatureCollection"
+	GEOJSON_FEATURE            = "Feature"
+	GEOJSON_POLYGON            = "Polygon"
+	GEOJSON_MULTI_POLYGON      = "MultiPolygon"
+
+	MAX_POLYGON_SUBSCRIPTIONS_PER_USER = 20
+	// all the positions of all the subscriptions of the user
+	MAX_POLYGON_SUBSCRIPTION_POSITIONS = 20000
+)
+
+var (
+	ErrInvalidGeoJSON              = errors.New("invalid geojson")
+	ErrTooManyPolygonSubscriptions = errors.New("too many polygon subscriptions")
+)
+
+// MultiPolygon are the coordinates of the GeoJSON MultiPolygon, the positions
+// are [longitude, latitude]
+type MultiPolygon [][][][]float64
+
+type PolygonGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type PolygonSubscriptionProperties struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type PolygonSubscriptionFeature struct {
+	Type       string                        `json:"type"`
+	Properties PolygonSubscriptionProperties `json:"properties"`
+	Geometry   PolygonGeometry               `json:"geometry"`
+}
+
+type PolygonSubscriptionFeatureCollection struct {
+	Type     string                       `json:"type"`
+	Features []PolygonSubscriptionFeature `json:"features"`
+}
+
+type WCACompAnnouncementsPolygonSubscription struct {
+	Id       int
+	UserId   int
+	Name     string
+	Polygons MultiPolygon
+}
+
+func validPosition(position []float64) bool {
+	return len(position) >= 2 &&
+		position[0] >= -180 && position[0] <= 180 &&
+		position[1] >= -90 && position[1] <= 90
+}
+
+// MultiPolygon returns the polygons of the geometry, without the altitudes
+func (g PolygonGeometry) MultiPolygon() (MultiPolygon, error) {
+	var polygons MultiPolygon
+	switch g.Type {
+	case GEOJSON_POLYGON:
+		var polygon [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return MultiPolygon{}, fmt.Errorf("%w: %s coordinates: %s", ErrInvalidGeoJSON, g.Type, err.Error())
+		}
+		polygons = MultiPolygon{polygon}
+	case GEOJSON_MULTI_POLYGON:
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return MultiPolygon{}, fmt.Errorf("%w: %s coordinates: %s", ErrInvalidGeoJSON, g.Type, err.Error())
+		}
+	default:
+		return MultiPolygon{}, fmt.Errorf("%w: geometry type %q is not %s or %s", ErrInvalidGeoJSON, g.Type, GEOJSON_POLYGON, GEOJSON_MULTI_POLYGON)
+	}
+
+	if len(polygons) == 0 {
+		return MultiPolygon{}, fmt.Errorf("%w: %s without polygons", ErrInvalidGeoJSON, g.Type)
+	}
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			return MultiPolygon{}, fmt.Errorf("%w: polygon without rings", ErrInvalidGeoJSON)
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 {
+				return MultiPolygon{}, fmt.Errorf("%w: ring with less than 4 positions", ErrInvalidGeoJSON)
+			}
+			for positionIdx, position := range ring {
+				if !validPosition(position) {
+					return MultiPolygon{}, fmt.Errorf("%w: invalid position %v", ErrInvalidGeoJSON, position)
+				}
+				ring[positionIdx] = position[:2]
+			}
+			if !slices.Equal(ring[0], ring[len(ring)-1]) {
+				return MultiPolygon{}, fmt.Errorf("%w: ring is not closed", ErrInvalidGeoJSON)
+			}
+		}
+	}
+
+	return polygons, nil
+}
+
+func (p MultiPolygon) Positions() int {
+	positions := 0
+	for _, polygon := range p {
+		for _, ring := range polygon {
+			positions += len(ring)
+		}
+	}
+
+	return positions
+}
+
+func (s WCACompAnnouncementsPolygonSubscription) Contains(lat, long float64) bool {
+	return slices.ContainsFunc(s.Polygons, func(polygon [][][]float64) bool {
+		return utils.PointInsidePolygon(lat, long, polygon)
+	})
+}
+
+func (s WCACompAnnouncementsPolygonSubscription) Feature() (PolygonSubscriptionFeature, error) {
+	coordinates, err := json.Marshal(s.Polygons)
+	if err != nil {
+		return PolygonSubscriptionFeature{}, fmt.Errorf("%w: when marshalling polygons of subscription with id=%d", err, s.Id)
+	}
+
+	return PolygonSubscriptionFeature{
+		Type:       GEOJSON_FEATURE,
+		Properties: PolygonSubscriptionProperties{Id: s.Id, Name: s.Name},
+		Geometry:   PolygonGeometry{Type: GEOJSON_MULTI_POLYGON, Coordinates: coordinates},
+	}, nil
+}
+
+func PolygonSubscriptionsFeatureCollection(subscriptions []WCACompAnnouncementsPolygonSubscription) (PolygonSubscriptionFeatureCollection, error) {
+	collection := PolygonSubscriptionFeatureCollection{
+		Type:     GEOJSON_FEATURE_COLLECTION,
+		Features: make([]PolygonSubscriptionFeature, 0, len(subscriptions)),
+	}
+	for _, subscription := range subscriptions {
+		feature, err := subscription.Feature()
+		if err != nil {
+			return PolygonSubscriptionFeatureCollection{}, err
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+
+	return collection, nil
+}
+
+// ParsePolygonSubscriptions returns the subscriptions of the user drawn in the
+// collection, the ids of the features are ignored
+func ParsePolygonSubscriptions(uid int, collection PolygonSubscriptionFeatureCollection) ([]WCACompAnnouncementsPolygonSubscription, error) {
+	if collection.Type != GEOJSON_FEATURE_COLLECTION {
+		return []WCACompAnnouncementsPolygonSubscription{}, fmt.Errorf("%w: type %q is not %s", ErrInvalidGeoJSON, collection.Type, GEOJSON_FEATURE_COLLECTION)
+	}
+	if len(collection.Features) > MAX_POLYGON_SUBSCRIPTIONS_PER_USER {
+		return []WCACompAnnouncementsPolygonSubscription{}, ErrTooManyPolygonSubscriptions
+	}
+
+	subscriptions := make([]WCACompAnnouncementsPolygonSubscription, 0, len(collection.Features))
+	positions := 0
+	for _, feature := range collection.Features {
+		if feature.Type != GEOJSON_FEATURE {
+			return []WCACompAnnouncementsPolygonSubscription{}, fmt.Errorf("%w: type %q is not %s", ErrInvalidGeoJSON, feature.Type, GEOJSON_FEATURE)
+		}
+
+		polygons, err := feature.Geometry.MultiPolygon()
+		if err != nil {
+			return []WCACompAnnouncementsPolygonSubscription{}, err
+		}
+		positions += polygons.Positions()
+		if positions > MAX_POLYGON_SUBSCRIPTION_POSITIONS {
+			return []WCACompAnnouncementsPolygonSubscription{}, fmt.Errorf("%w: more than %d positions", ErrInvalidGeoJSON, MAX_POLYGON_SUBSCRIPTION_POSITIONS)
+		}
+
+		subscriptions = append(subscriptions, WCACompAnnouncementsPolygonSubscription{
+			UserId:   uid,
+			Name:     strings.TrimSpace(feature.Properties.Name),
+			Polygons: polygons,
+		})
+	}
+
+	return subscriptions, nil
+}
+
+func (s *WCACompAnnouncementsPolygonSubscription) Insert(ctx context.Context, db interfaces.DB) error {
+	geometry, err := json.Marshal(map[string]any{"type": GEOJSON_MULTI_POLYGON, "coordinates": s.Polygons})
+	if err != nil {
+		return fmt.Errorf("%w: when marshalling geometry of polygon subscription %s", err, s.Name)
+	}
+
+	err = db.QueryRow(
+		ctx,
+		`INSERT INTO wca_competitions_announcements_polygon_subscriptions (user_id, name, geometry) VALUES ($1, $2, $3) RETURNING wca_competitions_announcements_polygon_subscription_id;`,
+		s.UserId,
+		s.Name,
+		geometry,
+	).Scan(&s.Id)
+	if err != nil {
+		return fmt.Errorf("%w: when inserting polygon subscription %s of user with id=%d", err, s.Name, s.UserId)
+	}
+
+	return nil
+}
+
+// GetWCACompAnnouncementsPolygonSubscriptions returns the polygon
+// subscriptions of the user, of all the users with uid 0
+func GetWCACompAnnouncementsPolygonSubscriptions(ctx context.Context, db interfaces.DB, uid int) ([]WCACompAnnouncementsPolygonSubscription, error) {
+	queryString := `SELECT ps.wca_competitions_announcements_polygon_subscription_id, ps.user_id, ps.name, ps.geometry FROM wca_competitions_announcements_polygon_subscriptions ps`
+	args := []any{}
+	if uid != 0 {
+		queryString += " WHERE ps.user_id = $1"
+		args = append(args, uid)
+	}
+	queryString += " ORDER BY ps.wca_competitions_announcements_polygon_subscription_id;"
+
+	rows, err := db.Query(ctx, queryString, args...)
+	if err != nil {
+		return []WCACompAnnouncementsPolygonSubscription{}, fmt.Errorf("%w: when querying polygon subscriptions of user with id=%d", err, uid)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]WCACompAnnouncementsPolygonSubscription, 0)
+	for rows.Next() {
+		var s WCACompAnnouncementsPolygonSubscription
+		var geometry []byte
+		if err = rows.Scan(&s.Id, &s.UserId, &s.Name, &geometry); err != nil {
+			return []WCACompAnnouncementsPolygonSubscription{}, fmt.Errorf("%w: when scanning polygon subscription", err)
+		}
+
+		var g struct {
+			Coordinates MultiPolygon `json:"coordinates"`
+		}
+		if err = json.Unmarshal(geometry, &g); err != nil {
+			return []WCACompAnnouncementsPolygonSubscription{}, fmt.Errorf("%w: when unmarshalling geometry of polygon subscription with id=%d", err, s.Id)
+		}
+		s.Polygons = g.Coordinates
+
+		subscriptions = append(subscriptions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return []WCACompAnnouncementsPolygonSubscription{}, fmt.Errorf("%w: when iterating through polygon subscriptions", err)
+	}
+
+	return subscriptions, nil
+}
+
+// ReplaceWCACompAnnouncementsPolygonSubscriptions replaces all the polygon
+// subscriptions of the user with the ones edited on the map
+func ReplaceWCACompAnnouncementsPolygonSubscriptions(ctx context.Context, db interfaces.DB, uid int, subscriptions []WCACompAnnouncementsPolygonSubscription) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: when starting db transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM wca_competitions_announcements_polygon_subscriptions WHERE user_id = $1;`, uid)
+	if err != nil {
+		return fmt.Errorf("%w: when deleting polygon subscriptions of user with id=%d", err, uid)
+	}
+
+	for idx := range subscriptions {
+		subscriptions[idx].UserId = uid
+		if err = subscriptions[idx].Insert(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: when commiting transaction", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+)
+
+// Slovakia (roughly, without Košice) plus Vienna and Budapest
+const slovakiaViennaBudapest = `{
+	"type": "FeatureCollection",
+	"features": [
+		{
+			"type": "Feature",
+			"properties": {"name": " Slovakia "},
+			"geometry": {
+				"type": "Polygon",
+				"coordinates": [
+					[[16.8, 47.7], [22.6, 47.7], [22.6, 49.6], [16.8, 49.6], [16.8, 47.7]],
+					[[21.1, 48.6], [21.4, 48.6], [21.4, 48.8], [21.1, 48.8], [21.1, 48.6]]
+				]
+			}
+		},
+		{
+			"type": "Feature",
+			"properties": {"id": 42, "name": "Vienna and Budapest"},
+			"geometry": {
+				"type": "MultiPolygon",
+				"coordinates": [
+					[[[16.2, 48.1, 150], [16.6, 48.1, 150], [16.6, 48.3, 150], [16.2, 48.3, 150], [16.2, 48.1, 150]]],
+					[[[18.9, 47.4], [19.2, 47.4], [19.2, 47.6], [18.9, 47.6], [18.9, 47.4]]]
+				]
+			}
+		}
+	]
+}`
+
+func parseCollection(t *testing.T, geojson string) models.PolygonSubscriptionFeatureCollection {
+	var collection models.PolygonSubscriptionFeatureCollection
+	require.NoError(t, json.Unmarshal([]byte(geojson), &collection))
+
+	return collection
+}
+
+func TestParsePolygonSubscriptions(t *testing.T) {
+	subscriptions, err := models.ParsePolygonSubscriptions(7, parseCollection(t, slovakiaViennaBudapest))
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 2)
+
+	slovakia, viennaBudapest := subscriptions[0], subscriptions[1]
+	require.Equal(t, 7, slovakia.UserId)
+	require.Equal(t, "Slovakia", slovakia.Name)
+	require.Equal(t, 0, viennaBudapest.Id)
+	require.Len(t, viennaBudapest.Polygons, 2)
+	// the altitudes are dropped
+	require.Equal(t, []float64{16.2, 48.1}, viennaBudapest.Polygons[0][0][0])
+
+	require.True(t, slovakia.Contains(48.15, 17.11), "Bratislava")
+	require.True(t, slovakia.Contains(49.22, 18.74), "Žilina")
+	require.False(t, slovakia.Contains(48.72, 21.26), "Košice is in the hole")
+	require.True(t, viennaBudapest.Contains(48.21, 16.37), "Vienna")
+	require.True(t, viennaBudapest.Contains(47.50, 19.04), "Budapest")
+	require.False(t, viennaBudapest.Contains(48.15, 17.11), "Bratislava")
+	require.False(t, slovakia.Contains(47.07, 15.44) || viennaBudapest.Contains(47.07, 15.44), "Graz")
+	require.False(t, slovakia.Contains(50.08, 14.44) || viennaBudapest.Contains(50.08, 14.44), "Prague")
+
+	invalid := map[string]string{
+		"collection type": `{"type": "Feature", "features": []}`,
+		"feature type":    `{"type": "FeatureCollection", "features": [{"type": "Polygon"}]}`,
+		"geometry type":   `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": [17.1, 48.1]}}]}`,
+		"not closed":      `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}}]}`,
+		"short ring":      `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [0, 0]]]}}]}`,
+		"out of range":    `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [200, 0], [1, 1], [0, 0]]]}}]}`,
+		"no polygons":     `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "MultiPolygon", "coordinates": []}}]}`,
+	}
+	for name, geojson := range invalid {
+		_, err := models.ParsePolygonSubscriptions(7, parseCollection(t, geojson))
+		require.ErrorIs(t, err, models.ErrInvalidGeoJSON, name)
+	}
+
+	tooMany := models.PolygonSubscriptionFeatureCollection{Type: models.GEOJSON_FEATURE_COLLECTION}
+	for range models.MAX_POLYGON_SUBSCRIPTIONS_PER_USER + 1 {
+		tooMany.Features = append(tooMany.Features, parseCollection(t, slovakiaViennaBudapest).Features[0])
+	}
+	_, err = models.ParsePolygonSubscriptions(7, tooMany)
+	require.ErrorIs(t, err, models.ErrTooManyPolygonSubscriptions)
+}
+
+func TestWCACompAnnouncementsPolygonSubscriptions(t *testing.T) {
+	ctx := t.Context()
+
+	user, _, _, err := models.TestInsertUser(ctx, testDb)
+	require.NoError(t, err)
+	otherUser, _, _, err := models.TestInsertUser(ctx, testDb)
+	require.NoError(t, err)
+
+	subscriptions, err := models.ParsePolygonSubscriptions(user.Id, parseCollection(t, slovakiaViennaBudapest))
+	require.NoError(t, err)
+	require.NoError(t, models.ReplaceWCACompAnnouncementsPolygonSubscriptions(ctx, testDb, user.Id, subscriptions))
+
+	otherSubscriptions, err := models.ParsePolygonSubscriptions(otherUser.Id, parseCollection(t, slovakiaViennaBudapest))
+	require.NoError(t, err)
+	require.NoError(t, models.ReplaceWCACompAnnouncementsPolygonSubscriptions(ctx, testDb, otherUser.Id, otherSubscriptions[:1]))
+
+	saved, err := models.GetWCACompAnnouncementsPolygonSubscriptions(ctx, testDb, user.Id)
+	require.NoError(t, err)
+	require.Equal(t, subscriptions, saved)
+
+	// the collection returned for the map parses back to the same areas
+	collection, err := models.PolygonSubscriptionsFeatureCollection(saved)
+	require.NoError(t, err)
+	require.Equal(t, saved[1].Id, collection.Features[1].Properties.Id)
+	require.Equal(t, models.GEOJSON_MULTI_POLYGON, collection.Features[0].Geometry.Type)
+	marshalled, err := json.Marshal(collection)
+	require.NoError(t, err)
+	reparsed, err := models.ParsePolygonSubscriptions(user.Id, parseCollection(t, string(marshalled)))
+	require.NoError(t, err)
+	for idx := range reparsed {
+		require.Equal(t, saved[idx].Name, reparsed[idx].Name)
+		require.Equal(t, saved[idx].Polygons, reparsed[idx].Polygons)
+	}
+
+	all, err := models.GetWCACompAnnouncementsPolygonSubscriptions(ctx, testDb, 0)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(all), 3)
+
+	// replacing removes the areas deleted on the map
+	require.NoError(t, models.ReplaceWCACompAnnouncementsPolygonSubscriptions(ctx, testDb, user.Id, saved[1:]))
+	saved, err = models.GetWCACompAnnouncementsPolygonSubscriptions(ctx, testDb, user.Id)
+	require.NoError(t, err)
+	require.Len(t, saved, 1)
+	require.Equal(t, "Vienna and Budapest", saved[0].Name)
+
+	otherSaved, err := models.GetWCACompAnnouncementsPolygonSubscriptions(ctx, testDb, otherUser.Id)
+	require.NoError(t, err)
+	require.Len(t, otherSaved, 1)
+}
@@ -0,0 +1,110 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+)
+
+func TestWCAReminderDue(t *testing.T) {
+	open := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	comp := models.UpcomingWCACompetition{
+		RegistrationOpen:  open,
+		RegistrationClose: open.AddDate(0, 1, 0),
+		CompetitorLimit:   100,
+		Registered:        89,
+	}
+
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_REGISTRATION_24H, comp, open.Add(-25*time.Hour)))
+	require.True(t, models.WCAReminderDue(models.WCA_REMINDER_REGISTRATION_24H, comp, open.Add(-24*time.Hour)))
+	require.True(t, models.WCAReminderDue(models.WCA_REMINDER_REGISTRATION_24H, comp, open.Add(-time.Hour)))
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_REGISTRATION_24H, comp, open.Add(-10*time.Minute)))
+
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_REGISTRATION_15M, comp, open.Add(-time.Hour)))
+	require.True(t, models.WCAReminderDue(models.WCA_REMINDER_REGISTRATION_15M, comp, open.Add(-10*time.Minute)))
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_REGISTRATION_15M, comp, open))
+
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_ALMOST_FULL, comp, open.Add(time.Hour)))
+	comp.Registered = 90
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_ALMOST_FULL, comp, open.Add(-time.Hour)))
+	require.True(t, models.WCAReminderDue(models.WCA_REMINDER_ALMOST_FULL, comp, open.Add(time.Hour)))
+	comp.Registered = 100
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_ALMOST_FULL, comp, open.Add(time.Hour)))
+	comp.CompetitorLimit = 0
+	require.False(t, models.WCAReminderDue(models.WCA_REMINDER_ALMOST_FULL, comp, open.Add(time.Hour)))
+}
+
+func TestWCACompetitionReminders(t *testing.T) {
+	ctx := t.Context()
+
+	user, country, _, err := models.TestInsertUser(ctx, testDb)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	comp := models.UpcomingWCACompetition{
+		Id:                "Watched" + uuid.NewString()[:8],
+		Name:              uuid.NewString(),
+		Startdate:         now.AddDate(0, 1, 0),
+		Enddate:           now.AddDate(0, 1, 0),
+		CompetitorLimit:   100,
+		VenueAddress:      uuid.NewString(),
+		Url:               "https://www.worldcubeassociation.org/competitions/watched",
+		CountryId:         country.Id,
+		RegistrationOpen:  now.Add(2 * time.Hour),
+		RegistrationClose: now.AddDate(0, 0, 14),
+	}
+	tx, err := testDb.Begin(ctx)
+	require.NoError(t, err)
+	_, err = comp.Save(tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+
+	err = models.WatchWCACompetition(ctx, testDb, user.Id, "Missing"+uuid.NewString())
+	require.ErrorIs(t, err, models.ErrUpcomingWCACompetitionNotFound)
+
+	require.NoError(t, models.WatchWCACompetition(ctx, testDb, user.Id, comp.Id))
+	require.NoError(t, models.WatchWCACompetition(ctx, testDb, user.Id, comp.Id))
+
+	ids, err := models.GetWatchedWCACompetitionIds(ctx, testDb, user.Id)
+	require.NoError(t, err)
+	require.Equal(t, []string{comp.Id}, ids)
+
+	next, ok, err := models.NextWCAReminderAt(ctx, testDb, now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.False(t, next.After(comp.RegistrationOpen.Add(-15*time.Minute)))
+
+	sentKinds := func(at time.Time) []string {
+		reminders, err := models.GetDueWCACompetitionReminders(ctx, testDb, at)
+		require.NoError(t, err)
+
+		kinds := make([]string, 0)
+		for _, reminder := range reminders {
+			if reminder.UserId != user.Id {
+				continue
+			}
+			require.NoError(t, reminder.Send(ctx, testDb, map[string]string{}))
+			kinds = append(kinds, reminder.Kind)
+		}
+		return kinds
+	}
+
+	require.Equal(t, []string{models.WCA_REMINDER_REGISTRATION_24H}, sentKinds(now))
+	require.Empty(t, sentKinds(now.Add(time.Minute)))
+	require.Equal(t, []string{models.WCA_REMINDER_REGISTRATION_15M}, sentKinds(comp.RegistrationOpen.Add(-10*time.Minute)))
+	require.Empty(t, sentKinds(comp.RegistrationOpen.Add(time.Hour)))
+
+	comp.Registered = 95
+	require.NoError(t, comp.UpdateRegistered(ctx, testDb))
+	require.Equal(t, []string{models.WCA_REMINDER_ALMOST_FULL}, sentKinds(comp.RegistrationOpen.Add(time.Hour)))
+	require.Empty(t, sentKinds(comp.RegistrationOpen.Add(2*time.Hour)))
+
+	require.NoError(t, models.UnwatchWCACompetition(ctx, testDb, user.Id, comp.Id))
+	ids, err = models.GetWatchedWCACompetitionIds(ctx, testDb, user.Id)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
@@ -0,0 +1,230 @@
+package models_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/controllers"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca/wcatest"
+)
+
+func TestWCALogin(t *testing.T) {
+	ctx := t.Context()
+
+	server := wcatest.NewServer()
+	defer server.Close()
+
+	country, _, err := models.TestInsertCountry(ctx, testDb)
+	require.NoError(t, err)
+
+	me := wca.Me{
+		Name:    uuid.NewString(),
+		WcaId:   uuid.NewString()[:10],
+		Gender:  "f",
+		Url:     "https://www.worldcubeassociation.org/persons/" + uuid.NewString(),
+		Country: wca.Country{Id: country.Id, Iso2: country.Iso2},
+		Avatar:  wca.Avatar{Url: uuid.NewString()},
+		Email:   uuid.NewString() + "@example.com",
+	}
+	server.AddUser("code", me)
+
+	provider := models.NewWCALoginProvider(server.WCAClient())
+
+	user, created, err := provider.Login(ctx, testDb, "code")
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, me.Name, user.Name)
+	require.Equal(t, me.WcaId, user.WcaId)
+	require.Equal(t, "f", user.Sex)
+	require.Equal(t, country.Id, user.CountryId)
+	require.Equal(t, me.Avatar.Url, user.AvatarUrl)
+	require.Equal(t, me.Email, user.Email)
+
+	again, created, err := provider.Login(ctx, testDb, "code")
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, user.Id, again.Id)
+
+	_, _, err = provider.Login(ctx, testDb, "wrong code")
+	require.Error(t, err)
+}
+
+func TestCheckUpcomingWCACompetitions(t *testing.T) {
+	ctx := t.Context()
+
+	server := wcatest.NewServer()
+	defer server.Close()
+
+	country, _, err := models.TestInsertCountry(ctx, testDb)
+	require.NoError(t, err)
+
+	now := time.Now()
+	upcoming := wca.Competition{
+		Id:               "Upcoming" + uuid.NewString()[:8],
+		Name:             uuid.NewString(),
+		Startdate:        now.AddDate(0, 1, 0).Format("2006-01-02"),
+		Enddate:          now.AddDate(0, 1, 1).Format("2006-01-02"),
+		RegistrationOpen: now.AddDate(0, 0, 7).UTC().Truncate(time.Second),
+		CompetitorLimit:  100,
+		Url:              "https://www.worldcubeassociation.org/competitions/upcoming",
+		CountryIso2:      country.Iso2,
+		VenueAddress:     "Hlavna 1",
+		City:             "Bratislava",
+		EventIds:         []string{"333", "222"},
+	}
+	server.AddCompetitions(upcoming, wca.Competition{
+		Id:          "Past" + uuid.NewString()[:8],
+		Name:        uuid.NewString(),
+		Startdate:   now.AddDate(0, -1, 0).Format("2006-01-02"),
+		Enddate:     now.AddDate(0, -1, 0).Format("2006-01-02"),
+		CountryIso2: country.Iso2,
+	})
+	server.SetRegistrations(upcoming.Id, 42)
+
+	// the failed request is retried by the client
+	server.Fail(http.StatusServiceUnavailable)
+
+	err = controllers.CheckUpcomingWCACompetitions(testDb, server.WCAClient(), map[string]string{})
+	require.NoError(t, err)
+
+	comps, err := controllers.GetSavedUpcomingWCACompetitions(testDb, country.Id, "")
+	require.NoError(t, err)
+	require.Len(t, comps, 1)
+	require.Equal(t, upcoming.Id, comps[0].Id)
+	require.Equal(t, upcoming.Name, comps[0].Name)
+	require.Equal(t, 42, comps[0].Registered)
+	require.Equal(t, 100, comps[0].CompetitorLimit)
+	require.Equal(t, "Hlavna 1, Bratislava, "+country.Name, comps[0].VenueAddress)
+	require.True(t, upcoming.RegistrationOpen.Equal(comps[0].RegistrationOpen))
+	require.Len(t, comps[0].Events, 2)
+
+	t.Run("changes", func(t *testing.T) {
+		changed := upcoming
+		changed.Startdate = now.AddDate(0, 1, 7).Format("2006-01-02")
+		changed.Enddate = now.AddDate(0, 1, 8).Format("2006-01-02")
+		changed.CompetitorLimit = 120
+		server.UpdateCompetition(changed)
+		server.SetRegistrations(upcoming.Id, 50)
+
+		err := controllers.CheckUpcomingWCACompetitions(testDb, server.WCAClient(), map[string]string{})
+		require.NoError(t, err)
+
+		comps, err := controllers.GetSavedUpcomingWCACompetitions(testDb, country.Id, "")
+		require.NoError(t, err)
+		require.Len(t, comps, 1)
+		require.Equal(t, 120, comps[0].CompetitorLimit)
+		require.Equal(t, 50, comps[0].Registered)
+
+		changes, err := models.GetUpcomingWCACompetitionChanges(ctx, testDb, upcoming.Id, country.Id)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, models.WCA_COMPETITION_CHANGE_DATES, changes[0].Field)
+		require.Equal(t, models.WCA_COMPETITION_CHANGE_COMPETITOR_LIMIT, changes[1].Field)
+		require.Equal(t, "100", changes[1].OldValue)
+		require.Equal(t, "120", changes[1].NewValue)
+
+		// nothing changed, only the registrations are updated
+		server.SetRegistrations(upcoming.Id, 51)
+		err = controllers.CheckUpcomingWCACompetitions(testDb, server.WCAClient(), map[string]string{})
+		require.NoError(t, err)
+
+		changes, err = models.GetUpcomingWCACompetitionChanges(ctx, testDb, upcoming.Id, country.Id)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		upcoming = changed
+	})
+
+	t.Run("cancelled + reinstated", func(t *testing.T) {
+		server.RemoveCompetition(upcoming.Id)
+
+		err := controllers.CheckUpcomingWCACompetitions(testDb, server.WCAClient(), map[string]string{})
+		require.NoError(t, err)
+
+		comps, err := controllers.GetSavedUpcomingWCACompetitions(testDb, country.Id, "")
+		require.NoError(t, err)
+		require.Len(t, comps, 0)
+
+		server.AddCompetitions(upcoming)
+		err = controllers.CheckUpcomingWCACompetitions(testDb, server.WCAClient(), map[string]string{})
+		require.NoError(t, err)
+
+		comps, err = controllers.GetSavedUpcomingWCACompetitions(testDb, country.Id, "")
+		require.NoError(t, err)
+		require.Len(t, comps, 1)
+
+		cancelledAt := now
+		upcoming.CancelledAt = &cancelledAt
+		server.UpdateCompetition(upcoming)
+		err = controllers.CheckUpcomingWCACompetitions(testDb, server.WCAClient(), map[string]string{})
+		require.NoError(t, err)
+
+		changes, err := models.GetUpcomingWCACompetitionChanges(ctx, testDb, upcoming.Id, country.Id)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			models.WCA_COMPETITION_CHANGE_DATES,
+			models.WCA_COMPETITION_CHANGE_COMPETITOR_LIMIT,
+			models.WCA_COMPETITION_CHANGE_CANCELLED,
+			models.WCA_COMPETITION_CHANGE_REINSTATED,
+			models.WCA_COMPETITION_CHANGE_CANCELLED,
+		}, utils.Map(changes, func(c models.UpcomingWCACompetitionChange) string { return c.Field }))
+	})
+
+	t.Run("wca down", func(t *testing.T) {
+		server.Fail(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+
+		err := controllers.CheckUpcomingWCACompetitions(testDb, server.WCAClient(), map[string]string{})
+		var statusErr *wca.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	})
+}
+
+func TestUpcomingWCACompetitionChanges(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	previous := models.UpcomingWCACompetition{
+		Id:                "BratislavaOpen2026",
+		Name:              "Bratislava Open 2026",
+		Startdate:         time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		Enddate:           time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		CompetitorLimit:   80,
+		VenueAddress:      "Hlavna 1, Bratislava, Slovakia",
+		RegistrationOpen:  time.Date(2026, 4, 1, 18, 0, 0, 0, time.UTC),
+		RegistrationClose: time.Date(2026, 4, 30, 18, 0, 0, 0, time.UTC),
+		Events:            []models.CompetitionEvent{{Iconcode: "333"}, {Iconcode: "222"}},
+	}
+
+	comp := previous
+	comp.Events = []models.CompetitionEvent{{Iconcode: "222"}, {Iconcode: "333"}}
+	comp.Registered = 79
+	require.Empty(t, comp.Changes(previous, now))
+
+	comp.VenueAddress = "Hlavna 2, Bratislava, Slovakia"
+	comp.CompetitorLimit = 100
+	comp.RegistrationClose = time.Date(2026, 5, 15, 18, 0, 0, 0, time.UTC)
+	changes := comp.Changes(previous, now)
+	require.Equal(t, []string{
+		models.WCA_COMPETITION_CHANGE_VENUE,
+		models.WCA_COMPETITION_CHANGE_COMPETITOR_LIMIT,
+		models.WCA_COMPETITION_CHANGE_REGISTRATION_REOPENED,
+	}, utils.Map(changes, func(c models.UpcomingWCACompetitionChange) string { return c.Field }))
+	require.Equal(t, "30 Apr 2026 18:00:00 UTC", changes[2].OldValue)
+	require.True(t, changes[2].Notify())
+
+	// the registration was still open, it is only extended
+	comp = previous
+	comp.RegistrationClose = time.Date(2026, 5, 15, 18, 0, 0, 0, time.UTC)
+	comp.Name = "Bratislava Open 2026 II"
+	changes = comp.Changes(previous, now.AddDate(0, 0, -7))
+	require.Len(t, changes, 2)
+	require.Equal(t, models.WCA_COMPETITION_CHANGE_REGISTRATION_CLOSE, changes[1].Field)
+	require.False(t, changes[0].Notify())
+	require.False(t, changes[1].Notify())
+}
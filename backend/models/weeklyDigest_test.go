@@ -0,0 +1,44 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/models"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestEvents(t *testing.T) {
+	p := models.ProfileType{ResultsHistory: []models.ProfileTypeResultHistory{
+		{
+			EventName: "3x3x3",
+			History: []models.ProfileTypeResultHistoryEntry{
+				{CompetitionId: "WeeklyCompetition2", Round: 2, Place: "1", Single: "9.00", SingleRecordColor: constants.PR_COLOR, Average: "11.00", AverageRecord: "NR", AverageRecordColor: constants.NR_COLOR},
+				{CompetitionId: "WeeklyCompetition2", Round: 1, Place: "3", Single: "10.00", Average: "12.00"},
+				{CompetitionId: "WeeklyCompetition1", Round: 1, Place: "2", Single: "9.50", Average: "11.50"},
+			},
+		},
+		{
+			EventName: "2x2x2",
+			History: []models.ProfileTypeResultHistoryEntry{
+				{CompetitionId: "WeeklyCompetition1", Round: 1, Place: "1", Single: "2.00", Average: "3.00"},
+			},
+		},
+	}}
+
+	events := p.DigestEvents("WeeklyCompetition2")
+	require.Len(t, events, 1)
+	require.Equal(t, 2, events[0].Round)
+	require.Equal(t, "1", events[0].Place)
+	require.Equal(t, "PR", events[0].SingleRecord)
+	require.Equal(t, "NR", events[0].AverageRecord)
+
+	records := models.RecordDigestEvents(append(events, templates.DigestEvent{Event: "2x2x2", Single: "2.00"}))
+	require.Len(t, records, 1)
+	require.Equal(t, events[0].Event, records[0].Event)
+
+	require.Equal(t, "+2.50", models.KinchChange("10.00", "12.50"))
+	require.Equal(t, "-1.00", models.KinchChange("5.00", "4.00"))
+	require.Equal(t, "+3.00", models.KinchChange("", "3.00"))
+}
@@ -0,0 +1,118 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the corner dials lie in the positions of the pins
+var clockPins = map[string][][2]int{
+	"UR":  {{0, 2}},
+	"DR":  {{2, 2}},
+	"DL":  {{2, 0}},
+	"UL":  {{0, 0}},
+	"U":   {{0, 0}, {0, 2}},
+	"R":   {{0, 2}, {2, 2}},
+	"D":   {{2, 0}, {2, 2}},
+	"L":   {{0, 0}, {2, 0}},
+	"ALL": {{0, 0}, {0, 2}, {2, 0}, {2, 2}},
+}
+
+var clockMoveRegexp = regexp.MustCompile(`^(UR|DR|DL|UL|U|R|D|L|ALL)(\d+)([+-])$`)
+
+// clock keeps the hours shown by the dials of both sides, each side is seen
+// from its own front, so the corner dial (r, c) of one side is linked to the
+// corner (r, 2-c) of the other one
+type clock struct {
+	front, back [3][3]int
+	flipped     bool
+}
+
+// turn moves every dial connected to the pushed up pins, the linked corners
+// on the back turn in the opposite direction
+func (c *clock) turn(pins [][2]int, amount int) {
+	var moved [3][3]bool
+	for _, pin := range pins {
+		for _, r := range []int{pin[0], 1} {
+			for _, col := range []int{pin[1], 1} {
+				moved[r][col] = true
+			}
+		}
+	}
+
+	for r := range moved {
+		for col := range moved[r] {
+			if !moved[r][col] {
+				continue
+			}
+			c.front[r][col] = ((c.front[r][col]+amount)%12 + 12) % 12
+			if r != 1 && col != 1 {
+				c.back[r][2-col] = ((c.back[r][2-col]-amount)%12 + 12) % 12
+			}
+		}
+	}
+}
+
+func (c *clock) apply(moves []string) error {
+	for _, move := range moves {
+		if move == "y2" {
+			c.front, c.back = c.back, c.front
+			c.flipped = !c.flipped
+			continue
+		}
+
+		// the pins left up at the end of older scrambles do not change the dials
+		if _, ok := clockPins[move]; ok {
+			continue
+		}
+
+		m := clockMoveRegexp.FindStringSubmatch(move)
+		if m == nil {
+			return fmt.Errorf("invalid clock move=%s", move)
+		}
+		amount, _ := strconv.Atoi(m[2])
+		if m[3] == "-" {
+			amount = -amount
+		}
+		c.turn(clockPins[m[1]], amount)
+	}
+
+	if c.flipped {
+		c.front, c.back = c.back, c.front
+		c.flipped = false
+	}
+
+	return nil
+}
+
+func drawClockSide(img *svgImage, dials [3][3]int, center point, face, dial, hand string) {
+	img.circle(center, 3.2, face)
+	for r := range dials {
+		for col, hours := range dials[r] {
+			c := point{center.x + float64(col-1)*2, center.y + float64(r-1)*2}
+			img.circle(c, 0.75, dial)
+			img.line(c, polar(c, 0.6, float64(hours*30)), 0.15, hand)
+		}
+	}
+
+	for _, r := range []int{-1, 1} {
+		for _, col := range []int{-1, 1} {
+			img.circle(point{center.x + float64(col), center.y + float64(r)}, 0.2, "#808080")
+		}
+	}
+}
+
+func renderClock(scramble string) (*svgImage, error) {
+	var c clock
+	if err := c.apply(strings.Fields(scramble)); err != nil {
+		return nil, err
+	}
+
+	img := newSVGImage(0.05, 360)
+	drawClockSide(img, c.front, point{0, 0}, "#3375b2", "#ffffff", "#ff0000")
+	drawClockSide(img, c.back, point{7, 0}, "#55ccff", "#3375b2", "#ffff00")
+
+	return img, nil
+}
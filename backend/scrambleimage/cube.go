@@ -0,0 +1,34 @@
+package scrambleimage
+
+import "github.com/jakubdrobny/speedcubingslovakia/backend/cube"
+
+// faces in order U, L, F, R, B, D, coloured the same way as cube.COLORS
+var cubeColors = []string{"#ffffff", "#ff8000", "#00d800", "#ff0000", "#0000ff", "#ffff00"}
+
+// position of every face in the cross shaped net, in face sizes
+var cubeNetPositions = []point{{1, 0}, {0, 1}, {1, 1}, {2, 1}, {3, 1}, {1, 2}}
+
+func renderCube(size int) func(string) (*svgImage, error) {
+	return func(scramble string) (*svgImage, error) {
+		c := cube.New(size)
+		if err := c.ApplyAlgorithm(scramble); err != nil {
+			return nil, err
+		}
+
+		const faceSize, gap = 1.0, 0.08
+		stickerSize := faceSize / float64(size)
+
+		img := newSVGImage(0.01, 320)
+		for face, pos := range cubeNetPositions {
+			x0, y0 := pos.x*(faceSize+gap), pos.y*(faceSize+gap)
+			for row := range c.State[face] {
+				for col, color := range c.State[face][row] {
+					x, y := x0+float64(col)*stickerSize, y0+float64(row)*stickerSize
+					img.polygon([]point{{x, y}, {x + stickerSize, y}, {x + stickerSize, y + stickerSize}, {x, y + stickerSize}}, cubeColors[color])
+				}
+			}
+		}
+
+		return img, nil
+	}
+}
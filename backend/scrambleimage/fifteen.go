@@ -0,0 +1,63 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const fifteenPuzzleSize = 4
+
+// the moves name the direction the tiles slide in, so U moves the empty
+// space down, an optional amount follows the direction (U3)
+var fifteenPuzzleDirections = map[byte][2]int{'U': {1, 0}, 'D': {-1, 0}, 'L': {0, 1}, 'R': {0, -1}}
+
+func renderFifteenPuzzle(scramble string) (*svgImage, error) {
+	var tiles [fifteenPuzzleSize][fifteenPuzzleSize]int
+	for row := range tiles {
+		for col := range tiles[row] {
+			tiles[row][col] = (row*fifteenPuzzleSize + col + 1) % (fifteenPuzzleSize * fifteenPuzzleSize)
+		}
+	}
+
+	row, col := fifteenPuzzleSize-1, fifteenPuzzleSize-1
+	for _, move := range strings.Fields(scramble) {
+		dir, ok := fifteenPuzzleDirections[move[0]]
+		if !ok {
+			return nil, fmt.Errorf("invalid 15 puzzle move=%s", move)
+		}
+
+		amount := 1
+		if len(move) > 1 {
+			n, err := strconv.Atoi(move[1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid 15 puzzle move=%s", move)
+			}
+			amount = n
+		}
+
+		for range amount {
+			nr, nc := row+dir[0], col+dir[1]
+			if nr < 0 || nr >= fifteenPuzzleSize || nc < 0 || nc >= fifteenPuzzleSize {
+				return nil, fmt.Errorf("15 puzzle move=%s goes out of the board", move)
+			}
+			tiles[row][col], tiles[nr][nc] = tiles[nr][nc], 0
+			row, col = nr, nc
+		}
+	}
+
+	img := newSVGImage(0.02, 240)
+	for r := range tiles {
+		for c, tile := range tiles[r] {
+			x, y := float64(c), float64(r)
+			if tile == 0 {
+				img.polygon([]point{{x, y}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}}, "#808080")
+				continue
+			}
+			img.polygon([]point{{x, y}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}}, "#ffffff")
+			img.text(point{x + 0.5, y + 0.5}, 0.5, strconv.Itoa(tile))
+		}
+	}
+
+	return img, nil
+}
@@ -0,0 +1,105 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// face turning octahedron faces in order U, R, L, B, F, BR, BL, D, opposite
+// faces have the colours of opposite faces of the cube
+var (
+	ftoFaces  = []string{"U", "R", "L", "B", "F", "BR", "BL", "D"}
+	ftoColors = []string{"#ffffff", "#ff0000", "#800080", "#0000ff", "#00d800", "#808080", "#ff8000", "#ffff00"}
+)
+
+const (
+	ftoU = 0
+	ftoD = 7
+)
+
+var (
+	ftoOnce   sync.Once
+	ftoPuzzle *polyPuzzle
+)
+
+// newFTO builds an octahedron with inradius 1, U on top and F towards the
+// viewer below R and L, every face is divided into 9 triangles
+func newFTO() *polyPuzzle {
+	tilt := math.Acos(1.0 / 3)
+	normal := func(polar, azimuth float64) vec {
+		a := azimuth * math.Pi / 180
+		return vec{math.Sin(polar) * math.Sin(a), math.Cos(polar), math.Sin(polar) * math.Cos(a)}
+	}
+
+	normals := []vec{{0, 1, 0}}
+	for _, azimuth := range []float64{60, -60, 180} {
+		normals = append(normals, normal(tilt, azimuth))
+	}
+	for _, azimuth := range []float64{0, 120, -120} {
+		normals = append(normals, normal(math.Pi-tilt, azimuth))
+	}
+	normals = append(normals, vec{0, -1, 0})
+
+	p := &polyPuzzle{}
+	for face, n := range normals {
+		// the faces sharing only a vertex with this one have normals at
+		// -1/3 to it, their sum points to the shared vertex
+		corners := make([]vec, 0, 3)
+		for _, m := range normals {
+			if math.Abs(n.dot(m)+1.0/3) < epsilon {
+				corners = append(corners, n.add(m).scale(1.5))
+			}
+		}
+		p.faces = append(p.faces, newPolyFace(n, corners))
+
+		grid := func(i, j int) vec {
+			return corners[0].add(corners[1].sub(corners[0]).scale(float64(i) / 3)).add(corners[2].sub(corners[0]).scale(float64(j) / 3))
+		}
+		for i := 0; i < 3; i++ {
+			for j := 0; i+j < 3; j++ {
+				p.stickers = append(p.stickers, sticker{face, []vec{grid(i, j), grid(i+1, j), grid(i, j+1)}})
+				if i+j < 2 {
+					p.stickers = append(p.stickers, sticker{face, []vec{grid(i+1, j), grid(i+1, j+1), grid(i, j+1)}})
+				}
+			}
+		}
+	}
+
+	return p
+}
+
+func renderFTO(scramble string) (*svgImage, error) {
+	ftoOnce.Do(func() { ftoPuzzle = newFTO() })
+	p := ftoPuzzle
+
+	state := p.solved()
+	for _, move := range strings.Fields(scramble) {
+		name, prime := strings.CutSuffix(move, "'")
+
+		face := slices.Index(ftoFaces, name)
+		if face == -1 {
+			return nil, fmt.Errorf("invalid fto move=%s", move)
+		}
+
+		// a face turn takes one layer of the neighbouring faces with it
+		t := polyTurn{p.faces[face].normal, 1.0 / 3, -2 * math.Pi / 3}
+		if prime {
+			t.angle = -t.angle
+		}
+		state = applyPermutation(state, p.permutation(t))
+	}
+
+	// the U face with R, L and B on the left, the D face seen from below with
+	// F, BR and BL on the right
+	top := netTree{root: ftoU, right: vec{1, 0, 0}, down: vec{0, 0, 1}, gap: 0.08, children: map[int][]int{ftoU: {1, 2, 3}}}
+	bottom := netTree{root: ftoD, right: vec{1, 0, 0}, down: vec{0, 0, -1}, gap: 0.08, children: map[int][]int{ftoD: {4, 5, 6}}}
+
+	img := newSVGImage(0.02, 400)
+	p.draw(img, state, top, point{}, ftoColors)
+	p.draw(img, state, bottom, point{x: img.maxX - img.minX + 0.4}, ftoColors)
+
+	return img, nil
+}
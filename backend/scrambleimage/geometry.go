@@ -0,0 +1,226 @@
+package scrambleimage
+
+import (
+	"math"
+	"sort"
+)
+
+// Pyraminx, skewb, megaminx and the other twisty puzzles which are not cubes
+// are modelled as polyhedra covered by sticker polygons. A turn rotates every
+// sticker in front of a cutting plane, the resulting permutation is found by
+// matching the rotated sticker centers with the original ones, so no move
+// tables have to be written by hand.
+
+const epsilon = 1e-6
+
+type vec [3]float64
+
+func (a vec) add(b vec) vec {
+	return vec{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func (a vec) sub(b vec) vec {
+	return vec{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func (a vec) scale(k float64) vec {
+	return vec{a[0] * k, a[1] * k, a[2] * k}
+}
+
+func (a vec) dot(b vec) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func (a vec) cross(b vec) vec {
+	return vec{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func (a vec) norm() float64 {
+	return math.Sqrt(a.dot(a))
+}
+
+func (a vec) unit() vec {
+	return a.scale(1 / a.norm())
+}
+
+// rotate turns a around the axis going through the origin, positive angles
+// are counterclockwise when looking at the origin from the tip of the axis
+func (a vec) rotate(axis vec, angle float64) vec {
+	k := axis.unit()
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	return a.scale(cos).add(k.cross(a).scale(sin)).add(k.scale(k.dot(a) * (1 - cos)))
+}
+
+func centroid(points []vec) vec {
+	var c vec
+	for _, p := range points {
+		c = c.add(p)
+	}
+	return c.scale(1 / float64(len(points)))
+}
+
+type polyFace struct {
+	normal   vec
+	vertices []vec
+}
+
+func (f polyFace) center() vec {
+	return centroid(f.vertices)
+}
+
+// newPolyFace orders the vertices counterclockwise as seen from outside
+func newPolyFace(normal vec, vertices []vec) polyFace {
+	c := centroid(vertices)
+	e1 := vertices[0].sub(c).unit()
+	e2 := normal.cross(e1)
+
+	sorted := append([]vec{}, vertices...)
+	angle := func(p vec) float64 {
+		d := p.sub(c)
+		return math.Atan2(d.dot(e2), d.dot(e1))
+	}
+	sort.Slice(sorted, func(i, j int) bool { return angle(sorted[i]) < angle(sorted[j]) })
+
+	return polyFace{normal, sorted}
+}
+
+type sticker struct {
+	face    int
+	polygon []vec
+}
+
+type polyPuzzle struct {
+	faces    []polyFace
+	stickers []sticker
+}
+
+// polyTurn rotates every sticker whose center c satisfies axis·c > depth
+type polyTurn struct {
+	axis  vec
+	depth float64
+	angle float64
+}
+
+func (p *polyPuzzle) nearest(c vec) int {
+	best, bestDist := -1, math.Inf(1)
+	for i, s := range p.stickers {
+		if d := centroid(s.polygon).sub(c).norm(); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// permutation returns for every sticker slot the slot it is moved to
+func (p *polyPuzzle) permutation(t polyTurn) []int {
+	axis := t.axis.unit()
+	perm := make([]int, len(p.stickers))
+	for i, s := range p.stickers {
+		c := centroid(s.polygon)
+		if c.dot(axis) <= t.depth {
+			perm[i] = i
+			continue
+		}
+		perm[i] = p.nearest(c.rotate(axis, t.angle))
+	}
+
+	return perm
+}
+
+func (p *polyPuzzle) solved() []int {
+	state := make([]int, len(p.stickers))
+	for i, s := range p.stickers {
+		state[i] = s.face
+	}
+	return state
+}
+
+func applyPermutation(state, perm []int) []int {
+	res := make([]int, len(state))
+	for i, to := range perm {
+		res[to] = state[i]
+	}
+	return res
+}
+
+// sharedEdge returns the two vertices the faces have in common
+func sharedEdge(a, b polyFace) (vec, vec, bool) {
+	common := make([]vec, 0, 2)
+	for _, u := range a.vertices {
+		for _, v := range b.vertices {
+			if u.sub(v).norm() < epsilon {
+				common = append(common, u)
+			}
+		}
+	}
+	if len(common) != 2 {
+		return vec{}, vec{}, false
+	}
+	return common[0], common[1], true
+}
+
+// netTree describes an unfolding, every face is hinged to its parent along
+// their shared edge and moved gap away from it
+type netTree struct {
+	root        int
+	right, down vec
+	children    map[int][]int
+	gap         float64
+}
+
+// unfold returns for every face of the tree a function which maps its points
+// into the plane of the root face, where they do not overlap
+func (p *polyPuzzle) unfold(tree netTree) map[int]func(vec) point {
+	center := p.faces[tree.root].center()
+	projections := map[int]func(vec) point{
+		tree.root: func(v vec) point {
+			d := v.sub(center)
+			return point{d.dot(tree.right), d.dot(tree.down)}
+		},
+	}
+
+	var hinge func(parent int)
+	hinge = func(parent int) {
+		for _, child := range tree.children[parent] {
+			a, b, ok := sharedEdge(p.faces[parent], p.faces[child])
+			if !ok {
+				continue
+			}
+
+			axis := b.sub(a).unit()
+			nc, np := p.faces[child].normal, p.faces[parent].normal
+			angle := math.Atan2(axis.dot(nc.cross(np)), nc.dot(np))
+			project := projections[parent]
+			mid, c := project(a.add(b).scale(0.5)), project(p.faces[parent].center())
+			dx, dy := mid.x-c.x, mid.y-c.y
+			shift := tree.gap / math.Hypot(dx, dy)
+			projections[child] = func(v vec) point {
+				q := project(v.sub(a).rotate(axis, angle).add(a))
+				return point{q.x + dx*shift, q.y + dy*shift}
+			}
+
+			hinge(child)
+		}
+	}
+	hinge(tree.root)
+
+	return projections
+}
+
+// draw paints the stickers of the faces in the tree, shifted by offset
+func (p *polyPuzzle) draw(img *svgImage, state []int, tree netTree, offset point, colors []string) {
+	projections := p.unfold(tree)
+	for i, s := range p.stickers {
+		project, ok := projections[s.face]
+		if !ok {
+			continue
+		}
+
+		points := make([]point, 0, len(s.polygon))
+		for _, v := range s.polygon {
+			q := project(v)
+			points = append(points, point{q.x + offset.x, q.y + offset.y})
+		}
+		img.polygon(points, colors[state[i]])
+	}
+}
@@ -0,0 +1,30 @@
+package scrambleimage
+
+import "sync"
+
+var (
+	kilominxOnce   sync.Once
+	kilominxPuzzle *megaminx
+)
+
+// newKilominx builds a megaminx without edges and centers, every face is
+// divided into 5 corners meeting in its center, the cuts go through the
+// middles of the edges
+func newKilominx() *megaminx {
+	m := &megaminx{polyPuzzle: polyPuzzle{faces: dodecahedronFaces()}}
+	for face, f := range m.faces {
+		v, c := f.vertices, f.center()
+		for i := range v {
+			next, prev := v[(i+1)%5], v[(i+4)%5]
+			m.stickers = append(m.stickers, sticker{face, []vec{v[i], v[i].add(next).scale(0.5), c, prev.add(v[i]).scale(0.5)}})
+		}
+	}
+	m.depth = m.cutDepth(0.5)
+
+	return m
+}
+
+func renderKilominx(scramble string) (*svgImage, error) {
+	kilominxOnce.Do(func() { kilominxPuzzle = newKilominx() })
+	return kilominxPuzzle.render(scramble)
+}
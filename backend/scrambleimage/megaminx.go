@@ -0,0 +1,181 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// megaminx faces in order U, F, R, BR, BL, L, D, DR, DBR, B, DBL, DL
+var megaminxColors = []string{
+	"#ffffff", "#008000", "#ff0000", "#000080", "#ffff00", "#800080",
+	"#808080", "#ffffb3", "#ff99cc", "#80c0ff", "#80ff00", "#ff8000",
+}
+
+const (
+	megaminxU   = 0
+	megaminxR   = 2
+	megaminxD   = 6
+	megaminxCut = 0.33 // distance of the cuts from the corners relative to the edge length
+)
+
+type megaminx struct {
+	polyPuzzle
+	depth float64 // distance of the cutting plane of a face from the center
+}
+
+var (
+	megaminxOnce   sync.Once
+	megaminxPuzzle *megaminx
+)
+
+// dodecahedronFaces returns the faces of a dodecahedron with inradius 1, U on
+// top and F towards the viewer
+func dodecahedronFaces() []polyFace {
+	tilt := math.Acos(1 / math.Sqrt(5))
+	normal := func(polar, azimuth float64) vec {
+		a := azimuth * math.Pi / 180
+		return vec{math.Sin(polar) * math.Sin(a), math.Cos(polar), math.Sin(polar) * math.Cos(a)}
+	}
+
+	normals := []vec{{0, 1, 0}}
+	for _, azimuth := range []float64{0, 72, 144, -144, -72} {
+		normals = append(normals, normal(tilt, azimuth))
+	}
+	normals = append(normals, vec{0, -1, 0})
+	for _, azimuth := range []float64{36, 108, 180, -108, -36} {
+		normals = append(normals, normal(math.Pi-tilt, azimuth))
+	}
+
+	adjacent := func(a, b vec) bool {
+		return math.Abs(a.dot(b)-1/math.Sqrt(5)) < epsilon
+	}
+
+	faces := make([]polyFace, 0, len(normals))
+	for i, n := range normals {
+		vertices := make([]vec, 0, 5)
+		for j := range normals {
+			for k := j + 1; k < len(normals); k++ {
+				if j == i || k == i || !adjacent(n, normals[j]) || !adjacent(n, normals[k]) || !adjacent(normals[j], normals[k]) {
+					continue
+				}
+				sum := n.add(normals[j]).add(normals[k])
+				vertices = append(vertices, sum.scale(1/n.dot(sum)))
+			}
+		}
+		faces = append(faces, newPolyFace(n, vertices))
+	}
+
+	return faces
+}
+
+// newMegaminx builds a dodecahedron where every face is divided into a
+// center, 5 edges and 5 corners
+func newMegaminx() *megaminx {
+	m := &megaminx{polyPuzzle: polyPuzzle{faces: dodecahedronFaces()}}
+	for face, f := range m.faces {
+		v := f.vertices
+		next := func(i int) int { return (i + 1) % 5 }
+		prev := func(i int) int { return (i + 4) % 5 }
+
+		// a[i] and b[i] cut the edge from v[i] to v[i+1], q[i] is the inner
+		// corner of the sticker at v[i]
+		a, b, q := make([]vec, 5), make([]vec, 5), make([]vec, 5)
+		for i := range v {
+			a[i] = v[i].add(v[next(i)].sub(v[i]).scale(megaminxCut))
+			b[i] = v[next(i)].add(v[i].sub(v[next(i)]).scale(megaminxCut))
+		}
+		for i := range v {
+			q[i] = b[prev(i)].add(a[i]).sub(v[i])
+		}
+
+		m.stickers = append(m.stickers, sticker{face, q})
+		for i := range v {
+			m.stickers = append(m.stickers, sticker{face, []vec{v[i], a[i], q[i], b[prev(i)]}})
+			m.stickers = append(m.stickers, sticker{face, []vec{a[i], b[i], q[next(i)], q[i]}})
+		}
+	}
+
+	// the cut of a face goes through the points on the neighbouring edges
+	// that are in the same distance from the corners as the sticker cuts
+	m.depth = m.cutDepth(megaminxCut)
+
+	return m
+}
+
+// cutDepth returns the distance from the center of the plane cutting the
+// edges leaving a face at cut of their length
+func (m *megaminx) cutDepth(cut float64) float64 {
+	u := m.faces[megaminxU]
+	v := u.vertices[0]
+	edge := u.vertices[1].sub(v).norm()
+	for _, f := range m.faces {
+		for _, w := range f.vertices {
+			if math.Abs(w.sub(v).norm()-edge) < epsilon && math.Abs(w.dot(u.normal)-1) > epsilon {
+				return v.add(w.sub(v).scale(cut)).dot(u.normal)
+			}
+		}
+	}
+
+	return 0
+}
+
+// turn understands Pochmann notation, R++ and D++ turn everything except the
+// face opposite to R and the U face by two fifths, U turns the U face
+func (m *megaminx) turn(move string) (polyTurn, error) {
+	fifth := 2 * math.Pi / 5
+	switch move {
+	case "R++", "R--":
+		angle := -2 * fifth
+		if move == "R--" {
+			angle = -angle
+		}
+		return polyTurn{m.faces[megaminxR].normal, -m.depth, angle}, nil
+	case "D++", "D--":
+		angle := -2 * fifth
+		if move == "D--" {
+			angle = -angle
+		}
+		return polyTurn{m.faces[megaminxD].normal, -m.depth, angle}, nil
+	case "U", "U'":
+		angle := -fifth
+		if move == "U'" {
+			angle = -angle
+		}
+		return polyTurn{m.faces[megaminxU].normal, m.depth, angle}, nil
+	}
+
+	return polyTurn{}, fmt.Errorf("invalid megaminx move=%s", move)
+}
+
+func renderMegaminx(scramble string) (*svgImage, error) {
+	megaminxOnce.Do(func() { megaminxPuzzle = newMegaminx() })
+	return megaminxPuzzle.render(scramble)
+}
+
+func (m *megaminx) render(scramble string) (*svgImage, error) {
+	state := m.solved()
+	perms := make(map[string][]int)
+	for _, move := range strings.Fields(scramble) {
+		if _, ok := perms[move]; !ok {
+			t, err := m.turn(move)
+			if err != nil {
+				return nil, err
+			}
+			perms[move] = m.permutation(t)
+		}
+		state = applyPermutation(state, perms[move])
+	}
+
+	// the U face with its neighbours on the left, the D face seen from below
+	// with its neighbours on the right
+	top := netTree{root: megaminxU, right: vec{1, 0, 0}, down: vec{0, 0, 1}, gap: 0.05, children: map[int][]int{megaminxU: {1, 2, 3, 4, 5}}}
+	bottom := netTree{root: megaminxD, right: vec{1, 0, 0}, down: vec{0, 0, -1}, gap: 0.05, children: map[int][]int{megaminxD: {7, 8, 9, 10, 11}}}
+
+	img := newSVGImage(0.01, 400)
+	m.draw(img, state, top, point{}, megaminxColors)
+	m.draw(img, state, bottom, point{x: img.maxX - img.minX + 0.2}, megaminxColors)
+
+	return img, nil
+}
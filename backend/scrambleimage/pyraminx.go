@@ -0,0 +1,113 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// pyraminx faces in order F, L, R, D, coloured as in the WCA colour scheme
+var pyraminxColors = []string{"#00d800", "#ff0000", "#0000ff", "#ffff00"}
+
+type pyraminx struct {
+	polyPuzzle
+	tips   map[string]vec
+	layers int
+}
+
+var (
+	pyraminxOnce         sync.Once
+	pyraminxPuzzle       *pyraminx
+	masterPyraminxOnce   sync.Once
+	masterPyraminxPuzzle *pyraminx
+)
+
+// newPyraminx builds a tetrahedron with circumradius 1 held with the D face
+// at the bottom and the F face towards the viewer, every face is divided
+// into layers*layers triangles
+func newPyraminx(layers int) *pyraminx {
+	r := math.Sqrt(8) / 3
+	u := vec{0, 1, 0}
+	l := vec{-r * math.Sin(math.Pi/3), -1.0 / 3, r / 2}
+	rt := vec{r * math.Sin(math.Pi/3), -1.0 / 3, r / 2}
+	b := vec{0, -1.0 / 3, -r}
+
+	p := &pyraminx{tips: map[string]vec{"U": u, "L": l, "R": rt, "B": b}, layers: layers}
+	for face, corners := range [][3]vec{{u, l, rt}, {u, b, l}, {u, rt, b}, {l, b, rt}} {
+		normal := centroid(corners[:]).unit()
+		p.faces = append(p.faces, newPolyFace(normal, corners[:]))
+
+		grid := func(i, j int) vec {
+			n := float64(layers)
+			return corners[0].add(corners[1].sub(corners[0]).scale(float64(i) / n)).add(corners[2].sub(corners[0]).scale(float64(j) / n))
+		}
+		for i := 0; i < layers; i++ {
+			for j := 0; i+j < layers; j++ {
+				p.stickers = append(p.stickers, sticker{face, []vec{grid(i, j), grid(i+1, j), grid(i, j+1)}})
+				if i+j < layers-1 {
+					p.stickers = append(p.stickers, sticker{face, []vec{grid(i+1, j), grid(i+1, j+1), grid(i, j+1)}})
+				}
+			}
+		}
+	}
+
+	return p
+}
+
+// lowercase moves turn the tip, uppercase moves the tip with the next layer
+// and wide moves (Uw) one more layer, the tip is 1 above the center and the
+// opposite face 1/3 below it
+func (p *pyraminx) turn(move string) (polyTurn, error) {
+	name, angle := move, -2*math.Pi/3
+	if strings.HasSuffix(move, "'") {
+		name, angle = strings.TrimSuffix(move, "'"), -angle
+	}
+
+	turned := 2
+	if strings.HasSuffix(name, "w") {
+		name, turned = strings.TrimSuffix(name, "w"), 3
+	} else if strings.ToLower(name) == name {
+		turned = 1
+	}
+
+	axis, ok := p.tips[strings.ToUpper(name)]
+	if !ok || len(name) != 1 || turned >= p.layers || (turned == 3 && strings.ToLower(name) == name) {
+		return polyTurn{}, fmt.Errorf("invalid pyraminx move=%s", move)
+	}
+
+	depth := 1 - float64(turned)*4/3/float64(p.layers)
+
+	return polyTurn{axis, depth, angle}, nil
+}
+
+func renderPyraminx(scramble string) (*svgImage, error) {
+	pyraminxOnce.Do(func() { pyraminxPuzzle = newPyraminx(3) })
+	return pyraminxPuzzle.render(scramble)
+}
+
+func renderMasterPyraminx(scramble string) (*svgImage, error) {
+	masterPyraminxOnce.Do(func() { masterPyraminxPuzzle = newPyraminx(4) })
+	return masterPyraminxPuzzle.render(scramble)
+}
+
+func (p *pyraminx) render(scramble string) (*svgImage, error) {
+	state := p.solved()
+	for _, move := range strings.Fields(scramble) {
+		t, err := p.turn(move)
+		if err != nil {
+			return nil, err
+		}
+		state = applyPermutation(state, p.permutation(t))
+	}
+
+	f := p.faces[0]
+	down := vec{0, -1, 0}
+	down = down.sub(f.normal.scale(down.dot(f.normal))).unit()
+	tree := netTree{root: 0, right: vec{1, 0, 0}, down: down, gap: 0.08, children: map[int][]int{0: {1, 2, 3}}}
+
+	img := newSVGImage(0.01, 300)
+	p.draw(img, state, tree, point{}, pyraminxColors)
+
+	return img, nil
+}
@@ -0,0 +1,103 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+const (
+	rediCut   = 0.4 // length of the legs of the corner stickers relative to the edge length
+	rediDepth = 0.8 // distance of the cutting plane of a corner from the center
+)
+
+// the scrambles turn the UFR (R) and UFL (L) corners and rotate the whole
+// puzzle around the R face (x)
+var rediCorners = map[string]vec{
+	"R": {1, 1, 1},
+	"L": {-1, 1, 1},
+}
+
+var (
+	rediOnce   sync.Once
+	rediPuzzle *polyPuzzle
+)
+
+// newRedi builds a cube with vertices at (±1, ±1, ±1) with the same faces as
+// the skewb, every face consists of four corner triangles and four edges
+// meeting in its center
+func newRedi() *polyPuzzle {
+	p := &polyPuzzle{}
+	for face, normal := range skewbNormals {
+		corners := make([]vec, 0, 4)
+		for _, x := range []float64{-1, 1} {
+			for _, y := range []float64{-1, 1} {
+				for _, z := range []float64{-1, 1} {
+					if v := (vec{x, y, z}); v.dot(normal) > 0 {
+						corners = append(corners, v)
+					}
+				}
+			}
+		}
+
+		f := newPolyFace(normal, corners)
+		p.faces = append(p.faces, f)
+
+		v, c := f.vertices, f.center()
+		// a[i] and b[i] cut the edge from v[i] to v[i+1], m[i] is the middle
+		// of the cut of the corner v[i]
+		a, b, m := make([]vec, 4), make([]vec, 4), make([]vec, 4)
+		for i := range v {
+			a[i] = v[i].add(v[(i+1)%4].sub(v[i]).scale(rediCut))
+			b[i] = v[(i+1)%4].add(v[i].sub(v[(i+1)%4]).scale(rediCut))
+		}
+		for i := range v {
+			m[i] = a[i].add(b[(i+3)%4]).scale(0.5)
+		}
+
+		for i := range v {
+			p.stickers = append(p.stickers, sticker{face, []vec{v[i], a[i], b[(i+3)%4]}})
+			p.stickers = append(p.stickers, sticker{face, []vec{c, m[i], a[i], b[i], m[(i+1)%4]}})
+		}
+	}
+
+	return p
+}
+
+func renderRedi(scramble string) (*svgImage, error) {
+	rediOnce.Do(func() { rediPuzzle = newRedi() })
+	p := rediPuzzle
+
+	state := p.solved()
+	for _, move := range strings.Fields(scramble) {
+		name, prime := strings.CutSuffix(move, "'")
+
+		var t polyTurn
+		if corner, ok := rediCorners[name]; ok {
+			t = polyTurn{corner, rediDepth, -2 * math.Pi / 3}
+		} else if name == "x" {
+			t = polyTurn{vec{1, 0, 0}, math.Inf(-1), -math.Pi / 2}
+		} else {
+			return nil, fmt.Errorf("invalid redi cube move=%s", move)
+		}
+		if prime {
+			t.angle = -t.angle
+		}
+		state = applyPermutation(state, p.permutation(t))
+	}
+
+	// the same net as the skewb
+	tree := netTree{
+		root:     2,
+		right:    vec{1, 0, 0},
+		down:     vec{0, -1, 0},
+		children: map[int][]int{2: {0, 1, 3, 5}, 3: {4}},
+		gap:      0.15,
+	}
+
+	img := newSVGImage(0.02, 300)
+	p.draw(img, state, tree, point{}, cubeColors)
+
+	return img, nil
+}
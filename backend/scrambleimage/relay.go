@@ -0,0 +1,51 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderRelay stacks the images of the parts, the scramble of a relay has a
+// "label: scramble" line for every part in the order of the parts, lines
+// without a label continue the scramble of the previous part (megaminx)
+func renderRelay(parts ...string) renderer {
+	return func(scramble string) (*svgImage, error) {
+		labels, scrambles := make([]string, 0, len(parts)), make([]string, 0, len(parts))
+		for _, line := range strings.Split(scramble, "\n") {
+			label, partScramble, ok := strings.Cut(line, ":")
+			switch {
+			case ok:
+				labels, scrambles = append(labels, strings.TrimSpace(label)), append(scrambles, partScramble)
+			case len(scrambles) > 0:
+				scrambles[len(scrambles)-1] += "\n" + line
+			case strings.TrimSpace(line) != "":
+				return nil, fmt.Errorf("relay scramble line=%s without a label", line)
+			}
+		}
+		if scramble == "" {
+			labels, scrambles = make([]string, len(parts)), make([]string, len(parts))
+		}
+		if len(scrambles) != len(parts) {
+			return nil, fmt.Errorf("relay scramble has %d parts instead of %d", len(scrambles), len(parts))
+		}
+
+		const width, gap = 1.0, 0.1
+		img := newSVGImage(0.01, 320)
+		y := 0.0
+		for idx, part := range parts {
+			partImg, err := renderers[part](strings.TrimSpace(scrambles[idx]))
+			if err != nil {
+				return nil, fmt.Errorf("%w: in relay part %s", err, part)
+			}
+
+			if labels[idx] != "" {
+				img.text(point{width / 2, y + 0.05}, 0.08, labels[idx])
+				y += 0.12
+			}
+			img.embed(partImg, point{0, y}, width)
+			y = img.maxY + gap
+		}
+
+		return img, nil
+	}
+}
@@ -0,0 +1,67 @@
+// Package scrambleimage draws the scrambled state of a puzzle as an SVG
+// image, so the scramble images do not depend on any external service.
+package scrambleimage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
+)
+
+var ErrUnsupported = errors.New("scramble images are not supported")
+
+type renderer func(scramble string) (*svgImage, error)
+
+// renderers maps the scramblingcodes of the events table to their renderers
+var renderers = map[string]renderer{
+	"222so":  renderCube(2),
+	"333":    renderCube(3),
+	"333oh":  renderCube(3),
+	"333ni":  renderCube(3),
+	"333fm":  renderCube(3),
+	"444wca": renderCube(4),
+	"444bld": renderCube(4),
+	"555wca": renderCube(5),
+	"555bld": renderCube(5),
+	"666wca": renderCube(6),
+	"777wca": renderCube(7),
+	"pyrso":  renderPyraminx,
+	"skbso":  renderSkewb,
+	"mgmp":   renderMegaminx,
+	"sqrs":   renderSquareOne,
+	"clkwca": renderClock,
+	"klmp":   renderKilominx,
+	"mpyrso": renderMasterPyraminx,
+	"rediso": renderRedi,
+	"ftoso":  renderFTO,
+	"15prp":  renderFifteenPuzzle,
+}
+
+func init() {
+	for code, parts := range scrambler.RELAYS {
+		renderers[code] = renderRelay(parts...)
+	}
+}
+
+// Render returns the SVG image of the puzzle after applying the scramble
+// to the solved state, an empty scramble draws the solved puzzle.
+func Render(scramblingcode string, scramble string) (string, error) {
+	render, ok := renderers[scramblingcode]
+	if !ok {
+		return "", fmt.Errorf("%w: scramblingcode=%s", ErrUnsupported, scramblingcode)
+	}
+
+	img, err := render(scramble)
+	if err != nil {
+		return "", fmt.Errorf("%w: when rendering scramble=%s", err, scramble)
+	}
+
+	return img.String(), nil
+}
+
+// Supports reports whether images of the scramblingcode can be rendered.
+func Supports(scramblingcode string) bool {
+	_, ok := renderers[scramblingcode]
+	return ok
+}
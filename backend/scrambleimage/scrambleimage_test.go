@@ -0,0 +1,209 @@
+package scrambleimage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
+)
+
+func TestRender(t *testing.T) {
+	s := scrambler.NewSeeded(3, 4)
+
+	for code := range renderers {
+		t.Run(code, func(t *testing.T) {
+			scrambles, err := s.Scrambles(code, 10)
+			require.NoError(t, err)
+
+			for _, scramble := range append(scrambles, "") {
+				img, err := Render(code, scramble)
+				require.NoError(t, err, scramble)
+				assert.True(t, strings.HasPrefix(img, "<svg "))
+				assert.True(t, strings.HasSuffix(img, "</svg>"))
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := Render("333mbf", "R U R' U'")
+		require.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("invalid moves", func(t *testing.T) {
+		for code, scramble := range map[string]string{
+			"333":    "R U Q",
+			"444wca": "R 5Rw",
+			"pyrso":  "U Rw",
+			"skbso":  "F",
+			"mgmp":   "R+ D++",
+			"sqrs":   "(1,0) / (1,0) /",
+			"clkwca": "UR7",
+			"klmp":   "R+",
+			"mpyrso": "uw",
+			"rediso": "F",
+			"ftoso":  "DR",
+			"15prp":  "U0",
+		} {
+			_, err := Render(code, scramble)
+			assert.Error(t, err, code)
+		}
+	})
+}
+
+func TestPolyPuzzleTurnsHaveTheirOrder(t *testing.T) {
+	pyraminxOnce.Do(func() { pyraminxPuzzle = newPyraminx(3) })
+	skewbOnce.Do(func() { skewbPuzzle = newSkewb() })
+	megaminxOnce.Do(func() { megaminxPuzzle = newMegaminx() })
+
+	repeat := func(p *polyPuzzle, t polyTurn, times int) []int {
+		state := p.solved()
+		perm := p.permutation(t)
+		for range times {
+			state = applyPermutation(state, perm)
+		}
+		return state
+	}
+
+	for _, move := range []string{"U", "L", "R", "B", "u", "l", "r", "b"} {
+		turn, err := pyraminxPuzzle.turn(move)
+		require.NoError(t, err)
+		assert.NotEqual(t, pyraminxPuzzle.solved(), repeat(&pyraminxPuzzle.polyPuzzle, turn, 1), move)
+		assert.Equal(t, pyraminxPuzzle.solved(), repeat(&pyraminxPuzzle.polyPuzzle, turn, 3), move)
+	}
+
+	for move, corner := range skewbCorners {
+		turn := polyTurn{corner, 0, 2 * 3.141592653589793 / 3}
+		assert.NotEqual(t, skewbPuzzle.solved(), repeat(skewbPuzzle, turn, 1), move)
+		assert.Equal(t, skewbPuzzle.solved(), repeat(skewbPuzzle, turn, 3), move)
+	}
+
+	for move, times := range map[string]int{"U": 5, "R++": 5, "D--": 5} {
+		turn, err := megaminxPuzzle.turn(move)
+		require.NoError(t, err)
+		assert.NotEqual(t, megaminxPuzzle.solved(), repeat(&megaminxPuzzle.polyPuzzle, turn, 1), move)
+		assert.Equal(t, megaminxPuzzle.solved(), repeat(&megaminxPuzzle.polyPuzzle, turn, times), move)
+	}
+
+	masterPyraminx := newPyraminx(4)
+	for _, move := range []string{"U", "Lw", "r", "B'"} {
+		turn, err := masterPyraminx.turn(move)
+		require.NoError(t, err)
+		assert.NotEqual(t, masterPyraminx.solved(), repeat(&masterPyraminx.polyPuzzle, turn, 1), move)
+		assert.Equal(t, masterPyraminx.solved(), repeat(&masterPyraminx.polyPuzzle, turn, 3), move)
+	}
+
+	redi := newRedi()
+	for move, corner := range rediCorners {
+		turn := polyTurn{corner, rediDepth, 2 * 3.141592653589793 / 3}
+		assert.NotEqual(t, redi.solved(), repeat(redi, turn, 1), move)
+		assert.Equal(t, redi.solved(), repeat(redi, turn, 3), move)
+	}
+
+	fto := newFTO()
+	for _, f := range fto.faces {
+		turn := polyTurn{f.normal, 1.0 / 3, 2 * 3.141592653589793 / 3}
+		assert.NotEqual(t, fto.solved(), repeat(fto, turn, 1))
+		assert.Equal(t, fto.solved(), repeat(fto, turn, 3))
+	}
+}
+
+func TestPolyPuzzleTurnsMoveLayers(t *testing.T) {
+	megaminxOnce.Do(func() { megaminxPuzzle = newMegaminx() })
+	m := megaminxPuzzle
+
+	moved := func(t polyTurn) int {
+		cnt := 0
+		for i, to := range m.permutation(t) {
+			if i != to {
+				cnt++
+			}
+		}
+		return cnt
+	}
+
+	// a face turn moves the 10 outer stickers of the face and 3 on every
+	// neighbour, the center stays in place
+	u, err := m.turn("U")
+	require.NoError(t, err)
+	assert.Equal(t, 10+5*3, moved(u))
+
+	// R++ moves everything except the 11 + 5*3 stickers of the DBL layer and
+	// the center of the R face
+	r, err := m.turn("R++")
+	require.NoError(t, err)
+	assert.Equal(t, 12*11-11-5*3-1, moved(r))
+}
+
+func TestPolyPuzzleTurnsMovePieces(t *testing.T) {
+	moved := func(p *polyPuzzle, t polyTurn) int {
+		cnt := 0
+		for i, to := range p.permutation(t) {
+			if i != to {
+				cnt++
+			}
+		}
+		return cnt
+	}
+
+	// U moves the 5 corners of the U face, R++ all the corners except the 5
+	// of the DBL face
+	kilominx := newKilominx()
+	u, err := kilominx.turn("U")
+	require.NoError(t, err)
+	assert.Equal(t, 5*3, moved(&kilominx.polyPuzzle, u))
+	r, err := kilominx.turn("R++")
+	require.NoError(t, err)
+	assert.Equal(t, 15*3, moved(&kilominx.polyPuzzle, r))
+
+	// a corner of the redi cube turns with its 3 edges
+	redi := newRedi()
+	assert.Equal(t, 3+3*2, moved(redi, polyTurn{rediCorners["R"], rediDepth, 2 * 3.141592653589793 / 3}))
+
+	// a face of the FTO turns with a row of 5 stickers on its 3 neighbours and
+	// a corner sticker on the 3 faces sharing a vertex with it
+	fto := newFTO()
+	assert.Equal(t, 9+3*5+3, moved(fto, polyTurn{fto.faces[ftoU].normal, 1.0 / 3, 2 * 3.141592653589793 / 3}))
+}
+
+func TestFifteenPuzzle(t *testing.T) {
+	img, err := renderFifteenPuzzle("D3 R")
+	require.NoError(t, err)
+	// the empty space went up the last column and one step to the left
+	assert.Contains(t, img.String(), ">4</text>")
+
+	_, err = renderFifteenPuzzle("L")
+	require.Error(t, err, "the empty space starts in the bottom right corner")
+}
+
+func TestRelay(t *testing.T) {
+	img, err := Render("r234w", "2x2x2: R U\n3x3x3: R U R' U'\n4x4x4: Rw U2")
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(img, "<g "))
+	assert.Contains(t, img, ">4x4x4</text>")
+
+	_, err = Render("r234w", "2x2x2: R U\n3x3x3: R U R' U'")
+	require.Error(t, err)
+}
+
+func TestSquareOneSlice(t *testing.T) {
+	sq := newSquareOne()
+	require.NoError(t, sq.apply("(1,0) /"))
+	assert.True(t, sq.middleFlipped)
+	require.NoError(t, sq.apply("/ (-1,0)"))
+	assert.Equal(t, newSquareOne().top, sq.top)
+	assert.Equal(t, newSquareOne().bottom, sq.bottom)
+	assert.False(t, sq.middleFlipped)
+
+	require.Error(t, newSquareOne().apply("(2,0) /"))
+}
+
+func TestClock(t *testing.T) {
+	var c clock
+	require.NoError(t, c.apply(strings.Fields("UR1+ y2 U3+")))
+
+	assert.Equal(t, [3][3]int{{9, 1, 10}, {0, 1, 1}, {0, 0, 0}}, c.front)
+	assert.Equal(t, [3][3]int{{2, 3, 3}, {3, 3, 3}, {0, 0, 0}}, c.back)
+}
@@ -0,0 +1,90 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// skewb faces in order U, L, F, R, B, D, the same as in cube.Cube
+var skewbNormals = []vec{{0, 1, 0}, {-1, 0, 0}, {0, 0, 1}, {1, 0, 0}, {0, 0, -1}, {0, -1, 0}}
+
+// WCA notation turns the corners DRB (R), UBL (U), DLF (L) and DBL (B),
+// so the UFR corner never moves
+var skewbCorners = map[string]vec{
+	"R": {1, -1, -1},
+	"U": {-1, 1, -1},
+	"L": {-1, -1, 1},
+	"B": {-1, -1, -1},
+}
+
+var (
+	skewbOnce   sync.Once
+	skewbPuzzle *polyPuzzle
+)
+
+// newSkewb builds a cube with vertices at (±1, ±1, ±1), every face consists
+// of a center square and four corner triangles
+func newSkewb() *polyPuzzle {
+	p := &polyPuzzle{}
+	for face, normal := range skewbNormals {
+		corners := make([]vec, 0, 4)
+		for _, x := range []float64{-1, 1} {
+			for _, y := range []float64{-1, 1} {
+				for _, z := range []float64{-1, 1} {
+					if v := (vec{x, y, z}); v.dot(normal) > 0 {
+						corners = append(corners, v)
+					}
+				}
+			}
+		}
+
+		f := newPolyFace(normal, corners)
+		p.faces = append(p.faces, f)
+
+		mid := make([]vec, 0, 4)
+		for i := range f.vertices {
+			mid = append(mid, f.vertices[i].add(f.vertices[(i+1)%4]).scale(0.5))
+		}
+		p.stickers = append(p.stickers, sticker{face, mid})
+		for i := range f.vertices {
+			p.stickers = append(p.stickers, sticker{face, []vec{f.vertices[i], mid[i], mid[(i+3)%4]}})
+		}
+	}
+
+	return p
+}
+
+func renderSkewb(scramble string) (*svgImage, error) {
+	skewbOnce.Do(func() { skewbPuzzle = newSkewb() })
+	p := skewbPuzzle
+
+	state := p.solved()
+	for _, move := range strings.Fields(scramble) {
+		name, angle := move, -2*math.Pi/3
+		if strings.HasSuffix(move, "'") {
+			name, angle = strings.TrimSuffix(move, "'"), -angle
+		}
+
+		axis, ok := skewbCorners[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid skewb move=%s", move)
+		}
+		state = applyPermutation(state, p.permutation(polyTurn{axis, 0, angle}))
+	}
+
+	// the usual cross shaped net with B hinged to R
+	tree := netTree{
+		root:     2,
+		right:    vec{1, 0, 0},
+		down:     vec{0, -1, 0},
+		children: map[int][]int{2: {0, 1, 3, 5}, 3: {4}},
+		gap:      0.15,
+	}
+
+	img := newSVGImage(0.02, 300)
+	p.draw(img, state, tree, point{}, cubeColors)
+
+	return img, nil
+}
@@ -0,0 +1,163 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Both layers of the square-1 are modelled as 12 slots of 30 degrees, the
+// top one seen from above starting at the back end of the slice, the bottom
+// one seen from below starting at the front end, so the slice swaps the
+// slots 0 to 5 of both layers. Corners take two consecutive slots.
+//
+// Colours are indexed as in cubeColors (U, L, F, R, B, D).
+
+type squareOnePiece struct {
+	corner bool
+	top    int
+	sides  []int
+}
+
+type squareOne struct {
+	pieces        []squareOnePiece
+	top, bottom   [12]int
+	middleFlipped bool
+}
+
+// directions of the sides clockwise from the top of the image
+var (
+	squareOneTopSides    = []int{4, 3, 2, 1} // B, R, F, L
+	squareOneBottomSides = []int{2, 3, 4, 1} // F, R, B, L
+)
+
+func squareOneSide(sides []int, angle float64) int {
+	return sides[int(math.Round(angle/90))%4]
+}
+
+// in the solved state the top layer starts with a corner and the bottom
+// layer with an edge, which is the orientation scrambles are generated in
+func newSquareOne() *squareOne {
+	sq := &squareOne{}
+
+	for slot := 0; slot < 12; slot += 3 {
+		sq.addCorner(&sq.top, slot, 0, squareOneTopSides)
+		sq.addEdge(&sq.top, slot+2, 0, squareOneTopSides)
+		sq.addEdge(&sq.bottom, slot, 5, squareOneBottomSides)
+		sq.addCorner(&sq.bottom, slot+1, 5, squareOneBottomSides)
+	}
+
+	return sq
+}
+
+func (sq *squareOne) addEdge(layer *[12]int, slot, color int, sides []int) {
+	layer[slot] = len(sq.pieces)
+	sq.pieces = append(sq.pieces, squareOnePiece{false, color, []int{squareOneSide(sides, float64(slot*30+15))}})
+}
+
+func (sq *squareOne) addCorner(layer *[12]int, slot, color int, sides []int) {
+	layer[slot], layer[(slot+1)%12] = len(sq.pieces), len(sq.pieces)
+	tip := float64(slot*30 + 30)
+	sq.pieces = append(sq.pieces, squareOnePiece{true, color, []int{squareOneSide(sides, tip-30), squareOneSide(sides, tip+30)}})
+}
+
+func turnSquareOneLayer(layer [12]int, amount int) [12]int {
+	var res [12]int
+	for i := range layer {
+		res[((i+amount)%12+12)%12] = layer[i]
+	}
+	return res
+}
+
+func squareOneSliceable(layer [12]int) bool {
+	return layer[11] != layer[0] && layer[5] != layer[6]
+}
+
+func (sq *squareOne) slice() error {
+	if !squareOneSliceable(sq.top) || !squareOneSliceable(sq.bottom) {
+		return fmt.Errorf("square-1 slice blocked by a corner")
+	}
+	for i := range 6 {
+		sq.top[i], sq.bottom[i] = sq.bottom[i], sq.top[i]
+	}
+	sq.middleFlipped = !sq.middleFlipped
+	return nil
+}
+
+var squareOneTwistRegexp = regexp.MustCompile(`^\((-?\d+),(-?\d+)\)$`)
+
+// apply understands the WCA notation, e.g. (1,0) / (-3,3) / (0,-1) /
+func (sq *squareOne) apply(scramble string) error {
+	for i, part := range strings.Split(strings.Join(strings.Fields(scramble), ""), "/") {
+		if i > 0 {
+			if err := sq.slice(); err != nil {
+				return err
+			}
+		}
+		if part == "" {
+			continue
+		}
+
+		m := squareOneTwistRegexp.FindStringSubmatch(part)
+		if m == nil {
+			return fmt.Errorf("invalid square-1 twist=%s", part)
+		}
+		u, _ := strconv.Atoi(m[1])
+		d, _ := strconv.Atoi(m[2])
+		sq.top, sq.bottom = turnSquareOneLayer(sq.top, u), turnSquareOneLayer(sq.bottom, d)
+	}
+
+	return nil
+}
+
+// drawLayer paints the pieces of a layer around c, the side stickers form
+// an outer ring
+func (sq *squareOne) drawLayer(img *svgImage, layer [12]int, c point) {
+	edge, corner, outer := 1/math.Cos(math.Pi/12), math.Sqrt2, 1.3
+
+	for slot := range layer {
+		id := layer[slot]
+		piece := sq.pieces[id]
+		if piece.corner && layer[(slot+11)%12] == id {
+			continue
+		}
+
+		a := float64(slot * 30)
+		if !piece.corner {
+			img.polygon([]point{c, polar(c, edge, a), polar(c, edge, a+30)}, cubeColors[piece.top])
+			img.polygon([]point{polar(c, edge, a), polar(c, edge, a+30), polar(c, edge*outer, a+30), polar(c, edge*outer, a)}, cubeColors[piece.sides[0]])
+			continue
+		}
+
+		img.polygon([]point{c, polar(c, edge, a), polar(c, corner, a+30), polar(c, edge, a+60)}, cubeColors[piece.top])
+		img.polygon([]point{polar(c, edge, a), polar(c, corner, a+30), polar(c, corner*outer, a+30), polar(c, edge*outer, a)}, cubeColors[piece.sides[0]])
+		img.polygon([]point{polar(c, corner, a+30), polar(c, edge, a+60), polar(c, edge*outer, a+60), polar(c, corner*outer, a+30)}, cubeColors[piece.sides[1]])
+	}
+
+	img.line(polar(c, corner*outer, 0), polar(c, corner*outer, 180), 0.03, "#ff0000")
+}
+
+func renderSquareOne(scramble string) (*svgImage, error) {
+	sq := newSquareOne()
+	if err := sq.apply(scramble); err != nil {
+		return nil, err
+	}
+
+	img := newSVGImage(0.02, 360)
+	top, bottom := point{0, 0}, point{4, 0}
+	sq.drawLayer(img, sq.top, top)
+	sq.drawLayer(img, sq.bottom, bottom)
+
+	// the middle layer, its right half shows the back colour when flipped
+	middle := 2.2
+	right := cubeColors[2]
+	if sq.middleFlipped {
+		right = cubeColors[4]
+	}
+	img.polygon([]point{{-1.8, middle}, {2, middle}, {2, middle + 0.4}, {-1.8, middle + 0.4}}, cubeColors[2])
+	img.polygon([]point{{2, middle}, {5.8, middle}, {5.8, middle + 0.4}, {2, middle + 0.4}}, right)
+
+	return img, nil
+}
@@ -0,0 +1,100 @@
+package scrambleimage
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+type point struct {
+	x, y float64
+}
+
+// polar returns the point at distance r from c in the direction given by
+// angle degrees measured clockwise from the top of the image
+func polar(c point, r, angle float64) point {
+	rad := angle * math.Pi / 180
+	return point{c.x + r*math.Sin(rad), c.y - r*math.Cos(rad)}
+}
+
+// svgImage collects shapes in arbitrary units, the view box is fitted around
+// them when the image is serialized, width is in pixels and the height is
+// derived from the aspect ratio of the drawn shapes
+type svgImage struct {
+	stroke float64
+	width  float64
+	shapes []string
+
+	minX, minY, maxX, maxY float64
+	empty                  bool
+}
+
+func newSVGImage(stroke, width float64) *svgImage {
+	return &svgImage{stroke: stroke, width: width, empty: true}
+}
+
+func (img *svgImage) extend(points ...point) {
+	for _, p := range points {
+		if img.empty {
+			img.minX, img.maxX, img.minY, img.maxY = p.x, p.x, p.y, p.y
+			img.empty = false
+			continue
+		}
+		img.minX, img.maxX = math.Min(img.minX, p.x), math.Max(img.maxX, p.x)
+		img.minY, img.maxY = math.Min(img.minY, p.y), math.Max(img.maxY, p.y)
+	}
+}
+
+func (img *svgImage) polygon(points []point, fill string) {
+	img.extend(points...)
+
+	coords := make([]string, 0, len(points))
+	for _, p := range points {
+		coords = append(coords, fmt.Sprintf("%.3f,%.3f", p.x, p.y))
+	}
+	img.shapes = append(img.shapes, fmt.Sprintf(`<polygon points="%s" fill="%s" stroke="#000000" stroke-width="%.3f"/>`, strings.Join(coords, " "), fill, img.stroke))
+}
+
+func (img *svgImage) circle(c point, r float64, fill string) {
+	img.extend(point{c.x - r, c.y - r}, point{c.x + r, c.y + r})
+	img.shapes = append(img.shapes, fmt.Sprintf(`<circle cx="%.3f" cy="%.3f" r="%.3f" fill="%s" stroke="#000000" stroke-width="%.3f"/>`, c.x, c.y, r, fill, img.stroke))
+}
+
+func (img *svgImage) line(a, b point, width float64, color string) {
+	img.extend(a, b)
+	img.shapes = append(img.shapes, fmt.Sprintf(`<line x1="%.3f" y1="%.3f" x2="%.3f" y2="%.3f" stroke="%s" stroke-width="%.3f" stroke-linecap="round"/>`, a.x, a.y, b.x, b.y, color, width))
+}
+
+func (img *svgImage) text(c point, size float64, content string) {
+	img.extend(point{c.x - size/2, c.y - size/2}, point{c.x + size/2, c.y + size/2})
+	img.shapes = append(img.shapes, fmt.Sprintf(`<text x="%.3f" y="%.3f" font-size="%.3f" font-family="sans-serif" text-anchor="middle" dominant-baseline="central">%s</text>`, c.x, c.y, size, html.EscapeString(content)))
+}
+
+// viewBox returns the drawn area with a margin for the strokes
+func (img *svgImage) viewBox() (minX, minY, w, h float64) {
+	margin := img.stroke * 2
+	return img.minX - margin, img.minY - margin, img.maxX - img.minX + 2*margin, img.maxY - img.minY + 2*margin
+}
+
+// embed draws the other image with its top left corner at the point, scaled
+// so that its width in the units of this image is width
+func (img *svgImage) embed(other *svgImage, at point, width float64) {
+	minX, minY, w, h := other.viewBox()
+	k := width / w
+	img.extend(at, point{at.x + width, at.y + h*k})
+	img.shapes = append(img.shapes, fmt.Sprintf(`<g transform="translate(%.3f %.3f) scale(%.5f) translate(%.3f %.3f)">%s</g>`, at.x, at.y, k, -minX, -minY, strings.Join(other.shapes, "")))
+}
+
+func (img *svgImage) String() string {
+	minX, minY, w, h := img.viewBox()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="%.3f %.3f %.3f %.3f">`, img.width, img.width*h/w, minX, minY, w, h)
+	for _, shape := range img.shapes {
+		sb.WriteString(shape)
+	}
+	sb.WriteString("</svg>")
+
+	return sb.String()
+}
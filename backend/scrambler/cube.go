@@ -0,0 +1,125 @@
+package scrambler
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+var cubeFaces = []string{"U", "D", "R", "L", "F", "B"}
+var moveSuffixes = []string{"", "'", "2"}
+
+type cubeMove struct {
+	face  int
+	width int
+}
+
+func (m cubeMove) axis() int {
+	return m.face / 2
+}
+
+func (m cubeMove) String() string {
+	switch m.width {
+	case 1:
+		return cubeFaces[m.face]
+	case 2:
+		return cubeFaces[m.face] + "w"
+	default:
+		return strconv.Itoa(m.width) + cubeFaces[m.face] + "w"
+	}
+}
+
+// allowedCubeMoves lists the turnable layers of an NxN cube in WCA notation,
+// on even cubes the middle wide move is only turned from U, R and F
+func allowedCubeMoves(size int) []cubeMove {
+	moves := make([]cubeMove, 0)
+	for face := range cubeFaces {
+		for width := 1; width <= size/2; width++ {
+			if size%2 == 0 && width == size/2 && face%2 == 1 {
+				continue
+			}
+			if size == 2 && face%2 == 1 {
+				continue
+			}
+			moves = append(moves, cubeMove{face, width})
+		}
+	}
+
+	return moves
+}
+
+// randomCubeMoves returns a random-move sequence in which no layer is turned
+// twice within a run of moves on the same axis, so nothing cancels out
+func randomCubeMoves(r *rand.Rand, size, length int, accept func(idx int, m cubeMove) bool) []string {
+	moves := allowedCubeMoves(size)
+	res := make([]string, 0, length)
+
+	usedOnAxis := make(map[cubeMove]bool)
+	lastAxis := -1
+	for len(res) < length {
+		move := moves[r.IntN(len(moves))]
+		if move.axis() == lastAxis && usedOnAxis[move] {
+			continue
+		}
+		if accept != nil && !accept(len(res), move) {
+			continue
+		}
+
+		if move.axis() != lastAxis {
+			clear(usedOnAxis)
+			lastAxis = move.axis()
+		}
+		usedOnAxis[move] = true
+
+		res = append(res, move.String()+moveSuffixes[r.IntN(len(moveSuffixes))])
+	}
+
+	return res
+}
+
+func cubeScramble(r *rand.Rand, size, length int) string {
+	return strings.Join(randomCubeMoves(r, size, length, nil), " ")
+}
+
+// blindScramble appends a random reorientation, so the solver can not rely
+// on holding the cube in the standard orientation
+func blindScramble(r *rand.Rand, size, length int) string {
+	moves := randomCubeMoves(r, size, length, nil)
+
+	var first, second []string
+	if size%2 == 1 {
+		prefix := cubeMove{2, (size + 1) / 2}.String()
+		upPrefix := cubeMove{0, (size + 1) / 2}.String()
+		first = []string{"", prefix, prefix + "'", prefix + "2", cubeMove{4, (size + 1) / 2}.String(), cubeMove{4, (size + 1) / 2}.String() + "'"}
+		second = []string{"", upPrefix, upPrefix + "'", upPrefix + "2"}
+	} else {
+		first = []string{"", "x", "x'", "x2", "z", "z'"}
+		second = []string{"", "y", "y'", "y2"}
+	}
+
+	for _, orientation := range []string{first[r.IntN(len(first))], second[r.IntN(len(second))]} {
+		if orientation != "" {
+			moves = append(moves, orientation)
+		}
+	}
+
+	return strings.Join(moves, " ")
+}
+
+// fmcScramble is padded with R' U' F on both ends, the first inner move must
+// not be on the F/B axis and the last one must not turn R
+func fmcScramble(r *rand.Rand) string {
+	const length = 19
+
+	inner := randomCubeMoves(r, 3, length, func(idx int, m cubeMove) bool {
+		if idx == 0 && m.axis() == 2 {
+			return false
+		}
+		if idx == length-1 && m.face == 2 {
+			return false
+		}
+		return true
+	})
+
+	return "R' U' F " + strings.Join(inner, " ") + " R' U' F"
+}
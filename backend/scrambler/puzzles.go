@@ -0,0 +1,269 @@
+package scrambler
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// randomFaceMoves picks moves from faces such that two consecutive moves never
+// turn the same face
+func randomFaceMoves(r *rand.Rand, faces, suffixes []string, length int) []string {
+	res := make([]string, 0, length)
+	last := -1
+	for len(res) < length {
+		face := r.IntN(len(faces))
+		if face == last {
+			continue
+		}
+		last = face
+
+		res = append(res, faces[face]+suffixes[r.IntN(len(suffixes))])
+	}
+
+	return res
+}
+
+func tips(r *rand.Rand, names []string) []string {
+	res := make([]string, 0)
+	for _, tip := range names {
+		switch r.IntN(3) {
+		case 1:
+			res = append(res, tip)
+		case 2:
+			res = append(res, tip+"'")
+		}
+	}
+
+	return res
+}
+
+func pyraminxScramble(r *rand.Rand) string {
+	moves := randomFaceMoves(r, []string{"U", "L", "R", "B"}, []string{"", "'"}, 10)
+	return strings.Join(append(moves, tips(r, []string{"u", "l", "r", "b"})...), " ")
+}
+
+func masterPyraminxScramble(r *rand.Rand) string {
+	moves := make([]string, 0)
+	last := ""
+	for len(moves) < 30 {
+		axis := []string{"U", "L", "R", "B"}[r.IntN(4)]
+		if axis == last {
+			continue
+		}
+		last = axis
+
+		if r.IntN(2) == 1 {
+			axis += "w"
+		}
+		moves = append(moves, axis+[]string{"", "'"}[r.IntN(2)])
+	}
+
+	return strings.Join(append(moves, tips(r, []string{"u", "l", "r", "b"})...), " ")
+}
+
+func skewbScramble(r *rand.Rand) string {
+	return strings.Join(randomFaceMoves(r, []string{"R", "U", "L", "B"}, []string{"", "'"}, 11), " ")
+}
+
+func ftoScramble(r *rand.Rand) string {
+	faces := []string{"U", "F", "R", "L", "B", "D", "BR", "BL"}
+	return strings.Join(randomFaceMoves(r, faces, []string{"", "'"}, 30), " ")
+}
+
+// pochmannScramble returns lines of ten alternating R and D moves, each line
+// finished by a U or U' turn, the lines are separated by newlines
+func pochmannScramble(r *rand.Rand, lines int) string {
+	res := make([]string, 0, lines)
+	for range lines {
+		line := make([]string, 0, 11)
+		for i := range 10 {
+			face := "R"
+			if i%2 == 1 {
+				face = "D"
+			}
+			line = append(line, face+[]string{"++", "--"}[r.IntN(2)])
+		}
+		line = append(line, []string{"U", "U'"}[r.IntN(2)])
+
+		res = append(res, strings.Join(line, " "))
+	}
+
+	return strings.Join(res, "\n")
+}
+
+// the redi cube is scrambled by turning the two front corners (R and L)
+// and rotating the whole puzzle in between
+func rediScramble(r *rand.Rand) string {
+	groups := make([]string, 0, 7)
+	for range 7 {
+		group := make([]string, 0)
+		face := r.IntN(2)
+		for range 3 + r.IntN(3) {
+			group = append(group, []string{"R", "L"}[face]+[]string{"", "'"}[r.IntN(2)])
+			face = 1 - face
+		}
+		groups = append(groups, strings.Join(group, " "))
+	}
+
+	return strings.Join(groups, " x ")
+}
+
+// clockScramble is random-state by definition, every pin configuration gets
+// a uniformly random amount in WCA notation (no pins are listed at the end)
+func clockScramble(r *rand.Rand) string {
+	front := []string{"UR", "DR", "DL", "UL", "U", "R", "D", "L", "ALL"}
+	back := []string{"U", "R", "D", "L", "ALL"}
+
+	amount := func() string {
+		v := r.IntN(12)
+		if v <= 6 {
+			return strconv.Itoa(v) + "+"
+		}
+		return strconv.Itoa(12-v) + "-"
+	}
+
+	res := make([]string, 0, len(front)+len(back)+1)
+	for _, pin := range front {
+		res = append(res, pin+amount())
+	}
+	res = append(res, "y2")
+	for _, pin := range back {
+		res = append(res, pin+amount())
+	}
+
+	return strings.Join(res, " ")
+}
+
+// square-1 layers are modelled as 12 slots of 30 degrees, corners take two
+// consecutive slots, the slice cuts between slots 11|0 and 5|6 and swaps the
+// slots 0 to 5 of both layers. The top layer is seen from above starting at
+// the back end of the slice, the bottom one from below starting at the front
+// end, the same model is used by the scrambleimage package.
+type squareOneLayer [12]int
+
+func (l squareOneLayer) turn(amount int) squareOneLayer {
+	var res squareOneLayer
+	for i := range l {
+		res[((i+amount)%12+12)%12] = l[i]
+	}
+	return res
+}
+
+func (l squareOneLayer) sliceable() bool {
+	return l[11] != l[0] && l[5] != l[6]
+}
+
+func squareOneSolvedLayer(offset int) squareOneLayer {
+	var l squareOneLayer
+	id := offset
+	for i := 0; i < 12; i += 3 {
+		l[i] = id
+		l[i+1], l[i+2] = id+1, id+1
+		id += 2
+	}
+	return l
+}
+
+func squareOneScramble(r *rand.Rand) string {
+	const twists = 12
+
+	// in the solved state the top layer starts with a corner, the bottom one
+	// with an edge
+	top, bottom := squareOneSolvedLayer(0).turn(-1), squareOneSolvedLayer(8)
+	res := make([]string, 0, twists)
+	for len(res) < twists {
+		u, d := r.IntN(12)-5, r.IntN(12)-5
+		if u == 0 && d == 0 {
+			continue
+		}
+
+		newTop, newBottom := top.turn(u), bottom.turn(d)
+		if !newTop.sliceable() || !newBottom.sliceable() {
+			continue
+		}
+
+		for i := range 6 {
+			newTop[i], newBottom[i] = newBottom[i], newTop[i]
+		}
+		top, bottom = newTop, newBottom
+
+		res = append(res, fmt.Sprintf("(%d,%d)", u, d))
+	}
+
+	return strings.Join(res, " / ") + " /"
+}
+
+// fifteenPuzzleScramble lists in which direction the tiles slide into the
+// empty space and how many of them, consecutive moves are perpendicular
+func fifteenPuzzleScramble(r *rand.Rand) string {
+	const size = 4
+
+	type direction struct {
+		name   string
+		dr, dc int
+	}
+	// sliding a tile up moves the empty space down
+	directions := []direction{{"U", 1, 0}, {"D", -1, 0}, {"L", 0, 1}, {"R", 0, -1}}
+
+	row, col := size-1, size-1
+	res := make([]string, 0)
+	lastVertical := -1
+	for len(res) < 20 {
+		dir := directions[r.IntN(len(directions))]
+		vertical := 0
+		if dir.dr != 0 {
+			vertical = 1
+		}
+		if vertical == lastVertical {
+			continue
+		}
+
+		maxAmount := 0
+		for nr, nc := row+dir.dr, col+dir.dc; nr >= 0 && nr < size && nc >= 0 && nc < size; nr, nc = nr+dir.dr, nc+dir.dc {
+			maxAmount++
+		}
+		if maxAmount == 0 {
+			continue
+		}
+
+		amount := 1 + r.IntN(maxAmount)
+		row, col = row+dir.dr*amount, col+dir.dc*amount
+		lastVertical = vertical
+
+		if amount == 1 {
+			res = append(res, dir.name)
+		} else {
+			res = append(res, dir.name+strconv.Itoa(amount))
+		}
+	}
+
+	return strings.Join(res, " ")
+}
+
+var relayLabels = map[string]string{
+	"222so":  "2x2x2",
+	"333":    "3x3x3",
+	"444wca": "4x4x4",
+	"555wca": "5x5x5",
+	"666wca": "6x6x6",
+	"777wca": "7x7x7",
+	"333oh":  "3x3x3 One-Handed",
+	"clkwca": "Clock",
+	"mgmp":   "Megaminx",
+	"pyrso":  "Pyraminx",
+	"skbso":  "Skewb",
+	"sqrs":   "Square-1",
+}
+
+// relayGenerator puts one scramble of every part on its own labeled line
+func relayGenerator(parts []string) generator {
+	return func(r *rand.Rand) string {
+		lines := make([]string, 0, len(parts))
+		for _, part := range parts {
+			lines = append(lines, relayLabels[part]+": "+generators[part](r))
+		}
+		return strings.Join(lines, "\n")
+	}
+}
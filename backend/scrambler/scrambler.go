@@ -0,0 +1,106 @@
+package scrambler
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// Scrambler generates scrambles for the events in the events table, which are
+// identified by their scramblingcode (333, 222so, 444wca, clkwca, ...).
+type Scrambler interface {
+	Scrambles(scramblingcode string, count int) ([]string, error)
+}
+
+type generator func(r *rand.Rand) string
+
+// generators maps every supported scramblingcode to its scramble generator,
+// lengths mirror the ones the cstimer sidecar used
+var generators = map[string]generator{
+	"333":    func(r *rand.Rand) string { return cubeScramble(r, 3, 25) },
+	"333oh":  func(r *rand.Rand) string { return cubeScramble(r, 3, 25) },
+	"333ni":  func(r *rand.Rand) string { return blindScramble(r, 3, 25) },
+	"333fm":  fmcScramble,
+	"222so":  twoByTwoScramble,
+	"444wca": func(r *rand.Rand) string { return cubeScramble(r, 4, 40) },
+	"555wca": func(r *rand.Rand) string { return cubeScramble(r, 5, 60) },
+	"666wca": func(r *rand.Rand) string { return cubeScramble(r, 6, 80) },
+	"777wca": func(r *rand.Rand) string { return cubeScramble(r, 7, 100) },
+	"444bld": func(r *rand.Rand) string { return blindScramble(r, 4, 40) },
+	"555bld": func(r *rand.Rand) string { return blindScramble(r, 5, 60) },
+	"clkwca": clockScramble,
+	"mgmp":   func(r *rand.Rand) string { return pochmannScramble(r, 7) },
+	"klmp":   func(r *rand.Rand) string { return pochmannScramble(r, 3) },
+	"pyrso":  pyraminxScramble,
+	"skbso":  skewbScramble,
+	"sqrs":   squareOneScramble,
+	"rediso": rediScramble,
+	"mpyrso": masterPyraminxScramble,
+	"15prp":  fifteenPuzzleScramble,
+	"ftoso":  ftoScramble,
+}
+
+// RELAYS maps the scramblingcodes of the relays to the scramblingcodes of
+// their parts in the order they are solved
+var RELAYS = map[string][]string{
+	"r234w":    {"222so", "333", "444wca"},
+	"r2345w":   {"222so", "333", "444wca", "555wca"},
+	"r23456w":  {"222so", "333", "444wca", "555wca", "666wca"},
+	"r234567w": {"222so", "333", "444wca", "555wca", "666wca", "777wca"},
+	"rmngf":    {"222so", "333", "444wca", "555wca", "333oh", "clkwca", "mgmp", "pyrso", "skbso", "sqrs"},
+}
+
+func init() {
+	for code, parts := range RELAYS {
+		generators[code] = relayGenerator(parts)
+	}
+}
+
+// RandomScrambler is the default Scrambler. It is safe for concurrent use.
+type RandomScrambler struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// New returns a RandomScrambler seeded from crypto/rand.
+func New() *RandomScrambler {
+	var seed [16]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		panic(fmt.Errorf("%w: when reading random seed", err))
+	}
+
+	return NewSeeded(binary.LittleEndian.Uint64(seed[:8]), binary.LittleEndian.Uint64(seed[8:]))
+}
+
+// NewSeeded returns a deterministic RandomScrambler, meant for tests.
+func NewSeeded(seed1, seed2 uint64) *RandomScrambler {
+	return &RandomScrambler{rng: rand.New(rand.NewPCG(seed1, seed2))}
+}
+
+func (s *RandomScrambler) Scrambles(scramblingcode string, count int) ([]string, error) {
+	gen, ok := generators[scramblingcode]
+	if !ok {
+		return []string{}, fmt.Errorf("unsupported scramblingcode=%s", scramblingcode)
+	}
+	if count < 0 {
+		return []string{}, fmt.Errorf("invalid number of scrambles=%d", count)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scrambles := make([]string, 0, count)
+	for range count {
+		scrambles = append(scrambles, gen(s.rng))
+	}
+
+	return scrambles, nil
+}
+
+// Supports reports whether the scramblingcode has a generator.
+func Supports(scramblingcode string) bool {
+	_, ok := generators[scramblingcode]
+	return ok
+}
@@ -0,0 +1,81 @@
+package scrambler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambler"
+)
+
+var scramblingcodes = []string{
+	"333", "222so", "444wca", "555wca", "666wca", "777wca", "333ni", "333fm", "333oh", "clkwca",
+	"mgmp", "pyrso", "skbso", "sqrs", "444bld", "555bld", "r234w", "r2345w", "r23456w",
+	"r234567w", "klmp", "rmngf", "rediso", "mpyrso", "15prp", "ftoso",
+}
+
+func TestScrambles(t *testing.T) {
+	s := scrambler.NewSeeded(1, 2)
+
+	for _, code := range scramblingcodes {
+		t.Run(code, func(t *testing.T) {
+			require.True(t, scrambler.Supports(code))
+
+			scrambles, err := s.Scrambles(code, 5)
+			require.NoError(t, err)
+			require.Len(t, scrambles, 5)
+
+			for _, scramble := range scrambles {
+				assert.NotEmpty(t, strings.TrimSpace(scramble))
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := s.Scrambles("invalid", 1)
+		require.Error(t, err)
+	})
+}
+
+func TestSeededScramblesAreDeterministic(t *testing.T) {
+	a, err := scrambler.NewSeeded(42, 42).Scrambles("333", 3)
+	require.NoError(t, err)
+	b, err := scrambler.NewSeeded(42, 42).Scrambles("333", 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+}
+
+func TestCubeScramblesDoNotCancel(t *testing.T) {
+	s := scrambler.NewSeeded(7, 7)
+	scrambles, err := s.Scrambles("444wca", 20)
+	require.NoError(t, err)
+
+	for _, scramble := range scrambles {
+		moves := strings.Split(scramble, " ")
+		require.Len(t, moves, 40)
+		for i := 1; i < len(moves); i++ {
+			assert.NotEqual(t, strings.TrimRight(moves[i-1], "'2"), strings.TrimRight(moves[i], "'2"), scramble)
+		}
+	}
+}
+
+func TestFMCScramblePadding(t *testing.T) {
+	scrambles, err := scrambler.NewSeeded(3, 4).Scrambles("333fm", 10)
+	require.NoError(t, err)
+
+	for _, scramble := range scrambles {
+		assert.True(t, strings.HasPrefix(scramble, "R' U' F "), scramble)
+		assert.True(t, strings.HasSuffix(scramble, " R' U' F"), scramble)
+	}
+}
+
+func TestClockScramble(t *testing.T) {
+	scrambles, err := scrambler.NewSeeded(5, 6).Scrambles("clkwca", 1)
+	require.NoError(t, err)
+
+	assert.Len(t, strings.Split(scrambles[0], " "), 15)
+	assert.Contains(t, scrambles[0], " y2 ")
+}
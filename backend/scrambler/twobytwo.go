@@ -0,0 +1,242 @@
+package scrambler
+
+import (
+	"math/rand/v2"
+	"strings"
+	"sync"
+)
+
+// 2x2 random-state scrambles. The DBL corner is kept in place, so the state
+// is fully described by the permutation of the other 7 corners and their
+// orientations, which gives 5040 * 729 states. A breadth first search from
+// the solved state gives the optimal distance of every state, scrambles are
+// the inverse of an optimal solution of a uniformly random state.
+
+const (
+	twoByTwoPerms   = 5040
+	twoByTwoOris    = 729
+	twoByTwoMinimum = 4
+)
+
+// corner cubies in order URF, UFL, ULB, UBR, DFR, DLF, DBL, DRB,
+// the index 6 (DBL) is never moved
+var twoByTwoMoves = []struct {
+	name string
+	cp   [8]int
+	co   [8]int
+}{
+	{"U", [8]int{3, 0, 1, 2, 4, 5, 6, 7}, [8]int{0, 0, 0, 0, 0, 0, 0, 0}},
+	{"R", [8]int{4, 1, 2, 0, 7, 5, 6, 3}, [8]int{2, 0, 0, 1, 1, 0, 0, 2}},
+	{"F", [8]int{1, 5, 2, 3, 0, 4, 6, 7}, [8]int{1, 2, 0, 0, 2, 1, 0, 0}},
+}
+
+var twoByTwoPositions = []int{0, 1, 2, 3, 4, 5, 7}
+
+type twoByTwoTables struct {
+	permMove [twoByTwoPerms][3]uint16
+	oriMove  [twoByTwoOris][3]uint16
+	distance []int8
+}
+
+var (
+	twoByTwoOnce  sync.Once
+	twoByTwoTable *twoByTwoTables
+)
+
+type twoByTwoState struct {
+	cp [8]int
+	co [8]int
+}
+
+func (s twoByTwoState) apply(move int) twoByTwoState {
+	m := twoByTwoMoves[move]
+	var res twoByTwoState
+	for i := range 8 {
+		res.cp[i] = s.cp[m.cp[i]]
+		res.co[i] = (s.co[m.cp[i]] + m.co[i]) % 3
+	}
+	return res
+}
+
+func (s twoByTwoState) permIndex() int {
+	perm := make([]int, 0, 7)
+	for _, pos := range twoByTwoPositions {
+		piece := s.cp[pos]
+		if piece == 7 {
+			piece = 6
+		}
+		perm = append(perm, piece)
+	}
+
+	idx := 0
+	for i := range perm {
+		smaller := 0
+		for j := i + 1; j < len(perm); j++ {
+			if perm[j] < perm[i] {
+				smaller++
+			}
+		}
+		idx = idx*(len(perm)-i) + smaller
+	}
+
+	return idx
+}
+
+func (s *twoByTwoState) setPerm(idx int) {
+	perm := make([]int, 7)
+	for i := 6; i >= 0; i-- {
+		perm[i] = idx % (7 - i)
+		idx /= 7 - i
+	}
+
+	used := make([]bool, 7)
+	for i := range perm {
+		cnt := perm[i]
+		for piece := range used {
+			if used[piece] {
+				continue
+			}
+			if cnt == 0 {
+				perm[i] = piece
+				used[piece] = true
+				break
+			}
+			cnt--
+		}
+	}
+
+	for i, pos := range twoByTwoPositions {
+		piece := perm[i]
+		if piece == 6 {
+			piece = 7
+		}
+		s.cp[pos] = piece
+	}
+	s.cp[6] = 6
+}
+
+// only the first 6 orientations are stored, the last one is implied
+func (s twoByTwoState) oriIndex() int {
+	idx := 0
+	for _, pos := range twoByTwoPositions[:6] {
+		idx = idx*3 + s.co[pos]
+	}
+	return idx
+}
+
+func (s *twoByTwoState) setOri(idx int) {
+	sum := 0
+	for i := 5; i >= 0; i-- {
+		s.co[twoByTwoPositions[i]] = idx % 3
+		sum += idx % 3
+		idx /= 3
+	}
+	s.co[7] = (3 - sum%3) % 3
+	s.co[6] = 0
+}
+
+func initTwoByTwoTables() *twoByTwoTables {
+	t := &twoByTwoTables{}
+
+	for p := range twoByTwoPerms {
+		var s twoByTwoState
+		s.setPerm(p)
+		for m := range twoByTwoMoves {
+			t.permMove[p][m] = uint16(s.apply(m).permIndex())
+		}
+	}
+
+	for o := range twoByTwoOris {
+		var s twoByTwoState
+		s.setPerm(0)
+		s.setOri(o)
+		for m := range twoByTwoMoves {
+			t.oriMove[o][m] = uint16(s.apply(m).oriIndex())
+		}
+	}
+
+	t.distance = make([]int8, twoByTwoPerms*twoByTwoOris)
+	for i := range t.distance {
+		t.distance[i] = -1
+	}
+
+	var solved twoByTwoState
+	solved.setPerm(0)
+	start := solved.permIndex()*twoByTwoOris + solved.oriIndex()
+	t.distance[start] = 0
+
+	queue := make([]int, 0, len(t.distance))
+	queue = append(queue, start)
+	for head := 0; head < len(queue); head++ {
+		cur := queue[head]
+
+		for _, next := range t.neighbours(cur) {
+			if t.distance[next.state] == -1 {
+				t.distance[next.state] = t.distance[cur] + 1
+				queue = append(queue, next.state)
+			}
+		}
+	}
+
+	return t
+}
+
+type twoByTwoNeighbour struct {
+	state int
+	move  string
+}
+
+func (t *twoByTwoTables) neighbours(state int) []twoByTwoNeighbour {
+	res := make([]twoByTwoNeighbour, 0, 9)
+	for m, move := range twoByTwoMoves {
+		p, o := state/twoByTwoOris, state%twoByTwoOris
+		for power := 1; power <= 3; power++ {
+			p, o = int(t.permMove[p][m]), int(t.oriMove[o][m])
+			res = append(res, twoByTwoNeighbour{p*twoByTwoOris + o, move.name + []string{"", "2", "'"}[power-1]})
+		}
+	}
+
+	return res
+}
+
+func twoByTwoScramble(r *rand.Rand) string {
+	twoByTwoOnce.Do(func() { twoByTwoTable = initTwoByTwoTables() })
+	t := twoByTwoTable
+
+	state := r.IntN(twoByTwoPerms)*twoByTwoOris + r.IntN(twoByTwoOris)
+	for t.distance[state] < twoByTwoMinimum {
+		state = r.IntN(twoByTwoPerms)*twoByTwoOris + r.IntN(twoByTwoOris)
+	}
+
+	solution := make([]string, 0)
+	for t.distance[state] > 0 {
+		for _, next := range t.neighbours(state) {
+			if t.distance[next.state] == t.distance[state]-1 {
+				solution = append(solution, next.move)
+				state = next.state
+				break
+			}
+		}
+	}
+
+	return strings.Join(invertMoves(solution), " ")
+}
+
+// invertMoves reverses a sequence and inverts every move, it expects moves
+// in the usual X, X' and X2 notation
+func invertMoves(moves []string) []string {
+	res := make([]string, 0, len(moves))
+	for i := len(moves) - 1; i >= 0; i-- {
+		move := moves[i]
+		switch {
+		case strings.HasSuffix(move, "'"):
+			res = append(res, strings.TrimSuffix(move, "'"))
+		case strings.HasSuffix(move, "2"):
+			res = append(res, move)
+		default:
+			res = append(res, move+"'")
+		}
+	}
+
+	return res
+}
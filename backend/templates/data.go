@@ -0,0 +1,143 @@
+package templates
+
+// data of the templates, all the fields are escaped when rendered
+
+type CompAnnouncementSubscriptionData struct {
+	Username       string
+	Regions        []SubscriptionRegion
+	WebsiteHome    string
+	UnsubscribeUrl string
+}
+
+// SubscriptionRegion is a subscribed country or its state
+type SubscriptionRegion struct {
+	Name         string
+	CountryName  string
+	CountryIso2  string
+	Competitions []Competition
+}
+
+type Competition struct {
+	Name             string
+	Place            string
+	Date             string
+	CompetitorLimit  int
+	RegistrationOpen string
+	Events           []string
+	Url              string
+}
+
+type CompChangesData struct {
+	Username       string
+	Competitions   []ChangedCompetition
+	WebsiteHome    string
+	UnsubscribeUrl string
+}
+
+type ChangedCompetition struct {
+	Name    string
+	Date    string
+	Url     string
+	Changes []CompetitionChange
+}
+
+// CompetitionChange is one of the fields of the upcoming WCA competition
+// changes (dates, venue, competitor_limit, registration_open,
+// registration_reopened, cancelled or reinstated)
+type CompetitionChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// CompReminderData is the reminder of the watched competition, the kind is
+// registration_24h, registration_15m or almost_full
+type CompReminderData struct {
+	Username       string
+	Kind           string
+	Competition    Competition
+	Registered     int
+	UnsubscribeUrl string
+}
+
+type SuspiciousResultData struct {
+	SuspiciousResult bool
+	SuspiciousChange bool
+	Username         string
+	ProfileUrl       string
+	Email            string
+	CompetitionName  string
+	CompetitionUrl   string
+	Event            string
+	Single           string
+	Average          string
+	Times            []string
+	TimeChanges      []TimeChange
+	Comment          string
+	DenyUrl          string
+	AllowUrl         string
+}
+
+type TimeChange struct {
+	Old     string
+	New     string
+	Changed bool
+}
+
+type LoginLinkData struct {
+	Link       string
+	TTLMinutes int
+}
+
+type WeeklyDigestData struct {
+	Username            string
+	CompetitionName     string
+	CompetitionUrl      string
+	Events              []DigestEvent
+	KinchBefore         string
+	KinchAfter          string
+	KinchChange         string
+	NextCompetitionName string
+	NextCompetitionUrl  string
+	UnsubscribeUrl      string
+}
+
+// DigestEvent is the result of the user in the last round of the event they
+// competed in, the records are PR, NR, CR, WR or empty
+type DigestEvent struct {
+	Event         string
+	Round         int
+	Place         string
+	Single        string
+	SingleRecord  string
+	Average       string
+	AverageRecord string
+}
+
+type WeeklyCompetitionData struct {
+	Username        string
+	CompetitionName string
+	CompetitionUrl  string
+	Startdate       string
+	Enddate         string
+	UnsubscribeUrl  string
+}
+
+type ResultsApprovedData struct {
+	Username        string
+	CompetitionName string
+	CompetitionUrl  string
+	Event           string
+	Round           int
+	UnsubscribeUrl  string
+}
+
+// PersonalRecordData has only the events of the competition in which the user
+// set a record
+type PersonalRecordData struct {
+	Username        string
+	CompetitionName string
+	CompetitionUrl  string
+	Events          []DigestEvent
+	UnsubscribeUrl  string
+}
@@ -0,0 +1,136 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+// emails and announcements are rendered from the files named
+// <name>.<language>.html (emails, escaped by html/template) and
+// <name>.<language>.md (announcements). Every file defines the "subject"
+// template next to the body.
+const (
+	LANGUAGE_EN      = "en"
+	LANGUAGE_SK      = "sk"
+	DEFAULT_LANGUAGE = LANGUAGE_EN
+
+	EMAIL_COMP_ANNOUNCEMENT_SUBSCRIPTION = "comp_announcement_subscription"
+	EMAIL_SUSPICIOUS_RESULT              = "suspicious_result"
+	EMAIL_LOGIN_LINK                     = "login_link"
+	EMAIL_WEEKLY_DIGEST                  = "weekly_digest"
+	EMAIL_COMP_CHANGES                   = "comp_changes"
+	EMAIL_COMP_REMINDER                  = "comp_reminder"
+	EMAIL_WEEKLY_COMPETITION             = "weekly_competition"
+	EMAIL_RESULTS_APPROVED               = "results_approved"
+	EMAIL_PERSONAL_RECORD                = "personal_record"
+
+	ANNOUNCEMENT_WCA_COMPETITION = "wca_competition"
+)
+
+var LANGUAGES = []string{LANGUAGE_EN, LANGUAGE_SK}
+
+//go:embed email/*.html announcement/*.md
+var files embed.FS
+
+var (
+	emailTemplates        = map[string]*htmltemplate.Template{}
+	announcementTemplates = map[string]*texttemplate.Template{}
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"#", `\#`, "|", `\|`, "~", `\~`, "<", "&lt;", ">", "&gt;", "&", "&amp;",
+)
+
+var markdownUrlEscaper = strings.NewReplacer("(", "%28", ")", "%29", " ", "%20", "<", "%3C", ">", "%3E")
+
+// EscapeMarkdown makes the user-controlled text show up as is in markdown
+// (which also renders HTML)
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// EscapeMarkdownUrl keeps the url from ending the markdown link early
+func EscapeMarkdownUrl(u string) string {
+	return markdownUrlEscaper.Replace(u)
+}
+
+func init() {
+	emailFiles, err := fs.Glob(files, "email/*.html")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range emailFiles {
+		emailTemplates[path.Base(file)] = htmltemplate.Must(
+			htmltemplate.New(path.Base(file)).Funcs(htmltemplate.FuncMap{"join": strings.Join}).ParseFS(files, file),
+		)
+	}
+
+	announcementFiles, err := fs.Glob(files, "announcement/*.md")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range announcementFiles {
+		announcementTemplates[path.Base(file)] = texttemplate.Must(
+			texttemplate.New(path.Base(file)).Funcs(texttemplate.FuncMap{"join": strings.Join, "md": EscapeMarkdown, "mdurl": EscapeMarkdownUrl}).ParseFS(files, file),
+		)
+	}
+}
+
+// Language returns the language if it has templates, the default one
+// otherwise
+func Language(lang string) string {
+	if slices.Contains(LANGUAGES, lang) {
+		return lang
+	}
+
+	return DEFAULT_LANGUAGE
+}
+
+// RenderEmail returns the subject and the HTML body of the email in the
+// language
+func RenderEmail(name string, lang string, data any) (string, string, error) {
+	file := name + "." + Language(lang) + ".html"
+	t, ok := emailTemplates[file]
+	if !ok {
+		return "", "", fmt.Errorf("email template %s not found", file)
+	}
+
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", fmt.Errorf("%w: when rendering subject of %s", err, file)
+	}
+	if err := t.ExecuteTemplate(&body, file, data); err != nil {
+		return "", "", fmt.Errorf("%w: when rendering %s", err, file)
+	}
+
+	return html.UnescapeString(strings.TrimSpace(subject.String())), strings.TrimSpace(body.String()), nil
+}
+
+// RenderAnnouncement returns the title and the markdown content of the
+// announcement in the language
+func RenderAnnouncement(name string, lang string, data any) (string, string, error) {
+	file := name + "." + Language(lang) + ".md"
+	t, ok := announcementTemplates[file]
+	if !ok {
+		return "", "", fmt.Errorf("announcement template %s not found", file)
+	}
+
+	var title, content bytes.Buffer
+	if err := t.ExecuteTemplate(&title, "subject", data); err != nil {
+		return "", "", fmt.Errorf("%w: when rendering title of %s", err, file)
+	}
+	if err := t.ExecuteTemplate(&content, file, data); err != nil {
+		return "", "", fmt.Errorf("%w: when rendering %s", err, file)
+	}
+
+	return strings.TrimSpace(title.String()), strings.TrimSpace(content.String()), nil
+}
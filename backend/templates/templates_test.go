@@ -0,0 +1,137 @@
+package templates_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/templates"
+)
+
+var competition = templates.Competition{
+	Name:             "Bratislava <Open> 2026",
+	Place:            "Hotel *Cube* & Co",
+	Date:             "01 Jan 2026",
+	CompetitorLimit:  80,
+	RegistrationOpen: "01 Dec 2025 18:00:00 UTC",
+	Events:           []string{"3x3x3 Cube", "2x2x2 Cube"},
+	Url:              "https://www.worldcubeassociation.org/competitions/BratislavaOpen2026",
+}
+
+func TestLanguage(t *testing.T) {
+	require.Equal(t, templates.LANGUAGE_SK, templates.Language(templates.LANGUAGE_SK))
+	require.Equal(t, templates.DEFAULT_LANGUAGE, templates.Language("de"))
+	require.Equal(t, templates.DEFAULT_LANGUAGE, templates.Language(""))
+}
+
+func TestRenderEmail(t *testing.T) {
+	emails := map[string]any{
+		templates.EMAIL_COMP_ANNOUNCEMENT_SUBSCRIPTION: templates.CompAnnouncementSubscriptionData{
+			Username: "Jozko <b>Mrkvicka</b>",
+			Regions: []templates.SubscriptionRegion{
+				{Name: "Slovakia", CountryName: "Slovakia", CountryIso2: "sk", Competitions: []templates.Competition{competition}},
+			},
+			WebsiteHome:    "https://speedcubingslovakia.sk",
+			UnsubscribeUrl: "https://speedcubingslovakia.sk/unsubscribe?token=1.wca_competitions.abc",
+		},
+		templates.EMAIL_SUSPICIOUS_RESULT: templates.SuspiciousResultData{
+			SuspiciousResult: true,
+			SuspiciousChange: true,
+			Username:         "Jozko <b>Mrkvicka</b>",
+			TimeChanges:      []templates.TimeChange{{Old: "10.00", New: "9.00", Changed: true}},
+			DenyUrl:          "https://example.com/validate?verdict=false",
+		},
+		templates.EMAIL_COMP_CHANGES: templates.CompChangesData{
+			Username: "Jozko <b>Mrkvicka</b>",
+			Competitions: []templates.ChangedCompetition{
+				{Name: competition.Name, Date: competition.Date, Url: competition.Url, Changes: []templates.CompetitionChange{
+					{Field: "dates", Old: "01 Jan 2026", New: "08 Jan 2026"},
+					{Field: "competitor_limit", Old: "80", New: "120"},
+					{Field: "cancelled"},
+				}},
+			},
+			UnsubscribeUrl: "https://speedcubingslovakia.sk/unsubscribe?token=1.wca_competitions.abc",
+		},
+		templates.EMAIL_COMP_REMINDER: templates.CompReminderData{
+			Username:       "Jozko <b>Mrkvicka</b>",
+			Kind:           "almost_full",
+			Competition:    competition,
+			Registered:     75,
+			UnsubscribeUrl: "https://speedcubingslovakia.sk/unsubscribe?token=1.wca_reminders.abc",
+		},
+		templates.EMAIL_LOGIN_LINK: templates.LoginLinkData{Link: "https://example.com/login/email?token=abc", TTLMinutes: 15},
+		templates.EMAIL_WEEKLY_DIGEST: templates.WeeklyDigestData{
+			Username:        "Jozko <b>Mrkvicka</b>",
+			CompetitionName: "Weekly Competition 42",
+			Events: []templates.DigestEvent{
+				{Event: "3x3x3", Round: 1, Place: "2", Single: "9.00", SingleRecord: "PR", Average: "11.00"},
+				{Event: "2x2x2", Round: 2, Place: "1", Single: "2.00", Average: "3.00", AverageRecord: "NR"},
+			},
+			KinchBefore:        "10.00",
+			KinchAfter:         "12.50",
+			KinchChange:        "+2.50",
+			NextCompetitionUrl: "https://speedcubingslovakia.sk/competition/WeeklyCompetition43",
+		},
+		templates.EMAIL_WEEKLY_COMPETITION: templates.WeeklyCompetitionData{
+			Username:        "Jozko <b>Mrkvicka</b>",
+			CompetitionName: "Weekly Competition 43",
+			CompetitionUrl:  "https://speedcubingslovakia.sk/competition/WeeklyCompetition43",
+			Startdate:       "05 Jan 2026",
+			Enddate:         "12 Jan 2026",
+		},
+		templates.EMAIL_RESULTS_APPROVED: templates.ResultsApprovedData{
+			Username:        "Jozko <b>Mrkvicka</b>",
+			CompetitionName: "Weekly Competition 42",
+			Event:           "3x3x3",
+			Round:           2,
+		},
+		templates.EMAIL_PERSONAL_RECORD: templates.PersonalRecordData{
+			Username:        "Jozko <b>Mrkvicka</b>",
+			CompetitionName: "Weekly Competition 42",
+			Events: []templates.DigestEvent{
+				{Event: "3x3x3", Round: 1, Place: "2", Single: "9.00", SingleRecord: "PR", Average: "11.00"},
+			},
+		},
+	}
+
+	for name, data := range emails {
+		for _, lang := range templates.LANGUAGES {
+			t.Run(name+"."+lang, func(t *testing.T) {
+				subject, body, err := templates.RenderEmail(name, lang, data)
+				require.NoError(t, err)
+				require.NotEmpty(t, subject)
+				require.NotEmpty(t, body)
+				require.False(t, strings.Contains(body, "<b>Mrkvicka</b>"))
+				require.False(t, strings.Contains(body, "<Open>"))
+			})
+		}
+	}
+
+	subject, body, err := templates.RenderEmail(templates.EMAIL_SUSPICIOUS_RESULT, templates.LANGUAGE_EN, emails[templates.EMAIL_SUSPICIOUS_RESULT])
+	require.NoError(t, err)
+	require.Equal(t, "Suspicious result and change in results detected !!!", subject)
+	require.True(t, strings.Contains(body, "Jozko &lt;b&gt;Mrkvicka&lt;/b&gt;"))
+
+	subject, _, err = templates.RenderEmail(templates.EMAIL_COMP_REMINDER, templates.LANGUAGE_EN, templates.CompReminderData{Kind: "registration_15m", Competition: competition})
+	require.NoError(t, err)
+	require.Equal(t, "Registration for Bratislava <Open> 2026 opens in 15 minutes", subject)
+
+	_, _, err = templates.RenderEmail("missing", templates.LANGUAGE_EN, nil)
+	require.Error(t, err)
+}
+
+func TestRenderAnnouncement(t *testing.T) {
+	for _, lang := range templates.LANGUAGES {
+		title, content, err := templates.RenderAnnouncement(templates.ANNOUNCEMENT_WCA_COMPETITION, lang, competition)
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(title, competition.Name))
+		require.True(t, strings.Contains(content, `Bratislava &lt;Open&gt; 2026`))
+		require.True(t, strings.Contains(content, `Hotel \*Cube\* &amp; Co`))
+		require.True(t, strings.Contains(content, "("+competition.Url+")"))
+	}
+}
+
+func TestEscapeMarkdownUrl(t *testing.T) {
+	require.Equal(t, "https://example.com/a%20%28b%29", templates.EscapeMarkdownUrl("https://example.com/a (b)"))
+}
@@ -0,0 +1,14 @@
+package utils
+
+import "time"
+
+// Backoff returns how long to wait after the attempt-th failure, the delay
+// starts at base and doubles with every attempt up to maxDelay
+func Backoff(attempt int, base time.Duration, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
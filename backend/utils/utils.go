@@ -4,12 +4,10 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
-	"io"
 	"log"
 	"math"
-	"net/http"
-	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -19,10 +17,11 @@ import (
 	"github.com/gocolly/colly"
 	"github.com/golang-jwt/jwt"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv"
 
 	"github.com/jakubdrobny/speedcubingslovakia/backend/constants"
 	"github.com/jakubdrobny/speedcubingslovakia/backend/cube"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/interfaces"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/scrambleimage"
 )
 
 // assumes region format of {country}{separator}{state} or {country}
@@ -41,25 +40,6 @@ func RemoveFromSliceBad[T any](slice []T, idx int) []T {
 	return slice[:len(slice)-1]
 }
 
-func GetRequest(url string) ([]byte, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return []byte{}, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return []byte{}, err
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, err
-	}
-
-	return body, nil
-}
-
 func GetMedian(arr []float64) float64 {
 	sort.Slice(arr, func(i, j int) bool {
 		return arr[i] < arr[j]
@@ -316,22 +296,14 @@ func DOES_NOT_WORK_CURRENTLY_FOR_SOME_REASON_GetWorldRecords(eventName string) (
 	return single, average, nil
 }
 
-func GetNoOfSolves(format string) (int, error) {
-	match := regexp.MustCompile(`\d+$`).FindString(format)
-	res, err := strconv.Atoi(match)
-
-	if err != nil {
-		return 0, fmt.Errorf("did not find a number at the end of format")
-	}
-
-	return res, nil
-}
-
-func CreateToken(userid int, secretKey string, expiresInSeconds int) (string, error) {
+// CreateToken signs an access token of the session of the user, the session
+// is checked on every request so the token stops working once it is revoked
+func CreateToken(userid int, sessionId int, secretKey string, expiresIn time.Duration) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
 		jwt.MapClaims{
 			"userid": userid,
-			"exp":    time.Now().Add(time.Hour * time.Duration(expiresInSeconds)).Unix(),
+			"sid":    sessionId,
+			"exp":    time.Now().Add(expiresIn).Unix(),
 		})
 
 	tokenString, err := token.SignedString([]byte(secretKey))
@@ -442,80 +414,54 @@ func RandSeq(n int) string {
 	return fmt.Sprintf("%x", b)[2 : n+2]
 }
 
-func SaveScrambleImg(img_id string, svg_content string) error {
-	envMap, err := godotenv.Read()
+func SaveScrambleImg(folderPath string, imgId string, svgContent string) error {
+	err := os.WriteFile(filepath.Join(folderPath, imgId), []byte(svgContent), 0o644)
 	if err != nil {
 		return err
 	}
 
-	folder_path := envMap["SCRAMBLE_IMAGES_PATH"]
-	f, err := os.Create(fmt.Sprintf("%s/%s", folder_path, img_id))
+	log.Printf("Wrote %d bytes.", len(svgContent))
+	return nil
+}
+
+// GenerateScrambleImg renders the scramble and saves the image into
+// folderPath, returns the id (file name) of the image
+func GenerateScrambleImg(folderPath string, scramblingcode string, scramble string) (string, error) {
+	svg, err := scrambleimage.Render(scramblingcode, scramble)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	n, err := f.WriteString(svg_content)
+	imgId := RandSeq(64) + ".svg" // with extension
+	err = SaveScrambleImg(folderPath, imgId, svg)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	log.Printf("Wrote %d bytes.", n)
-	return nil
+	return imgId, nil
 }
 
 func RegenerateImageForScramble(
-	db *pgxpool.Pool,
+	ctx context.Context,
+	db interfaces.DB,
+	envMap map[string]string,
 	scrambleId int,
 	scramble string,
 	scramblingcode string,
 ) (string, error) {
-	url := fmt.Sprintf(
-		"http://localhost:2014/api/v0/view/%s/svg?scramble=%s",
-		scramblingcode,
-		url.QueryEscape(scramble),
-	)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", err
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	imgId := RandSeq(64) + ".svg" // with extension
-	err = SaveScrambleImg(imgId, string(respBody))
+	imgId, err := GenerateScrambleImg(envMap["SCRAMBLE_IMAGES_PATH"], scramblingcode, scramble)
 	if err != nil {
 		return "", err
 	}
 
 	_, err = db.Exec(
-		context.Background(),
+		ctx,
 		`UPDATE scrambles SET img = $1 WHERE scramble_id = $2;`,
 		imgId,
 		scrambleId,
 	)
 	if err != nil {
-		return "", err
-	}
-
-	if scramblingcode == "sq1" {
-		_, err = db.Exec(
-			context.Background(),
-			`UPDATE scrambles SET scramble = $1 WHERE scramble_id = $2;`,
-			imgId,
-			scrambleId,
-		)
-		if err != nil {
-			return "", err
-		}
+		return "", fmt.Errorf("%w: when updating image of scramble_id=%d", err, scrambleId)
 	}
 
 	return imgId, nil
@@ -563,19 +509,19 @@ func GetSolve(solve string, isfmc bool, scramble string) string {
 	return FormatTime(ParseSolveToMilliseconds(solve, isfmc, scramble), isfmc)
 }
 
-func GetScramblesByResultEntryId(db *pgxpool.Pool, eid int, cid string) ([]string, error) {
+func GetScramblesByResultEntryId(db *pgxpool.Pool, eid int, cid string, round int) ([]string, error) {
 	rows, err := db.Query(
 		context.Background(),
-		`SELECT scramble FROM scrambles WHERE event_id = $1 AND competition_id = $2 ORDER BY "order";`,
+		`SELECT scramble FROM scrambles WHERE event_id = $1 AND competition_id = $2 AND round = $3 ORDER BY "order";`,
 		eid,
 		cid,
+		max(round, 1),
 	)
 	if err != nil {
 		return []string{}, err
 	}
 
-	scrambles := make([]string, 5)
-	idx := 0
+	scrambles := make([]string, 0)
 	for rows.Next() {
 		var scramble string
 		err = rows.Scan(&scramble)
@@ -583,13 +529,7 @@ func GetScramblesByResultEntryId(db *pgxpool.Pool, eid int, cid string) ([]strin
 			return []string{}, err
 		}
 
-		scrambles[idx] = scramble
-		idx++
-
-		if idx == 5 {
-			rows.Close()
-			break
-		}
+		scrambles = append(scrambles, scramble)
 	}
 
 	return scrambles, nil
@@ -654,3 +594,35 @@ func DistanceTwoPointsInKm(lat1, long1, lat2, long2 float64) float64 {
 func PointInsideCircle(lat1, long1, radiusInKm, lat2, long2 float64) bool {
 	return radiusInKm-DistanceTwoPointsInKm(lat1, long1, lat2, long2) > constants.EPS
 }
+
+// pointInsideRing casts a ray from the point along its latitude and counts the
+// crossed edges of the ring, the positions are [longitude, latitude] as in
+// GeoJSON and the edges are straight on the map (not great circles)
+func pointInsideRing(lat, long float64, ring [][]float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		long1, lat1 := ring[i][0], ring[i][1]
+		long2, lat2 := ring[j][0], ring[j][1]
+		if (lat1 > lat) != (lat2 > lat) && long < (long2-long1)*(lat-lat1)/(lat2-lat1)+long1 {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// PointInsidePolygon checks if the point is inside the GeoJSON polygon, its
+// first ring is the outline and the other rings are the holes
+func PointInsidePolygon(lat, long float64, polygon [][][]float64) bool {
+	if len(polygon) == 0 || !pointInsideRing(lat, long, polygon[0]) {
+		return false
+	}
+
+	for _, hole := range polygon[1:] {
+		if pointInsideRing(lat, long, hole) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package wca
+
+import "time"
+
+// responses of the WCA API, only the fields the website uses
+
+type Competition struct {
+	Id                string     `json:"id"`
+	Name              string     `json:"name"`
+	Startdate         string     `json:"start_date"`
+	Enddate           string     `json:"end_date"`
+	RegistrationOpen  time.Time  `json:"registration_open"`
+	RegistrationClose time.Time  `json:"registration_close"`
+	LatitudeDegrees   float64    `json:"latitude_degrees"`
+	LongitudeDegrees  float64    `json:"longitude_degrees"`
+	CompetitorLimit   int        `json:"competitor_limit"`
+	Url               string     `json:"url"`
+	CountryIso2       string     `json:"country_iso2"`
+	VenueAddress      string     `json:"venue_address"`
+	City              string     `json:"city"`
+	EventIds          []string   `json:"event_ids"`
+	CancelledAt       *time.Time `json:"cancelled_at"`
+}
+
+type Registration struct {
+	Id     int `json:"id"`
+	UserId int `json:"user_id"`
+}
+
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+type Country struct {
+	Id   string `json:"id"`
+	Iso2 string `json:"iso2"`
+}
+
+type Avatar struct {
+	Url      string `json:"url"`
+	ThumbUrl string `json:"thumb_url"`
+}
+
+// Me is the user of the access token, the email is there with the email scope
+type Me struct {
+	Id      int     `json:"id"`
+	Name    string  `json:"name"`
+	WcaId   string  `json:"wca_id"`
+	Gender  string  `json:"gender"`
+	Url     string  `json:"url"`
+	Country Country `json:"country"`
+	Avatar  Avatar  `json:"avatar"`
+	Email   string  `json:"email"`
+}
+
+type PersonInfo struct {
+	WcaId       string `json:"wca_id"`
+	Name        string `json:"name"`
+	Gender      string `json:"gender"`
+	Url         string `json:"url"`
+	CountryIso2 string `json:"country_iso2"`
+	Avatar      Avatar `json:"avatar"`
+}
+
+// Rank is a personal record with its ranks, the best is in centiseconds (moves
+// for FMC, the encoded result for MBLD)
+type Rank struct {
+	Best            int `json:"best"`
+	WorldRank       int `json:"world_rank"`
+	ContinentalRank int `json:"continent_rank"`
+	NationalRank    int `json:"country_rank"`
+}
+
+type PersonalRecord struct {
+	Single  *Rank `json:"single"`
+	Average *Rank `json:"average"`
+}
+
+type Person struct {
+	Person           PersonInfo                `json:"person"`
+	CompetitionCount int                       `json:"competition_count"`
+	PersonalRecords  map[string]PersonalRecord `json:"personal_records"`
+}
+
+// EventRecords are the best results of the event, 0 when there is none
+type EventRecords struct {
+	Single  int `json:"single"`
+	Average int `json:"average"`
+}
+
+// Records by event id, the continental ones by continent id (e.g. _Europe)
+// and the national ones by country id
+type Records struct {
+	World       map[string]EventRecords            `json:"world_records"`
+	Continental map[string]map[string]EventRecords `json:"continental_records"`
+	National    map[string]map[string]EventRecords `json:"national_records"`
+}
@@ -0,0 +1,318 @@
+package wca
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/utils"
+)
+
+// the requests failing on the network, on 429 or 5xx are retried with
+// exponential backoff, the requests are spaced by the interval to stay below
+// the rate limits of the WCA
+const (
+	DEFAULT_BASE_URL         = "https://www.worldcubeassociation.org"
+	DEFAULT_MAX_ATTEMPTS     = 6
+	DEFAULT_RETRY_BASE_DELAY = 2 * time.Second
+	DEFAULT_RETRY_MAX_DELAY  = 2 * time.Minute
+	DEFAULT_REQUEST_INTERVAL = time.Second
+	DEFAULT_TIMEOUT          = 30 * time.Second
+
+	COMPETITIONS_PAGE_SIZE = 25
+)
+
+var ErrNotFound = errors.New("not found on wca")
+
+// StatusError is returned for the responses with unexpected status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("wca responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Client is the part of the WCA API the website uses
+type Client interface {
+	// GetCompetitions returns the page (from 1) of the competitions, the
+	// latest ending first
+	GetCompetitions(ctx context.Context, page int) ([]Competition, error)
+	GetCompetitionRegistrations(ctx context.Context, competitionId string) ([]Registration, error)
+	// ExchangeCode exchanges the OAuth authorization code for the access token
+	ExchangeCode(ctx context.Context, code string) (Token, error)
+	// GetMe returns the user of the access token
+	GetMe(ctx context.Context, accessToken string) (Me, error)
+	GetPerson(ctx context.Context, wcaId string) (Person, error)
+	GetRecords(ctx context.Context) (Records, error)
+}
+
+type Config struct {
+	BaseURL         string
+	ClientId        string
+	ClientSecret    string
+	RedirectUri     string
+	MaxAttempts     int
+	RetryBaseDelay  time.Duration
+	RetryMaxDelay   time.Duration
+	RequestInterval time.Duration
+	HTTPClient      *http.Client
+}
+
+// HTTPClient calls the WCA API over HTTP
+type HTTPClient struct {
+	config Config
+
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+var _ Client = (*HTTPClient)(nil)
+
+// New returns the client, the zero fields of the config are defaulted except
+// the request interval
+func New(config Config) *HTTPClient {
+	if config.BaseURL == "" {
+		config.BaseURL = DEFAULT_BASE_URL
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DEFAULT_MAX_ATTEMPTS
+	}
+	if config.RetryBaseDelay <= 0 {
+		config.RetryBaseDelay = DEFAULT_RETRY_BASE_DELAY
+	}
+	if config.RetryMaxDelay <= 0 {
+		config.RetryMaxDelay = DEFAULT_RETRY_MAX_DELAY
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: DEFAULT_TIMEOUT}
+	}
+
+	return &HTTPClient{config: config}
+}
+
+// NewClient returns the client configured by the environment, WCA_BASE_URL
+// points it to another server (e.g. the fake one). The jobs going through many
+// pages should space the requests by the interval, the logins do not need to.
+func NewClient(envMap map[string]string, requestInterval time.Duration) *HTTPClient {
+	return New(Config{
+		BaseURL:         envMap["WCA_BASE_URL"],
+		ClientId:        envMap["WCA_CLIENT_ID"],
+		ClientSecret:    envMap["WCA_CLIENT_SECRET"],
+		RedirectUri:     envMap["WCA_REDIRECT_URI"],
+		RequestInterval: requestInterval,
+	})
+}
+
+// RetryDelay returns how long to wait after the attempt-th failed request,
+// the delay doubles with every attempt
+func (c *HTTPClient) RetryDelay(attempt int) time.Duration {
+	return utils.Backoff(attempt, c.config.RetryBaseDelay, c.config.RetryMaxDelay)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttle waits until the interval since the previous request passes
+func (c *HTTPClient) throttle(ctx context.Context) error {
+	c.mu.Lock()
+	wait := time.Until(c.lastRequest.Add(c.config.RequestInterval))
+	c.lastRequest = time.Now().Add(max(wait, 0))
+	c.mu.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+func retryAfter(res *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// do sends the request made by newRequest until it succeeds or runs out of
+// attempts and decodes the JSON body into out
+func (c *HTTPClient) do(ctx context.Context, newRequest func() (*http.Request, error), out any) error {
+	var lastErr error
+	// the server may ask for a longer wait in the Retry-After header
+	var retryAfterHint time.Duration
+	for attempt := 1; attempt <= c.config.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			delay := max(c.RetryDelay(attempt-1), min(retryAfterHint, c.config.RetryMaxDelay))
+			if err := sleep(ctx, delay); err != nil {
+				return fmt.Errorf("%w: after %v", err, lastErr)
+			}
+			retryAfterHint = 0
+		}
+		if err := c.throttle(ctx); err != nil {
+			return err
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return fmt.Errorf("%w: when creating request", err)
+		}
+		req = req.WithContext(ctx)
+
+		res, err := c.config.HTTPClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("%w: when sending request to %s", err, req.URL.Path)
+			continue
+		}
+
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("%w: when reading response from %s", err, req.URL.Path)
+			continue
+		}
+
+		if res.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", ErrNotFound, req.URL.Path)
+		}
+		if res.StatusCode != http.StatusOK {
+			lastErr = &StatusError{StatusCode: res.StatusCode, Body: string(body)}
+			if !retryable(res.StatusCode) {
+				return lastErr
+			}
+			retryAfterHint = retryAfter(res)
+			continue
+		}
+
+		if len(body) == 0 {
+			return nil
+		}
+		if err = json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("%w: when decoding response from %s", err, req.URL.Path)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: after %d attempts", lastErr, c.config.MaxAttempts)
+}
+
+func (c *HTTPClient) get(ctx context.Context, path string, query url.Values, accessToken string, out any) error {
+	return c.do(ctx, func() (*http.Request, error) {
+		u := c.config.BaseURL + path
+		if len(query) > 0 {
+			u += "?" + query.Encode()
+		}
+
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+
+		return req, nil
+	}, out)
+}
+
+func (c *HTTPClient) GetCompetitions(ctx context.Context, page int) ([]Competition, error) {
+	competitions := []Competition{}
+	query := url.Values{"page": {strconv.Itoa(page)}, "sort": {"-end_date"}}
+	if err := c.get(ctx, "/api/v0/competitions", query, "", &competitions); err != nil {
+		return []Competition{}, fmt.Errorf("%w: when getting page %d of competitions", err, page)
+	}
+
+	return competitions, nil
+}
+
+func (c *HTTPClient) GetCompetitionRegistrations(ctx context.Context, competitionId string) ([]Registration, error) {
+	registrations := []Registration{}
+	path := "/api/v0/competitions/" + url.PathEscape(competitionId) + "/registrations"
+	if err := c.get(ctx, path, nil, "", &registrations); err != nil {
+		return []Registration{}, fmt.Errorf("%w: when getting registrations of competition %s", err, competitionId)
+	}
+
+	return registrations, nil
+}
+
+func (c *HTTPClient) ExchangeCode(ctx context.Context, code string) (Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {c.config.ClientId},
+		"client_secret": {c.config.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectUri},
+	}
+
+	var token Token
+	err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, c.config.BaseURL+"/oauth/token", strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+
+		return req, nil
+	}, &token)
+	if err != nil {
+		return Token{}, fmt.Errorf("%w: when exchanging authorization code", err)
+	}
+
+	return token, nil
+}
+
+func (c *HTTPClient) GetMe(ctx context.Context, accessToken string) (Me, error) {
+	var res struct {
+		Me Me `json:"me"`
+	}
+	if err := c.get(ctx, "/api/v0/me", nil, accessToken, &res); err != nil {
+		return Me{}, fmt.Errorf("%w: when getting me", err)
+	}
+
+	return res.Me, nil
+}
+
+func (c *HTTPClient) GetPerson(ctx context.Context, wcaId string) (Person, error) {
+	var person Person
+	if err := c.get(ctx, "/api/v0/persons/"+url.PathEscape(wcaId), nil, "", &person); err != nil {
+		return Person{}, fmt.Errorf("%w: when getting person %s", err, wcaId)
+	}
+
+	return person, nil
+}
+
+func (c *HTTPClient) GetRecords(ctx context.Context) (Records, error) {
+	var records Records
+	if err := c.get(ctx, "/api/v0/records", nil, "", &records); err != nil {
+		return Records{}, fmt.Errorf("%w: when getting records", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,120 @@
+package wca_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca/wcatest"
+)
+
+func TestGetCompetitions(t *testing.T) {
+	server := wcatest.NewServer()
+	defer server.Close()
+	client := server.WCAClient()
+
+	for i := range wca.COMPETITIONS_PAGE_SIZE + 5 {
+		server.AddCompetitions(wca.Competition{
+			Id:      fmt.Sprintf("Comp%02d2026", i),
+			Enddate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i).Format("2006-01-02"),
+		})
+	}
+
+	competitions, err := client.GetCompetitions(t.Context(), 1)
+	require.NoError(t, err)
+	require.Len(t, competitions, wca.COMPETITIONS_PAGE_SIZE)
+	require.Equal(t, "Comp292026", competitions[0].Id)
+
+	competitions, err = client.GetCompetitions(t.Context(), 2)
+	require.NoError(t, err)
+	require.Len(t, competitions, 5)
+
+	server.SetRegistrations("Comp002026", 3)
+	registrations, err := client.GetCompetitionRegistrations(t.Context(), "Comp002026")
+	require.NoError(t, err)
+	require.Len(t, registrations, 3)
+}
+
+func TestRetries(t *testing.T) {
+	server := wcatest.NewServer()
+	defer server.Close()
+	client := server.WCAClient()
+
+	server.Fail(http.StatusInternalServerError, http.StatusTooManyRequests)
+	_, err := client.GetCompetitions(t.Context(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, server.Requests("/api/v0/competitions"))
+
+	server.Fail(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	_, err = client.GetCompetitions(t.Context(), 1)
+	var statusErr *wca.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+
+	// client errors are not retried
+	server.Fail(http.StatusForbidden)
+	_, err = client.GetRecords(t.Context())
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	require.Equal(t, 1, server.Requests("/api/v0/records"))
+}
+
+func TestRetryDelay(t *testing.T) {
+	client := wca.New(wca.Config{RetryBaseDelay: time.Second, RetryMaxDelay: 5 * time.Second})
+
+	require.Equal(t, time.Second, client.RetryDelay(1))
+	require.Equal(t, 2*time.Second, client.RetryDelay(2))
+	require.Equal(t, 4*time.Second, client.RetryDelay(3))
+	require.Equal(t, 5*time.Second, client.RetryDelay(4))
+	require.Equal(t, 5*time.Second, client.RetryDelay(100))
+}
+
+func TestLogin(t *testing.T) {
+	server := wcatest.NewServer()
+	defer server.Close()
+	client := server.WCAClient()
+
+	server.AddUser("code", wca.Me{Name: "Jozko Mrkvicka", WcaId: "2016MRKV01", Country: wca.Country{Id: "Slovakia", Iso2: "SK"}})
+
+	token, err := client.ExchangeCode(t.Context(), "code")
+	require.NoError(t, err)
+
+	me, err := client.GetMe(t.Context(), token.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, "2016MRKV01", me.WcaId)
+	require.Equal(t, "Slovakia", me.Country.Id)
+
+	_, err = client.ExchangeCode(t.Context(), "wrong code")
+	require.Error(t, err)
+
+	_, err = client.GetMe(t.Context(), "wrong token")
+	require.Error(t, err)
+}
+
+func TestPersonAndRecords(t *testing.T) {
+	server := wcatest.NewServer()
+	defer server.Close()
+	client := server.WCAClient()
+
+	server.AddPerson(wca.Person{
+		Person:          wca.PersonInfo{WcaId: "2016MRKV01", Name: "Jozko Mrkvicka"},
+		PersonalRecords: map[string]wca.PersonalRecord{"333": {Single: &wca.Rank{Best: 899, NationalRank: 3}}},
+	})
+	server.SetRecords(wca.Records{World: map[string]wca.EventRecords{"333": {Single: 313, Average: 409}}})
+
+	person, err := client.GetPerson(t.Context(), "2016MRKV01")
+	require.NoError(t, err)
+	require.Equal(t, 899, person.PersonalRecords["333"].Single.Best)
+	require.Nil(t, person.PersonalRecords["333"].Average)
+
+	_, err = client.GetPerson(t.Context(), "2000NONE01")
+	require.ErrorIs(t, err, wca.ErrNotFound)
+
+	records, err := client.GetRecords(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 409, records.World["333"].Average)
+}
@@ -0,0 +1,265 @@
+// Package wcatest runs a fake of the WCA API in the process, so the sync of
+// the competitions and the WCA login can be tested (and developed) offline.
+package wcatest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakubdrobny/speedcubingslovakia/backend/wca"
+)
+
+// Server serves the competitions, users, persons and records added to it
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	competitions  []wca.Competition
+	registrations map[string][]wca.Registration
+	codes         map[string]string
+	users         map[string]wca.Me
+	persons       map[string]wca.Person
+	records       wca.Records
+	failures      []int
+	requests      map[string]int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		registrations: map[string][]wca.Registration{},
+		codes:         map[string]string{},
+		users:         map[string]wca.Me{},
+		persons:       map[string]wca.Person{},
+		records: wca.Records{
+			World:       map[string]wca.EventRecords{},
+			Continental: map[string]map[string]wca.EventRecords{},
+			National:    map[string]map[string]wca.EventRecords{},
+		},
+		requests: map[string]int{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v0/competitions", s.handleCompetitions)
+	mux.HandleFunc("GET /api/v0/competitions/{id}/registrations", s.handleRegistrations)
+	mux.HandleFunc("POST /oauth/token", s.handleToken)
+	mux.HandleFunc("GET /api/v0/me", s.handleMe)
+	mux.HandleFunc("GET /api/v0/persons/{wcaId}", s.handlePerson)
+	mux.HandleFunc("GET /api/v0/records", s.handleRecords)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+
+	return s
+}
+
+// WCAClient returns a client of the server which retries without waiting
+func (s *Server) WCAClient() *wca.HTTPClient {
+	return wca.New(wca.Config{
+		BaseURL:        s.URL,
+		ClientId:       "client-id",
+		ClientSecret:   "client-secret",
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Nanosecond,
+		RetryMaxDelay:  time.Nanosecond,
+	})
+}
+
+func (s *Server) AddCompetitions(competitions ...wca.Competition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.competitions = append(s.competitions, competitions...)
+}
+
+// UpdateCompetition replaces the competition with the same id
+func (s *Server) UpdateCompetition(competition wca.Competition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx := range s.competitions {
+		if s.competitions[idx].Id == competition.Id {
+			s.competitions[idx] = competition
+			return
+		}
+	}
+}
+
+// RemoveCompetition removes the competition, e.g. when it was cancelled
+func (s *Server) RemoveCompetition(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx, competition := range s.competitions {
+		if competition.Id == id {
+			s.competitions = append(s.competitions[:idx], s.competitions[idx+1:]...)
+			return
+		}
+	}
+}
+
+// SetRegistrations makes the competition have the number of registrations
+func (s *Server) SetRegistrations(competitionId string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registrations := make([]wca.Registration, 0, count)
+	for i := range count {
+		registrations = append(registrations, wca.Registration{Id: i + 1, UserId: i + 1})
+	}
+	s.registrations[competitionId] = registrations
+}
+
+// AddUser lets the user log in with the authorization code
+func (s *Server) AddUser(code string, me wca.Me) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accessToken := "access-token-" + code
+	s.codes[code] = accessToken
+	s.users[accessToken] = me
+}
+
+func (s *Server) AddPerson(person wca.Person) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.persons[person.Person.WcaId] = person
+}
+
+func (s *Server) SetRecords(records wca.Records) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = records
+}
+
+// Fail makes the next requests respond with the statuses, one per request
+func (s *Server) Fail(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, statuses...)
+}
+
+// Requests returns how many requests were sent to the path
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		status := 0
+		if len(s.failures) > 0 {
+			status, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) handleCompetitions(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	s.mu.Lock()
+	competitions := append([]wca.Competition{}, s.competitions...)
+	s.mu.Unlock()
+
+	sort.SliceStable(competitions, func(i, j int) bool {
+		return competitions[i].Enddate > competitions[j].Enddate
+	})
+
+	from := min((page-1)*wca.COMPETITIONS_PAGE_SIZE, len(competitions))
+	to := min(from+wca.COMPETITIONS_PAGE_SIZE, len(competitions))
+	writeJSON(w, competitions[from:to])
+}
+
+func (s *Server) handleRegistrations(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registrations, ok := s.registrations[r.PathValue("id")]
+	if !ok {
+		registrations = []wca.Registration{}
+	}
+	writeJSON(w, registrations)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.mu.Lock()
+	accessToken, ok := s.codes[r.PostForm.Get("code")]
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, wca.Token{AccessToken: accessToken, TokenType: "Bearer", ExpiresIn: 7200, Scope: "public email"})
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	me, ok := s.users[accessToken]
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, map[string]wca.Me{"me": me})
+}
+
+func (s *Server) handlePerson(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	person, ok := s.persons[r.PathValue("wcaId")]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeJSON(w, person)
+}
+
+func (s *Server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, s.records)
+}
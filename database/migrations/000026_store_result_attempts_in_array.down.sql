@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE results
+  ADD COLUMN solve1 TEXT NOT NULL DEFAULT 'DNS',
+  ADD COLUMN solve2 TEXT NOT NULL DEFAULT 'DNS',
+  ADD COLUMN solve3 TEXT NOT NULL DEFAULT 'DNS',
+  ADD COLUMN solve4 TEXT NOT NULL DEFAULT 'DNS',
+  ADD COLUMN solve5 TEXT NOT NULL DEFAULT 'DNS';
+UPDATE results SET
+  solve1 = COALESCE(solves[1], 'DNS'),
+  solve2 = COALESCE(solves[2], 'DNS'),
+  solve3 = COALESCE(solves[3], 'DNS'),
+  solve4 = COALESCE(solves[4], 'DNS'),
+  solve5 = COALESCE(solves[5], 'DNS');
+ALTER TABLE results DROP COLUMN solves;
+
+COMMIT;
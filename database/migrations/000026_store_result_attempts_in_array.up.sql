@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE results ADD COLUMN solves TEXT[] NOT NULL DEFAULT '{}';
+UPDATE results SET solves = ARRAY[solve1, solve2, solve3, solve4, solve5];
+ALTER TABLE results DROP COLUMN solve1, DROP COLUMN solve2, DROP COLUMN solve3, DROP COLUMN solve4, DROP COLUMN solve5;
+
+COMMIT;
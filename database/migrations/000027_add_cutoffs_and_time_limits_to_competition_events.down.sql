@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE competition_events DROP COLUMN cutoff_attempts, DROP COLUMN cutoff_time, DROP COLUMN time_limit, DROP COLUMN time_limit_cumulative;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE competition_events ADD COLUMN cutoff_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE competition_events ADD COLUMN cutoff_time INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE competition_events ADD COLUMN time_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE competition_events ADD COLUMN time_limit_cumulative BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
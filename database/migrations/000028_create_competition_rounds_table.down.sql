@@ -0,0 +1,9 @@
+BEGIN;
+
+DELETE FROM results WHERE round > 1;
+DELETE FROM scrambles WHERE round > 1;
+ALTER TABLE results DROP COLUMN round;
+ALTER TABLE scrambles DROP COLUMN round;
+DROP TABLE IF EXISTS competition_rounds;
+
+COMMIT;
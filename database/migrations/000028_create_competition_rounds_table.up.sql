@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS competition_rounds (
+  competition_round_id BIGSERIAL PRIMARY KEY,
+  competition_id TEXT REFERENCES competitions (competition_id) ON UPDATE CASCADE NOT NULL,
+  event_id INTEGER REFERENCES events (event_id) ON UPDATE CASCADE NOT NULL,
+  round INTEGER NOT NULL,
+  startdate TIMESTAMP NOT NULL,
+  advancement_type TEXT NOT NULL,
+  advancement_level INTEGER NOT NULL,
+  cutoff_attempts INTEGER NOT NULL DEFAULT 0,
+  cutoff_time INTEGER NOT NULL DEFAULT 0,
+  time_limit INTEGER NOT NULL DEFAULT 0,
+  time_limit_cumulative BOOLEAN NOT NULL DEFAULT FALSE,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT competition_event_round_unique UNIQUE (competition_id, event_id, round),
+  CONSTRAINT round_after_first CHECK (round > 1)
+);
+
+ALTER TABLE results ADD COLUMN round INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE scrambles ADD COLUMN round INTEGER NOT NULL DEFAULT 1;
+
+COMMIT;
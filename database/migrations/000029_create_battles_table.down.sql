@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS battle_attempts;
+DROP TABLE IF EXISTS battles;
+
+COMMIT;
@@ -0,0 +1,31 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS battles (
+  battle_id BIGSERIAL PRIMARY KEY,
+  challenger_id INTEGER REFERENCES users (user_id) ON UPDATE CASCADE NOT NULL,
+  opponent_id INTEGER REFERENCES users (user_id) ON UPDATE CASCADE NOT NULL,
+  event_id INTEGER REFERENCES events (event_id) ON UPDATE CASCADE NOT NULL,
+  attempts INTEGER NOT NULL,
+  state TEXT NOT NULL DEFAULT 'pending',
+  winner_id INTEGER REFERENCES users (user_id) ON UPDATE CASCADE,
+  finished_at TIMESTAMP,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT battle_between_two_users CHECK (challenger_id <> opponent_id)
+);
+
+CREATE TABLE IF NOT EXISTS battle_attempts (
+  battle_attempt_id BIGSERIAL PRIMARY KEY,
+  battle_id INTEGER REFERENCES battles (battle_id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  "order" INTEGER NOT NULL,
+  scramble TEXT NOT NULL,
+  img TEXT NOT NULL DEFAULT '',
+  challenger_solve TEXT,
+  opponent_solve TEXT,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT battle_attempt_unique UNIQUE (battle_id, "order")
+);
+
+CREATE INDEX IF NOT EXISTS battles_challenger_id_idx ON battles (challenger_id);
+CREATE INDEX IF NOT EXISTS battles_opponent_id_idx ON battles (opponent_id);
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ranking_entries (
+  ranking_entry_id BIGSERIAL PRIMARY KEY,
+  result_id INTEGER REFERENCES results (result_id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  event_id INTEGER REFERENCES events (event_id) ON UPDATE CASCADE NOT NULL,
+  type TEXT NOT NULL,
+  value BIGINT NOT NULL,
+  result TEXT NOT NULL,
+  times TEXT[] NOT NULL DEFAULT '{}',
+  CONSTRAINT ranking_entry_type CHECK (type IN ('single', 'average'))
+);
+
+CREATE INDEX IF NOT EXISTS ranking_entries_event_type_value_idx ON ranking_entries (event_id, type, value);
+CREATE INDEX IF NOT EXISTS ranking_entries_result_id_idx ON ranking_entries (result_id);
+
+COMMIT;
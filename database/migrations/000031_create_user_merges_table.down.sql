@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS user_merge_rows;
+DROP TABLE IF EXISTS user_merges;
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+-- no foreign keys to users on purpose, merging rewrites all of them and the
+-- log has to keep the original ids
+CREATE TABLE IF NOT EXISTS user_merges (
+  user_merge_id BIGSERIAL PRIMARY KEY,
+  old_user_id INTEGER NOT NULL,
+  new_user_id INTEGER NOT NULL,
+  old_user JSONB NOT NULL,
+  merged_by INTEGER NOT NULL,
+  merged_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  unmerged_by INTEGER,
+  unmerged_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_merge_rows (
+  user_merge_row_id BIGSERIAL PRIMARY KEY,
+  user_merge_id INTEGER REFERENCES user_merges (user_merge_id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  table_name TEXT NOT NULL,
+  column_name TEXT NOT NULL,
+  row_id TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS user_merge_rows_user_merge_id_idx ON user_merge_rows (user_merge_id);
+
+COMMIT;
@@ -0,0 +1,56 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS roles (
+  role_id SERIAL PRIMARY KEY,
+  name TEXT UNIQUE NOT NULL,
+  display_name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+  permission_id SERIAL PRIMARY KEY,
+  name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_permission_id SERIAL PRIMARY KEY,
+  role_id INTEGER REFERENCES roles (role_id) ON DELETE CASCADE NOT NULL,
+  permission_id INTEGER REFERENCES permissions (permission_id) ON DELETE CASCADE NOT NULL,
+  UNIQUE (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_role_id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER REFERENCES users (user_id) ON DELETE CASCADE NOT NULL,
+  role_id INTEGER REFERENCES roles (role_id) ON DELETE CASCADE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_user_id_idx ON user_roles (user_id);
+
+INSERT INTO roles (name, display_name) VALUES
+  ('super_admin', 'Super admin'),
+  ('results_moderator', 'Results moderator'),
+  ('announcement_editor', 'Announcement editor'),
+  ('competition_organizer', 'Competition organizer');
+
+INSERT INTO permissions (name) VALUES
+  ('results.validate'),
+  ('results.edit'),
+  ('announcements.manage'),
+  ('competitions.manage'),
+  ('users.manage'),
+  ('stats.view');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id
+FROM roles r, permissions p
+WHERE r.name = 'super_admin'
+  OR (r.name = 'results_moderator' AND p.name = 'results.validate')
+  OR (r.name = 'announcement_editor' AND p.name = 'announcements.manage')
+  OR (r.name = 'competition_organizer' AND p.name = 'competitions.manage');
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.user_id, r.role_id
+FROM users u, roles r
+WHERE u.isadmin AND r.name = 'super_admin';
+
+COMMIT;
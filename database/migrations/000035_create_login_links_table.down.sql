@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS users_email_idx;
+DROP TABLE IF EXISTS login_links;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS login_links (
+  login_link_id BIGSERIAL PRIMARY KEY,
+  email TEXT NOT NULL,
+  name TEXT NOT NULL,
+  country_id TEXT REFERENCES countries (country_id) NOT NULL,
+  token_hash TEXT UNIQUE NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS login_links_email_idx ON login_links (lower(email));
+CREATE INDEX IF NOT EXISTS users_email_idx ON users (lower(email));
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE login_links DROP COLUMN IF EXISTS language;
+ALTER TABLE users DROP COLUMN IF EXISTS language;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE login_links ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'en';
+
+COMMIT;
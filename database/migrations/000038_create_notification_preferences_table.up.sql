@@ -0,0 +1,14 @@
+BEGIN;
+
+-- the categories without a row of the user are on
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  notification_preference_id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER REFERENCES users (user_id) ON DELETE CASCADE NOT NULL,
+  category TEXT NOT NULL,
+  enabled BOOLEAN NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notification_preferences_user_id_idx ON notification_preferences (user_id, category);
+
+COMMIT;
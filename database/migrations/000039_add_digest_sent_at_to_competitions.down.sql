@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE competitions DROP COLUMN IF EXISTS digest_sent_at;
+
+COMMIT;
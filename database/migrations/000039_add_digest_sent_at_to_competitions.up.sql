@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE competitions ADD COLUMN IF NOT EXISTS digest_sent_at TIMESTAMP;
+
+-- no digests for the competitions which ended before the digests existed
+UPDATE competitions SET digest_sent_at = CURRENT_TIMESTAMP WHERE enddate <= CURRENT_TIMESTAMP;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS upcoming_wca_competition_changes;
+
+ALTER TABLE upcoming_wca_competitions DROP COLUMN IF EXISTS cancelled;
+
+COMMIT;
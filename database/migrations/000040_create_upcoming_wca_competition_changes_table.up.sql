@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE upcoming_wca_competitions ADD COLUMN IF NOT EXISTS cancelled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS upcoming_wca_competition_changes(
+  upcoming_wca_competition_change_id BIGSERIAL PRIMARY KEY,
+  upcoming_wca_competition_id TEXT NOT NULL,
+  country_id TEXT NOT NULL,
+  field TEXT NOT NULL,
+  old_value TEXT NOT NULL,
+  new_value TEXT NOT NULL,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (upcoming_wca_competition_id, country_id) REFERENCES upcoming_wca_competitions (upcoming_wca_competition_id, country_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS upcoming_wca_competition_changes_competition_idx ON upcoming_wca_competition_changes (upcoming_wca_competition_id, country_id);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS wca_competition_watch_reminders;
+DROP TABLE IF EXISTS wca_competition_watches;
+
+COMMIT;
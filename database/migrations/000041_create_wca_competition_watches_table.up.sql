@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS wca_competition_watches(
+  wca_competition_watch_id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER REFERENCES users (user_id) ON DELETE CASCADE NOT NULL,
+  upcoming_wca_competition_id TEXT NOT NULL,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS wca_competition_watches_user_id_idx ON wca_competition_watches (user_id);
+CREATE INDEX IF NOT EXISTS wca_competition_watches_competition_idx ON wca_competition_watches (upcoming_wca_competition_id);
+
+-- the reminders are sent once per registration opening, so moving the
+-- opening sends them again
+CREATE TABLE IF NOT EXISTS wca_competition_watch_reminders(
+  wca_competition_watch_reminder_id BIGSERIAL PRIMARY KEY,
+  wca_competition_watch_id BIGINT REFERENCES wca_competition_watches (wca_competition_watch_id) ON DELETE CASCADE NOT NULL,
+  kind TEXT NOT NULL,
+  registration_open TIMESTAMP NOT NULL,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (wca_competition_watch_id, kind, registration_open)
+);
+
+COMMIT;
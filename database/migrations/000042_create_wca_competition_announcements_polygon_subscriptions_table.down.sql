@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS wca_competitions_announcements_polygon_subscriptions;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- the geometry is a GeoJSON MultiPolygon, the subscription covers all of its
+-- polygons
+CREATE TABLE IF NOT EXISTS wca_competitions_announcements_polygon_subscriptions(
+  wca_competitions_announcements_polygon_subscription_id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER REFERENCES users (user_id) ON DELETE CASCADE NOT NULL,
+  name TEXT NOT NULL DEFAULT '',
+  geometry JSONB NOT NULL,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS wca_competitions_announcements_polygon_subscriptions_user_id_idx ON wca_competitions_announcements_polygon_subscriptions (user_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS login_provider TEXT NOT NULL DEFAULT 'wca';
+
+-- the users registered by a login link have neither a WCA ID nor a WCA avatar
+UPDATE users SET login_provider = 'email' WHERE wcaid = '' AND avatarurl = '';
+
+COMMIT;
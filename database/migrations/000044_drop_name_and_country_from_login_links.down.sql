@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE login_links ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';
+ALTER TABLE login_links ADD COLUMN IF NOT EXISTS country_id TEXT REFERENCES countries (country_id) NOT NULL DEFAULT 'Slovakia';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- new users fill in their name after opening the link
+ALTER TABLE login_links DROP COLUMN IF EXISTS name;
+ALTER TABLE login_links DROP COLUMN IF EXISTS country_id;
+
+COMMIT;
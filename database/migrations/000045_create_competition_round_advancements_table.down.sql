@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS competition_round_advancements;
+ALTER TABLE competition_rounds DROP COLUMN IF EXISTS advancement_saved;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- the competitors of the previous round who advanced to the round, saved once
+-- the round opens and saved again after the previous round results change
+ALTER TABLE competition_rounds ADD COLUMN IF NOT EXISTS advancement_saved BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS competition_round_advancements(
+  competition_round_advancement_id BIGSERIAL PRIMARY KEY,
+  competition_round_id BIGINT REFERENCES competition_rounds (competition_round_id) ON DELETE CASCADE NOT NULL,
+  user_id INTEGER REFERENCES users (user_id) ON DELETE CASCADE NOT NULL,
+  timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS competition_round_advancements_round_user_idx ON competition_round_advancements (competition_round_id, user_id);
+
+COMMIT;
@@ -23,6 +23,9 @@ const AnnouncementEdit = lazy(
 const Announcements = lazy(
   () => import("./components/Announcement/Announcements"),
 );
+const Battles = lazy(() => import("./components/Battles/Battles"));
+const BattleView = lazy(() => import("./components/Battles/BattleView"));
+const LiveBoard = lazy(() => import("./components/Competition/LiveBoard"));
 const Competition = lazy(() => import("./components/Competition/Competition"));
 const CompetitionEdit = lazy(
   () => import("./components/Dashboard/CompetitionEdit"),
@@ -51,6 +54,12 @@ const WCACompetitions = lazy(
 const MergeUsers = lazy(
   () => import("./components/Dashboard/MergeDuplicateUsers"),
 );
+const EmailOutbox = lazy(() => import("./components/Dashboard/EmailOutbox"));
+const ApiTokens = lazy(() => import("./components/Profile/ApiTokens"));
+const Notifications = lazy(
+  () => import("./components/Profile/Notifications"),
+);
+const Unsubscribe = lazy(() => import("./components/Profile/Unsubscribe"));
 const SubscriptionsDashboard = lazy(
   () => import("./components/Dashboard/SubscriptionsDashboard"),
 );
@@ -84,8 +93,8 @@ const App = () => {
 
     if (authStateRef.current.token) {
       authorizeAdmin()
-        .then((_) => {
-          setAuthState({ ...authStateRef.current, isadmin: true });
+        .then((permissions) => {
+          setAuthState({ ...authStateRef.current, isadmin: true, permissions });
           setAuthorizationLoadingState((ps) => ({ ...ps, loading: false }));
         })
         .catch((_) => {
@@ -127,6 +136,7 @@ const App = () => {
             <Route path="/" Component={Home} />
             <Route path="/competitions" Component={Competitions} />
             <Route path="/competition/:id" Component={Competition} />
+            <Route path="/competition/:id/live" Component={LiveBoard} />
             <Route
               path="/upcoming-wca-competitions"
               Component={() => <Navigate to="/competitions/wca" replace />}
@@ -140,6 +150,7 @@ const App = () => {
             <Route path="/announcement/:id" Component={Announcement} />
             <Route path="/not-found" Component={NotFound} />
             <Route path="/login" Component={LogIn} />
+            <Route path="/login/email" Component={LogIn} />
             <Route
               element={
                 <ProtectedRoute loadingState={authorizationLoadingState} />
@@ -165,6 +176,7 @@ const App = () => {
                 element={<AnnouncementEdit edit={false} />}
               />
               <Route path="/admin/stats" Component={AdminStats} />
+              <Route path="/admin/emails" Component={EmailOutbox} />
               <Route
                 path="/admin/subscriptions"
                 Component={SubscriptionsDashboard}
@@ -175,9 +187,14 @@ const App = () => {
               />
             </Route>
             <Route path="/profile/:id" Component={Profile} />
+            <Route path="/tokens" Component={ApiTokens} />
+            <Route path="/notifications" Component={Notifications} />
+            <Route path="/unsubscribe" Component={Unsubscribe} />
             <Route path="/results/users" Component={Users} />
             <Route path="/results/records" Component={Records} />
             <Route path="/results/rankings" Component={Rankings} />
+            <Route path="/battles" Component={Battles} />
+            <Route path="/battle/:id" Component={BattleView} />
             <Route path="*" element={<Navigate to="/not-found" replace />} />
           </Routes>
         </Grid>
@@ -21,6 +21,7 @@ export type Scramble = {
 
 export type ScrambleSet = {
   event: CompetitionEvent;
+  round: number;
   scrambles: Scramble[];
 };
 
@@ -30,12 +31,44 @@ export enum FilterValue {
   Future = "Future",
 }
 
+export type Cutoff = {
+  attempts: number;
+  time: number;
+};
+
+export type TimeLimit = {
+  time: number;
+  cumulative: boolean;
+};
+
+export enum AdvancementType {
+  Ranking = "ranking",
+  Percent = "percent",
+  Result = "result",
+}
+
+export type Advancement = {
+  type: AdvancementType;
+  level: number;
+};
+
+export type CompetitionRound = {
+  number: number;
+  startdate: string;
+  advancement: Advancement;
+  cutoff: Cutoff;
+  timeLimit: TimeLimit;
+};
+
 export type CompetitionEvent = {
   id: number;
   displayname: string;
   format: string;
   iconcode: string;
   scramblingcode: string;
+  cutoff: Cutoff;
+  timeLimit: TimeLimit;
+  rounds: CompetitionRound[];
 };
 
 export enum InputMethod {
@@ -51,6 +84,7 @@ export type CompetitionLoadingState = {
 
 export type CompetitionState = CompetitionData & {
   currentEventIdx: number;
+  currentRound: number;
   currentSolveIdx: number;
   noOfSolves: number;
   inputMethod: InputMethod;
@@ -68,6 +102,7 @@ export type CompetitionContextType = {
   currentResults: ResultEntry;
   updateBasicInfo: (info: CompetitionData, currentEventIdx: number) => void;
   updateCurrentEvent: (idx: number) => void;
+  updateCurrentRound: (round: number) => void;
   updateCurrentSolve: (idx: number) => void;
   saveResults: () => Promise<void>;
   updateSolve: (newTime: string) => void;
@@ -104,28 +139,19 @@ export type ResultEntry = {
   eventname: string;
   iconcode: string;
   format: string;
-  solve1: string;
-  solve2: string;
-  solve3: string;
-  solve4: string;
-  solve5: string;
+  round: number;
+  solves: string[];
   comment: string;
   status: ResultsStatus;
   badFormat: boolean;
 };
 
-export enum ResultEntrySolves {
-  solve1,
-  solve2,
-  solve3,
-  solve4,
-  solve5,
-}
-
 export type AuthState = {
   token: string;
+  refreshToken: string;
   wcaid: string;
   isadmin: boolean;
+  permissions: string[];
   avatarUrl: string;
   username: string;
 };
@@ -188,6 +214,81 @@ export type ManageUser = {
   country_name: string;
   country_iso2: string;
   is_admin: boolean;
+  roles: string[];
+};
+
+export type Role = {
+  id: number;
+  name: string;
+  displayName: string;
+  permissions: string[];
+};
+
+export type OutboxEmail = {
+  id: number;
+  userId: number | null;
+  message: {
+    from: string;
+    to: string;
+    subject: string;
+    body: string;
+    headers: Record<string, string>;
+  };
+  status: "pending" | "sent" | "failed";
+  attempts: number;
+  lastError: string;
+  createdAt: string;
+  nextAttemptAt: string;
+  sentAt: string | null;
+};
+
+export type NotificationPreference = {
+  category: string;
+  enabled: boolean;
+};
+
+export type ApiToken = {
+  id: number;
+  userId: number;
+  name: string;
+  prefix: string;
+  scopes: string[];
+  createdAt: string;
+  lastUsedAt: string | null;
+  expiresAt: string | null;
+};
+
+export type CreatedApiToken = ApiToken & {
+  token: string;
+};
+
+export type DuplicateUserPair = {
+  oldUser: ManageUser;
+  newUser: ManageUser;
+  score: number;
+  nameSimilarity: number;
+  sameCountry: boolean;
+  sameWcaId: boolean;
+  eventOverlap: number;
+  sharedCompetitions: number;
+};
+
+export type UserMergeRow = {
+  tableName: string;
+  columnName: string;
+  rowId: string;
+};
+
+export type UserMerge = {
+  id: number;
+  oldUserId: number;
+  newUserId: number;
+  oldUser: { name: string; wcaid: string };
+  mergedBy: number;
+  mergedAt: string;
+  unmergedBy: number | null;
+  unmergedAt: string | null;
+  rows: UserMergeRow[];
 };
 
 export enum DashboardPanel {
@@ -228,6 +329,7 @@ export type CompetitionResult = {
   score: string;
   scores: KinchScore[];
   comment: string;
+  advancing: boolean;
 };
 
 export type KinchScore = {
@@ -394,6 +496,7 @@ export type AverageInfo = {
   wpa: string;
   showPossibleAverage: boolean;
   finishedCompeting: boolean;
+  cutoffFailed: boolean;
   place: string;
   singleRecord: string;
   singleRecordColor: string;
@@ -428,6 +531,7 @@ export type WCACompetitionType = {
   registrationClose: Date;
   latitudeDegrees: number;
   longitudeDegrees: number;
+  cancelled: boolean;
 };
 
 export type CompetitionAnnouncementSubscription = {
@@ -455,6 +559,21 @@ export type MarkerType = {
   open: boolean;
 };
 
+export type PolygonSubscriptionFeature = {
+  type: "Feature";
+  properties: { id: number; name: string };
+  geometry: {
+    type: "Polygon" | "MultiPolygon";
+    // [longitude, latitude] positions, a Polygon has one level less
+    coordinates: number[][][] | number[][][][];
+  };
+};
+
+export type PolygonSubscriptionFeatureCollection = {
+  type: "FeatureCollection";
+  features: PolygonSubscriptionFeature[];
+};
+
 export type User = {
   id: number;
   name: string;
@@ -464,6 +583,7 @@ export type User = {
   isadmin: boolean;
   url: string;
   avatarurl: string;
+  language: string;
 };
 
 export type SubscriptionStats = {
@@ -493,3 +613,71 @@ export type ListItemDropdownContent = {
   itemIcon: React.ElementType;
   dropdownItems: ListItemDropdownOption[];
 };
+
+export type FMCMove = {
+  position: number;
+  move: string;
+  counted: boolean;
+};
+
+export type FMCIllegalMove = {
+  position: number;
+  move: string;
+  reason: string;
+};
+
+export type FMCReport = {
+  moves: FMCMove[];
+  moveCount: number;
+  totalLength: number;
+  solved: boolean;
+  illegalMoves: FMCIllegalMove[];
+  tooLong: boolean;
+  copiesInverseScramble: boolean;
+  result: number;
+};
+
+export type FMCSolveVerification = {
+  solve: number;
+  scramble: string;
+  solution: string;
+  report: FMCReport | null;
+};
+
+export enum BattleState {
+  Pending = "pending",
+  Active = "active",
+  Declined = "declined",
+  Finished = "finished",
+}
+
+export type BattleAttempt = {
+  order: number;
+  scramble: string;
+  img: string;
+  challengerSolve: string;
+  opponentSolve: string;
+  winnerId: number;
+};
+
+export type Battle = {
+  id: number;
+  challengerId: number;
+  challengerName: string;
+  opponentId: number;
+  opponentName: string;
+  event: CompetitionEvent;
+  noOfAttempts: number;
+  state: BattleState;
+  winnerId: number;
+  challengerWins: number;
+  opponentWins: number;
+  finishedAt: string | null;
+  attempts: BattleAttempt[];
+};
+
+export type BattleChallenge = {
+  opponent: string;
+  eventId: number;
+  noOfAttempts: number;
+};
@@ -1,8 +1,10 @@
 import {
   AddReactionToAnnouncement,
+  PERMISSIONS,
   ReadAnnouncement,
   getAnnouncementById,
   getError,
+  hasPermission,
   isObjectEmpty,
   renderResponseError,
 } from "../../utils/utils";
@@ -166,7 +168,10 @@ const Announcement: React.FC<{
                 )}
                 <Typography level="h2">{announcementState.title}</Typography>
               </Stack>
-              {authStateRef.current.isadmin && (
+              {hasPermission(
+                authStateRef.current,
+                PERMISSIONS.ANNOUNCEMENTS_MANAGE,
+              ) && (
                 <Stack direction="row" gap="10px">
                   <Edit
                     color="primary"
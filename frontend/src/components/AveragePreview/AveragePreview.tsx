@@ -122,19 +122,32 @@ const AveragePreview: React.FC<{
                 </Chip>
               </Typography>
             </div>
-            {averageInfo.finishedCompeting && !ismbld && !isBo1 && (
-              <div>
-                <Typography
-                  sx={{ display: "flex", alignItems: "center" }}
-                  component="div"
-                >
-                  <b>Average:</b>&nbsp;
-                  <Chip variant="soft" color="primary">
-                    {averageInfo.average}
-                  </Chip>
-                </Typography>
-              </div>
+            {averageInfo.cutoffFailed && (
+              <Typography
+                sx={{ display: "flex", alignItems: "center" }}
+                component="div"
+              >
+                <Chip variant="soft" color="danger">
+                  Cutoff not made
+                </Chip>
+              </Typography>
             )}
+            {averageInfo.finishedCompeting &&
+              !averageInfo.cutoffFailed &&
+              !ismbld &&
+              !isBo1 && (
+                <div>
+                  <Typography
+                    sx={{ display: "flex", alignItems: "center" }}
+                    component="div"
+                  >
+                    <b>Average:</b>&nbsp;
+                    <Chip variant="soft" color="primary">
+                      {averageInfo.average}
+                    </Chip>
+                  </Typography>
+                </div>
+              )}
           </Stack>
           <div
             style={{
@@ -0,0 +1,196 @@
+import {
+  Alert,
+  Button,
+  Card,
+  Input,
+  Stack,
+  Table,
+  Typography,
+} from "@mui/joy";
+import {
+  AuthContextType,
+  Battle,
+  BattleState,
+  LoadingState,
+} from "../../Types";
+import {
+  acceptBattle,
+  declineBattle,
+  getBattle,
+  getCubingIconClassName,
+  getError,
+  initialLoadingState,
+  isObjectEmpty,
+  renderResponseError,
+  submitBattleSolve,
+} from "../../utils/utils";
+import { useContext, useEffect } from "react";
+
+import { AuthContext } from "../../context/AuthContext";
+import LoadingComponent from "../Loading/LoadingComponent";
+import { useParams } from "react-router-dom";
+import useState from "react-usestateref";
+
+const BattleView = () => {
+  const { id = "" } = useParams<{ id: string }>();
+  const { authState } = useContext(AuthContext) as AuthContextType;
+  const [loadingState, setLoadingState] =
+    useState<LoadingState>(initialLoadingState);
+  const [battle, setBattle] = useState<Battle>();
+  const [solve, setSolve] = useState<string>("");
+  const [actionError, setActionError] = useState<string>("");
+
+  useEffect(() => {
+    setLoadingState({ isLoading: true, error: {} });
+
+    getBattle(id)
+      .then((b) => {
+        setBattle(b);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) => {
+        setLoadingState({ isLoading: false, error: getError(err) });
+      });
+  }, [id]);
+
+  const handleAction = (action: Promise<Battle>) => {
+    setActionError("");
+    action
+      .then((b) => {
+        setBattle(b);
+        setSolve("");
+      })
+      .catch((err) => setActionError(err.response?.data ?? err.message));
+  };
+
+  if (loadingState.isLoading || battle === undefined) {
+    return !isObjectEmpty(loadingState.error) ? (
+      renderResponseError(loadingState.error)
+    ) : (
+      <LoadingComponent title="Loading battle..." />
+    );
+  }
+
+  const isChallenger = authState.username === battle.challengerName;
+  const isOpponent = authState.username === battle.opponentName;
+  const current = battle.attempts.find(
+    (a) => a.challengerSolve === "" || a.opponentSolve === "",
+  );
+  const submitted =
+    current !== undefined &&
+    ((isChallenger && current.challengerSolve !== "") ||
+      (isOpponent && current.opponentSolve !== ""));
+  const winnerName = (winnerId: number) =>
+    winnerId === battle.challengerId
+      ? battle.challengerName
+      : winnerId === battle.opponentId
+        ? battle.opponentName
+        : "";
+
+  return (
+    <Stack sx={{ margin: "1em" }} spacing={2}>
+      <Typography level="h2">
+        {battle.challengerName} vs. {battle.opponentName}
+      </Typography>
+      <Typography level="h4">
+        <span className={getCubingIconClassName(battle.event.iconcode)} />
+        &nbsp;{battle.event.displayname}, {battle.noOfAttempts} attempts
+      </Typography>
+      {battle.state === BattleState.Declined && (
+        <Alert color="neutral">The battle was declined.</Alert>
+      )}
+      {battle.state === BattleState.Pending && (
+        <Card>
+          <Typography>Waiting for {battle.opponentName} to accept.</Typography>
+          {(isChallenger || isOpponent) && (
+            <Stack direction="row" spacing={1}>
+              {isOpponent && (
+                <Button onClick={() => handleAction(acceptBattle(battle.id))}>
+                  Accept
+                </Button>
+              )}
+              <Button
+                color="danger"
+                onClick={() => handleAction(declineBattle(battle.id))}
+              >
+                {isOpponent ? "Decline" : "Withdraw"}
+              </Button>
+            </Stack>
+          )}
+        </Card>
+      )}
+      {battle.state !== BattleState.Pending &&
+        battle.state !== BattleState.Declined && (
+          <Typography level="h3">
+            {battle.challengerWins} : {battle.opponentWins}
+            {battle.state === BattleState.Finished &&
+              (battle.winnerId === 0
+                ? " (draw)"
+                : ` (${winnerName(battle.winnerId)} won)`)}
+          </Typography>
+        )}
+      {battle.state === BattleState.Active && current !== undefined && (
+        <Card>
+          <Typography level="h4">Attempt {current.order}</Typography>
+          <Typography sx={{ whiteSpace: "pre-wrap" }}>
+            {current.scramble}
+          </Typography>
+          {current.img !== "" && (
+            <img
+              src={`${import.meta.env.VITE_SCRAMBLE_IMAGES_PATH}/${current.img}`}
+              alt="scramble"
+              style={{ maxWidth: "300px" }}
+            />
+          )}
+          {(isChallenger || isOpponent) &&
+            (submitted ? (
+              <Typography>Waiting for your opponent...</Typography>
+            ) : (
+              <Stack direction="row" spacing={1}>
+                <Input
+                  placeholder="Solve"
+                  value={solve}
+                  onChange={(e) => setSolve(e.target.value)}
+                />
+                <Button
+                  onClick={() =>
+                    handleAction(submitBattleSolve(battle.id, solve))
+                  }
+                  disabled={solve === ""}
+                >
+                  Submit
+                </Button>
+              </Stack>
+            ))}
+        </Card>
+      )}
+      {actionError !== "" && <Alert color="danger">{actionError}</Alert>}
+      {battle.attempts.length > 0 && (
+        <Table>
+          <thead>
+            <tr>
+              <th>#</th>
+              <th>{battle.challengerName}</th>
+              <th>{battle.opponentName}</th>
+              <th>Winner</th>
+            </tr>
+          </thead>
+          <tbody>
+            {battle.attempts
+              .filter((a) => a.challengerSolve !== "" || a.opponentSolve !== "")
+              .map((a) => (
+                <tr key={a.order}>
+                  <td>{a.order}</td>
+                  <td>{a.challengerSolve}</td>
+                  <td>{a.opponentSolve}</td>
+                  <td>{winnerName(a.winnerId)}</td>
+                </tr>
+              ))}
+          </tbody>
+        </Table>
+      )}
+    </Stack>
+  );
+};
+
+export default BattleView;
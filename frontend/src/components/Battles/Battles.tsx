@@ -0,0 +1,162 @@
+import {
+  Alert,
+  Button,
+  Card,
+  Input,
+  Option,
+  Select,
+  Stack,
+  Table,
+  Typography,
+} from "@mui/joy";
+import {
+  Battle,
+  BattleChallenge,
+  BattleState,
+  CompetitionEvent,
+  LoadingState,
+} from "../../Types";
+import {
+  getAvailableEvents,
+  getCubingIconClassName,
+  getError,
+  getMyBattles,
+  initialLoadingState,
+  isObjectEmpty,
+  postBattle,
+  renderResponseError,
+} from "../../utils/utils";
+import { Link, useNavigate } from "react-router-dom";
+
+import LoadingComponent from "../Loading/LoadingComponent";
+import { useEffect } from "react";
+import useState from "react-usestateref";
+
+const MAX_BATTLE_ATTEMPTS = 12;
+
+const Battles = () => {
+  const [loadingState, setLoadingState] =
+    useState<LoadingState>(initialLoadingState);
+  const [battles, setBattles] = useState<Battle[]>([]);
+  const [events, setEvents] = useState<CompetitionEvent[]>([]);
+  const [challenge, setChallenge] = useState<BattleChallenge>({
+    opponent: "",
+    eventId: 0,
+    noOfAttempts: 5,
+  });
+  const [challengeError, setChallengeError] = useState<string>("");
+  const navigate = useNavigate();
+
+  useEffect(() => {
+    setLoadingState({ isLoading: true, error: {} });
+
+    Promise.all([getMyBattles(), getAvailableEvents()])
+      .then(([b, e]) => {
+        setBattles(b);
+        setEvents(e);
+        if (e.length > 0) setChallenge((ps) => ({ ...ps, eventId: e[0].id }));
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) => {
+        setLoadingState({ isLoading: false, error: getError(err) });
+      });
+  }, []);
+
+  const handleChallenge = () => {
+    setChallengeError("");
+    postBattle(challenge)
+      .then((b) => navigate(`/battle/${b.id}`))
+      .catch((err) => setChallengeError(err.response?.data ?? err.message));
+  };
+
+  const stateText = (b: Battle) =>
+    b.state === BattleState.Pending
+      ? "Waiting for acceptance"
+      : `${b.challengerWins} : ${b.opponentWins}`;
+
+  return (
+    <Stack sx={{ margin: "1em" }} spacing={2}>
+      <Typography level="h2">Battles</Typography>
+      <Card>
+        <Typography level="h4">Challenge someone</Typography>
+        <Stack direction="row" spacing={1} flexWrap="wrap" useFlexGap>
+          <Input
+            placeholder="WCA ID or name"
+            value={challenge.opponent}
+            onChange={(e) =>
+              setChallenge({ ...challenge, opponent: e.target.value })
+            }
+          />
+          <Select
+            value={challenge.eventId}
+            onChange={(_, val) =>
+              setChallenge({ ...challenge, eventId: val ?? 0 })
+            }
+          >
+            {events.map((e) => (
+              <Option key={e.id} value={e.id}>
+                {e.displayname}
+              </Option>
+            ))}
+          </Select>
+          <Input
+            type="number"
+            slotProps={{ input: { min: 1, max: MAX_BATTLE_ATTEMPTS } }}
+            value={challenge.noOfAttempts}
+            onChange={(e) =>
+              setChallenge({
+                ...challenge,
+                noOfAttempts: parseInt(e.target.value) || 1,
+              })
+            }
+            endDecorator="attempts"
+          />
+          <Button
+            onClick={handleChallenge}
+            disabled={challenge.opponent === "" || challenge.eventId === 0}
+          >
+            Challenge
+          </Button>
+        </Stack>
+        {challengeError !== "" && (
+          <Alert color="danger">{challengeError}</Alert>
+        )}
+      </Card>
+      {loadingState.isLoading ? (
+        <LoadingComponent title="Loading battles..." />
+      ) : !isObjectEmpty(loadingState.error) ? (
+        renderResponseError(loadingState.error)
+      ) : battles.length === 0 ? (
+        <Typography>You have no battles.</Typography>
+      ) : (
+        <Table>
+          <thead>
+            <tr>
+              <th>Challenger</th>
+              <th>Opponent</th>
+              <th>Event</th>
+              <th>State</th>
+            </tr>
+          </thead>
+          <tbody>
+            {battles.map((b) => (
+              <tr key={b.id}>
+                <td>{b.challengerName}</td>
+                <td>{b.opponentName}</td>
+                <td>
+                  <span className={getCubingIconClassName(b.event.iconcode)} />
+                  &nbsp;{b.event.displayname}
+                </td>
+                <td>
+                  <Link to={`/battle/${b.id}`}>{stateText(b)}</Link>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </Table>
+      )}
+    </Stack>
+  );
+};
+
+export default Battles;
@@ -16,6 +16,7 @@ import { useContext, useEffect, useRef, useState } from "react";
 
 import AveragePreview from "../AveragePreview/AveragePreview";
 import { CompetitionContext } from "../../context/CompetitionContext";
+import FMCVerification from "./FMCVerification";
 import LoadingComponent from "../Loading/LoadingComponent";
 import ManualInput from "./ManualInput";
 import ManualInputMBLD from "./ManualInputMBLD";
@@ -40,6 +41,9 @@ const Compete = () => {
   const ismbld =
     competitionState?.events[competitionState?.currentEventIdx]?.iconcode ===
     "333mbf";
+  const isfmc =
+    competitionState?.events[competitionState?.currentEventIdx]?.iconcode ===
+    "333fm";
   const [showResultsModal, setShowResultsModal] = useState<boolean>(false);
   const competeRef = useRef<HTMLDivElement>(null);
 
@@ -141,6 +145,7 @@ const Compete = () => {
             showResultsModal={showResultsModal}
             loadingResults={loadingState.results}
           />
+          {isfmc && <FMCVerification loadingResults={loadingState.results} />}
           <Scramble ismbld={ismbld} />
           <Grid container>
             <Grid
@@ -33,6 +33,7 @@ import CompetitionResults from "./CompetitionResults";
 import CompetitorArea from "./CompetitorArea";
 import { EventSelector } from "./EventSelector";
 import ResultsCompeteChoice from "./ResultsCompeteChoice";
+import { RoundSelector } from "./RoundSelector";
 import { Warning } from "@mui/icons-material";
 import { RESULTS_COMPETE_CHOICE_QUERY_PARAM_NAME } from "../../constants";
 
@@ -192,6 +193,7 @@ const Competition = () => {
             {formatDate(competitionState.enddate)}
           </Typography>
           <EventSelector />
+          <RoundSelector />
           <ResultsCompeteChoice
             resultsCompeteChoice={resultsCompeteChoice}
             setResultsCompeteChoice={setResultsCompeteChoice}
@@ -9,10 +9,12 @@ import {
 } from "@mui/joy";
 import { Comment, Help } from "@mui/icons-material";
 import {
+  competitionOnGoing,
   isObjectEmpty,
   reformatMultiTime,
   renderResponseError,
   getCubingIconClassName,
+  subscribeLiveResults,
 } from "../../utils/utils";
 import { useContext, useEffect } from "react";
 
@@ -28,6 +30,8 @@ const CompetitionResults = () => {
     loadingState,
     fetchCompetitionResults,
     anyComment,
+    setResults,
+    setAnyComment,
   } = useContext(CompetitionContext) as CompetitionContextType;
   const format =
     competitionState?.events[competitionState?.currentEventIdx]?.format;
@@ -87,6 +91,26 @@ const CompetitionResults = () => {
       fetchCompetitionResults();
   }, []);
 
+  useEffect(() => {
+    const event = competitionState?.events[competitionState?.currentEventIdx];
+    if (!event || event.id === -1 || !competitionOnGoing(competitionState))
+      return;
+
+    return subscribeLiveResults(
+      competitionState.id,
+      event.id,
+      competitionState.currentRound,
+      (res) => {
+        setResults(res.results);
+        setAnyComment(res.anyComment);
+      },
+    );
+  }, [
+    competitionState.id,
+    competitionState.currentEventIdx,
+    competitionState.currentRound,
+  ]);
+
   const getColumnAlignment = (idx: number) => {
     switch (idx) {
       case 0:
@@ -206,7 +230,15 @@ const CompetitionResults = () => {
                     : result.times;
                 return (
                   <tr key={idx}>
-                    <td style={{ height: "1em", textAlign: "right" }}>
+                    <td
+                      style={{
+                        height: "1em",
+                        textAlign: "right",
+                        backgroundColor: result.advancing
+                          ? "#C7F7C7"
+                          : undefined,
+                      }}
+                    >
                       {result.place}
                     </td>
                     <td style={{ height: "1em", textAlign: "left" }}>
@@ -0,0 +1,110 @@
+import { Alert, Chip, Stack, Typography } from "@mui/joy";
+import {
+  CompetitionContextType,
+  FMCSolveVerification,
+  LoadingState,
+} from "../../Types";
+import {
+  GetFMCVerification,
+  getError,
+  isObjectEmpty,
+  renderResponseError,
+} from "../../utils/utils";
+import { useContext, useEffect, useState } from "react";
+
+import { CompetitionContext } from "../../context/CompetitionContext";
+
+const FMCVerification: React.FC<{ loadingResults: boolean }> = ({
+  loadingResults,
+}) => {
+  const { competitionState } = useContext(
+    CompetitionContext,
+  ) as CompetitionContextType;
+  const [verifications, setVerifications] = useState<FMCSolveVerification[]>(
+    [],
+  );
+  const [loadingState, setLoadingState] = useState<LoadingState>({
+    isLoading: false,
+    error: {},
+  });
+  const event = competitionState.events[competitionState.currentEventIdx];
+
+  useEffect(() => {
+    if (loadingResults || competitionState.id === undefined || !event) return;
+
+    setLoadingState({ isLoading: true, error: {} });
+    GetFMCVerification(
+      competitionState.id,
+      event.id,
+      competitionState.currentRound,
+    )
+      .then((res) => {
+        setVerifications(res);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) => {
+        setLoadingState({ isLoading: false, error: getError(err) });
+      });
+  }, [
+    loadingResults,
+    competitionState.id,
+    event?.id,
+    competitionState.currentRound,
+  ]);
+
+  if (!isObjectEmpty(loadingState.error))
+    return renderResponseError(loadingState.error);
+
+  const verification = verifications.find(
+    (v) => v.solve === competitionState.currentSolveIdx + 1,
+  );
+  const report = verification?.report;
+  if (!report) return <></>;
+
+  const problems = [
+    ...report.illegalMoves.map(
+      (m) => `Move ${m.position} (${m.move}): ${m.reason}`,
+    ),
+    ...(!report.solved && report.illegalMoves.length === 0
+      ? ["The solution does not solve the scramble."]
+      : []),
+    ...(report.tooLong
+      ? [`The solution has ${report.totalLength} moves, at most 80 are allowed.`]
+      : []),
+  ];
+
+  return (
+    <Stack spacing={1} sx={{ mt: 1, mb: 1 }}>
+      <Typography component="div">
+        <b>Move count:</b>&nbsp;
+        <Chip variant="soft" color={problems.length ? "danger" : "primary"}>
+          {problems.length ? "DNF" : report.moveCount}
+        </Chip>
+      </Typography>
+      <Stack direction="row" flexWrap="wrap" useFlexGap spacing={0.5}>
+        {report.moves.map((m) => (
+          <Chip
+            key={m.position}
+            size="sm"
+            variant={m.counted ? "outlined" : "plain"}
+            title={m.counted ? "" : "Rotations are not counted."}
+          >
+            {m.move}
+          </Chip>
+        ))}
+      </Stack>
+      {problems.map((problem, idx) => (
+        <Alert key={idx} color="danger" size="sm">
+          {problem}
+        </Alert>
+      ))}
+      {report.copiesInverseScramble && (
+        <Alert color="warning" size="sm">
+          The solution looks like the inverse scramble, an admin may check it.
+        </Alert>
+      )}
+    </Stack>
+  );
+};
+
+export default FMCVerification;
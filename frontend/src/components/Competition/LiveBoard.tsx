@@ -0,0 +1,102 @@
+import { CompetitionData, CompetitionResult, LoadingState } from "../../Types";
+import { Stack, Table, Typography } from "@mui/joy";
+import {
+  getCompetitionById,
+  getCubingIconClassName,
+  getError,
+  initialLoadingState,
+  isObjectEmpty,
+  reformatMultiTime,
+  renderResponseError,
+  subscribeLiveResults,
+} from "../../utils/utils";
+import { useParams, useSearchParams } from "react-router-dom";
+
+import LoadingComponent from "../Loading/LoadingComponent";
+import { useEffect } from "react";
+import useState from "react-usestateref";
+
+// LiveBoard shows the results of one round of an event updated in real time,
+// meant to be put on a projector
+const LiveBoard = () => {
+  const { id } = useParams<{ id: string }>();
+  const [searchParams] = useSearchParams();
+  const [loadingState, setLoadingState] =
+    useState<LoadingState>(initialLoadingState);
+  const [competition, setCompetition] = useState<CompetitionData>();
+  const [results, setResults] = useState<CompetitionResult[]>([]);
+  const round = parseInt(searchParams.get("round") || "1");
+  const event = competition?.events.find(
+    (e) => e.iconcode === searchParams.get("event") && e.id !== -1,
+  );
+  const ismbld = event?.iconcode === "333mbf";
+
+  useEffect(() => {
+    setLoadingState({ isLoading: true, error: {} });
+
+    getCompetitionById(id)
+      .then((c) => {
+        setCompetition(c);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) => {
+        setLoadingState({ isLoading: false, error: getError(err) });
+      });
+  }, [id]);
+
+  useEffect(() => {
+    if (!competition || !event) return;
+
+    return subscribeLiveResults(competition.id, event.id, round, (res) =>
+      setResults(res.results),
+    );
+  }, [competition, event?.id, round]);
+
+  if (loadingState.isLoading) return <LoadingComponent title="Loading..." />;
+  if (!isObjectEmpty(loadingState.error))
+    return renderResponseError(loadingState.error);
+  if (!competition || !event)
+    return <Typography level="h3">Event not found.</Typography>;
+
+  return (
+    <Stack sx={{ margin: "1em" }} spacing={2}>
+      <Typography level="h1">
+        <span className={getCubingIconClassName(event.iconcode)} />
+        &nbsp;{competition.name} - {event.displayname}
+        {event.rounds.length > 0 &&
+          (round === event.rounds.length + 1 ? " Final" : ` Round ${round}`)}
+      </Typography>
+      <Table size="lg" sx={{ fontSize: "1.5em" }}>
+        <thead>
+          <tr>
+            <th style={{ width: "10%" }}>#</th>
+            <th>Name</th>
+            {!ismbld && <th>Average</th>}
+            <th>Single</th>
+          </tr>
+        </thead>
+        <tbody>
+          {results.map((result, idx) => (
+            <tr
+              key={idx}
+              style={
+                result.advancing
+                  ? { backgroundColor: "rgba(0, 200, 0, 0.15)" }
+                  : {}
+              }
+            >
+              <td>{result.place}</td>
+              <td>{result.username}</td>
+              {!ismbld && <td>{result.average}</td>}
+              <td>
+                {ismbld ? reformatMultiTime(result.single) : result.single}
+              </td>
+            </tr>
+          ))}
+        </tbody>
+      </Table>
+    </Stack>
+  );
+};
+
+export default LiveBoard;
@@ -1,4 +1,4 @@
-import { CompetitionContextType, ResultEntry } from "../../Types";
+import { CompetitionContextType } from "../../Types";
 import React, { useContext, useEffect, useState } from "react";
 import { competitionOnGoing, reformatTime } from "../../utils/utils";
 
@@ -16,10 +16,9 @@ const ManualInput: React.FC<{
     currentResultsRef,
     competitionStateRef,
   } = useContext(CompetitionContext) as CompetitionContextType;
-  const solveProp: keyof ResultEntry = `solve${
-    competitionState.currentSolveIdx + 1
-  }` as keyof ResultEntry;
-  const formattedTime = currentResultsRef.current[solveProp].toString();
+  const formattedTime =
+    currentResultsRef.current.solves[competitionState.currentSolveIdx] ??
+    "DNS";
   const isfmc =
     competitionState?.events[competitionState?.currentEventIdx]?.iconcode ===
     "333fm";
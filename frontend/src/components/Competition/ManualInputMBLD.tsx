@@ -1,4 +1,4 @@
-import { CompetitionContextType, ResultEntry } from "../../Types";
+import { CompetitionContextType } from "../../Types";
 import { Input, Stack, Typography } from "@mui/joy";
 import { useContext, useEffect } from "react";
 
@@ -11,9 +11,10 @@ const ManualInputMBLD = () => {
   const { competitionStateRef, updateSolve, currentResultsRef } = useContext(
     CompetitionContext,
   ) as CompetitionContextType;
-  const solveProp: keyof ResultEntry = `solve${competitionStateRef.current.currentSolveIdx + 1
-    }` as keyof ResultEntry;
-  const formattedTime = currentResultsRef.current[solveProp].toString();
+  const formattedTime =
+    currentResultsRef.current.solves[
+      competitionStateRef.current.currentSolveIdx
+    ] ?? "DNS";
 
   const [__, setSolvedCubes, solvedCubesRef] = useState(
     formattedTime === "DNS" ? "0" : formattedTime?.split("/")[0],
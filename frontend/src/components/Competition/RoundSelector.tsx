@@ -0,0 +1,37 @@
+import { Button, ButtonGroup, Grid } from "@mui/joy";
+
+import { CompetitionContext } from "../../context/CompetitionContext";
+import { CompetitionContextType } from "../../Types";
+import { useContext } from "react";
+
+export const RoundSelector = () => {
+  const { competitionState, updateCurrentRound, loadingState } = useContext(
+    CompetitionContext,
+  ) as CompetitionContextType;
+
+  const event = competitionState.events[competitionState.currentEventIdx];
+  const noOfRounds = (event?.rounds?.length ?? 0) + 1;
+  if (noOfRounds <= 1) return null;
+
+  return (
+    <Grid container>
+      <ButtonGroup sx={{ flexWrap: "wrap" }}>
+        {Array.from({ length: noOfRounds }, (_, idx) => idx + 1).map(
+          (round) => (
+            <Button
+              key={round}
+              onClick={() => updateCurrentRound(round)}
+              variant={
+                round === competitionState.currentRound ? "solid" : "soft"
+              }
+              color="neutral"
+              disabled={loadingState.results}
+            >
+              {round === noOfRounds ? "Final" : `Round ${round}`}
+            </Button>
+          ),
+        )}
+      </ButtonGroup>
+    </Grid>
+  );
+};
@@ -30,14 +30,15 @@ const Scramble: React.FC<{ ismbld: boolean }> = ({ ismbld }) => {
     competitionState.scrambles.find(
       (s: ScrambleSet) =>
         s.event.displayname ===
-        competitionState.events[competitionState.currentEventIdx].displayname,
+          competitionState.events[competitionState.currentEventIdx]
+            .displayname && s.round === competitionState.currentRound,
     ) !== undefined
       ? (
           competitionState.scrambles.find(
             (s: ScrambleSet) =>
               s.event.displayname ===
-              competitionState.events[competitionState.currentEventIdx]
-                .displayname,
+                competitionState.events[competitionState.currentEventIdx]
+                  .displayname && s.round === competitionState.currentRound,
           ) as ScrambleSet
         ).scrambles[competitionState.currentSolveIdx].scramble
       : "";
@@ -60,13 +61,15 @@ const Scramble: React.FC<{ ismbld: boolean }> = ({ ismbld }) => {
       competitionState.scrambles.find(
         (s: ScrambleSet) =>
           s.event.displayname ===
-          competitionState.events[competitionState.currentEventIdx].displayname,
+            competitionState.events[competitionState.currentEventIdx]
+              .displayname && s.round === competitionState.currentRound,
       ) !== undefined
     ) {
       const scrambleSet = competitionState.scrambles.find(
         (s: ScrambleSet) =>
           s.event.displayname ===
-          competitionState.events[competitionState.currentEventIdx].displayname,
+            competitionState.events[competitionState.currentEventIdx]
+              .displayname && s.round === competitionState.currentRound,
       ) as ScrambleSet;
       setScrambleImg(
         scrambleSet.scrambles[competitionState.currentSolveIdx].img,
@@ -85,6 +88,7 @@ const Scramble: React.FC<{ ismbld: boolean }> = ({ ismbld }) => {
     competitionState.currentSolveIdx,
     competitionState.scrambles,
     competitionState.currentEventIdx,
+    competitionState.currentRound,
   ]);
 
   const [scramblePage, setScramblePage] = useState(0);
@@ -164,7 +168,8 @@ const Scramble: React.FC<{ ismbld: boolean }> = ({ ismbld }) => {
         }}
       >
         {scrambleImgRef === undefined ||
-        scrambleImgRef.current === undefined ? (
+        scrambleImgRef.current === undefined ||
+        scrambleImgRef.current === "" ? (
           <DefaultScramble />
         ) : (
           <img
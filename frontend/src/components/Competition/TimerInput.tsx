@@ -1,6 +1,5 @@
 import {
   CompetitionContextType,
-  ResultEntry,
   TimerInputContextType,
   TimerInputCurrentState,
 } from "../../Types";
@@ -21,11 +20,9 @@ const Timer: React.FC<{
     TimerInputContext,
   ) as TimerInputContextType;
   const formattedTime =
-    currentResultsRef.current[
-      `solve${
-        competitionStateRef.current.currentSolveIdx + 1
-      }` as keyof ResultEntry
-    ].toString();
+    currentResultsRef.current.solves[
+      competitionStateRef.current.currentSolveIdx
+    ] ?? "DNS";
   const location = useLocation();
   const { handleTimerInputKeyDown, handleTimerInputKeyUp } = useContext(
     TimerInputContext,
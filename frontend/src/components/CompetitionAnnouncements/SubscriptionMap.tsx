@@ -5,22 +5,39 @@ import {
   useMapEvents,
   Marker,
   Circle,
+  Polygon,
+  Polyline,
 } from "react-leaflet";
 import "leaflet/dist/leaflet.css";
 import { useEffect } from "react";
 import useState from "react-usestateref";
-import { Stack, Button, Chip, Input, Typography, IconButton } from "@mui/joy";
+import {
+  Stack,
+  Button,
+  Chip,
+  Input,
+  Typography,
+  IconButton,
+  Textarea,
+} from "@mui/joy";
 import { MAX_RADIUS, MIN_RADIUS } from "../../constants";
-import { LoadingState, MarkerType, ResponseError } from "../../Types";
+import {
+  LoadingState,
+  MarkerType,
+  PolygonSubscriptionFeature,
+  ResponseError,
+} from "../../Types";
 import { AxiosError } from "axios";
 import {
   DeleteMarker,
   getError,
   GetMarkers,
+  GetPolygonSubscriptions,
   initialLoadingState,
   isObjectEmpty,
   renderResponseError,
   SaveMarker,
+  SavePolygonSubscriptions,
 } from "../../utils/utils";
 import { Close } from "@mui/icons-material";
 import L from "leaflet";
@@ -42,6 +59,11 @@ const SubscriptionMap = () => {
   const [loadingState, setLoadingState] =
     useState<LoadingState>(initialLoadingState);
   const [markers, setMarkers] = useState<MarkerType[]>([]);
+  const [areas, setAreas] = useState<PolygonSubscriptionFeature[]>([]);
+  const [drawing, setDrawing] = useState(false);
+  const [drawnPoints, setDrawnPoints] = useState<[number, number][]>([]);
+  const [areaName, setAreaName] = useState("");
+  const [geojson, setGeojson] = useState("");
 
   useEffect(() => {
     setLoadingState({
@@ -49,9 +71,10 @@ const SubscriptionMap = () => {
       error: {},
     });
 
-    GetMarkers()
-      .then((res: MarkerType[]) => {
-        setMarkers(res);
+    Promise.all([GetMarkers(), GetPolygonSubscriptions()])
+      .then(([markersRes, areasRes]) => {
+        setMarkers(markersRes);
+        setAreas(areasRes.features);
         setLoadingState({
           isLoading: false,
           error: {},
@@ -77,6 +100,11 @@ const SubscriptionMap = () => {
   const MapClickHandler = () => {
     useMapEvents({
       click: (e) => {
+        if (drawing) {
+          setDrawnPoints((p) => [...p, [e.latlng.lat, e.latlng.lng]]);
+          return;
+        }
+
         const newMarker = {
           id: 0,
           lat: e.latlng.lat,
@@ -178,6 +206,82 @@ const SubscriptionMap = () => {
     }
   };
 
+  // the GeoJSON positions are [longitude, latitude], leaflet wants them swapped
+  const areaPositions = (area: PolygonSubscriptionFeature) => {
+    const polygons =
+      area.geometry.type === "Polygon"
+        ? [area.geometry.coordinates as number[][][]]
+        : (area.geometry.coordinates as number[][][][]);
+    return polygons.map((polygon) =>
+      polygon.map((ring) =>
+        ring.map(([long, lat]) => [lat, long] as [number, number]),
+      ),
+    );
+  };
+
+  const handleAreaFinish = () => {
+    const ring = [...drawnPoints, drawnPoints[0]].map(([lat, long]) => [
+      long,
+      lat,
+    ]);
+    setAreas((p) => [
+      ...p,
+      {
+        type: "Feature",
+        properties: { id: 0, name: areaName },
+        geometry: { type: "Polygon", coordinates: [ring] },
+      },
+    ]);
+    setDrawnPoints([]);
+    setDrawing(false);
+    setAreaName("");
+  };
+
+  const handleAreaDrawCancel = () => {
+    setDrawnPoints([]);
+    setDrawing(false);
+  };
+
+  const handleAreasImport = () => {
+    try {
+      const parsed = JSON.parse(geojson);
+      const features: PolygonSubscriptionFeature[] =
+        parsed.type === "FeatureCollection" ? parsed.features : [parsed];
+      setAreas((p) => [
+        ...p,
+        ...features.map((f) => ({
+          ...f,
+          properties: { id: 0, name: f.properties?.name || "" },
+        })),
+      ]);
+      setGeojson("");
+    } catch {
+      setLoadingState({
+        isLoading: false,
+        error: { message: "Invalid GeoJSON." },
+      });
+    }
+  };
+
+  const handleAreasSave = () => {
+    setLoadingState({
+      isLoading: true,
+      error: {},
+    });
+
+    SavePolygonSubscriptions({ type: "FeatureCollection", features: areas })
+      .then((res) => {
+        setAreas(res.features);
+        setLoadingState({
+          isLoading: false,
+          error: {},
+        });
+      })
+      .catch((err: AxiosError) => {
+        setLoadingState({ isLoading: false, error: customGetError(err) });
+      });
+  };
+
   const formatRadius = (radius: number): string => {
     let newRadius: string = radius.toString();
     while (newRadius.length > 1 && newRadius[0] === "0")
@@ -279,9 +383,100 @@ const SubscriptionMap = () => {
                 />
               </div>
             ))}
+            {areas.map((area, areaIdx) => (
+              <Polygon
+                key={areaIdx + "" + area.properties.id}
+                positions={areaPositions(area)}
+                pathOptions={{ color: "purple" }}
+              >
+                {area.properties.name && (
+                  <Tooltip>{area.properties.name}</Tooltip>
+                )}
+              </Polygon>
+            ))}
+            {drawnPoints.length > 0 && (
+              <Polyline
+                positions={drawnPoints}
+                pathOptions={{ color: "purple" }}
+              />
+            )}
           </MapContainer>
         </div>
       </Stack>
+      <Stack spacing={1}>
+        <Typography level="h4">Areas</Typography>
+        <Typography level="body-sm">
+          Get announcements of the competitions inside the areas. Draw an area
+          by clicking its corners on the map, or paste a GeoJSON (e.g. from
+          geojson.io) with polygons.
+        </Typography>
+        <Stack direction="row" spacing={1} flexWrap="wrap" useFlexGap>
+          {areas.map((area, areaIdx) => (
+            <Chip
+              key={areaIdx + "" + area.properties.id}
+              endDecorator={
+                <IconButton
+                  size="sm"
+                  onClick={() =>
+                    setAreas((p) => p.filter((_, i) => i !== areaIdx))
+                  }
+                >
+                  <Close fontSize="small" />
+                </IconButton>
+              }
+            >
+              {area.properties.name || `Area ${areaIdx + 1}`}
+            </Chip>
+          ))}
+        </Stack>
+        {drawing ? (
+          <Stack direction="row" spacing={1}>
+            <Input
+              size="sm"
+              placeholder="Name"
+              value={areaName}
+              onChange={(e) => setAreaName(e.target.value)}
+            />
+            <Button
+              disabled={drawnPoints.length < 3}
+              onClick={handleAreaFinish}
+            >
+              Finish area
+            </Button>
+            <Button color="neutral" onClick={handleAreaDrawCancel}>
+              Cancel
+            </Button>
+          </Stack>
+        ) : (
+          <Stack direction="row" spacing={1}>
+            <Button variant="soft" onClick={() => setDrawing(true)}>
+              Draw area
+            </Button>
+            <Button
+              color="primary"
+              disabled={loadingState.isLoading}
+              onClick={handleAreasSave}
+            >
+              Save areas!
+            </Button>
+          </Stack>
+        )}
+        <Textarea
+          minRows={2}
+          maxRows={6}
+          placeholder="GeoJSON"
+          value={geojson}
+          onChange={(e) => setGeojson(e.target.value)}
+        />
+        <Button
+          variant="soft"
+          disabled={!geojson.trim()}
+          onClick={handleAreasImport}
+          sx={{ alignSelf: "flex-start" }}
+        >
+          Import GeoJSON
+        </Button>
+      </Stack>
     </Stack>
   );
 };
@@ -2,6 +2,7 @@ import {
   Box,
   Button,
   Card,
+  Checkbox,
   Chip,
   FormControl,
   FormHelperText,
@@ -13,8 +14,10 @@ import {
   Typography,
 } from "@mui/joy";
 import {
+  AdvancementType,
   CompetitionData,
   CompetitionEvent,
+  CompetitionRound,
   CompetitionState,
   ResponseError,
 } from "../../Types";
@@ -97,6 +100,45 @@ const CompetitionEdit: React.FC<CompetitionEditProps> = ({ edit }) => {
     setCompetitionState({ ...competitionState, events: selectedEvents });
   };
 
+  const updateEvent = (eventId: number, update: Partial<CompetitionEvent>) =>
+    setCompetitionState({
+      ...competitionState,
+      events: competitionState.events.map((e) =>
+        e.id === eventId ? { ...e, ...update } : e,
+      ),
+    });
+
+  const secondsToMilliseconds = (value: string) =>
+    Math.round(parseFloat(value || "0") * 1000);
+
+  const updateRound = (
+    ev: CompetitionEvent,
+    number: number,
+    update: Partial<CompetitionRound>,
+  ) =>
+    updateEvent(ev.id, {
+      rounds: ev.rounds.map((r) =>
+        r.number === number ? { ...r, ...update } : r,
+      ),
+    });
+
+  const addRound = (ev: CompetitionEvent) =>
+    updateEvent(ev.id, {
+      rounds: [
+        ...ev.rounds,
+        {
+          number: ev.rounds.length + 2,
+          startdate: competitionState.enddate,
+          advancement: { type: AdvancementType.Ranking, level: 8 },
+          cutoff: { attempts: 0, time: 0 },
+          timeLimit: { time: 0, cumulative: false },
+        },
+      ],
+    });
+
+  const removeRound = (ev: CompetitionEvent) =>
+    updateEvent(ev.id, { rounds: ev.rounds.slice(0, -1) });
+
   const handleSubmit = (e: React.FormEvent) => {
     e.preventDefault();
     if (
@@ -212,6 +254,211 @@ const CompetitionEdit: React.FC<CompetitionEditProps> = ({ edit }) => {
               ))}
             </Select>
           </FormControl>
+          {competitionState.events.some((e) => e.id >= 0) && (
+            <FormControl>
+              <FormLabel>
+                <Typography level="h4">Cutoffs and time limits:</Typography>
+              </FormLabel>
+              <Stack spacing={1}>
+                {competitionState.events
+                  .filter((e) => e.id >= 0)
+                  .map((ev) => (
+                    <Stack
+                      key={ev.id}
+                      direction="row"
+                      spacing={1}
+                      alignItems="center"
+                    >
+                      <Typography sx={{ minWidth: "6em" }}>
+                        <span className={getCubingIconClassName(ev.iconcode)} />
+                        &nbsp;{ev.displayname}
+                      </Typography>
+                      <Input
+                        type="number"
+                        size="sm"
+                        placeholder="Cutoff attempts"
+                        value={ev.cutoff.attempts || ""}
+                        disabled={isLoadingRef.current}
+                        onChange={(e) =>
+                          updateEvent(ev.id, {
+                            cutoff: {
+                              ...ev.cutoff,
+                              attempts: parseInt(e.target.value || "0"),
+                            },
+                          })
+                        }
+                      />
+                      <Input
+                        type="number"
+                        size="sm"
+                        placeholder="Cutoff (s)"
+                        value={ev.cutoff.time ? ev.cutoff.time / 1000 : ""}
+                        disabled={isLoadingRef.current}
+                        onChange={(e) =>
+                          updateEvent(ev.id, {
+                            cutoff: {
+                              ...ev.cutoff,
+                              time: secondsToMilliseconds(e.target.value),
+                            },
+                          })
+                        }
+                      />
+                      <Input
+                        type="number"
+                        size="sm"
+                        placeholder="Time limit (s)"
+                        value={
+                          ev.timeLimit.time ? ev.timeLimit.time / 1000 : ""
+                        }
+                        disabled={isLoadingRef.current}
+                        onChange={(e) =>
+                          updateEvent(ev.id, {
+                            timeLimit: {
+                              ...ev.timeLimit,
+                              time: secondsToMilliseconds(e.target.value),
+                            },
+                          })
+                        }
+                      />
+                      <Checkbox
+                        size="sm"
+                        label="Cumulative"
+                        checked={ev.timeLimit.cumulative}
+                        disabled={isLoadingRef.current}
+                        onChange={(e) =>
+                          updateEvent(ev.id, {
+                            timeLimit: {
+                              ...ev.timeLimit,
+                              cumulative: e.target.checked,
+                            },
+                          })
+                        }
+                      />
+                    </Stack>
+                  ))}
+              </Stack>
+              <FormHelperText>
+                (leave empty for no cutoff or time limit, times are in seconds)
+              </FormHelperText>
+            </FormControl>
+          )}
+          {competitionState.events.some((e) => e.id >= 0) && (
+            <FormControl>
+              <FormLabel>
+                <Typography level="h4">Rounds:</Typography>
+              </FormLabel>
+              <Stack spacing={1}>
+                {competitionState.events
+                  .filter((e) => e.id >= 0)
+                  .map((ev) => (
+                    <Stack key={ev.id} spacing={1}>
+                      <Stack direction="row" spacing={1} alignItems="center">
+                        <Typography sx={{ minWidth: "6em" }}>
+                          <span
+                            className={getCubingIconClassName(ev.iconcode)}
+                          />
+                          &nbsp;{ev.displayname}
+                        </Typography>
+                        <Button
+                          size="sm"
+                          variant="soft"
+                          disabled={isLoadingRef.current}
+                          onClick={() => addRound(ev)}
+                        >
+                          Add round
+                        </Button>
+                        {(ev.rounds ?? []).length > 0 && (
+                          <Button
+                            size="sm"
+                            variant="soft"
+                            color="danger"
+                            disabled={isLoadingRef.current}
+                            onClick={() => removeRound(ev)}
+                          >
+                            Remove last round
+                          </Button>
+                        )}
+                      </Stack>
+                      {(ev.rounds ?? []).map((round) => (
+                        <Stack
+                          key={round.number}
+                          direction="row"
+                          spacing={1}
+                          alignItems="center"
+                          sx={{ pl: 2 }}
+                        >
+                          <Typography sx={{ minWidth: "5em" }}>
+                            Round {round.number}
+                          </Typography>
+                          <Input
+                            type="datetime-local"
+                            size="sm"
+                            value={formatCompetitionDateForInput(
+                              round.startdate,
+                            )}
+                            disabled={isLoadingRef.current}
+                            onChange={(e) =>
+                              !isNaN(Date.parse(e.target.value)) &&
+                              updateRound(ev, round.number, {
+                                startdate: e.target.value,
+                              })
+                            }
+                          />
+                          <Select
+                            size="sm"
+                            value={round.advancement.type}
+                            disabled={isLoadingRef.current}
+                            onChange={(_, val) =>
+                              val &&
+                              updateRound(ev, round.number, {
+                                advancement: { ...round.advancement, type: val },
+                              })
+                            }
+                          >
+                            <Option value={AdvancementType.Ranking}>
+                              Top N
+                            </Option>
+                            <Option value={AdvancementType.Percent}>
+                              Top %
+                            </Option>
+                            <Option value={AdvancementType.Result}>
+                              Result better than (s)
+                            </Option>
+                          </Select>
+                          <Input
+                            type="number"
+                            size="sm"
+                            placeholder="Advancement level"
+                            value={
+                              round.advancement.type === AdvancementType.Result
+                                ? round.advancement.level / 1000 || ""
+                                : round.advancement.level || ""
+                            }
+                            disabled={isLoadingRef.current}
+                            onChange={(e) =>
+                              updateRound(ev, round.number, {
+                                advancement: {
+                                  ...round.advancement,
+                                  level:
+                                    round.advancement.type ===
+                                    AdvancementType.Result
+                                      ? secondsToMilliseconds(e.target.value)
+                                      : parseInt(e.target.value || "0"),
+                                },
+                              })
+                            }
+                          />
+                        </Stack>
+                      ))}
+                    </Stack>
+                  ))}
+              </Stack>
+              <FormHelperText>
+                (the first round starts with the competition, every round ends
+                when the next one starts, at most 75% of competitors advance)
+              </FormHelperText>
+            </FormControl>
+          )}
           <FormControl>
             <Button onClick={handleSubmit} loading={isLoadingRef.current}>
               {edit ? "Edit" : "Create"} competition
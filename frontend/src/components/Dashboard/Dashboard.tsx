@@ -1,76 +1,84 @@
 import { Button, Stack } from "@mui/joy";
+import { PERMISSIONS, hasPermission } from "../../utils/utils";
 
+import { AuthContext } from "../../context/AuthContext";
+import { AuthContextType } from "../../Types";
 import { Link } from "react-router-dom";
+import { useContext } from "react";
 
 const Dashboard = () => {
+  const { authState } = useContext(AuthContext) as AuthContextType;
+
+  const links: { to: string; title: string; permission: string }[] = [
+    {
+      to: "/admin/stats",
+      title: "Show stats",
+      permission: PERMISSIONS.STATS_VIEW,
+    },
+    {
+      to: "/admin/subscriptions",
+      title: "Subscriptions",
+      permission: PERMISSIONS.STATS_VIEW,
+    },
+    {
+      to: "/admin/emails",
+      title: "Emails",
+      permission: PERMISSIONS.STATS_VIEW,
+    },
+    {
+      to: "/admin/manage-users",
+      title: "Manage users",
+      permission: PERMISSIONS.USERS_MANAGE,
+    },
+    {
+      to: "/admin/merge-duplicate-users",
+      title: "Merge duplicate users",
+      permission: PERMISSIONS.USERS_MANAGE,
+    },
+    {
+      to: "/competition/create",
+      title: "Create competition",
+      permission: PERMISSIONS.COMPETITIONS_MANAGE,
+    },
+    {
+      to: "/results/edit",
+      title: "Edit results",
+      permission: PERMISSIONS.RESULTS_EDIT,
+    },
+    {
+      to: "/announcement/create",
+      title: "Create announcement",
+      permission: PERMISSIONS.ANNOUNCEMENTS_MANAGE,
+    },
+  ];
+
   return (
     <div style={{ margin: "1em" }}>
       <Stack direction="column" spacing={1}>
-        <Button
-          component={Link}
-          to="/admin/stats"
-          color="primary"
-          variant="outlined"
-        >
-          Show stats
-        </Button>
-        <Button
-          component={Link}
-          to="/admin/subscriptions"
-          color="primary"
-          variant="outlined"
-        >
-          Subscriptions
-        </Button>
-        <Button
-          component={Link}
-          to={import.meta.env.VITE_MONITORING_PATH}
-          reloadDocument
-          color="primary"
-          variant="outlined"
-        >
-          Monitoring
-        </Button>
-        <Button
-          component={Link}
-          to="/admin/manage-users"
-          color="primary"
-          variant="outlined"
-        >
-          Manage users
-        </Button>
-        <Button
-          component={Link}
-          to="/admin/merge-duplicate-users"
-          color="primary"
-          variant="outlined"
-        >
-          Merge duplicate users
-        </Button>
-        <Button
-          component={Link}
-          to="/competition/create"
-          color="primary"
-          variant="outlined"
-        >
-          Create competition
-        </Button>
-        <Button
-          component={Link}
-          to="/results/edit"
-          color="primary"
-          variant="outlined"
-        >
-          Edit results
-        </Button>
-        <Button
-          component={Link}
-          to="/announcement/create"
-          color="primary"
-          variant="outlined"
-        >
-          Create announcement
-        </Button>
+        {hasPermission(authState, PERMISSIONS.STATS_VIEW) && (
+          <Button
+            component={Link}
+            to={import.meta.env.VITE_MONITORING_PATH}
+            reloadDocument
+            color="primary"
+            variant="outlined"
+          >
+            Monitoring
+          </Button>
+        )}
+        {links
+          .filter((link) => hasPermission(authState, link.permission))
+          .map((link) => (
+            <Button
+              key={link.to}
+              component={Link}
+              to={link.to}
+              color="primary"
+              variant="outlined"
+            >
+              {link.title}
+            </Button>
+          ))}
       </Stack>
     </div>
   );
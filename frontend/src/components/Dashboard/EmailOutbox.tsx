@@ -0,0 +1,115 @@
+import {
+  Button,
+  CircularProgress,
+  Option,
+  Select,
+  Stack,
+  Table,
+  Typography,
+} from "@mui/joy";
+import { AuthContextType, LoadingState, OutboxEmail } from "../../Types";
+import {
+  PERMISSIONS,
+  getError,
+  getOutboxEmails,
+  hasPermission,
+  isObjectEmpty,
+  renderResponseError,
+  retryOutboxEmail,
+} from "../../utils/utils";
+import { useContext, useEffect, useState } from "react";
+
+import { AuthContext } from "../../context/AuthContext";
+
+const EmailOutbox = () => {
+  const { authState } = useContext(AuthContext) as AuthContextType;
+  const [loadingState, setLoadingState] = useState<LoadingState>({
+    isLoading: true,
+    error: {},
+  });
+  const [status, setStatus] = useState("");
+  const [emails, setEmails] = useState<OutboxEmail[]>([]);
+
+  const loadEmails = () => {
+    setLoadingState({ isLoading: true, error: {} });
+    getOutboxEmails(status)
+      .then((res) => {
+        setEmails(res);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  useEffect(loadEmails, [status]);
+
+  const handleRetry = (e: OutboxEmail) => {
+    retryOutboxEmail(e.id)
+      .then(loadEmails)
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  return (
+    <Stack spacing={2} sx={{ margin: "1em" }}>
+      <Typography level="h2" className="bottom-divider">
+        Emails
+      </Typography>
+      <Select
+        value={status}
+        onChange={(_, value) => setStatus(value || "")}
+        sx={{ maxWidth: "15em" }}
+      >
+        <Option value="">All</Option>
+        <Option value="pending">Pending</Option>
+        <Option value="sent">Sent</Option>
+        <Option value="failed">Failed</Option>
+      </Select>
+
+      {!isObjectEmpty(loadingState.error) &&
+        renderResponseError(loadingState.error)}
+
+      {loadingState.isLoading ? (
+        <CircularProgress />
+      ) : (
+        <Table>
+          <thead>
+            <tr>
+              <th>Created</th>
+              <th>To</th>
+              <th>Subject</th>
+              <th>Status</th>
+              <th>Attempts</th>
+              <th>Last error</th>
+              <th></th>
+            </tr>
+          </thead>
+          <tbody>
+            {emails.map((e) => (
+              <tr key={e.id}>
+                <td>{new Date(e.createdAt).toLocaleString()}</td>
+                <td>{e.message.to}</td>
+                <td>{e.message.subject}</td>
+                <td>{e.status}</td>
+                <td>{e.attempts}</td>
+                <td>{e.lastError}</td>
+                <td>
+                  {e.status === "failed" &&
+                    hasPermission(authState, PERMISSIONS.USERS_MANAGE) && (
+                      <Button size="sm" onClick={() => handleRetry(e)}>
+                        Retry
+                      </Button>
+                    )}
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </Table>
+      )}
+    </Stack>
+  );
+};
+
+export default EmailOutbox;
@@ -1,8 +1,9 @@
 import { Card, Stack, Switch, Table, Typography } from "@mui/joy";
-import { LoadingState, ManageUser } from "../../Types";
+import { LoadingState, ManageUser, Role } from "../../Types";
 import {
   getError,
   getManageUsers,
+  getRoles,
   initialLoadingState,
   renderResponseError,
   updateUserRoles,
@@ -14,15 +15,17 @@ import LoadingComponent from "../Loading/LoadingComponent";
 
 const ManageRoles = () => {
   const [users, setUsers] = useState<ManageUser[]>([]);
+  const [roles, setRoles] = useState<Role[]>([]);
   const [loadingState, setLoadingState] =
     useState<LoadingState>(initialLoadingState);
 
   useEffect(() => {
     setLoadingState({ isLoading: true, error: {} });
 
-    getManageUsers()
-      .then((res: ManageUser[]) => {
+    Promise.all([getManageUsers(), getRoles()])
+      .then(([res, roles]: [ManageUser[], Role[]]) => {
         setUsers(res.sort((u1: ManageUser, u2: ManageUser) => u2.id - u1.id));
+        setRoles(roles);
         setLoadingState({ isLoading: false, error: {} });
       })
       .catch((err) => {
@@ -31,18 +34,21 @@ const ManageRoles = () => {
   }, []);
 
   const handleUserRoleChange =
-    (users_idx: number) => (e: React.ChangeEvent<HTMLInputElement>) => {
+    (users_idx: number, role: string) =>
+    (e: React.ChangeEvent<HTMLInputElement>) => {
       const checked: boolean = e.target.checked;
       const user = users[users_idx];
-      user.is_admin = checked;
+      const newRoles = checked
+        ? [...user.roles, role]
+        : user.roles.filter((r) => r !== role);
 
       setLoadingState({ isLoading: true, error: {} });
-      updateUserRoles(user)
+      updateUserRoles({ ...user, roles: newRoles })
         .then((_) => {
           setUsers(
             users.map(
               (u: ManageUser, idx: number): ManageUser =>
-                idx === users_idx ? { ...u, is_admin: checked } : { ...u },
+                idx === users_idx ? { ...u, roles: newRoles } : { ...u },
             ),
           );
           setLoadingState({ isLoading: false, error: {} });
@@ -52,7 +58,12 @@ const ManageRoles = () => {
         });
     };
 
-  const columnNames = () => ["User no.", "Name", "Country", "Is Admin?"];
+  const columnNames = () => [
+    "User no.",
+    "Name",
+    "Country",
+    ...roles.map((role) => role.displayName),
+  ];
 
   return (
     <Stack style={{ padding: 16 }} spacing={2}>
@@ -119,13 +130,18 @@ const ManageRoles = () => {
                       />
                       &nbsp;&nbsp;{user.country_name}
                     </td>
-                    <td style={{ height: "1em", textAlign: "left" }}>
-                      <Switch
-                        disabled={loadingState.isLoading}
-                        checked={user.is_admin}
-                        onChange={handleUserRoleChange(idx)}
-                      />
-                    </td>
+                    {roles.map((role) => (
+                      <td
+                        key={role.name}
+                        style={{ height: "1em", textAlign: "left" }}
+                      >
+                        <Switch
+                          disabled={loadingState.isLoading}
+                          checked={user.roles.includes(role.name)}
+                          onChange={handleUserRoleChange(idx, role.name)}
+                        />
+                      </td>
+                    ))}
                   </tr>
                 );
               })}
@@ -7,17 +7,27 @@ import {
   CircularProgress,
   Divider,
   Stack,
+  Table,
   Typography,
 } from "@mui/joy";
 import {
   findDuplicateUser,
+  getDuplicateUsers,
   getError,
   getSearchUsers,
+  getUserMerges,
   isObjectEmpty,
   mergeUsers,
   renderResponseError,
+  unmergeUsers,
 } from "../../utils/utils";
-import { LoadingState, ManageUser, User } from "../../Types";
+import {
+  DuplicateUserPair,
+  LoadingState,
+  ManageUser,
+  User,
+  UserMerge,
+} from "../../Types";
 import { useEffect, useState } from "react";
 import { Link } from "react-router-dom";
 
@@ -31,6 +41,19 @@ const MergeUsers = () => {
   const [selectedUser, setSelectedUser] = useState<ManageUser | null>(null);
   const [duplicateUser, setDuplicateUser] = useState<User | null>(null);
   const [noDuplicateFound, setNoDuplicateFound] = useState(false);
+  const [merges, setMerges] = useState<UserMerge[]>([]);
+  const [duplicates, setDuplicates] = useState<DuplicateUserPair[]>([]);
+  const [duplicatesLoaded, setDuplicatesLoaded] = useState(false);
+
+  const loadMerges = () => {
+    getUserMerges()
+      .then(setMerges)
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  useEffect(loadMerges, []);
 
   useEffect(() => {
     if (searchQuery.length < 2) {
@@ -80,6 +103,70 @@ const MergeUsers = () => {
         setDuplicateUser(null);
         setNoDuplicateFound(false);
         setLoadingState({ isLoading: false, error: {} });
+        loadMerges();
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  const loadDuplicates = () => {
+    setLoadingState({ isLoading: true, error: {} });
+    getDuplicateUsers()
+      .then((res) => {
+        setDuplicates(res);
+        setDuplicatesLoaded(true);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  const handleMergePair = (pair: DuplicateUserPair) => {
+    if (
+      !confirm(
+        `Do you really want to merge ${pair.oldUser.name} (${pair.oldUser.id}) into ${pair.newUser.name} (${pair.newUser.id})?`,
+      )
+    )
+      return;
+    setLoadingState({ isLoading: true, error: {} });
+
+    mergeUsers(pair.oldUser.id, pair.newUser.id)
+      .then(() => {
+        setDuplicates((prev) =>
+          prev.filter(
+            (p) =>
+              ![p.oldUser.id, p.newUser.id].includes(pair.oldUser.id),
+          ),
+        );
+        setLoadingState({ isLoading: false, error: {} });
+        loadMerges();
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  const renderPairUser = (user: ManageUser) => (
+    <Link to={`/profile/${user.wca_id || user.name}`}>
+      {user.name} ({user.id})
+    </Link>
+  );
+
+  const handleUnmerge = (merge: UserMerge) => {
+    if (
+      !confirm(
+        `Do you really want to undo the merge of ${merge.oldUser.name} (${merge.oldUserId}) into user ${merge.newUserId}?`,
+      )
+    )
+      return;
+    setLoadingState({ isLoading: true, error: {} });
+
+    unmergeUsers(merge.id)
+      .then(() => {
+        setLoadingState({ isLoading: false, error: {} });
+        loadMerges();
       })
       .catch((err) =>
         setLoadingState({ isLoading: false, error: getError(err) }),
@@ -153,7 +240,8 @@ const MergeUsers = () => {
             <Typography>
               A potential duplicate has been found. All data from the user with
               the lower ID will be moved to the user with the higher ID, and the
-              old user will be deleted. This action cannot be undone.
+              old user will be deleted. The merge can be undone in the merge
+              history below.
             </Typography>
             <Box display="flex" gap={2}>
               {renderUserCard(selectedUser, "Selected User")}
@@ -170,6 +258,112 @@ const MergeUsers = () => {
           </Stack>
         </Card>
       )}
+
+      <Card>
+        <Stack spacing={2}>
+          <Typography level="h3">Duplicates report</Typography>
+          <Typography>
+            Pairs of users scored by name similarity, country, WCA ID and the
+            competitions they took part in, the most likely duplicates first.
+          </Typography>
+          <Button onClick={loadDuplicates} loading={loadingState.isLoading}>
+            {duplicatesLoaded ? "Refresh report" : "Find all duplicates"}
+          </Button>
+          {duplicatesLoaded && duplicates.length === 0 && (
+            <Typography>No likely duplicates found.</Typography>
+          )}
+          {duplicates.length > 0 && (
+            <Table size="sm">
+              <thead>
+                <tr>
+                  <th>Score</th>
+                  <th>Old user</th>
+                  <th>New user</th>
+                  <th>Name</th>
+                  <th>Country</th>
+                  <th>WCA ID</th>
+                  <th>Events</th>
+                  <th>Shared comps</th>
+                  <th></th>
+                </tr>
+              </thead>
+              <tbody>
+                {duplicates.map((pair) => (
+                  <tr key={`${pair.oldUser.id}-${pair.newUser.id}`}>
+                    <td>
+                      <b>{pair.score.toFixed(1)}</b>
+                    </td>
+                    <td>{renderPairUser(pair.oldUser)}</td>
+                    <td>{renderPairUser(pair.newUser)}</td>
+                    <td>{Math.round(pair.nameSimilarity * 100)}%</td>
+                    <td>{pair.sameCountry ? "same" : "different"}</td>
+                    <td>{pair.sameWcaId ? "same" : "-"}</td>
+                    <td>{Math.round(pair.eventOverlap * 100)}%</td>
+                    <td>{pair.sharedCompetitions}</td>
+                    <td>
+                      <Button
+                        size="sm"
+                        variant="outlined"
+                        color="danger"
+                        onClick={() => handleMergePair(pair)}
+                        loading={loadingState.isLoading}
+                      >
+                        Merge
+                      </Button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </Table>
+          )}
+        </Stack>
+      </Card>
+
+      <Card>
+        <Stack spacing={2}>
+          <Typography level="h3">Merge history</Typography>
+          {merges.length === 0 ? (
+            <Typography>No users have been merged yet.</Typography>
+          ) : (
+            <Table size="sm">
+              <thead>
+                <tr>
+                  <th>Merged at</th>
+                  <th>Old user</th>
+                  <th>New user ID</th>
+                  <th></th>
+                </tr>
+              </thead>
+              <tbody>
+                {merges.map((merge) => (
+                  <tr key={merge.id}>
+                    <td>{new Date(merge.mergedAt).toLocaleString()}</td>
+                    <td>
+                      {merge.oldUser.name} ({merge.oldUserId})
+                    </td>
+                    <td>{merge.newUserId}</td>
+                    <td>
+                      {merge.unmergedAt ? (
+                        `Undone ${new Date(merge.unmergedAt).toLocaleString()}`
+                      ) : (
+                        <Button
+                          size="sm"
+                          variant="outlined"
+                          color="warning"
+                          onClick={() => handleUnmerge(merge)}
+                          loading={loadingState.isLoading}
+                        >
+                          Unmerge
+                        </Button>
+                      )}
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </Table>
+          )}
+        </Stack>
+      </Card>
     </Stack>
   );
 };
@@ -112,10 +112,17 @@ const ResultsEdit = () => {
   const updateSolve = (
     newTime: string,
     resultsIdx: number,
-    solveProp: string,
+    solveIdx: number,
   ) => {
     const newResults = results.map((val, idx) =>
-      idx === resultsIdx ? { ...val, [solveProp]: newTime } : { ...val },
+      idx === resultsIdx
+        ? {
+            ...val,
+            solves: val.solves.map((solve, i) =>
+              i === solveIdx ? newTime : solve,
+            ),
+          }
+        : { ...val },
     );
     setResults(newResults);
   };
@@ -124,10 +131,10 @@ const ResultsEdit = () => {
     newValue: string,
     oldValue: string,
     resultsIdx: number,
-    solveProp: string,
+    solveIdx: number,
   ) => {
     if (results[resultsIdx].eventname === "FMC") {
-      updateSolve(newValue, resultsIdx, solveProp);
+      updateSolve(newValue, resultsIdx, solveIdx);
       return;
     }
 
@@ -136,31 +143,28 @@ const ResultsEdit = () => {
     // character deleted
     if (newValue.length + 1 === oldValue.length) {
       if (newValue.endsWith("N")) {
-        updateSolve("0.00", resultsIdx, solveProp);
+        updateSolve("0.00", resultsIdx, solveIdx);
         return;
       } else {
-        updateSolve(reformatTime(newValue), resultsIdx, solveProp);
+        updateSolve(reformatTime(newValue), resultsIdx, solveIdx);
       }
     } else {
       if (newValue.endsWith("d")) {
-        updateSolve("DNF", resultsIdx, solveProp);
+        updateSolve("DNF", resultsIdx, solveIdx);
       } else if (newValue.endsWith("s")) {
-        updateSolve("DNS", resultsIdx, solveProp);
+        updateSolve("DNS", resultsIdx, solveIdx);
       } else if (/\d$/.test(newValue.slice(-1))) {
-        updateSolve(reformatTime(newValue, true), resultsIdx, solveProp);
+        updateSolve(reformatTime(newValue, true), resultsIdx, solveIdx);
       } else {
-        updateSolve("DNF", resultsIdx, solveProp);
+        updateSolve("DNF", resultsIdx, solveIdx);
       }
     }
   };
 
-  const getSolveProps = (resultsIdx: number): string[] => {
+  const getSolveIndices = (resultsIdx: number): number[] => {
     const match = results[resultsIdx].format.match(/\d+$/)?.[0];
     const noOfSolves = match ? parseInt(match) : 1;
-    return ["solve1", "solve2", "solve3", "solve4", "solve5"].slice(
-      0,
-      noOfSolves,
-    );
+    return [...Array(noOfSolves).keys()];
   };
 
   const saveResult = async (resultsIdx: number) => {
@@ -416,26 +420,25 @@ const ResultsEdit = () => {
                       </Grid>
                       <Grid xs={6}>
                         <Stack spacing={1}>
-                          {getSolveProps(resultIdx).map(
-                            (solveProp, solveIdx) => {
+                          {getSolveIndices(resultIdx).map(
+                            (solveIdx) => {
                               return (
-                                <FormControl key={solveProp}>
+                                <FormControl key={solveIdx}>
                                   <FormLabel>Solve {solveIdx + 1}</FormLabel>
                                   <Input
                                     autoFocus
                                     size="sm"
                                     placeholder="Enter your time or solution..."
-                                    value={results[resultIdx][
-                                      solveProp as keyof ResultEntry
-                                    ].toString()}
+                                    value={
+                                      results[resultIdx].solves[solveIdx] ??
+                                      "DNS"
+                                    }
                                     onChange={(e) =>
                                       handleTimeInputChange(
                                         e.target.value,
-                                        result[
-                                          solveProp as keyof ResultEntry
-                                        ].toString(),
+                                        result.solves[solveIdx] ?? "DNS",
                                         resultIdx,
-                                        solveProp,
+                                        solveIdx,
                                       )
                                     }
                                   />
@@ -1,11 +1,27 @@
-import { Alert, CircularProgress, Grid, Typography } from "@mui/joy";
+import {
+  Alert,
+  Button,
+  Card,
+  CircularProgress,
+  Grid,
+  Input,
+  Typography,
+} from "@mui/joy";
 import { AuthContextType, AuthState } from "../../Types";
-import { getError, logIn } from "../../utils/utils";
+import {
+  WCA_LINK_STATE,
+  getError,
+  isRegistrationError,
+  linkWCAAccount,
+  logIn,
+  logInByEmail,
+} from "../../utils/utils";
 import { useContext, useEffect, useState } from "react";
-import { useNavigate, useSearchParams } from "react-router-dom";
+import { useLocation, useNavigate, useSearchParams } from "react-router-dom";
 
 import { AuthContext } from "../../context/AuthContext";
 import Cookies from "universal-cookie";
+import LogInOptions from "./LogInOptions";
 
 const LogIn = () => {
   const { setAuthState } = useContext(AuthContext) as AuthContextType;
@@ -13,12 +29,22 @@ const LogIn = () => {
     loading: boolean;
     error: any;
   }>({ loading: false, error: "" });
+  const [name, setName] = useState("");
   const [searchParams, _] = useSearchParams();
+  const location = useLocation();
   const navigate = useNavigate();
 
-  useEffect(() => {
+  const byEmail = location.pathname === "/login/email";
+  const hasCredentials = searchParams.has(byEmail ? "token" : "code");
+
+  const handleLogIn = (name: string) => {
     setLoadingState({ loading: true, error: "" });
-    logIn(searchParams)
+    const login = byEmail
+      ? (searchParams: URLSearchParams) => logInByEmail(searchParams, name)
+      : searchParams.get("state") === WCA_LINK_STATE
+        ? linkWCAAccount
+        : logIn;
+    login(searchParams)
       .then((res: AuthState) => {
         setAuthState(res);
 
@@ -30,8 +56,45 @@ const LogIn = () => {
       .catch((err) => {
         setLoadingState({ loading: false, error: getError(err) });
       });
+  };
+
+  useEffect(() => {
+    if (!hasCredentials) return;
+
+    handleLogIn("");
   }, []);
 
+  if (!hasCredentials) return <LogInOptions />;
+
+  if (byEmail && loadingState.error && isRegistrationError(loadingState.error))
+    return (
+      <Card
+        variant="outlined"
+        sx={{ margin: "1em", maxWidth: "30em", mx: "auto" }}
+      >
+        <Typography level="h3">Welcome!</Typography>
+        <Typography>
+          No user has this email yet. Fill in your name to register, you can
+          link your WCA account later.
+        </Typography>
+        <Input
+          placeholder="Name"
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+        />
+        <Button
+          onClick={() => handleLogIn(name)}
+          loading={loadingState.loading}
+          disabled={!name.trim()}
+        >
+          Register
+        </Button>
+        {loadingState.error.status === 409 && (
+          <Alert color="danger">{loadingState.error.message}</Alert>
+        )}
+      </Card>
+    );
+
   return (
     <Grid
       container
@@ -59,11 +122,10 @@ const LogIn = () => {
         ) : (
           <>
             <Alert color="danger" sx={{ gap: 0 }}>
-              Oops. Something went wrong. Please&nbsp;
-              <a href={import.meta.env.VITE_WCA_GET_CODE_URL || ""}>
-                try again
-              </a>
-              .
+              {loadingState.error.message ||
+                "Oops. Something went wrong."}
+              &nbsp;Please&nbsp;
+              <a href="/login">try again</a>.
             </Alert>
           </>
         )}
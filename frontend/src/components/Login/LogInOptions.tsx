@@ -0,0 +1,76 @@
+import {
+  Alert,
+  Button,
+  Card,
+  Divider,
+  Input,
+  Stack,
+  Typography,
+} from "@mui/joy";
+import {
+  getError,
+  isObjectEmpty,
+  renderResponseError,
+  requestLoginLink,
+} from "../../utils/utils";
+import { LoadingState } from "../../Types";
+import { useState } from "react";
+
+const LogInOptions = () => {
+  const [loadingState, setLoadingState] = useState<LoadingState>({
+    isLoading: false,
+    error: {},
+  });
+  const [email, setEmail] = useState("");
+  const [sentMessage, setSentMessage] = useState("");
+
+  const handleSendLink = () => {
+    setLoadingState({ isLoading: true, error: {} });
+    setSentMessage("");
+
+    requestLoginLink(email)
+      .then((res) => {
+        setSentMessage(res);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  return (
+    <Stack spacing={2} sx={{ margin: "1em", maxWidth: "30em", mx: "auto" }}>
+      <Typography level="h2" className="bottom-divider">
+        Log in
+      </Typography>
+      <Button component="a" href={import.meta.env.VITE_WCA_GET_CODE_URL || ""}>
+        Log in with WCA
+      </Button>
+      <Divider>or without a WCA account</Divider>
+      <Card variant="outlined">
+        <Typography>
+          We will email you a link to log in. If you are logging in for the
+          first time, you will fill in your name after opening it.
+        </Typography>
+        <Input
+          type="email"
+          placeholder="Email"
+          value={email}
+          onChange={(e) => setEmail(e.target.value)}
+        />
+        <Button
+          onClick={handleSendLink}
+          loading={loadingState.isLoading}
+          disabled={!email.trim()}
+        >
+          Send login link
+        </Button>
+        {sentMessage && <Alert color="success">{sentMessage}</Alert>}
+        {!isObjectEmpty(loadingState.error) &&
+          renderResponseError(loadingState.error)}
+      </Card>
+    </Stack>
+  );
+};
+
+export default LogInOptions;
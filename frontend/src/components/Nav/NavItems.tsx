@@ -79,7 +79,7 @@ const NavItems: React.FC<{
       ) : (
         <ListItemButton
           component={Link}
-          to={import.meta.env.VITE_WCA_GET_CODE_URL || ""}
+          to="/login"
           onClick={() => {
             saveCurrentLocation();
             closeNav();
@@ -3,6 +3,7 @@ import {
   FormatListNumbered,
   Leaderboard,
   Map,
+  SportsKabaddi,
 } from "@mui/icons-material";
 
 import ListItemDropdown from "../ListItemDropdown";
@@ -29,6 +30,11 @@ const ResultsListItem = () => {
             url: "/results/users",
             icon: Map,
           },
+          {
+            text: "Battles",
+            url: "/battles",
+            icon: SportsKabaddi,
+          },
         ],
       }}
     />
@@ -0,0 +1,208 @@
+import {
+  Alert,
+  Button,
+  Card,
+  Checkbox,
+  CircularProgress,
+  Input,
+  Stack,
+  Table,
+  Typography,
+} from "@mui/joy";
+import {
+  API_TOKEN_SCOPES,
+  createApiToken,
+  getApiTokens,
+  getError,
+  getMyCalendarUrl,
+  isObjectEmpty,
+  renderResponseError,
+  revokeApiToken,
+} from "../../utils/utils";
+import { ApiToken, AuthContextType, LoadingState } from "../../Types";
+import { useContext, useEffect, useState } from "react";
+
+import { AuthContext } from "../../context/AuthContext";
+import { Navigate } from "react-router-dom";
+
+const ApiTokens = () => {
+  const { authState } = useContext(AuthContext) as AuthContextType;
+  const [loadingState, setLoadingState] = useState<LoadingState>({
+    isLoading: true,
+    error: {},
+  });
+  const [tokens, setTokens] = useState<ApiToken[]>([]);
+  const [name, setName] = useState("");
+  const [scopes, setScopes] = useState<string[]>([]);
+  const [expiresInDays, setExpiresInDays] = useState(90);
+  const [createdToken, setCreatedToken] = useState("");
+  const [createdScopes, setCreatedScopes] = useState<string[]>([]);
+
+  const loadTokens = () => {
+    getApiTokens()
+      .then((res) => {
+        setTokens(res);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  useEffect(() => {
+    if (authState.token) loadTokens();
+  }, [authState.token]);
+
+  if (!authState.token) return <Navigate to="/login" />;
+
+  const toggleScope = (scope: string, checked: boolean) =>
+    setScopes((prev) =>
+      checked ? [...prev, scope] : prev.filter((s) => s !== scope),
+    );
+
+  const handleCreate = () => {
+    setLoadingState({ isLoading: true, error: {} });
+    setCreatedToken("");
+
+    createApiToken(name, scopes, expiresInDays)
+      .then((res) => {
+        setCreatedToken(res.token);
+        setCreatedScopes(res.scopes);
+        setName("");
+        setScopes([]);
+        loadTokens();
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  const handleRevoke = (token: ApiToken) => {
+    if (!confirm(`Do you really want to revoke the token ${token.name}?`))
+      return;
+    setLoadingState({ isLoading: true, error: {} });
+
+    revokeApiToken(token.id)
+      .then(loadTokens)
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  const formatDate = (date: string | null) =>
+    date ? new Date(date).toLocaleString() : "-";
+
+  return (
+    <Stack spacing={2} sx={{ margin: "1em" }}>
+      <Typography level="h2" className="bottom-divider">
+        Personal access tokens
+      </Typography>
+      <Typography>
+        Tokens let other tools (e.g. timers) read and submit your results. Send
+        them in the Authorization header as <code>Bearer &lt;token&gt;</code>.
+        Tokens with the <code>calendar:read</code> scope give you a calendar
+        feed with the competitions you follow.
+      </Typography>
+
+      {!isObjectEmpty(loadingState.error) &&
+        renderResponseError(loadingState.error)}
+
+      <Card variant="outlined">
+        <Typography level="h4">New token</Typography>
+        <Input
+          placeholder="Name"
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+        />
+        <Stack direction="row" spacing={2} flexWrap="wrap">
+          {API_TOKEN_SCOPES.map((scope) => (
+            <Checkbox
+              key={scope}
+              label={scope}
+              checked={scopes.includes(scope)}
+              onChange={(e) => toggleScope(scope, e.target.checked)}
+            />
+          ))}
+        </Stack>
+        <Input
+          type="number"
+          startDecorator="Expires in days (0 = never)"
+          value={expiresInDays}
+          onChange={(e) => setExpiresInDays(Math.max(0, +e.target.value))}
+        />
+        <Button
+          onClick={handleCreate}
+          disabled={loadingState.isLoading || !name.trim() || !scopes.length}
+        >
+          Create token
+        </Button>
+        {createdToken && (
+          <Alert color="success">
+            <Stack spacing={1}>
+              <Typography>
+                Copy the token now, it will not be shown again.
+              </Typography>
+              <Typography fontFamily="monospace" sx={{ wordBreak: "break-all" }}>
+                {createdToken}
+              </Typography>
+              {createdScopes.includes("calendar:read") && (
+                <>
+                  <Typography>
+                    Subscribe to this URL in your calendar app:
+                  </Typography>
+                  <Typography
+                    fontFamily="monospace"
+                    sx={{ wordBreak: "break-all" }}
+                  >
+                    {getMyCalendarUrl(createdToken)}
+                  </Typography>
+                </>
+              )}
+            </Stack>
+          </Alert>
+        )}
+      </Card>
+
+      {loadingState.isLoading ? (
+        <CircularProgress />
+      ) : (
+        <Table>
+          <thead>
+            <tr>
+              <th>Name</th>
+              <th>Token</th>
+              <th>Scopes</th>
+              <th>Last used</th>
+              <th>Expires</th>
+              <th></th>
+            </tr>
+          </thead>
+          <tbody>
+            {tokens.map((token) => (
+              <tr key={token.id}>
+                <td>{token.name}</td>
+                <td>
+                  <code>{token.prefix}...</code>
+                </td>
+                <td>{token.scopes.join(", ")}</td>
+                <td>{formatDate(token.lastUsedAt)}</td>
+                <td>{formatDate(token.expiresAt)}</td>
+                <td>
+                  <Button
+                    color="danger"
+                    size="sm"
+                    onClick={() => handleRevoke(token)}
+                  >
+                    Revoke
+                  </Button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </Table>
+      )}
+    </Stack>
+  );
+};
+
+export default ApiTokens;
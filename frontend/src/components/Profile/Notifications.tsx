@@ -0,0 +1,100 @@
+import {
+  CircularProgress,
+  List,
+  ListItem,
+  Stack,
+  Switch,
+  Typography,
+} from "@mui/joy";
+import {
+  NOTIFICATION_CATEGORIES,
+  getError,
+  getNotificationPreferences,
+  isObjectEmpty,
+  renderResponseError,
+  setNotificationPreference,
+} from "../../utils/utils";
+import {
+  AuthContextType,
+  LoadingState,
+  NotificationPreference,
+} from "../../Types";
+import { useContext, useEffect, useState } from "react";
+
+import { AuthContext } from "../../context/AuthContext";
+import { Navigate } from "react-router-dom";
+
+const Notifications = () => {
+  const { authState } = useContext(AuthContext) as AuthContextType;
+  const [loadingState, setLoadingState] = useState<LoadingState>({
+    isLoading: true,
+    error: {},
+  });
+  const [preferences, setPreferences] = useState<NotificationPreference[]>(
+    [],
+  );
+
+  useEffect(() => {
+    if (!authState.token) return;
+
+    getNotificationPreferences()
+      .then((res) => {
+        setPreferences(res);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  }, [authState.token]);
+
+  if (!authState.token) return <Navigate to="/login" />;
+
+  const handleToggle = (category: string, enabled: boolean) => {
+    setNotificationPreference(category, enabled)
+      .then((res) =>
+        setPreferences((prev) =>
+          prev.map((p) => (p.category === res.category ? res : p)),
+        ),
+      )
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  };
+
+  return (
+    <Stack spacing={2} sx={{ margin: "1em" }}>
+      <Typography level="h2" className="bottom-divider">
+        Email notifications
+      </Typography>
+      <Typography>Choose which emails you want to receive from us.</Typography>
+
+      {!isObjectEmpty(loadingState.error) &&
+        renderResponseError(loadingState.error)}
+
+      {loadingState.isLoading ? (
+        <CircularProgress />
+      ) : (
+        <List>
+          {preferences.map((preference) => (
+            <ListItem
+              key={preference.category}
+              endAction={
+                <Switch
+                  checked={preference.enabled}
+                  onChange={(e) =>
+                    handleToggle(preference.category, e.target.checked)
+                  }
+                />
+              }
+            >
+              {NOTIFICATION_CATEGORIES[preference.category] ||
+                preference.category}
+            </ListItem>
+          ))}
+        </List>
+      )}
+    </Stack>
+  );
+};
+
+export default Notifications;
@@ -11,6 +11,7 @@ import { useEffect, useState } from "react";
 
 import MedalRecordColletion from "./MedalRecordColletion";
 import ProfileBasics from "./ProfileBasics";
+import ProfileBattleHistory from "./ProfileBattleHistory";
 import ProfilePersonalBests from "./ProfilePersonalBests";
 import ProfileResultsHistory from "./ProfileResultsHistory";
 import { WIN_SMALL } from "../../constants";
@@ -103,6 +104,7 @@ const Profile = () => {
           {profile.resultsHistory && profile.resultsHistory.length > 0 && (
             <ProfileResultsHistory resultsHistory={profile.resultsHistory} />
           )}
+          <ProfileBattleHistory id={id} />
         </Stack>
       )}
     </div>
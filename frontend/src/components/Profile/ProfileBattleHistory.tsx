@@ -0,0 +1,53 @@
+import { Card, Table, Typography } from "@mui/joy";
+import { getBattleHistory, getCubingIconClassName } from "../../utils/utils";
+import { useEffect, useState } from "react";
+
+import { Battle } from "../../Types";
+import { Link } from "react-router-dom";
+
+const ProfileBattleHistory: React.FC<{ id: string }> = ({ id }) => {
+  const [battles, setBattles] = useState<Battle[]>([]);
+
+  useEffect(() => {
+    getBattleHistory(id)
+      .then((b) => setBattles(b))
+      .catch(() => setBattles([]));
+  }, [id]);
+
+  if (battles.length === 0) return null;
+
+  return (
+    <Card>
+      <Typography level="h3">Battle History</Typography>
+      <Table>
+        <thead>
+          <tr>
+            <th>Event</th>
+            <th>Challenger</th>
+            <th>Opponent</th>
+            <th>Score</th>
+          </tr>
+        </thead>
+        <tbody>
+          {battles.map((b) => (
+            <tr key={b.id}>
+              <td>
+                <span className={getCubingIconClassName(b.event.iconcode)} />
+                &nbsp;{b.event.displayname}
+              </td>
+              <td>{b.challengerName}</td>
+              <td>{b.opponentName}</td>
+              <td>
+                <Link to={`/battle/${b.id}`}>
+                  {b.challengerWins} : {b.opponentWins}
+                </Link>
+              </td>
+            </tr>
+          ))}
+        </tbody>
+      </Table>
+    </Card>
+  );
+};
+
+export default ProfileBattleHistory;
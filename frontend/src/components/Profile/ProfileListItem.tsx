@@ -6,12 +6,29 @@ import {
   ListItemDecorator,
   Tooltip,
 } from "@mui/joy";
-import { Logout, Person } from "@mui/icons-material";
-import { initialAuthState, logOut } from "../../utils/utils";
+import {
+  Devices,
+  Key,
+  Link,
+  Logout,
+  Mail,
+  Notifications,
+  Person,
+} from "@mui/icons-material";
+import {
+  LANGUAGES,
+  WCA_LINK_STATE,
+  getMe,
+  hasWCAId,
+  initialAuthState,
+  logOut,
+  saveCurrentLocation,
+  setMyLanguage,
+} from "../../utils/utils";
 
 import { AuthContext } from "../../context/AuthContext";
 import { NavContext } from "../../context/NavContext";
-import { useContext } from "react";
+import { useContext, useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
 
 const ProfileListItem = () => {
@@ -20,12 +37,26 @@ const ProfileListItem = () => {
   ) as AuthContextType;
   const { closeNav } = useContext(NavContext) as NavContextType;
   const navigate = useNavigate();
+  const [language, setLanguage] = useState("");
+
+  useEffect(() => {
+    getMe()
+      .then((me) => setLanguage(me.language))
+      .catch(() => setLanguage(""));
+  }, []);
+
+  // cycles through the languages of the emails
+  const handleChangeLanguage = () => {
+    const languages = Object.keys(LANGUAGES);
+    const next =
+      languages[(languages.indexOf(language) + 1) % languages.length];
+    setMyLanguage(next).then(setLanguage);
+  };
 
-  const handleLogOut = () => {
+  const handleLogOut = (everywhere: boolean) => {
     setAuthState(initialAuthState);
     closeNav();
-    logOut();
-    document.location.reload();
+    logOut(everywhere).finally(() => document.location.reload());
   };
 
   const goToProfile = () => {
@@ -33,6 +64,16 @@ const ProfileListItem = () => {
     navigate(`/profile/${authState.wcaid}`, { replace: true });
   };
 
+  const goToTokens = () => {
+    closeNav();
+    navigate("/tokens");
+  };
+
+  const goToNotifications = () => {
+    closeNav();
+    navigate("/notifications");
+  };
+
   return (
     <Tooltip
       variant="soft"
@@ -44,12 +85,50 @@ const ProfileListItem = () => {
             </ListItemDecorator>
             My profile
           </ListItemButton>
-          <ListItemButton onClick={handleLogOut}>
+          <ListItemButton onClick={goToTokens}>
+            <ListItemDecorator>
+              <Key />
+            </ListItemDecorator>
+            Access tokens
+          </ListItemButton>
+          <ListItemButton onClick={goToNotifications}>
+            <ListItemDecorator>
+              <Notifications />
+            </ListItemDecorator>
+            Email notifications
+          </ListItemButton>
+          {!hasWCAId(authState) && (
+            <ListItemButton
+              component="a"
+              href={`${import.meta.env.VITE_WCA_GET_CODE_URL || ""}&state=${WCA_LINK_STATE}`}
+              onClick={() => saveCurrentLocation()}
+            >
+              <ListItemDecorator>
+                <Link />
+              </ListItemDecorator>
+              Link WCA account
+            </ListItemButton>
+          )}
+          {language && (
+            <ListItemButton onClick={handleChangeLanguage}>
+              <ListItemDecorator>
+                <Mail />
+              </ListItemDecorator>
+              Email language: {LANGUAGES[language]}
+            </ListItemButton>
+          )}
+          <ListItemButton onClick={() => handleLogOut(false)}>
             <ListItemDecorator>
               <Logout />
             </ListItemDecorator>
             Logout
           </ListItemButton>
+          <ListItemButton onClick={() => handleLogOut(true)}>
+            <ListItemDecorator>
+              <Devices />
+            </ListItemDecorator>
+            Logout everywhere
+          </ListItemButton>
         </List>
       }
       arrow
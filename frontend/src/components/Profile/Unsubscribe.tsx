@@ -0,0 +1,55 @@
+import { Alert, CircularProgress, Stack, Typography } from "@mui/joy";
+import { Link, useSearchParams } from "react-router-dom";
+import {
+  NOTIFICATION_CATEGORIES,
+  getError,
+  isObjectEmpty,
+  renderResponseError,
+  unsubscribe,
+} from "../../utils/utils";
+import { useEffect, useState } from "react";
+
+import { LoadingState } from "../../Types";
+
+// landing page of the unsubscribe links in the emails, works without logging in
+const Unsubscribe = () => {
+  const [searchParams] = useSearchParams();
+  const [loadingState, setLoadingState] = useState<LoadingState>({
+    isLoading: true,
+    error: {},
+  });
+  const [category, setCategory] = useState("");
+
+  useEffect(() => {
+    unsubscribe(searchParams.get("token") || "")
+      .then((res) => {
+        setCategory(res.category);
+        setLoadingState({ isLoading: false, error: {} });
+      })
+      .catch((err) =>
+        setLoadingState({ isLoading: false, error: getError(err) }),
+      );
+  }, [searchParams]);
+
+  return (
+    <Stack spacing={2} sx={{ margin: "1em" }}>
+      <Typography level="h2" className="bottom-divider">
+        Unsubscribe
+      </Typography>
+      {loadingState.isLoading ? (
+        <CircularProgress />
+      ) : !isObjectEmpty(loadingState.error) ? (
+        renderResponseError(loadingState.error)
+      ) : (
+        <Alert color="success">
+          You will no longer receive emails about:{" "}
+          {NOTIFICATION_CATEGORIES[category] || category}. You can change this
+          anytime in your email notification settings.
+        </Alert>
+      )}
+      <Link to="/notifications">Email notification settings</Link>
+    </Stack>
+  );
+};
+
+export default Unsubscribe;
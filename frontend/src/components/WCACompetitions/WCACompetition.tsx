@@ -39,7 +39,11 @@ __typename\n    }\n    __typename\n  }\n  recentRecords { \n    ...records\n
   }
 };
 
-const WCACompetition: React.FC<{ comp: WCACompetitionType }> = ({ comp }) => {
+const WCACompetition: React.FC<{
+  comp: WCACompetitionType;
+  watched?: boolean;
+  onWatchChange?: (watched: boolean) => void;
+}> = ({ comp, watched, onWatchChange }) => {
   const [wcaLiveId, setWcaLiveId] = useState("");
   const isLive = dayjs(comp.startdate).isBefore(dayjs());
 
@@ -191,14 +195,20 @@ const WCACompetition: React.FC<{ comp: WCACompetitionType }> = ({ comp }) => {
         </Typography>
       )}
       <Divider />
-      <Button
-        sx={{ float: "right" }}
-        variant="outlined"
-        component={Link}
-        to={comp.url}
-      >
-        More info!
-      </Button>
+      <Stack spacing={1} direction="row" justifyContent="flex-end">
+        {onWatchChange && dayjs().isBefore(dayjs(comp.registrationClose)) && (
+          <Button
+            variant={watched ? "solid" : "outlined"}
+            color="warning"
+            onClick={() => onWatchChange(!watched)}
+          >
+            {watched ? "Watching" : "Watch"}
+          </Button>
+        )}
+        <Button variant="outlined" component={Link} to={comp.url}>
+          More info!
+        </Button>
+      </Stack>
     </Stack>
   );
 };
@@ -18,10 +18,13 @@ import {
 import {
   GetAnnouncementSubscriptions,
   getError,
+  getPublicCalendarUrl,
   GetWCACompetitions,
+  GetWCACompetitionWatches,
   GetWCARegionGroups,
   isObjectEmpty,
   renderResponseError,
+  UpdateWCACompetitionWatch,
 } from "../../utils/utils";
 import LoadingComponent from "../Loading/LoadingComponent";
 import { Link } from "react-router-dom";
@@ -65,8 +68,17 @@ const WCACompetitions = () => {
     authStateRef.current.token !== undefined &&
     authStateRef.current.token !== "";
   const [subscriptionTooltipOpen, setSubscriptionTooltipOpen] = useState(false);
+  const [watches, setWatches] = useState<Set<string>>(new Set());
 
   useEffect(() => {
+    if (loggedIn) {
+      GetWCACompetitionWatches()
+        .then((res: string[]) => setWatches(new Set(res)))
+        .catch((err) => {
+          setLoadingState((p) => ({ ...p, error: getError(err) }));
+        });
+    }
+
     GetWCARegionGroups()
       .then((res: RegionSelectGroup[]) => {
         setRegionGroups(res);
@@ -112,6 +124,21 @@ const WCACompetitions = () => {
       });
   };
 
+  const handleWatchChange = (competitionId: string, watched: boolean) => {
+    UpdateWCACompetitionWatch(competitionId, watched)
+      .then(() => {
+        setWatches((p) => {
+          const newWatches = new Set(p);
+          if (watched) newWatches.add(competitionId);
+          else newWatches.delete(competitionId);
+          return newWatches;
+        });
+      })
+      .catch((err) => {
+        setLoadingState((p) => ({ ...p, error: getError(err) }));
+      });
+  };
+
   const handleRegionChange = (newRegionValue: string) => {
     setRegionValue(newRegionValue);
     fetchWCACompetitions();
@@ -179,6 +206,18 @@ const WCACompetitions = () => {
             >
               Subscribe
             </Button>
+            <Button
+              variant="soft"
+              component="a"
+              color="primary"
+              sx={{ px: 2 }}
+              href={getPublicCalendarUrl(
+                regionValue.split("+")[regionValue.split("+").length - 1],
+              )}
+              target="_blank"
+            >
+              Calendar
+            </Button>
             <InfoTooltip
               open={subscriptionTooltipOpen}
               setOpen={setSubscriptionTooltipOpen}
@@ -196,7 +235,16 @@ const WCACompetitions = () => {
           {competitions.map(
             (comp: WCACompetitionType, idx1: number) =>
               dayjs().isBefore(dayjs(comp.enddate).add(1, "day")) && (
-                <WCACompetition comp={comp} key={idx1} />
+                <WCACompetition
+                  comp={comp}
+                  key={idx1}
+                  watched={watches.has(comp.id)}
+                  onWatchChange={
+                    loggedIn
+                      ? (watched: boolean) => handleWatchChange(comp.id, watched)
+                      : undefined
+                  }
+                />
               ),
           )}
         </Stack>
@@ -50,6 +50,8 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
   const updateBasicInfo = (info: CompetitionData, currentEventIdx: number) => {
     const match = info.events[currentEventIdx].format.match(/\d+$/)?.[0];
     const noOfSolves = match ? parseInt(match) : 1;
+    const round = parseInt(searchParams.get("round") || "1");
+    const noOfRounds = (info.events[currentEventIdx].rounds?.length ?? 0) + 1;
 
     setCompetitionState((ps) => {
       return {
@@ -57,6 +59,7 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
         ...info,
         noOfSolves: noOfSolves,
         currentEventIdx: currentEventIdx,
+        currentRound: round >= 1 && round <= noOfRounds ? round : 1,
         currentSolveIdx: 0,
       };
     });
@@ -84,7 +87,11 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
     }
 
     setLoadingState((ps) => ({ ...ps, results: true, error: {} }));
-    getResultsFromCompetitionAndEvent(compId, event)
+    getResultsFromCompetitionAndEvent(
+      compId,
+      event,
+      competitionStateRef.current.currentRound,
+    )
       .then((resultEntry) => {
         setCurrentResults(resultEntry);
         if (!resultEntry.status.approvalFinished) {
@@ -113,7 +120,11 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
     compId: string = competitionState.id,
   ) => {
     setLoadingState((ps) => ({ ...ps, results: true, error: {} }));
-    getCompetitionResults(compId, event)
+    getCompetitionResults(
+      compId,
+      event,
+      competitionStateRef.current.currentRound,
+    )
       .then((res) => {
         setResults(res.results);
         setAnyComment(res.anyComment);
@@ -137,9 +148,10 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
     setCompetitionState((ps) => ({
       ...ps,
       currentEventIdx: idx,
+      currentRound: 1,
       noOfSolves: noOfSolves,
       currentSolveIdx: 0,
-      penalties: Array(5).fill("0"),
+      penalties: Array(noOfSolves).fill("0"),
       inputMethod:
         competitionState.events[idx].displayname === "FMC"
           ? InputMethod.Manual
@@ -148,6 +160,7 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
     setLoadingState((ps) => ({ ...ps, results: true, error: {} }));
     const events = competitionStateRef.current.events;
     searchParams.set("event", events[idx].iconcode);
+    searchParams.delete("round");
     setSearchParams(searchParams);
 
     if (resultsCompeteChoice === ResultsCompeteChoiceEnum.Compete)
@@ -155,31 +168,29 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
     else fetchCompetitionResults(competitionStateRef.current.events[idx]);
   };
 
+  const updateCurrentRound = (round: number) => {
+    setCompetitionState((ps) => ({
+      ...ps,
+      currentRound: round,
+      currentSolveIdx: 0,
+      penalties: Array(ps.noOfSolves).fill("0"),
+    }));
+    searchParams.set("round", round.toString());
+    setSearchParams(searchParams);
+
+    if (resultsCompeteChoice === ResultsCompeteChoiceEnum.Compete)
+      fetchCompeteResultEntry();
+    else fetchCompetitionResults();
+  };
+
   const updateCurrentSolve = (idx: number) =>
     setCompetitionState({ ...competitionState, currentSolveIdx: idx });
 
   const saveResults = async (): Promise<void> => {
     let results = { ...currentResultsRef.current };
     if (results.eventname !== "MBLD" && results.eventname !== "FMC") {
-      results.solve1 = reformatWithPenalties(
-        currentResultsRef.current.solve1,
-        competitionState.penalties[0],
-      );
-      results.solve2 = reformatWithPenalties(
-        currentResultsRef.current.solve2,
-        competitionState.penalties[1],
-      );
-      results.solve3 = reformatWithPenalties(
-        currentResultsRef.current.solve3,
-        competitionState.penalties[2],
-      );
-      results.solve4 = reformatWithPenalties(
-        currentResultsRef.current.solve4,
-        competitionState.penalties[3],
-      );
-      results.solve5 = reformatWithPenalties(
-        currentResultsRef.current.solve5,
-        competitionState.penalties[4],
+      results.solves = currentResultsRef.current.solves.map((solve, idx) =>
+        reformatWithPenalties(solve, competitionState.penalties[idx] ?? "0"),
       );
     }
 
@@ -196,7 +207,7 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
       setCurrentResults(resultEntry);
       setCompetitionState((ps) => ({
         ...ps,
-        penalties: Array(5).fill("0"),
+        penalties: Array(ps.noOfSolves).fill("0"),
       }));
       return Promise.resolve();
     } catch (e) {
@@ -226,12 +237,10 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
   };
 
   const updateSolve = (newTime: string) => {
-    const solveProp: keyof ResultEntry = `solve${
-      competitionStateRef.current.currentSolveIdx + 1
-    }` as keyof ResultEntry;
+    const solveIdx = competitionStateRef.current.currentSolveIdx;
     setCurrentResults((ps) => ({
       ...ps,
-      [solveProp]: newTime,
+      solves: ps.solves.map((solve, idx) => (idx === solveIdx ? newTime : solve)),
     }));
   };
 
@@ -264,6 +273,7 @@ export const CompetitionProvider: React.FC<{ children?: ReactNode }> = ({
         competitionState,
         updateBasicInfo,
         updateCurrentEvent,
+        updateCurrentRound,
         updateCurrentSolve,
         saveResults,
         toggleInputMethod,
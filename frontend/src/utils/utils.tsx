@@ -4,6 +4,8 @@ import {
   AnnouncementState,
   AuthState,
   AverageInfo,
+  Battle,
+  BattleChallenge,
   CompetitionAnnouncementSubcriptionUpdateResponse,
   CompetitionAnnouncementSubscription,
   CompetitionData,
@@ -11,6 +13,7 @@ import {
   CompetitionLoadingState,
   CompetitionResultStruct,
   CompetitionState,
+  FMCSolveVerification,
   FilterValue,
   InputMethod,
   LoadingState,
@@ -25,9 +28,17 @@ import {
   WCACompetitionType,
   MarkerType,
   ManageUser,
+  Role,
+  DuplicateUserPair,
+  UserMerge,
   User,
   UserSubscriptionDetail,
   SubscriptionStats,
+  ApiToken,
+  CreatedApiToken,
+  OutboxEmail,
+  NotificationPreference,
+  PolygonSubscriptionFeatureCollection,
 } from "../Types";
 import { FeatureCollection } from "geojson";
 import axios, { AxiosError } from "axios";
@@ -57,10 +68,13 @@ export const getCompetitionById = async (
 export const getResultsFromCompetitionAndEvent = async (
   cid: string | undefined,
   event: CompetitionEvent,
+  round: number = 1,
 ): Promise<ResultEntry> => {
   if (cid === undefined || event === undefined)
     return Promise.reject("invalid competition/event id");
-  const response = await axios.get(`/api/results/compete/${cid}/${event.id}`);
+  const response = await axios.get(
+    `/api/results/compete/${cid}/${event.id}?round=${round}`,
+  );
   return response.data;
 };
 
@@ -148,6 +162,60 @@ export const getManageUsers = async (): Promise<ManageUser[]> => {
   return response.data;
 };
 
+export const getRoles = async (): Promise<Role[]> => {
+  const response = await axios.get("/api/users/roles");
+  return response.data;
+};
+
+export const getOutboxEmails = async (
+  status: string,
+): Promise<OutboxEmail[]> => {
+  const response = await axios.get(`/api/emails?status=${status}`);
+  return response.data;
+};
+
+export const retryOutboxEmail = async (id: number): Promise<string> => {
+  const response = await axios.post(`/api/emails/${id}/retry`);
+  return response.data;
+};
+
+export const API_TOKEN_SCOPES = [
+  "results:read",
+  "results:write",
+  "profile:read",
+  "calendar:read",
+];
+
+export const getMyCalendarUrl = (token: string): string =>
+  `${window.location.origin}/api/calendar/me.ics?token=${encodeURIComponent(token)}`;
+
+export const getPublicCalendarUrl = (region: string): string =>
+  `${window.location.origin}/api/calendar/public.ics` +
+  (region ? `?region=${encodeURIComponent(region)}` : "");
+
+export const getApiTokens = async (): Promise<ApiToken[]> => {
+  const response = await axios.get("/api/users/tokens");
+  return response.data;
+};
+
+export const createApiToken = async (
+  name: string,
+  scopes: string[],
+  expiresInDays: number,
+): Promise<CreatedApiToken> => {
+  const response = await axios.post("/api/users/tokens", {
+    name,
+    scopes,
+    expiresInDays,
+  });
+  return response.data;
+};
+
+export const revokeApiToken = async (id: number): Promise<string> => {
+  const response = await axios.delete(`/api/users/tokens/${id}`);
+  return response.data;
+};
+
 export const updateUserRoles = async (newUser: ManageUser): Promise<string> => {
   const response = await axios.post("/api/users/manage-roles", newUser);
   return response.data;
@@ -223,11 +291,12 @@ export const initialCompetitionState: CompetitionState = {
   enddate: formatCompetitionDateForInput(new Date().toISOString()),
   events: [],
   currentEventIdx: 0,
+  currentRound: 1,
   noOfSolves: 1,
   currentSolveIdx: 0,
   scrambles: [],
   inputMethod: InputMethod.Manual,
-  penalties: Array(5).fill("0"),
+  penalties: Array(1).fill("0"),
 };
 
 export const isObjectEmpty = (obj: object) => {
@@ -250,11 +319,8 @@ export const initialCurrentResults: ResultEntry = {
   eventname: "",
   iconcode: "",
   format: "",
-  solve1: "",
-  solve2: "",
-  solve3: "",
-  solve4: "",
-  solve5: "",
+  round: 1,
+  solves: [],
   comment: "",
   status: {
     id: 0,
@@ -339,20 +405,125 @@ export const logIn = async (
   }
 
   const response = await axios.post("/api/users/login", code);
-  const data: {
-    access_token: string;
-    expires_in: number;
-    isadmin: boolean;
-    avatarUrl: string;
-    wcaid: string;
-    username: string;
-  } = response.data;
+  return storeAuthResponse(response.data);
+};
+
+export const logInByEmail = async (
+  searchParams: URLSearchParams,
+  name: string = "",
+): Promise<AuthState> => {
+  const token = searchParams.get("token");
+  if (token === null) {
+    return Promise.reject("Missing token.");
+  }
+
+  const response = await axios.post("/api/users/login/email", {
+    token,
+    name,
+  });
+  return storeAuthResponse(response.data);
+};
+
+// new users fill in their name after opening the login link
+export const isRegistrationError = (error: ResponseError): boolean =>
+  error.status === 422 || error.status === 409;
+
+export const requestLoginLink = async (email: string): Promise<string> => {
+  const response = await axios.post("/api/users/login/email/link", {
+    email,
+    language: browserLanguage(),
+  });
+  return response.data;
+};
+
+export const LANGUAGES: { [key: string]: string } = {
+  en: "English",
+  sk: "Slovenčina",
+};
+
+const browserLanguage = () =>
+  navigator.language.toLowerCase().startsWith("sk") ? "sk" : "en";
+
+export const getMe = async (): Promise<User> => {
+  const response = await axios.get("/api/users/me");
+  return response.data;
+};
+
+export const setMyLanguage = async (language: string): Promise<string> => {
+  const response = await axios.post("/api/users/language", { language });
+  return response.data;
+};
+
+export const NOTIFICATION_CATEGORIES: { [key: string]: string } = {
+  wca_competitions: "New WCA competitions in my subscribed regions",
+  weekly_competition: "New weekly competition",
+  results_approved: "My results approved",
+  personal_record: "New personal records",
+  weekly_digest: "Weekly digest of my results",
+  wca_reminders: "Reminders of WCA competitions I watch",
+};
+
+export const getNotificationPreferences = async (): Promise<
+  NotificationPreference[]
+> => {
+  const response = await axios.get("/api/users/notifications");
+  return response.data;
+};
+
+export const setNotificationPreference = async (
+  category: string,
+  enabled: boolean,
+): Promise<NotificationPreference> => {
+  const response = await axios.post("/api/users/notifications", {
+    category,
+    enabled,
+  });
+  return response.data;
+};
+
+export const unsubscribe = async (
+  token: string,
+): Promise<NotificationPreference> => {
+  const response = await axios.post("/api/notifications/unsubscribe", null, {
+    params: { token },
+  });
+  return response.data;
+};
+
+export const WCA_LINK_STATE = "link";
+
+export const linkWCAAccount = async (
+  searchParams: URLSearchParams,
+): Promise<AuthState> => {
+  const code = searchParams.get("code");
+  if (code === null) {
+    return Promise.reject("Missing code.");
+  }
+
+  const response = await axios.post("/api/users/link/wca", code);
+  return storeAuthResponse(response.data);
+};
+
+export const hasWCAId = (authState: AuthState) =>
+  /^\d{4}[A-Z]{4}\d{2}$/.test(authState.wcaid);
 
+const storeAuthResponse = (data: {
+  access_token: string;
+  expires_in: number;
+  refresh_token: string;
+  refresh_expires_in: number;
+  isadmin: boolean;
+  avatarUrl: string;
+  wcaid: string;
+  username: string;
+}): AuthState => {
   setBearerIfPresent(data.access_token);
 
   const result: AuthState = {
     token: data.access_token,
+    refreshToken: data.refresh_token,
     isadmin: data.isadmin,
+    permissions: [],
     avatarUrl: data.avatarUrl,
     wcaid: data.wcaid,
     username: data.username,
@@ -362,9 +533,9 @@ export const logIn = async (
 
   let key: keyof AuthState;
   for (key in result) {
-    if (key === "isadmin") continue;
+    if (key === "isadmin" || key === "permissions") continue;
     cookies.set(key, result[key], {
-      expires: new Date(new Date().getTime() + data.expires_in * 1000),
+      expires: new Date(new Date().getTime() + data.refresh_expires_in * 1000),
     });
   }
 
@@ -375,23 +546,108 @@ const cookies = new Cookies(null, { path: "/" });
 
 export const initialAuthState: AuthState = {
   token: cookies.get("token") || "",
+  refreshToken: cookies.get("refreshToken") || "",
   isadmin: false,
+  permissions: [],
   avatarUrl: cookies.get("avatarUrl") || "",
   wcaid: cookies.get("wcaid") || "",
   username: cookies.get("username") || "",
 };
 
-export const logOut = async () => {
+const removeAuthCookies = () => {
   let key: keyof AuthState;
   for (key in initialAuthState) {
     cookies.remove(key);
   }
 };
 
-export const authorizeAdmin = async () => {
-  return axios.get("/api/users/auth/admin");
+export const logOut = async (everywhere: boolean = false) => {
+  await axios
+    .post(everywhere ? "/api/users/logout-all" : "/api/users/logout")
+    .catch(() => {
+      // the session is already gone, nothing to revoke
+    });
+  removeAuthCookies();
+};
+
+let refreshingSession: Promise<string> | null = null;
+
+// refreshSession exchanges the refresh token for a new access token, parallel
+// callers share one request, as every refresh token can be used only once
+const refreshSession = async (): Promise<string> => {
+  if (refreshingSession === null) {
+    refreshingSession = axios
+      .post("/api/users/refresh", {
+        refresh_token: cookies.get("refreshToken"),
+      })
+      .then((response) => {
+        const data: {
+          access_token: string;
+          refresh_token: string;
+          refresh_expires_in: number;
+        } = response.data;
+        const expires = new Date(
+          new Date().getTime() + data.refresh_expires_in * 1000,
+        );
+
+        cookies.set("token", data.access_token, { expires });
+        cookies.set("refreshToken", data.refresh_token, { expires });
+        setBearerIfPresent(data.access_token);
+        return data.access_token;
+      })
+      .catch((err) => {
+        removeAuthCookies();
+        return Promise.reject(err);
+      })
+      .finally(() => {
+        refreshingSession = null;
+      });
+  }
+
+  return refreshingSession;
+};
+
+axios.interceptors.response.use(undefined, async (err) => {
+  const config = err.config;
+  if (
+    err.response?.status !== 401 ||
+    !config ||
+    config._retried ||
+    config.url === "/api/users/refresh" ||
+    !cookies.get("refreshToken")
+  ) {
+    return Promise.reject(err);
+  }
+
+  config._retried = true;
+  const token = await refreshSession().catch(() => null);
+  if (token === null) {
+    return Promise.reject(err);
+  }
+
+  config.headers.Authorization = `Bearer ${token}`;
+  return axios(config);
+});
+
+export const PERMISSIONS = {
+  RESULTS_VALIDATE: "results.validate",
+  RESULTS_EDIT: "results.edit",
+  ANNOUNCEMENTS_MANAGE: "announcements.manage",
+  COMPETITIONS_MANAGE: "competitions.manage",
+  USERS_MANAGE: "users.manage",
+  STATS_VIEW: "stats.view",
 };
 
+// authorizeAdmin returns the permissions of the user, it fails for users
+// without any
+export const authorizeAdmin = async (): Promise<string[]> => {
+  const response = await axios.get("/api/users/auth/admin");
+  return response.data;
+};
+
+export const hasPermission = (authState: AuthState, permission: string) =>
+  authState.permissions.includes(permission);
+
 export const setBearerIfPresent = (token: string) => {
   axios.defaults.headers.common["Authorization"] = `Bearer ${token}`;
 };
@@ -399,9 +655,10 @@ export const setBearerIfPresent = (token: string) => {
 export const getCompetitionResults = async (
   competitionId: string,
   event: CompetitionEvent,
+  round: number = 1,
 ): Promise<CompetitionResultStruct> => {
   const response = await axios.get(
-    `/api/competitions/results/${competitionId}/${event.id}`,
+    `/api/competitions/results/${competitionId}/${event.id}?round=${round}`,
   );
   return response.data;
 };
@@ -418,6 +675,9 @@ export const emptyEvent: CompetitionEvent = {
   format: "",
   iconcode: "",
   scramblingcode: "",
+  cutoff: { attempts: 0, time: 0 },
+  timeLimit: { time: 0, cumulative: false },
+  rounds: [],
 };
 
 export const initialLoadingState: LoadingState = {
@@ -644,6 +904,7 @@ export const initialAverageInfo: AverageInfo = {
   wpa: "",
   showPossibleAverage: false,
   finishedCompeting: false,
+  cutoffFailed: false,
   place: "",
   singleRecord: "",
   singleRecordColor: "",
@@ -674,6 +935,17 @@ export const GetAverageInfoRecords = async (
   return response.data;
 };
 
+export const GetFMCVerification = async (
+  cid: string,
+  eid: number,
+  round: number = 1,
+): Promise<FMCSolveVerification[]> => {
+  const response = await axios.get(
+    `/api/results/fmc-verification/${cid}/${eid}?round=${round}`,
+  );
+  return response.data;
+};
+
 export const isBrowser = typeof window !== "undefined";
 
 export const GetMapData = async (): Promise<FeatureCollection> => {
@@ -693,6 +965,18 @@ export const GetWCACompetitions = async (
   return response.data;
 };
 
+export const GetWCACompetitionWatches = async (): Promise<string[]> => {
+  const response = await axios.get(`/api/competitions/wca/watches`);
+  return response.data;
+};
+
+export const UpdateWCACompetitionWatch = async (
+  competitionId: string,
+  watched: boolean,
+): Promise<void> => {
+  await axios.post(`/api/competitions/wca/watch`, { competitionId, watched });
+};
+
 export const GetAdminStats = async (): Promise<AdminStatsCollection> => {
   const response = await axios.get(`/api/stats/dashboard`);
   return response.data;
@@ -765,6 +1049,24 @@ export const DeleteMarker = async (marker: MarkerType): Promise<void> => {
   return response.data;
 };
 
+export const GetPolygonSubscriptions =
+  async (): Promise<PolygonSubscriptionFeatureCollection> => {
+    const response = await axios.get(
+      `/api/competitions/wca/subscriptions/polygons`,
+    );
+    return response.data;
+  };
+
+export const SavePolygonSubscriptions = async (
+  collection: PolygonSubscriptionFeatureCollection,
+): Promise<PolygonSubscriptionFeatureCollection> => {
+  const response = await axios.put(
+    `/api/competitions/wca/subscriptions/polygons`,
+    collection,
+  );
+  return response.data;
+};
+
 export const getSearchUsers = async (query: string): Promise<ManageUser[]> => {
   const response = await axios.get(`/api/users/search?query=${query}`);
   return response.data;
@@ -775,6 +1077,15 @@ export const findDuplicateUser = async (userId: number): Promise<User> => {
   return response.data;
 };
 
+export const getDuplicateUsers = async (
+  minScore?: number,
+): Promise<DuplicateUserPair[]> => {
+  const response = await axios.get("/api/users/duplicates", {
+    params: { minScore },
+  });
+  return response.data;
+};
+
 export const mergeUsers = async (
   oldUserId: number,
   newUserId: number,
@@ -786,6 +1097,16 @@ export const mergeUsers = async (
   return response.data;
 };
 
+export const getUserMerges = async (): Promise<UserMerge[]> => {
+  const response = await axios.get("/api/users/merges");
+  return response.data;
+};
+
+export const unmergeUsers = async (mergeId: number): Promise<string> => {
+  const response = await axios.post(`/api/users/merges/${mergeId}/unmerge`);
+  return response.data;
+};
+
 export const getSubscriptionStats = async (): Promise<SubscriptionStats> => {
   const response = await axios.get("/api/stats/subscriptions");
   return response.data;
@@ -797,3 +1118,62 @@ export const getUserSubscriptionDetails = async (): Promise<
   const response = await axios.get("/api/stats/subscriptions/details");
   return response.data;
 };
+
+export const getMyBattles = async (): Promise<Battle[]> => {
+  const response = await axios.get("/api/battles/");
+  return response.data;
+};
+
+export const getBattle = async (id: string): Promise<Battle> => {
+  const response = await axios.get(`/api/battles/id/${id}`);
+  return response.data;
+};
+
+export const getBattleHistory = async (id: string): Promise<Battle[]> => {
+  const response = await axios.get(`/api/battles/history/${id}`);
+  return response.data;
+};
+
+export const postBattle = async (
+  challenge: BattleChallenge,
+): Promise<Battle> => {
+  const response = await axios.post("/api/battles/", challenge);
+  return response.data;
+};
+
+export const acceptBattle = async (id: number): Promise<Battle> => {
+  const response = await axios.post(`/api/battles/id/${id}/accept`);
+  return response.data;
+};
+
+export const declineBattle = async (id: number): Promise<Battle> => {
+  const response = await axios.post(`/api/battles/id/${id}/decline`);
+  return response.data;
+};
+
+export const submitBattleSolve = async (
+  id: number,
+  solve: string,
+): Promise<Battle> => {
+  const response = await axios.post(`/api/battles/id/${id}/solve`, { solve });
+  return response.data;
+};
+
+export const subscribeLiveResults = (
+  competitionId: string,
+  eventId: number,
+  round: number,
+  onResults: (results: CompetitionResultStruct) => void,
+): (() => void) => {
+  const params = new URLSearchParams({
+    competitionId: competitionId,
+    eventId: eventId.toString(),
+    round: round.toString(),
+  });
+  const source = new EventSource(`/api/competitions/live?${params}`);
+  source.addEventListener("results", (e: MessageEvent) =>
+    onResults(JSON.parse(e.data)),
+  );
+
+  return () => source.close();
+};